@@ -5,15 +5,23 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"os"
 
 	"github.com/botanikn/go_sso_service/internal/config"
+	"github.com/botanikn/go_sso_service/pkg/database"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/lib/pq"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "reencrypt" {
+		reencrypt(os.Args[2:])
+		return
+	}
+
 	var (
 		migrationsPath string
 		migrationTable string
@@ -21,17 +29,13 @@ func main() {
 		direction      string
 	)
 
-	flag.StringVar(&migrationsPath, "migrationsPath", "migrations", "Path to migrations directory")
+	flag.StringVar(&migrationsPath, "migrationsPath", "", "Path to migrations directory (default migrations, or migrations/sqlite for the sqlite driver)")
 	flag.StringVar(&migrationTable, "migrationTable", "schema_migrations", "Name of migration table")
 	flag.StringVar(&dbSchema, "dbSchema", "", "Database schema name (optional)")
 	flag.StringVar(&direction, "direction", "up", "Migration direction: up or down")
 
 	flag.Parse()
 
-	if migrationsPath == "" {
-		log.Fatal("migrationsPath is required")
-	}
-
 	if migrationTable == "" {
 		log.Fatal("migrationTable is required")
 	}
@@ -42,20 +46,33 @@ func main() {
 
 	cfg := config.MustLoad()
 
-	// Формируем connStr с схемой БД
-	connStr := fmt.Sprintf(
-		"postgres://%s:%s@%s:%d/%s?sslmode=disable&x-migrations-table=%s",
-		cfg.DbConfig.User,
-		cfg.DbConfig.Password,
-		cfg.DbConfig.Host,
-		cfg.DbConfig.Port,
-		cfg.DbConfig.Dbname,
-		migrationTable,
-	)
+	var connStr string
+	if cfg.DbConfig.Driver == database.DriverSQLite {
+		// SQLite has its own schema, dbname is the path of the database file.
+		if migrationsPath == "" {
+			migrationsPath = "migrations/sqlite"
+		}
+		connStr = fmt.Sprintf("sqlite://%s?x-migrations-table=%s", cfg.DbConfig.Dbname, migrationTable)
+	} else {
+		if migrationsPath == "" {
+			migrationsPath = "migrations"
+		}
 
-	// Добавляем схему БД, если указана
-	if dbSchema != "" {
-		connStr += fmt.Sprintf("&search_path=%s", dbSchema)
+		// Формируем connStr с схемой БД
+		connStr = fmt.Sprintf(
+			"postgres://%s:%s@%s:%d/%s?sslmode=disable&x-migrations-table=%s",
+			cfg.DbConfig.User,
+			cfg.DbConfig.Password,
+			cfg.DbConfig.Host,
+			cfg.DbConfig.Port,
+			cfg.DbConfig.Dbname,
+			migrationTable,
+		)
+
+		// Добавляем схему БД, если указана
+		if dbSchema != "" {
+			connStr += fmt.Sprintf("&search_path=%s", dbSchema)
+		}
 	}
 
 	m, err := migrate.New(
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/botanikn/go_sso_service/internal/config"
+	"github.com/botanikn/go_sso_service/internal/storage/postgresql"
+	"github.com/botanikn/go_sso_service/internal/storage/sqlite"
+	"github.com/botanikn/go_sso_service/pkg/database"
+	"github.com/botanikn/go_sso_service/pkg/envelope"
+)
+
+// reencrypter is implemented by the sqlite and postgresql repositories.
+type reencrypter interface {
+	ReencryptAppSecrets(ctx context.Context, toPlaintext bool) (int, error)
+}
+
+// reencrypt moves the app secrets to the current encryption key, run it after the
+// key version is changed and before the old key is removed from the config.
+func reencrypt(args []string) {
+	fs := flag.NewFlagSet("reencrypt", flag.ExitOnError)
+	configPath := fs.String("config", os.Getenv("SSO_CONFIG_PATH"), "Path to config file (defaults to $SSO_CONFIG_PATH)")
+	toPlaintext := fs.Bool("plaintext", false, "Decrypt the secrets instead, before encryption is turned off")
+	fs.Parse(args)
+
+	cfg := config.MustLoadPath(*configPath)
+	if cfg.DbConfig.Driver == config.DriverMemory {
+		log.Fatal("reencrypt needs a database, the config selects the in-memory storage")
+	}
+
+	keyring, err := envelope.Load(cfg.Encryption.KeyVersion, cfg.Encryption.Keys, cfg.Encryption.KeysFile)
+	if err != nil {
+		log.Fatalf("failed to load encryption keys: %v", err)
+	}
+	if keyring == nil && !*toPlaintext {
+		log.Fatal("no encryption keys are configured, add them to encryption.keys or encryption.keys_file")
+	}
+
+	var storage reencrypter
+	if cfg.DbConfig.Driver == database.DriverSQLite {
+		db, err := database.NewDB(cfg.DbConfig.Host, cfg.DbConfig.Port, cfg.DbConfig.User, cfg.DbConfig.Password, cfg.DbConfig.Dbname, cfg.DbConfig.Driver)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer db.Close()
+		storage = sqlite.New(db, keyring)
+	} else {
+		pool, err := database.NewPool(context.Background(), cfg.DbConfig.Host, cfg.DbConfig.Port, cfg.DbConfig.User, cfg.DbConfig.Password, cfg.DbConfig.Dbname, database.PoolOptions{
+			MaxConns:     2,
+			QueryTimeout: cfg.DbConfig.QueryTimeout,
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer pool.Close()
+		storage = postgresql.New(pool, nil, keyring)
+	}
+
+	changed, err := storage.ReencryptAppSecrets(context.Background(), *toPlaintext)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Re-encrypted %d app secrets\n", changed)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/botanikn/go_sso_service/internal/app"
 
 	"github.com/botanikn/go_sso_service/internal/config"
+	"github.com/botanikn/go_sso_service/internal/grpc/middleware"
 )
 
 func main() {
@@ -25,7 +26,17 @@ func main() {
 		log,
 		cfg.GRPC.Port,
 		&cfg.DbConfig,
-		cfg.GRPC.Timeout,
+		&cfg.Grants,
+		&cfg.Bootstrap,
+		&cfg.Invitations,
+		&cfg.Mail,
+		&cfg.Accounts,
+		&cfg.Metrics,
+		&cfg.Cache,
+		&cfg.Encryption,
+		cfg.TokenTTL,
+		cfg.ImpersonationTTL,
+		cfg.Admin.AppId,
 	)
 
 	go application.MustRun()
@@ -66,5 +77,6 @@ func setupLogger(env string) *slog.Logger {
 			&slog.HandlerOptions{Level: slog.LevelDebug},
 		))
 	}
-	return log
+	// Records logged with the context of a gRPC call carry its request ID.
+	return slog.New(middleware.NewLogHandler(log.Handler()))
 }
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	ssov1 "github.com/botanikn/protos/gen/go/sso"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// backend is implemented once on top of the gRPC API and once on top of the
+// database, so that every command works both online and offline.
+type backend interface {
+	CreateApp(ctx context.Context, name string) (models.App, error)
+	RotateAppSecret(ctx context.Context, appId int64) (models.App, error)
+	CreateUser(ctx context.Context, email string, username string, password string) (int64, error)
+	SetRole(ctx context.Context, change models.PermissionChange) (models.PermissionChangeResult, error)
+	RevokeSessions(ctx context.Context, userId int64) (time.Time, error)
+	InspectToken(ctx context.Context, token string, appId int64) (tokenInfo, error)
+	ExportAudit(ctx context.Context, filter models.AuditFilter, send func(models.AuditEvent) error) error
+	Close() error
+}
+
+// tokenInfo is what the service makes of a token once it has verified it.
+type tokenInfo struct {
+	UserId     int64
+	Permission string
+}
+
+type grpcBackend struct {
+	conn       *grpc.ClientConn
+	token      string
+	tokenAppId int64
+	auth       ssov1.AuthClient
+	apps       ssov1.AppAdminClient
+	users      ssov1.UserAdminClient
+	audit      ssov1.AuditLogClient
+}
+
+func newGRPCBackend(addr string, token string, tokenAppId int64, useTLS bool) (*grpcBackend, error) {
+	creds := insecure.NewCredentials()
+	if useTLS {
+		creds = credentials.NewClientTLSFromCert(nil, "")
+	}
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+
+	return &grpcBackend{
+		conn:       conn,
+		token:      token,
+		tokenAppId: tokenAppId,
+		auth:       ssov1.NewAuthClient(conn),
+		apps:       ssov1.NewAppAdminClient(conn),
+		users:      ssov1.NewUserAdminClient(conn),
+		audit:      ssov1.NewAuditLogClient(conn),
+	}, nil
+}
+
+func (b *grpcBackend) CreateApp(ctx context.Context, name string) (models.App, error) {
+	res, err := b.apps.CreateApp(b.authorized(ctx), &ssov1.CreateAppRequest{Name: name})
+	if err != nil {
+		return models.App{}, err
+	}
+	return appFromProto(res.App, res.Secret), nil
+}
+
+func (b *grpcBackend) RotateAppSecret(ctx context.Context, appId int64) (models.App, error) {
+	res, err := b.apps.RotateAppSecret(b.authorized(ctx), &ssov1.RotateAppSecretRequest{AppId: appId})
+	if err != nil {
+		return models.App{}, err
+	}
+	return appFromProto(res.App, res.Secret), nil
+}
+
+func (b *grpcBackend) CreateUser(ctx context.Context, email string, username string, password string) (int64, error) {
+	res, err := b.auth.Register(ctx, &ssov1.RegisterRequest{
+		Email:    email,
+		Username: username,
+		Password: password,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.UserId, nil
+}
+
+// SetRole goes through BatchUpdatePermissions, which creates missing permissions
+// and accepts a token issued for another app than the one being changed.
+func (b *grpcBackend) SetRole(ctx context.Context, change models.PermissionChange) (models.PermissionChangeResult, error) {
+	tokenAppId := b.tokenAppId
+	if tokenAppId == 0 {
+		tokenAppId = change.AppId
+	}
+
+	res, err := b.auth.BatchUpdatePermissions(b.authorized(ctx), &ssov1.BatchUpdatePermissionsRequest{
+		AppId: tokenAppId,
+		Changes: []*ssov1.PermissionChange{{
+			UserId:     change.UserId,
+			AppId:      change.AppId,
+			Permission: change.Permission,
+		}},
+	})
+	if err != nil {
+		return models.PermissionChangeResult{}, err
+	}
+	if len(res.Results) != 1 {
+		return models.PermissionChangeResult{}, fmt.Errorf("expected 1 result, got %d", len(res.Results))
+	}
+
+	result := res.Results[0]
+	out := models.PermissionChangeResult{
+		Change:             change,
+		Outcome:            outcomeFromProto(result.Outcome),
+		PreviousPermission: result.PreviousPermission,
+	}
+	if result.Error != "" {
+		out.Err = errors.New(result.Error)
+	}
+	return out, nil
+}
+
+func (b *grpcBackend) RevokeSessions(ctx context.Context, userId int64) (time.Time, error) {
+	res, err := b.users.RevokeSessions(b.authorized(ctx), &ssov1.RevokeSessionsRequest{UserId: userId})
+	if err != nil {
+		return time.Time{}, err
+	}
+	return res.RevokedAt.AsTime(), nil
+}
+
+// InspectToken asks the service to verify token itself rather than the admin token.
+func (b *grpcBackend) InspectToken(ctx context.Context, token string, appId int64) (tokenInfo, error) {
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+	res, err := b.auth.CheckPermissionsByJwt(ctx, &ssov1.PermissionsByJwtRequest{AppId: appId})
+	if err != nil {
+		return tokenInfo{}, err
+	}
+	return tokenInfo{
+		UserId:     res.UserId,
+		Permission: res.Permission,
+	}, nil
+}
+
+func (b *grpcBackend) ExportAudit(ctx context.Context, filter models.AuditFilter, send func(models.AuditEvent) error) error {
+	req := &ssov1.ExportAuditEventsRequest{
+		AppId:   filter.AppId,
+		ActorId: filter.ActorId,
+		UserId:  filter.UserId,
+		Action:  filter.Action,
+	}
+	if !filter.Since.IsZero() {
+		req.Since = timestamppb.New(filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		req.Until = timestamppb.New(filter.Until)
+	}
+
+	stream, err := b.audit.ExportAuditEvents(b.authorized(ctx), req)
+	if err != nil {
+		return err
+	}
+	for {
+		event, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := send(models.AuditEvent{
+			ID:        event.Id,
+			AppId:     event.AppId,
+			ActorId:   event.ActorId,
+			UserId:    event.UserId,
+			Action:    event.Action,
+			Details:   event.Details.AsMap(),
+			CreatedAt: event.CreatedAt.AsTime(),
+		}); err != nil {
+			return err
+		}
+	}
+}
+
+func (b *grpcBackend) Close() error {
+	return b.conn.Close()
+}
+
+func (b *grpcBackend) authorized(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+b.token)
+}
+
+func appFromProto(app *ssov1.AppInfo, secret string) models.App {
+	return models.App{
+		ID:     int(app.GetId()),
+		Name:   app.GetName(),
+		Secret: secret,
+	}
+}
+
+func outcomeFromProto(outcome ssov1.PermissionChangeResult_Outcome) string {
+	switch outcome {
+	case ssov1.PermissionChangeResult_OUTCOME_CREATED:
+		return models.PermissionChangeCreated
+	case ssov1.PermissionChangeResult_OUTCOME_UPDATED:
+		return models.PermissionChangeUpdated
+	case ssov1.PermissionChangeResult_OUTCOME_UNCHANGED:
+		return models.PermissionChangeUnchanged
+	case ssov1.PermissionChangeResult_OUTCOME_REJECTED:
+		return models.PermissionChangeRejected
+	default:
+		return models.PermissionChangeFailed
+	}
+}
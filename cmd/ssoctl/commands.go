@@ -0,0 +1,401 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type appView struct {
+	ID     int    `json:"id" yaml:"id"`
+	Name   string `json:"name" yaml:"name"`
+	Secret string `json:"secret,omitempty" yaml:"secret,omitempty"`
+}
+
+type userView struct {
+	ID       int64  `json:"id" yaml:"id"`
+	Email    string `json:"email" yaml:"email"`
+	Username string `json:"username" yaml:"username"`
+}
+
+type roleView struct {
+	UserId       int64  `json:"user_id" yaml:"user_id"`
+	AppId        int64  `json:"app_id" yaml:"app_id"`
+	Role         string `json:"role" yaml:"role"`
+	PreviousRole string `json:"previous_role,omitempty" yaml:"previous_role,omitempty"`
+	Outcome      string `json:"outcome" yaml:"outcome"`
+}
+
+type sessionsView struct {
+	UserId    int64     `json:"user_id" yaml:"user_id"`
+	RevokedAt time.Time `json:"revoked_at" yaml:"revoked_at"`
+}
+
+type decodedTokenView struct {
+	Header map[string]any `json:"header" yaml:"header"`
+	Claims map[string]any `json:"claims" yaml:"claims"`
+}
+
+type inspectedTokenView struct {
+	Valid      bool   `json:"valid" yaml:"valid"`
+	AppId      int64  `json:"app_id" yaml:"app_id"`
+	UserId     int64  `json:"user_id" yaml:"user_id"`
+	Permission string `json:"permission" yaml:"permission"`
+}
+
+type auditEventView struct {
+	ID        int64          `json:"id" yaml:"id"`
+	AppId     int64          `json:"app_id,omitempty" yaml:"app_id,omitempty"`
+	ActorId   int64          `json:"actor_id,omitempty" yaml:"actor_id,omitempty"`
+	UserId    int64          `json:"user_id,omitempty" yaml:"user_id,omitempty"`
+	Action    string         `json:"action" yaml:"action"`
+	Details   map[string]any `json:"details,omitempty" yaml:"details,omitempty"`
+	CreatedAt time.Time      `json:"created_at" yaml:"created_at"`
+}
+
+func appsCreate(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("apps create", flag.ContinueOnError)
+	name := fs.String("name", "", "app name")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*name) == "" {
+		return errors.New("-name is required")
+	}
+
+	b, err := e.connect()
+	if err != nil {
+		return err
+	}
+	app, err := b.CreateApp(ctx, *name)
+	if err != nil {
+		return err
+	}
+	return printApp(e.printer, app)
+}
+
+func appsRotateSecret(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("apps rotate-secret", flag.ContinueOnError)
+	appId := fs.Int64("app-id", 0, "app whose secret to rotate")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *appId == 0 {
+		return errors.New("-app-id is required")
+	}
+
+	b, err := e.connect()
+	if err != nil {
+		return err
+	}
+	app, err := b.RotateAppSecret(ctx, *appId)
+	if err != nil {
+		return err
+	}
+	return printApp(e.printer, app)
+}
+
+func usersCreate(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("users create", flag.ContinueOnError)
+	email := fs.String("email", "", "user email")
+	username := fs.String("username", "", "username, defaults to the local part of the email")
+	password := fs.String("password", "", "initial password")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" || *password == "" {
+		return errors.New("-email and -password are required")
+	}
+	if *username == "" {
+		*username, _, _ = strings.Cut(*email, "@")
+	}
+
+	b, err := e.connect()
+	if err != nil {
+		return err
+	}
+	userId, err := b.CreateUser(ctx, *email, *username, *password)
+	if err != nil {
+		return err
+	}
+
+	view := userView{ID: userId, Email: *email, Username: *username}
+	return e.printer.print(view,
+		[]string{"ID", "EMAIL", "USERNAME"},
+		[][]string{{strconv.FormatInt(view.ID, 10), view.Email, view.Username}},
+	)
+}
+
+func rolesGrant(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("roles grant", flag.ContinueOnError)
+	userId := fs.Int64("user-id", 0, "user to grant the role to")
+	appId := fs.Int64("app-id", 0, "app the role applies to")
+	role := fs.String("role", "", "role to grant")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *role == "" {
+		return errors.New("-role is required")
+	}
+	if _, ok := models.RoleRank(*role); !ok {
+		return fmt.Errorf("unknown role %q", *role)
+	}
+	return setRole(ctx, e, *userId, *appId, *role)
+}
+
+// rolesRevoke takes the user back to the plain user role, which every member of an app has.
+func rolesRevoke(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("roles revoke", flag.ContinueOnError)
+	userId := fs.Int64("user-id", 0, "user to revoke the role from")
+	appId := fs.Int64("app-id", 0, "app the role applies to")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return setRole(ctx, e, *userId, *appId, models.RoleUser)
+}
+
+func setRole(ctx context.Context, e *env, userId int64, appId int64, role string) error {
+	if userId == 0 || appId == 0 {
+		return errors.New("-user-id and -app-id are required")
+	}
+
+	b, err := e.connect()
+	if err != nil {
+		return err
+	}
+	result, err := b.SetRole(ctx, models.PermissionChange{
+		UserId:     userId,
+		AppId:      appId,
+		Permission: role,
+	})
+	if err != nil {
+		return err
+	}
+	if result.Err != nil {
+		return fmt.Errorf("role change %s: %w", result.Outcome, result.Err)
+	}
+
+	view := roleView{
+		UserId:       userId,
+		AppId:        appId,
+		Role:         role,
+		PreviousRole: result.PreviousPermission,
+		Outcome:      result.Outcome,
+	}
+	return e.printer.print(view,
+		[]string{"USER", "APP", "ROLE", "PREVIOUS", "OUTCOME"},
+		[][]string{{
+			strconv.FormatInt(view.UserId, 10),
+			strconv.FormatInt(view.AppId, 10),
+			view.Role,
+			view.PreviousRole,
+			view.Outcome,
+		}},
+	)
+}
+
+func sessionsRevoke(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("sessions revoke", flag.ContinueOnError)
+	userId := fs.Int64("user-id", 0, "user whose sessions to revoke")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *userId == 0 {
+		return errors.New("-user-id is required")
+	}
+
+	b, err := e.connect()
+	if err != nil {
+		return err
+	}
+	revokedAt, err := b.RevokeSessions(ctx, *userId)
+	if err != nil {
+		return err
+	}
+
+	view := sessionsView{UserId: *userId, RevokedAt: revokedAt}
+	return e.printer.print(view,
+		[]string{"USER", "REVOKED AT"},
+		[][]string{{strconv.FormatInt(view.UserId, 10), view.RevokedAt.Format(time.RFC3339)}},
+	)
+}
+
+// tokensDecode shows what a token says without checking its signature,
+// so it needs neither the service nor the database.
+func tokensDecode(_ context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("tokens decode", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("expected exactly one token")
+	}
+
+	claims := jwt.MapClaims{}
+	token, _, err := jwt.NewParser().ParseUnverified(fs.Arg(0), claims)
+	if err != nil {
+		return fmt.Errorf("failed to decode token: %w", err)
+	}
+
+	view := decodedTokenView{Header: token.Header, Claims: claims}
+
+	var rows [][]string
+	for _, key := range sortedKeys(token.Header) {
+		rows = append(rows, []string{"header", key, formatClaim(key, token.Header[key])})
+	}
+	for _, key := range sortedKeys(claims) {
+		rows = append(rows, []string{"claims", key, formatClaim(key, claims[key])})
+	}
+	return e.printer.print(view, []string{"PART", "KEY", "VALUE"}, rows)
+}
+
+// tokensInspect has the service verify the token the way it would for any other caller.
+func tokensInspect(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("tokens inspect", flag.ContinueOnError)
+	appId := fs.Int64("app-id", 0, "app the token was issued for")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *appId == 0 {
+		return errors.New("-app-id is required")
+	}
+	if fs.NArg() != 1 {
+		return errors.New("expected exactly one token")
+	}
+
+	b, err := e.connect()
+	if err != nil {
+		return err
+	}
+	info, err := b.InspectToken(ctx, fs.Arg(0), *appId)
+	if err != nil {
+		return fmt.Errorf("token is not valid: %w", err)
+	}
+
+	view := inspectedTokenView{
+		Valid:      true,
+		AppId:      *appId,
+		UserId:     info.UserId,
+		Permission: info.Permission,
+	}
+	return e.printer.print(view,
+		[]string{"VALID", "APP", "USER", "PERMISSION"},
+		[][]string{{
+			strconv.FormatBool(view.Valid),
+			strconv.FormatInt(view.AppId, 10),
+			strconv.FormatInt(view.UserId, 10),
+			view.Permission,
+		}},
+	)
+}
+
+func auditExport(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("audit export", flag.ContinueOnError)
+	var filter models.AuditFilter
+	fs.Int64Var(&filter.AppId, "app-id", 0, "only events of this app")
+	fs.Int64Var(&filter.ActorId, "actor-id", 0, "only events caused by this user")
+	fs.Int64Var(&filter.UserId, "user-id", 0, "only events affecting this user")
+	fs.StringVar(&filter.Action, "action", "", "only events with this action")
+	since := fs.String("since", "", "only events at or after this time (RFC 3339)")
+	until := fs.String("until", "", "only events before this time (RFC 3339)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var err error
+	if filter.Since, err = parseTime(*since); err != nil {
+		return fmt.Errorf("invalid -since: %w", err)
+	}
+	if filter.Until, err = parseTime(*until); err != nil {
+		return fmt.Errorf("invalid -until: %w", err)
+	}
+
+	b, err := e.connect()
+	if err != nil {
+		return err
+	}
+
+	views := []auditEventView{}
+	var rows [][]string
+	err = b.ExportAudit(ctx, filter, func(event models.AuditEvent) error {
+		view := auditEventView{
+			ID:        event.ID,
+			AppId:     event.AppId,
+			ActorId:   event.ActorId,
+			UserId:    event.UserId,
+			Action:    event.Action,
+			Details:   event.Details,
+			CreatedAt: event.CreatedAt,
+		}
+		views = append(views, view)
+
+		details, _ := json.Marshal(view.Details)
+		rows = append(rows, []string{
+			strconv.FormatInt(view.ID, 10),
+			view.CreatedAt.Format(time.RFC3339),
+			view.Action,
+			formatId(view.AppId),
+			formatId(view.ActorId),
+			formatId(view.UserId),
+			string(details),
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return e.printer.print(views, []string{"ID", "TIME", "ACTION", "APP", "ACTOR", "USER", "DETAILS"}, rows)
+}
+
+func printApp(p printer, app models.App) error {
+	view := appView{ID: app.ID, Name: app.Name, Secret: app.Secret}
+	return p.print(view,
+		[]string{"ID", "NAME", "SECRET"},
+		[][]string{{strconv.Itoa(view.ID), view.Name, view.Secret}},
+	)
+}
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func formatId(id int64) string {
+	if id == 0 {
+		return "-"
+	}
+	return strconv.FormatInt(id, 10)
+}
+
+// formatClaim shows the registered time claims as times rather than unix seconds.
+func formatClaim(key string, value any) string {
+	if seconds, ok := value.(float64); ok && (key == "exp" || key == "iat" || key == "nbf") {
+		return time.Unix(int64(seconds), 0).UTC().Format(time.RFC3339)
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(value)
+	return string(b)
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
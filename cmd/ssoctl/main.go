@@ -0,0 +1,142 @@
+// Command ssoctl administers the SSO service, either through its gRPC API
+// or, with -offline, directly against the database.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, env *env, args []string) error
+}
+
+var commands = map[string]command{
+	"apps create":        {"-name NAME", appsCreate},
+	"apps rotate-secret": {"-app-id ID", appsRotateSecret},
+	"users create":       {"-email EMAIL -password PASSWORD [-username NAME]", usersCreate},
+	"roles grant":        {"-user-id ID -app-id ID -role ROLE", rolesGrant},
+	"roles revoke":       {"-user-id ID -app-id ID", rolesRevoke},
+	"sessions revoke":    {"-user-id ID", sessionsRevoke},
+	"tokens decode":      {"TOKEN", tokensDecode},
+	"tokens inspect":     {"-app-id ID TOKEN", tokensInspect},
+	"audit export":       {"[-app-id ID] [-actor-id ID] [-user-id ID] [-action ACTION] [-since TIME] [-until TIME]", auditExport},
+}
+
+// commandOrder keeps the usage output stable.
+var commandOrder = []string{
+	"apps create",
+	"apps rotate-secret",
+	"users create",
+	"roles grant",
+	"roles revoke",
+	"sessions revoke",
+	"tokens decode",
+	"tokens inspect",
+	"audit export",
+}
+
+// env is what every command needs: a backend, connected lazily so that
+// local commands like tokens decode work without a server, and a printer.
+type env struct {
+	opts    options
+	printer printer
+	backend backend
+}
+
+type options struct {
+	addr       string
+	token      string
+	tokenAppId int64
+	tls        bool
+	offline    bool
+	configPath string
+	output     string
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.addr, "addr", "localhost:50051", "gRPC address of the SSO service")
+	flag.StringVar(&opts.token, "token", os.Getenv("SSO_TOKEN"), "admin token, defaults to $SSO_TOKEN")
+	flag.Int64Var(&opts.tokenAppId, "token-app-id", 0, "app the token was issued for, used by role changes (defaults to the target app)")
+	flag.BoolVar(&opts.tls, "tls", false, "connect to the service over TLS")
+	flag.BoolVar(&opts.offline, "offline", false, "work directly against the database instead of the gRPC API")
+	flag.StringVar(&opts.configPath, "config", os.Getenv("SSO_CONFIG_PATH"), "service config file, used with -offline (defaults to $SSO_CONFIG_PATH)")
+	flag.StringVar(&opts.output, "o", formatTable, "output format: table, json or yaml")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() < 2 {
+		usage()
+		os.Exit(2)
+	}
+	name := flag.Arg(0) + " " + flag.Arg(1)
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "ssoctl: unknown command %q\n\n", name)
+		usage()
+		os.Exit(2)
+	}
+
+	p, err := newPrinter(os.Stdout, opts.output)
+	if err != nil {
+		fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	e := &env{opts: opts, printer: p}
+	err = cmd.run(ctx, e, flag.Args()[2:])
+	if e.backend != nil {
+		e.backend.Close()
+	}
+	if err != nil {
+		fatal(err)
+	}
+}
+
+// connect returns the backend selected by the global flags, opening it on first use.
+func (e *env) connect() (backend, error) {
+	if e.backend != nil {
+		return e.backend, nil
+	}
+
+	var (
+		b   backend
+		err error
+	)
+	if e.opts.offline {
+		b, err = newOfflineBackend(e.opts.configPath)
+	} else {
+		b, err = newGRPCBackend(e.opts.addr, e.opts.token, e.opts.tokenAppId, e.opts.tls)
+	}
+	if err != nil {
+		return nil, err
+	}
+	e.backend = b
+	return b, nil
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintln(out, "Usage: ssoctl [flags] <command> [command flags]")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Commands:")
+	for _, name := range commandOrder {
+		fmt.Fprintf(out, "  %-20s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Flags:")
+	flag.PrintDefaults()
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "ssoctl:", err)
+	os.Exit(1)
+}
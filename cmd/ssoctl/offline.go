@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/app"
+	"github.com/botanikn/go_sso_service/internal/config"
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/services/apps"
+	"github.com/botanikn/go_sso_service/internal/services/audit"
+	"github.com/botanikn/go_sso_service/internal/services/auth"
+	"github.com/botanikn/go_sso_service/internal/services/users"
+	"github.com/botanikn/go_sso_service/internal/storage/postgresql"
+	"github.com/botanikn/go_sso_service/internal/storage/sqlite"
+	"github.com/botanikn/go_sso_service/pkg/database"
+	"github.com/botanikn/go_sso_service/pkg/envelope"
+)
+
+// offlineActorId is recorded as the actor of audited changes made offline,
+// the same id the service uses for its own actions.
+const offlineActorId int64 = 0
+
+// offlineBackend runs the service's own business logic against the database.
+// There is no caller to authorize, whoever can reach the database is trusted.
+type offlineBackend struct {
+	close func() error
+	auth  *auth.Auth
+	apps  *apps.Apps
+	users *users.Users
+	audit *audit.Audit
+}
+
+func newOfflineBackend(configPath string) (*offlineBackend, error) {
+	if configPath == "" {
+		return nil, errors.New("-offline needs the service config, pass -config or set SSO_CONFIG_PATH")
+	}
+	cfg := config.MustLoadPath(configPath)
+	if cfg.DbConfig.Driver == config.DriverMemory {
+		return nil, errors.New("-offline needs a database, the config selects the in-memory storage")
+	}
+
+	keyring, err := envelope.Load(cfg.Encryption.KeyVersion, cfg.Encryption.Keys, cfg.Encryption.KeysFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load encryption keys: %w", err)
+	}
+
+	var storage app.Storage
+	var closeStorage func() error
+	if cfg.DbConfig.Driver == database.DriverSQLite {
+		db, err := database.NewDB(cfg.DbConfig.Host, cfg.DbConfig.Port, cfg.DbConfig.User, cfg.DbConfig.Password, cfg.DbConfig.Dbname, cfg.DbConfig.Driver)
+		if err != nil {
+			return nil, err
+		}
+		storage, closeStorage = sqlite.New(db, keyring), db.Close
+	} else {
+		// A command runs one operation at a time, it needs no more than a couple of connections.
+		pool, err := database.NewPool(context.Background(), cfg.DbConfig.Host, cfg.DbConfig.Port, cfg.DbConfig.User, cfg.DbConfig.Password, cfg.DbConfig.Dbname, database.PoolOptions{
+			MaxConns:     2,
+			QueryTimeout: cfg.DbConfig.QueryTimeout,
+		})
+		if err != nil {
+			return nil, err
+		}
+		storage, closeStorage = postgresql.New(pool, nil, keyring), func() error {
+			pool.Close()
+			return nil
+		}
+	}
+
+	// Service logs would drown the command output, only problems are worth showing.
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	return &offlineBackend{
+		close: closeStorage,
+		auth:  auth.New(log, storage, storage, storage, storage, storage, storage, storage, storage, cfg.TokenTTL),
+		apps:  apps.New(log, storage, storage, storage, storage, storage),
+		users: users.New(log, storage, storage, storage, storage, cfg.Admin.AppId),
+		audit: audit.New(log, storage),
+	}, nil
+}
+
+func (b *offlineBackend) CreateApp(ctx context.Context, name string) (models.App, error) {
+	return b.apps.CreateApp(ctx, offlineActorId, name)
+}
+
+func (b *offlineBackend) RotateAppSecret(ctx context.Context, appId int64) (models.App, error) {
+	return b.apps.RotateSecret(ctx, offlineActorId, appId)
+}
+
+func (b *offlineBackend) CreateUser(ctx context.Context, email string, username string, password string) (int64, error) {
+	return b.auth.Register(ctx, email, username, password)
+}
+
+func (b *offlineBackend) SetRole(ctx context.Context, change models.PermissionChange) (models.PermissionChangeResult, error) {
+	results, _, err := b.auth.BatchUpdatePermissions(ctx, []models.PermissionChange{change}, false)
+	if err != nil {
+		return models.PermissionChangeResult{}, err
+	}
+	return results[0], nil
+}
+
+func (b *offlineBackend) RevokeSessions(ctx context.Context, userId int64) (time.Time, error) {
+	return b.users.RevokeSessions(ctx, offlineActorId, userId)
+}
+
+func (b *offlineBackend) InspectToken(ctx context.Context, token string, appId int64) (tokenInfo, error) {
+	valid, err := b.auth.ValidateToken(ctx, token, appId)
+	if err != nil {
+		return tokenInfo{}, err
+	}
+
+	permission, err := b.auth.CheckPermissions(ctx, valid.UserId, appId, token)
+	if err != nil {
+		return tokenInfo{}, err
+	}
+	return tokenInfo{
+		UserId:     valid.UserId,
+		Permission: permission,
+	}, nil
+}
+
+func (b *offlineBackend) ExportAudit(ctx context.Context, filter models.AuditFilter, send func(models.AuditEvent) error) error {
+	return b.audit.Export(ctx, filter, send)
+}
+
+func (b *offlineBackend) Close() error {
+	return b.close()
+}
@@ -1,34 +1,259 @@
 package app
 
 import (
+	"context"
 	"log/slog"
 	"time"
 
 	"github.com/botanikn/go_sso_service/internal/app/grpcapp"
+	"github.com/botanikn/go_sso_service/internal/app/metricsapp"
 	"github.com/botanikn/go_sso_service/internal/config"
+	"github.com/botanikn/go_sso_service/internal/mail"
+	"github.com/botanikn/go_sso_service/internal/services/account"
+	"github.com/botanikn/go_sso_service/internal/services/apps"
+	"github.com/botanikn/go_sso_service/internal/services/audit"
+	"github.com/botanikn/go_sso_service/internal/services/auth"
+	"github.com/botanikn/go_sso_service/internal/services/authz"
+	"github.com/botanikn/go_sso_service/internal/services/bootstrap"
+	"github.com/botanikn/go_sso_service/internal/services/delegation"
+	"github.com/botanikn/go_sso_service/internal/services/grants"
+	"github.com/botanikn/go_sso_service/internal/services/impersonation"
+	"github.com/botanikn/go_sso_service/internal/services/invitations"
+	"github.com/botanikn/go_sso_service/internal/services/members"
+	"github.com/botanikn/go_sso_service/internal/services/relations"
+	"github.com/botanikn/go_sso_service/internal/services/users"
+	"github.com/botanikn/go_sso_service/internal/storage/cache"
+	"github.com/botanikn/go_sso_service/internal/storage/memory"
+	"github.com/botanikn/go_sso_service/internal/storage/postgresql"
+	"github.com/botanikn/go_sso_service/internal/storage/sqlite"
+	"github.com/botanikn/go_sso_service/pkg/database"
+	"github.com/botanikn/go_sso_service/pkg/envelope"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 )
 
+// Storage is everything the services need from a storage backend.
+type Storage interface {
+	auth.UserSaver
+	auth.UserProvider
+	auth.AppProvider
+	auth.PermissionProvider
+	auth.PermissionCreator
+	auth.PermissionUpdater
+	auth.SettingsProvider
+	auth.Transactor
+	authz.PolicyProvider
+	authz.UserProvider
+	authz.PolicyStore
+	relations.NamespaceProvider
+	relations.NamespaceWriter
+	relations.TupleProvider
+	relations.TupleWriter
+	grants.PermissionGranter
+	grants.PermissionExpirer
+	grants.AuditSaver
+	impersonation.UserProvider
+	delegation.RoleMemberProvider
+	apps.AppProvider
+	apps.SettingsStore
+	users.UserProvider
+	users.UserUpdater
+	members.MemberProvider
+	audit.EventProvider
+	bootstrap.SuperAdminStore
+	invitations.InvitationStore
+	account.UserProvider
+	account.AccountStore
+	account.AuditStore
+	cache.Store
+}
+
 type App struct {
-	grpcSrv *grpcapp.App
+	grpcSrv        *grpcapp.App
+	metricsSrv     *metricsapp.App
+	grants         *grants.Grants
+	grantsCfg      *config.GrantsConfig
+	account        *account.Account
+	accountsCfg    *config.AccountsConfig
+	replicas       *database.Replicas
+	storageCfg     *config.DbConfig
+	background     context.Context
+	stopBackground context.CancelFunc
 }
 
 func New(
 	log *slog.Logger,
 	grpcPort int,
 	storageCfg *config.DbConfig,
+	grantsCfg *config.GrantsConfig,
+	bootstrapCfg *config.BootstrapConfig,
+	invitationsCfg *config.InvitationsConfig,
+	mailCfg *config.MailConfig,
+	accountsCfg *config.AccountsConfig,
+	metricsCfg *config.MetricsConfig,
+	cacheCfg *config.CacheConfig,
+	encryptionCfg *config.EncryptionConfig,
 	tokenTTL time.Duration,
+	impersonationTTL time.Duration,
+	adminAppId int64,
 ) *App {
-	grpcApp := grpcapp.New(log, grpcPort, storageCfg, tokenTTL)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	keyring, err := envelope.Load(encryptionCfg.KeyVersion, encryptionCfg.Keys, encryptionCfg.KeysFile)
+	if err != nil {
+		panic("failed to load encryption keys: " + err.Error())
+	}
+	if keyring == nil && storageCfg.Driver != config.DriverMemory {
+		log.Warn("no encryption keys are configured, app secrets are stored in plaintext")
+	}
+
+	backend, replicas := newStorage(log, storageCfg, keyring, registry)
+	storage := newCachedStorage(log, backend, cacheCfg, keyring, registry)
+
+	authService := auth.New(log, storage, storage, storage, storage, storage, storage, storage, storage, tokenTTL)
+	authzService, err := authz.New(log, storage, storage, storage, storage, storage, storage)
+	if err != nil {
+		panic("failed to create authz service: " + err.Error())
+	}
+	relationsService := relations.New(log, storage, storage, storage, storage)
+	grantsService := grants.New(log, storage, storage, storage, storage, grantsCfg.BreakGlassMaxDuration)
+	impersonationService := impersonation.New(log, storage, storage, storage, storage, authService, impersonationTTL)
+	delegationService := delegation.New(log, storage, storage, storage, storage, storage)
+	appsService := apps.New(log, storage, storage, storage, storage, storage)
+	usersService := users.New(log, storage, storage, storage, storage, adminAppId)
+	membersService := members.New(log, storage)
+	auditService := audit.New(log, storage)
+	bootstrapService := bootstrap.New(log, authService, storage, storage, storage, storage)
+
+	var invitationSender invitations.Sender
+	if mailCfg.Host != "" {
+		invitationSender = mail.New(mailCfg.Host, mailCfg.Port, mailCfg.Username, mailCfg.Password, mailCfg.From, invitationsCfg.AcceptURL)
+	} else {
+		log.Info("mail is not configured, invitation tokens have to be delivered by the inviter")
+	}
+	invitationsService := invitations.New(log, storage, storage, authService, storage, storage, invitationSender, invitationsCfg.TTL)
+	accountService := account.New(log, storage, storage, storage, accountsCfg.DeletionGracePeriod)
+
+	setupToken, err := bootstrapService.Init(context.Background(), bootstrapCfg.Email, bootstrapCfg.Username, bootstrapCfg.Password)
+	if err != nil {
+		panic("failed to bootstrap super-admin: " + err.Error())
+	}
+	if setupToken != "" {
+		log.Warn("no super-admin exists yet, call Setup with this one-time token to create one",
+			slog.String("setupToken", setupToken))
+	}
+
+	grpcApp := grpcapp.New(
+		log,
+		grpcPort,
+		authService,
+		authzService,
+		relationsService,
+		grantsService,
+		impersonationService,
+		delegationService,
+		membersService,
+		bootstrapService,
+		appsService,
+		usersService,
+		auditService,
+		invitationsService,
+		accountService,
+		adminAppId,
+	)
+
+	var metricsApp *metricsapp.App
+	if metricsCfg.Address != "" {
+		metricsApp = metricsapp.New(log, metricsCfg.Address, registry)
+	}
+
+	background, stopBackground := context.WithCancel(context.Background())
 
 	return &App{
-		grpcSrv: grpcApp,
+		grpcSrv:        grpcApp,
+		metricsSrv:     metricsApp,
+		grants:         grantsService,
+		grantsCfg:      grantsCfg,
+		account:        accountService,
+		accountsCfg:    accountsCfg,
+		replicas:       replicas,
+		storageCfg:     storageCfg,
+		background:     background,
+		stopBackground: stopBackground,
+	}
+}
+
+// newStorage returns the backend selected by the driver, the memory driver keeps
+// everything in process and needs no database, the sqlite driver uses a local file.
+// App secrets are encrypted with keyring, the memory driver keeps them as they are.
+// PostgreSQL reads go to the configured replicas, which are returned to be health checked.
+// Connection pool statistics are registered in registry.
+func newStorage(log *slog.Logger, storageCfg *config.DbConfig, keyring *envelope.Keyring, registry prometheus.Registerer) (Storage, *database.Replicas) {
+	switch storageCfg.Driver {
+	case config.DriverMemory:
+		log.Warn("using in-memory storage, all data is lost on restart")
+		return memory.New(), nil
+	case database.DriverSQLite:
+		db, err := database.NewDB(storageCfg.Host, storageCfg.Port, storageCfg.User, storageCfg.Password, storageCfg.Dbname, storageCfg.Driver)
+		if err != nil {
+			panic("failed to connect to the database: " + err.Error())
+		}
+		registry.MustRegister(collectors.NewDBStatsCollector(db, "sqlite"))
+		return sqlite.New(db, keyring), nil
+	}
+
+	pool, err := database.NewPool(context.Background(), storageCfg.Host, storageCfg.Port, storageCfg.User, storageCfg.Password, storageCfg.Dbname, poolOptions(storageCfg))
+	if err != nil {
+		panic("failed to connect to the database: " + err.Error())
+	}
+	registry.MustRegister(database.NewPoolCollector(pool))
+
+	addrs := make([]database.ReplicaAddr, 0, len(storageCfg.Replicas))
+	for _, replica := range storageCfg.Replicas {
+		addrs = append(addrs, database.ReplicaAddr{Host: replica.Host, Port: replica.Port})
+	}
+	replicas, err := database.NewReplicas(context.Background(), log, addrs, storageCfg.User, storageCfg.Password, storageCfg.Dbname, poolOptions(storageCfg))
+	if err != nil {
+		panic("failed to configure read replicas: " + err.Error())
+	}
+	if replicas != nil {
+		registry.MustRegister(database.NewReplicasCollector(replicas))
+	}
+	return postgresql.New(pool, replicas, keyring), replicas
+}
+
+func poolOptions(storageCfg *config.DbConfig) database.PoolOptions {
+	return database.PoolOptions{
+		MaxConns:          storageCfg.Pool.MaxConns,
+		MinConns:          storageCfg.Pool.MinConns,
+		MaxConnLifetime:   storageCfg.Pool.MaxConnLifetime,
+		MaxConnIdleTime:   storageCfg.Pool.MaxConnIdleTime,
+		HealthCheckPeriod: storageCfg.Pool.HealthCheckPeriod,
+		QueryTimeout:      storageCfg.QueryTimeout,
 	}
 }
 
 func (a *App) MustRun() {
+	go a.grants.RunReaper(a.background, a.grantsCfg.ReaperInterval)
+	go a.account.RunPurger(a.background, a.accountsCfg.PurgeInterval)
+	if a.replicas != nil {
+		go a.replicas.Run(a.background, a.storageCfg.ReplicaCheckInterval)
+	}
+	if a.metricsSrv != nil {
+		go a.metricsSrv.MustRun()
+	}
+
 	a.grpcSrv.MustRun()
 }
 
 func (a *App) Stop() {
+	a.stopBackground()
 	a.grpcSrv.Stop()
+	if a.metricsSrv != nil {
+		a.metricsSrv.Stop()
+	}
 }
@@ -0,0 +1,177 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/config"
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/storage/cache"
+	"github.com/botanikn/go_sso_service/pkg/envelope"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+// newCachedStorage puts the cache selected by cacheCfg in front of storage,
+// storage is returned as is when the cache is disabled. App secrets are cached
+// encrypted with keyring.
+func newCachedStorage(log *slog.Logger, storage Storage, cacheCfg *config.CacheConfig, keyring *envelope.Keyring, registry prometheus.Registerer) Storage {
+	if cacheCfg.Disabled {
+		log.Info("cache is disabled")
+		return storage
+	}
+
+	metrics := cache.NewMetrics(registry)
+	var backend cache.Cache
+	switch cacheCfg.Backend {
+	case config.CacheBackendMemory:
+		backend = cache.NewLRU(cacheCfg.Size, metrics.Evicted)
+	case config.CacheBackendRedis:
+		client := redis.NewClient(&redis.Options{
+			Addr:     cacheCfg.Redis.Address,
+			Password: cacheCfg.Redis.Password,
+			DB:       cacheCfg.Redis.DB,
+			// Failed calls fall back to the database, retrying them would only delay that.
+			MaxRetries:    -1,
+			DialerRetries: 1,
+			DialTimeout:   cacheCfg.Redis.Timeout,
+			ReadTimeout:   cacheCfg.Redis.Timeout,
+			WriteTimeout:  cacheCfg.Redis.Timeout,
+		})
+		// The service works without the cache, reads go to the database until Redis is back.
+		if err := client.Ping(context.Background()).Err(); err != nil {
+			log.Warn("failed to connect to redis", slog.String("address", cacheCfg.Redis.Address), slog.String("error", err.Error()))
+		}
+		backend = cache.NewRedis(client, cacheCfg.Redis.Prefix)
+	default:
+		panic("unknown cache backend: " + cacheCfg.Backend)
+	}
+
+	return &cachedStorage{
+		Storage: storage,
+		cache:   cache.New(log, storage, backend, keyring, cacheCfg.TTL, metrics),
+	}
+}
+
+// cachedStorage reads apps and permissions through the cache. Writes that change an app
+// or a permission drop the cached value after they succeed.
+type cachedStorage struct {
+	Storage
+	cache *cache.Repository
+}
+
+func (s *cachedStorage) App(ctx context.Context, appId int64) (models.App, error) {
+	return s.cache.App(ctx, appId)
+}
+
+func (s *cachedStorage) Permission(ctx context.Context, userId int64, appId int64) (string, error) {
+	return s.cache.Permission(ctx, userId, appId)
+}
+
+func (s *cachedStorage) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return s.cache.WithTx(ctx, s.Storage.WithTx, fn)
+}
+
+func (s *cachedStorage) UpdateApp(ctx context.Context, appId int64, name string) error {
+	if err := s.Storage.UpdateApp(ctx, appId, name); err != nil {
+		return err
+	}
+	s.cache.InvalidateApp(ctx, appId)
+	return nil
+}
+
+func (s *cachedStorage) UpdateAppSecret(ctx context.Context, appId int64, secret string) (models.App, error) {
+	app, err := s.Storage.UpdateAppSecret(ctx, appId, secret)
+	if err != nil {
+		return models.App{}, err
+	}
+	s.cache.InvalidateApp(ctx, appId)
+	return app, nil
+}
+
+// DeleteApp drops the app only, the permissions in it can't be read anyway once
+// the app is gone and expire on their own.
+func (s *cachedStorage) DeleteApp(ctx context.Context, appId int64) error {
+	if err := s.Storage.DeleteApp(ctx, appId); err != nil {
+		return err
+	}
+	s.cache.InvalidateApp(ctx, appId)
+	return nil
+}
+
+func (s *cachedStorage) CreatePermission(ctx context.Context, userId int64, appId int64, permission string) (bool, error) {
+	created, err := s.Storage.CreatePermission(ctx, userId, appId, permission)
+	if err != nil {
+		return false, err
+	}
+	s.cache.InvalidatePermission(ctx, userId, appId)
+	return created, nil
+}
+
+func (s *cachedStorage) UpdatePermission(ctx context.Context, userId int64, appId int64, permission string) error {
+	if err := s.Storage.UpdatePermission(ctx, userId, appId, permission); err != nil {
+		return err
+	}
+	s.cache.InvalidatePermission(ctx, userId, appId)
+	return nil
+}
+
+func (s *cachedStorage) BatchUpdatePermissions(
+	ctx context.Context,
+	changes []models.PermissionChange,
+	dryRun bool,
+) ([]models.PermissionChangeResult, bool, error) {
+	results, committed, err := s.Storage.BatchUpdatePermissions(ctx, changes, dryRun)
+	if err != nil {
+		return nil, false, err
+	}
+	if committed {
+		for _, result := range results {
+			s.cache.InvalidatePermission(ctx, result.Change.UserId, result.Change.AppId)
+		}
+	}
+	return results, committed, nil
+}
+
+func (s *cachedStorage) GrantPermission(ctx context.Context, grant models.PermissionGrant) (int64, error) {
+	grantId, err := s.Storage.GrantPermission(ctx, grant)
+	if err != nil {
+		return 0, err
+	}
+	s.cache.InvalidatePermission(ctx, grant.UserId, grant.AppId)
+	return grantId, nil
+}
+
+func (s *cachedStorage) DeleteExpiredPermissions(ctx context.Context, now time.Time) ([]models.PermissionGrant, error) {
+	expired, err := s.Storage.DeleteExpiredPermissions(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, grant := range expired {
+		s.cache.InvalidatePermission(ctx, grant.UserId, grant.AppId)
+	}
+	return expired, nil
+}
+
+func (s *cachedStorage) AcceptInvitation(ctx context.Context, invitationId int64, userId int64) (models.PermissionChangeResult, error) {
+	result, err := s.Storage.AcceptInvitation(ctx, invitationId, userId)
+	if err != nil {
+		return models.PermissionChangeResult{}, err
+	}
+	s.cache.InvalidatePermission(ctx, result.Change.UserId, result.Change.AppId)
+	return result, nil
+}
+
+func (s *cachedStorage) PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) ([]models.PurgedUser, error) {
+	purged, err := s.Storage.PurgeDeletedUsers(ctx, deletedBefore)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range purged {
+		for _, appId := range user.AppIds {
+			s.cache.InvalidatePermission(ctx, user.ID, appId)
+		}
+	}
+	return purged, nil
+}
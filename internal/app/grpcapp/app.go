@@ -5,13 +5,16 @@ import (
 	"log"
 	"log/slog"
 	"net"
-	"time"
 
-	"github.com/botanikn/go_sso_service/internal/config"
+	accountgrpc "github.com/botanikn/go_sso_service/internal/grpc/account"
+	appsgrpc "github.com/botanikn/go_sso_service/internal/grpc/apps"
+	auditgrpc "github.com/botanikn/go_sso_service/internal/grpc/audit"
 	authgrpc "github.com/botanikn/go_sso_service/internal/grpc/auth"
-	"github.com/botanikn/go_sso_service/internal/services/auth"
-	"github.com/botanikn/go_sso_service/internal/storage/postgresql"
-	"github.com/botanikn/go_sso_service/pkg/database"
+	invitationsgrpc "github.com/botanikn/go_sso_service/internal/grpc/invitations"
+	"github.com/botanikn/go_sso_service/internal/grpc/middleware"
+	policiesgrpc "github.com/botanikn/go_sso_service/internal/grpc/policies"
+	relationsgrpc "github.com/botanikn/go_sso_service/internal/grpc/relations"
+	usersgrpc "github.com/botanikn/go_sso_service/internal/grpc/users"
 	"google.golang.org/grpc"
 )
 
@@ -21,21 +24,84 @@ type App struct {
 	port       int
 }
 
+// AuthService serves the Auth service and validates the tokens of every call.
+type AuthService interface {
+	authgrpc.AuthService
+	middleware.TokenValidator
+}
+
+// AuthzService authorizes the calls of every service and manages the policies it evaluates.
+type AuthzService interface {
+	authgrpc.Authorizer
+	policiesgrpc.PoliciesService
+}
+
+// Delegator checks role changes for both the Auth and the Invitations services.
+type Delegator interface {
+	authgrpc.Delegator
+	invitationsgrpc.Delegator
+}
+
 func New(
 	log *slog.Logger,
 	port int,
-	storageCfg *config.DbConfig,
-	tokenTTL time.Duration,
+	authService AuthService,
+	authzService AuthzService,
+	relationsService relationsgrpc.RelationsService,
+	grantsService authgrpc.Granter,
+	impersonationService authgrpc.Impersonator,
+	delegationService Delegator,
+	membersService authgrpc.MemberLister,
+	bootstrapService authgrpc.Bootstrapper,
+	appsService appsgrpc.AppsService,
+	usersService usersgrpc.UsersService,
+	auditService auditgrpc.AuditService,
+	invitationsService invitationsgrpc.InvitationsService,
+	accountService accountgrpc.AccountService,
+	adminAppId int64,
 ) *App {
-	gRPCServer := grpc.NewServer()
-	db, err := database.NewDB(storageCfg.Host, storageCfg.Port, storageCfg.User, storageCfg.Password, storageCfg.Dbname, storageCfg.Driver)
-	if err != nil {
-		panic("failed to connect to the database: " + err.Error())
+	auth := middleware.NewAuth(authService, adminAppId,
+		authgrpc.Roles,
+		relationsgrpc.Roles,
+		appsgrpc.Roles,
+		usersgrpc.Roles,
+		auditgrpc.Roles,
+		invitationsgrpc.Roles,
+		accountgrpc.Roles,
+		policiesgrpc.Roles,
+	)
+
+	// Request IDs come first so that every log line of the call has one, and recovery
+	// is inside the access log so that a panic is logged as the Internal it becomes.
+	gRPCServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			middleware.UnaryRequestID,
+			middleware.UnaryAccessLog(log),
+			middleware.UnaryRecovery(log),
+			middleware.UnarySession,
+			auth.Unary,
+		),
+		grpc.ChainStreamInterceptor(
+			middleware.StreamRequestID,
+			middleware.StreamAccessLog(log),
+			middleware.StreamRecovery(log),
+			middleware.StreamSession,
+			auth.Stream,
+		),
+	)
+
+	authgrpc.Register(gRPCServer, authService, authzService, grantsService, impersonationService, delegationService, membersService, bootstrapService)
+	relationsgrpc.Register(gRPCServer, relationsService, authzService)
+	appsgrpc.Register(gRPCServer, appsService, authzService)
+	usersgrpc.Register(gRPCServer, usersService, authzService)
+	auditgrpc.Register(gRPCServer, auditService, authzService)
+	invitationsgrpc.Register(gRPCServer, invitationsService, authzService, delegationService)
+	accountgrpc.Register(gRPCServer, accountService)
+	policiesgrpc.Register(gRPCServer, authzService, authzService)
+
+	if err := auth.Check(gRPCServer.GetServiceInfo()); err != nil {
+		panic(err)
 	}
-	storage := postgresql.New(db)
-	authService := auth.New(log, storage, storage, storage, storage, storage, storage, tokenTTL)
-
-	authgrpc.Register(gRPCServer, authService)
 
 	return &App{
 		log:        log,
@@ -0,0 +1,76 @@
+package metricsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// shutdownTimeout bounds how long Stop waits for scrapes in progress.
+const shutdownTimeout = 5 * time.Second
+
+// App serves Prometheus metrics on /metrics.
+type App struct {
+	log     *slog.Logger
+	server  *http.Server
+	address string
+}
+
+func New(log *slog.Logger, address string, gatherer prometheus.Gatherer) *App {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}))
+
+	return &App{
+		log: log,
+		server: &http.Server{
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		},
+		address: address,
+	}
+}
+
+func (a *App) MustRun() {
+	if err := a.Run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func (a *App) Run() error {
+	const op = "metricsapp.Run"
+
+	log := a.log.With(slog.String("op", op), slog.String("address", a.address))
+
+	lis, err := net.Listen("tcp", a.address)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("metrics server is running", slog.String("addr", lis.Addr().String()))
+
+	if err := a.server.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (a *App) Stop() {
+	const op = "metricsapp.Stop"
+
+	a.log.With(slog.String("op", op)).Info("stopping metrics server", slog.String("address", a.address))
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := a.server.Shutdown(ctx); err != nil {
+		a.log.Error("failed to stop metrics server", slog.String("error", err.Error()))
+	}
+}
@@ -3,27 +3,66 @@ package config
 import (
 	"flag"
 	"log"
+	"log/slog"
 	"os"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
+// DriverMemory selects the in-memory storage backend instead of a database.
+const DriverMemory = "memory"
+
 type Config struct {
 	Env      string        `yaml:"env" env-default:"local"`
 	DbConfig DbConfig      `yaml:"db" env-required:"true"`
 	GRPC     GRPCConfig    `yaml:"grpc"`
-	TokenTTL time.Duration `yaml:"token_ttl"`
+	TokenTTL time.Duration `yaml:"token_ttl" env-default:"1h"`
+	Grants   GrantsConfig  `yaml:"grants"`
+	// ImpersonationTTL is the longest lifetime of an impersonation token.
+	ImpersonationTTL time.Duration     `yaml:"impersonation_ttl" env-default:"15m"`
+	Admin            AdminConfig       `yaml:"admin"`
+	Bootstrap        BootstrapConfig   `yaml:"bootstrap"`
+	Invitations      InvitationsConfig `yaml:"invitations"`
+	Mail             MailConfig        `yaml:"mail"`
+	Accounts         AccountsConfig    `yaml:"accounts"`
+	Metrics          MetricsConfig     `yaml:"metrics"`
+	Cache            CacheConfig       `yaml:"cache"`
+	Encryption       EncryptionConfig  `yaml:"encryption"`
 }
 
 // COMMENT структуру можно сделать приватной, особеность cleanenv, что поля нет, но при этом все равно стоит получать их через методы
 type DbConfig struct {
+	// Driver is the database/sql driver name, or DriverMemory to keep everything in process memory.
+	// With the sqlite driver Dbname is the path of the database file.
 	Driver   string `yaml:"driver"`
 	Host     string `yaml:"host"`
 	Port     int    `yaml:"port"`
 	User     string `yaml:"user"`
 	Password string `yaml:"password"`
 	Dbname   string `yaml:"dbname"`
+	// Pool and QueryTimeout apply to PostgreSQL only.
+	Pool         PoolConfig    `yaml:"pool"`
+	QueryTimeout time.Duration `yaml:"query_timeout" env-default:"5s"`
+	// Replicas are read replicas of the PostgreSQL primary at Host and Port, connected with the same
+	// user, password and database. They are health checked every ReplicaCheckInterval.
+	Replicas             []ReplicaConfig `yaml:"replicas"`
+	ReplicaCheckInterval time.Duration   `yaml:"replica_check_interval" env-default:"5s"`
+}
+
+type ReplicaConfig struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+}
+
+// PoolConfig sizes the PostgreSQL connection pool. Connections are closed after MaxConnLifetime,
+// or after MaxConnIdleTime without use, and idle connections are checked every HealthCheckPeriod.
+type PoolConfig struct {
+	MaxConns          int32         `yaml:"max_conns" env-default:"10"`
+	MinConns          int32         `yaml:"min_conns" env-default:"0"`
+	MaxConnLifetime   time.Duration `yaml:"max_conn_lifetime" env-default:"1h"`
+	MaxConnIdleTime   time.Duration `yaml:"max_conn_idle_time" env-default:"30m"`
+	HealthCheckPeriod time.Duration `yaml:"health_check_period" env-default:"1m"`
 }
 
 type GRPCConfig struct {
@@ -31,8 +70,98 @@ type GRPCConfig struct {
 	Timeout time.Duration `yaml:"timeout"`
 }
 
+type GrantsConfig struct {
+	ReaperInterval        time.Duration `yaml:"reaper_interval" env-default:"1m"`
+	BreakGlassMaxDuration time.Duration `yaml:"break_glass_max_duration" env-default:"4h"`
+}
+
+// AdminConfig points to the app whose users administer the SSO itself.
+type AdminConfig struct {
+	AppId int64 `yaml:"app_id"`
+}
+
+// BootstrapConfig names the initial super-admin of a fresh deployment.
+// Without an email a one-time setup token is printed at startup instead.
+type BootstrapConfig struct {
+	Email    string `yaml:"email" env:"SSO_BOOTSTRAP_EMAIL"`
+	Username string `yaml:"username" env:"SSO_BOOTSTRAP_USERNAME"`
+	Password string `yaml:"password" env:"SSO_BOOTSTRAP_PASSWORD"`
+}
+
+// InvitationsConfig controls invitations to apps. AcceptURL is where invitees accept,
+// the invitation token is added to it as the token query parameter.
+type InvitationsConfig struct {
+	TTL       time.Duration `yaml:"ttl" env-default:"168h"`
+	AcceptURL string        `yaml:"accept_url"`
+}
+
+// MailConfig is the SMTP server used to send emails, nothing is sent when Host is empty.
+type MailConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port" env-default:"587"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password" env:"SSO_MAIL_PASSWORD"`
+	From     string `yaml:"from"`
+}
+
+// AccountsConfig controls self-service account deletion. Deleted accounts can be
+// restored during DeletionGracePeriod and are purged after it, checked every PurgeInterval.
+type AccountsConfig struct {
+	DeletionGracePeriod time.Duration `yaml:"deletion_grace_period" env-default:"720h"`
+	PurgeInterval       time.Duration `yaml:"purge_interval" env-default:"1h"`
+}
+
+// MetricsConfig is where Prometheus metrics are served, nothing is served when Address is empty.
+type MetricsConfig struct {
+	Address string `yaml:"address"`
+}
+
+// Cache backends.
+const (
+	CacheBackendMemory = "memory"
+	CacheBackendRedis  = "redis"
+)
+
+// CacheConfig is the cache of apps and permissions in front of the database, cached values
+// are at most TTL old. The memory backend is an in-process LRU of Size values, the redis
+// backend is shared by every instance. Disabled is the kill switch, everything is read
+// from the database then.
+type CacheConfig struct {
+	Disabled bool          `yaml:"disabled" env:"SSO_CACHE_DISABLED"`
+	Backend  string        `yaml:"backend" env-default:"memory"`
+	TTL      time.Duration `yaml:"ttl" env-default:"30s"`
+	Size     int           `yaml:"size" env-default:"10000"`
+	Redis    RedisConfig   `yaml:"redis"`
+}
+
+// RedisConfig is the server of the redis cache backend. App secrets are cached too,
+// so it needs the same protection as the database.
+type RedisConfig struct {
+	Address  string `yaml:"address" env-default:"localhost:6379"`
+	Password string `yaml:"password" env:"SSO_REDIS_PASSWORD"`
+	DB       int    `yaml:"db"`
+	// Prefix is put in front of every key, so deployments can share a Redis database.
+	Prefix string `yaml:"prefix" env-default:"sso:"`
+	// Timeout bounds every Redis call, a slow Redis shouldn't be slower than the database.
+	Timeout time.Duration `yaml:"timeout" env-default:"100ms"`
+}
+
+// EncryptionConfig holds the key-encryption keys app secrets are encrypted with at rest,
+// base64 encoded 32-byte keys by version, in Keys or in the YAML file KeysFile. New secrets
+// are encrypted with KeyVersion, older versions only decrypt until `migrator reencrypt`
+// has moved their secrets. Without keys secrets are stored in plaintext.
+type EncryptionConfig struct {
+	KeyVersion int            `yaml:"key_version" env:"SSO_ENCRYPTION_KEY_VERSION" env-default:"1"`
+	Keys       map[int]string `yaml:"keys"`
+	KeysFile   string         `yaml:"keys_file" env:"SSO_ENCRYPTION_KEYS_FILE"`
+}
+
 func MustLoad() *Config {
-	path := fetchConfigPath()
+	return MustLoadPath(fetchConfigPath())
+}
+
+// MustLoadPath loads the config from path, for tools that parse their own flags.
+func MustLoadPath(path string) *Config {
 	if path == "" {
 		log.Fatal("config path is empty")
 	}
@@ -46,6 +175,10 @@ func MustLoad() *Config {
 	if err := cleanenv.ReadConfig(path, &cfg); err != nil {
 		log.Fatalf("failed to read config file: %v", err.Error())
 	}
+	// Apps without settings of their own issue tokens with TokenTTL.
+	if cfg.TokenTTL <= 0 {
+		log.Fatalf("token_ttl must be positive, got %s", cfg.TokenTTL)
+	}
 
 	return &cfg
 }
@@ -62,6 +195,38 @@ func fetchConfigPath() string {
 	return res
 }
 
+// redacted replaces secrets when the config is logged.
+const redacted = "[REDACTED]"
+
+// loggedConfig is Config without its LogValue method, so LogValue can return one.
+type loggedConfig Config
+
+// LogValue is the config with passwords and encryption keys redacted. slog only asks the
+// logged value itself, not its fields, so the secrets of every section are redacted here.
+func (c *Config) LogValue() slog.Value {
+	l := loggedConfig(*c)
+	l.DbConfig.Password = redactString(l.DbConfig.Password)
+	l.Bootstrap.Password = redactString(l.Bootstrap.Password)
+	l.Mail.Password = redactString(l.Mail.Password)
+	l.Cache.Redis.Password = redactString(l.Cache.Redis.Password)
+	if l.Encryption.Keys != nil {
+		keys := make(map[int]string, len(l.Encryption.Keys))
+		for version := range l.Encryption.Keys {
+			keys[version] = redacted
+		}
+		l.Encryption.Keys = keys
+	}
+	return slog.AnyValue(l)
+}
+
+// redactString keeps an empty secret empty, so the log still shows which ones are unset.
+func redactString(s string) string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
 func (c *Config) GetEnv() string {
 	switch c.Env {
 	case EnvLocal:
@@ -1,7 +1,80 @@
 package models
 
+import (
+	"strings"
+	"time"
+)
+
 type App struct {
 	ID     int
 	Name   string
 	Secret string
 }
+
+// Login methods an app can allow.
+const (
+	LoginMethodPassword    = "password"
+	LoginMethodPasskey     = "passkey"
+	LoginMethodExternalIdP = "external_idp"
+)
+
+// Registration policies of an app.
+const (
+	RegistrationOpen             = "open"
+	RegistrationInviteOnly       = "invite_only"
+	RegistrationDomainRestricted = "domain_restricted"
+)
+
+// Membership policies decide what happens when a registered user first logs in to an app.
+const (
+	MembershipOpen             = "open"
+	MembershipDomainRestricted = "domain_restricted"
+	MembershipApproval         = "approval"
+	MembershipClosed           = "closed"
+)
+
+// AppSettings tune authentication per app. Zero TTLs mean the service-wide default.
+// The service issues no refresh tokens yet, RefreshTokenTTL is kept for when it does.
+type AppSettings struct {
+	AppId              int64
+	AccessTokenTTL     time.Duration
+	RefreshTokenTTL    time.Duration
+	LoginMethods       []string
+	RegistrationPolicy string
+	MembershipPolicy   string
+	// AllowedDomains are the email domains that may register or become members
+	// under the domain restricted policies.
+	AllowedDomains []string
+	MFARequired    bool
+}
+
+// DefaultAppSettings returns the settings of an app that has none stored.
+func DefaultAppSettings(appId int64) AppSettings {
+	return AppSettings{
+		AppId:              appId,
+		LoginMethods:       []string{LoginMethodPassword},
+		RegistrationPolicy: RegistrationOpen,
+		MembershipPolicy:   MembershipOpen,
+	}
+}
+
+// AllowsLoginMethod reports whether users may log in to the app with method.
+func (s AppSettings) AllowsLoginMethod(method string) bool {
+	for _, m := range s.LoginMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsEmailDomain reports whether the domain of email is one of AllowedDomains.
+func (s AppSettings) AllowsEmailDomain(email string) bool {
+	_, domain, _ := strings.Cut(email, "@")
+	for _, allowed := range s.AllowedDomains {
+		if strings.EqualFold(domain, allowed) {
+			return true
+		}
+	}
+	return false
+}
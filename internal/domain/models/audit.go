@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// AuditEvent records who did what to whom. ActorId is zero for actions taken by the service itself.
+type AuditEvent struct {
+	ID        int64
+	AppId     int64
+	ActorId   int64
+	UserId    int64
+	Action    string
+	Details   map[string]any
+	CreatedAt time.Time
+}
+
+// AuditFilter narrows an audit log export, zero values match everything.
+// Since is inclusive and Until is exclusive.
+type AuditFilter struct {
+	AppId   int64
+	ActorId int64
+	UserId  int64
+	Action  string
+	Since   time.Time
+	Until   time.Time
+}
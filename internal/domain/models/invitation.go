@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Invitation statuses, derived from the invitation's timestamps.
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationRevoked  = "revoked"
+	InvitationExpired  = "expired"
+)
+
+// Invitation lets the holder of its token join the app with Role.
+// InvitedBy and AcceptedBy are zero when unknown.
+type Invitation struct {
+	ID         int64
+	AppId      int64
+	Email      string
+	Role       string
+	InvitedBy  int64
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+	AcceptedAt time.Time
+	AcceptedBy int64
+	RevokedAt  time.Time
+}
+
+// Status returns the status of the invitation at now.
+func (i Invitation) Status(now time.Time) string {
+	switch {
+	case !i.AcceptedAt.IsZero():
+		return InvitationAccepted
+	case !i.RevokedAt.IsZero():
+		return InvitationRevoked
+	case !now.Before(i.ExpiresAt):
+		return InvitationExpired
+	default:
+		return InvitationPending
+	}
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// PermissionGrant is a permission that is only in effect between ValidFrom and ValidUntil.
+// Zero times mean the grant is unbounded on that side.
+type PermissionGrant struct {
+	ID            int64
+	UserId        int64
+	AppId         int64
+	Permission    string
+	ValidFrom     time.Time
+	ValidUntil    time.Time
+	GrantedBy     int64
+	Justification string
+}
+
+// AppMember is a user with access to an app and their effective permission there.
+type AppMember struct {
+	UserId     int64
+	Email      string
+	Username   string
+	Permission string
+}
+
+// Outcomes of a permission change in a batch.
+const (
+	PermissionChangeCreated   = "created"
+	PermissionChangeUpdated   = "updated"
+	PermissionChangeUnchanged = "unchanged"
+	PermissionChangeRejected  = "rejected"
+	PermissionChangeFailed    = "failed"
+)
+
+// PermissionChange sets the user's permanent permission in the app.
+type PermissionChange struct {
+	UserId     int64
+	AppId      int64
+	Permission string
+}
+
+type PermissionChangeResult struct {
+	Change             PermissionChange
+	Outcome            string
+	PreviousPermission string
+	Err                error
+}
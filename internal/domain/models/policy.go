@@ -0,0 +1,16 @@
+package models
+
+const (
+	PolicyEffectAllow = "allow"
+	PolicyEffectDeny  = "deny"
+)
+
+// Policy is a CEL expression stored per app and evaluated for a single action.
+type Policy struct {
+	ID         int64
+	AppId      int64
+	Name       string
+	Action     string
+	Effect     string
+	Expression string
+}
@@ -0,0 +1,63 @@
+package models
+
+// Subject is either a concrete object (user:42) or a userset (team:z#member)
+// when Relation is set.
+type Subject struct {
+	Namespace string
+	ObjectId  string
+	Relation  string
+}
+
+// RelationTuple is a single object#relation@subject fact.
+type RelationTuple struct {
+	Namespace string
+	ObjectId  string
+	Relation  string
+	Subject   Subject
+}
+
+// RelationTupleFilter selects tuples by any combination of fields, empty fields match everything.
+type RelationTupleFilter struct {
+	Namespace string
+	ObjectId  string
+	Relation  string
+	Subject   *Subject
+}
+
+type Namespace struct {
+	AppId  int64
+	Name   string
+	Config NamespaceConfig
+}
+
+// NamespaceConfig lists the relations of a namespace together with their rewrite rules.
+type NamespaceConfig struct {
+	Relations map[string]Rewrite `json:"relations"`
+}
+
+// Rewrite describes how a relation is computed. Exactly one field should be set,
+// an empty rewrite means the relation only consists of its own tuples.
+type Rewrite struct {
+	This            *struct{}        `json:"this,omitempty"`
+	ComputedUserset *ComputedUserset `json:"computed_userset,omitempty"`
+	TupleToUserset  *TupleToUserset  `json:"tuple_to_userset,omitempty"`
+	Union           []Rewrite        `json:"union,omitempty"`
+	Intersection    []Rewrite        `json:"intersection,omitempty"`
+	Exclusion       *Exclusion       `json:"exclusion,omitempty"`
+}
+
+type ComputedUserset struct {
+	Relation string `json:"relation"`
+}
+
+// TupleToUserset follows the objects found in Tupleset and checks ComputedUserset on them,
+// e.g. "viewers of the parent folder are viewers of the document".
+type TupleToUserset struct {
+	Tupleset        string `json:"tupleset"`
+	ComputedUserset string `json:"computed_userset"`
+}
+
+type Exclusion struct {
+	Base     Rewrite `json:"base"`
+	Subtract Rewrite `json:"subtract"`
+}
@@ -0,0 +1,56 @@
+package models
+
+// Roles stored in permissions.permission.
+const (
+	RoleBanned = "banned"
+	// RolePending is held by users waiting for approval to join the app.
+	RolePending      = "pending"
+	RoleUser         = "user"
+	RoleImpersonator = "impersonator"
+	RoleUserManager  = "user_manager"
+	RoleAdmin        = "admin"
+	RoleOwner        = "owner"
+)
+
+// Capabilities are the administrative actions a role allows within its app.
+const (
+	CapabilityManageUsers    = "manage_users"
+	CapabilityManageRoles    = "manage_roles"
+	CapabilityManageSettings = "manage_settings"
+)
+
+// roleRanks orders roles by privilege, nobody can hand out a role ranked above their own.
+var roleRanks = map[string]int{
+	RoleBanned:       0,
+	RolePending:      0,
+	RoleUser:         1,
+	RoleImpersonator: 2,
+	RoleUserManager:  3,
+	RoleAdmin:        4,
+	RoleOwner:        5,
+}
+
+var roleCapabilities = map[string][]string{
+	RoleUserManager: {CapabilityManageUsers},
+	RoleAdmin:       {CapabilityManageUsers, CapabilityManageRoles, CapabilityManageSettings},
+	RoleOwner:       {CapabilityManageUsers, CapabilityManageRoles, CapabilityManageSettings},
+}
+
+// RoleRank returns the rank of the role and false if the role is unknown.
+func RoleRank(role string) (int, bool) {
+	rank, ok := roleRanks[role]
+	return rank, ok
+}
+
+func RoleCapabilities(role string) []string {
+	return roleCapabilities[role]
+}
+
+func RoleHasCapability(role string, capability string) bool {
+	for _, c := range roleCapabilities[role] {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
@@ -1,8 +1,37 @@
 package models
 
+import "time"
+
+const (
+	UserStatusActive   = "active"
+	UserStatusDisabled = "disabled"
+)
+
 type User struct {
-	ID       string
-	Username string
-	Email    string
-	PassHash []byte
+	ID         string
+	Username   string
+	Email      string
+	PassHash   []byte
+	Status     string
+	SuperAdmin bool
+	// TokensRevokedAt invalidates every token issued up to that moment, zero if never revoked.
+	TokensRevokedAt time.Time
+	// DeletedAt is set while the account waits to be purged, zero otherwise.
+	DeletedAt  time.Time
+	Attributes map[string]any
+}
+
+// UserFilter narrows a user listing, zero values match everything.
+type UserFilter struct {
+	EmailPrefix string
+	Username    string
+	AppId       int64
+	Status      string
+}
+
+// PurgedUser is a user removed once its deletion grace period was over, AppIds are the
+// apps it had permissions in.
+type PurgedUser struct {
+	ID     int64
+	AppIds []int64
 }
@@ -0,0 +1,119 @@
+package account
+
+import (
+	"context"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/grpc/grpcerr"
+	"github.com/botanikn/go_sso_service/internal/grpc/middleware"
+	ssov1 "github.com/botanikn/protos/gen/go/sso"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	emptyInteger int64 = 0
+)
+
+type AccountService interface {
+	DeleteAccount(ctx context.Context, userId int64, password string) (time.Time, error)
+	RestoreAccount(ctx context.Context, email string, password string) error
+	ExportData(ctx context.Context, userId int64) ([]byte, error)
+}
+
+type serverAPI struct {
+	ssov1.UnimplementedAccountServer
+	account AccountService
+}
+
+// Roles are the roles the methods of the Account service require.
+var Roles = map[string]middleware.Role{
+	ssov1.Account_DeleteMyAccount_FullMethodName:  middleware.RoleUser,
+	ssov1.Account_RestoreMyAccount_FullMethodName: middleware.RolePublic,
+	ssov1.Account_ExportMyData_FullMethodName:     middleware.RoleUser,
+}
+
+// Register registers the Account service.
+func Register(gRPC *grpc.Server, account AccountService) {
+	ssov1.RegisterAccountServer(gRPC, &serverAPI{
+		account: account,
+	})
+}
+
+func (s *serverAPI) DeleteMyAccount(
+	ctx context.Context,
+	req *ssov1.DeleteMyAccountRequest,
+) (*ssov1.DeleteMyAccountResponse, error) {
+	if req.GetPassword() == "" {
+		return nil, grpcerr.FieldViolation("password", "password is required")
+	}
+
+	caller, err := self(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if caller.ReadOnly {
+		return nil, grpcerr.New(codes.PermissionDenied, grpcerr.ReasonReadOnlyToken, "read-only tokens can't delete the account")
+	}
+
+	purgeAfter, err := s.account.DeleteAccount(ctx, caller.UserId, req.Password)
+	if err != nil {
+		return nil, grpcerr.FromError("failed to delete account", err)
+	}
+
+	return &ssov1.DeleteMyAccountResponse{
+		PurgeAfter: timestamppb.New(purgeAfter),
+	}, nil
+}
+
+// RestoreMyAccount is not authenticated with a token, the deletion has ended every session of the user.
+func (s *serverAPI) RestoreMyAccount(
+	ctx context.Context,
+	req *ssov1.RestoreMyAccountRequest,
+) (*ssov1.RestoreMyAccountResponse, error) {
+	if req.GetEmail() == "" {
+		return nil, grpcerr.FieldViolation("email", "email is required")
+	}
+	if req.GetPassword() == "" {
+		return nil, grpcerr.FieldViolation("password", "password is required")
+	}
+
+	if err := s.account.RestoreAccount(ctx, req.Email, req.Password); err != nil {
+		return nil, grpcerr.FromError("failed to restore account", err)
+	}
+
+	return &ssov1.RestoreMyAccountResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *serverAPI) ExportMyData(
+	ctx context.Context,
+	req *ssov1.ExportMyDataRequest,
+) (*ssov1.ExportMyDataResponse, error) {
+
+	caller, err := self(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := s.account.ExportData(ctx, caller.UserId)
+	if err != nil {
+		return nil, grpcerr.FromError("failed to export data", err)
+	}
+
+	return &ssov1.ExportMyDataResponse{
+		Archive: archive,
+	}, nil
+}
+
+// self returns the caller. Impersonation tokens are refused, only users themselves
+// may delete or export their account.
+func self(ctx context.Context) (middleware.Principal, error) {
+	caller := middleware.Caller(ctx)
+	if caller.ActorId != emptyInteger {
+		return middleware.Principal{}, grpcerr.New(codes.PermissionDenied, grpcerr.ReasonImpersonationToken, "impersonation tokens can't manage the account")
+	}
+	return caller, nil
+}
@@ -0,0 +1,327 @@
+package apps
+
+import (
+	"context"
+	"strings"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/grpc/grpcerr"
+	"github.com/botanikn/go_sso_service/internal/grpc/middleware"
+	"github.com/botanikn/go_sso_service/internal/services/authz"
+	ssov1 "github.com/botanikn/protos/gen/go/sso"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	emptyInteger int64 = 0
+)
+
+type AppsService interface {
+	CreateApp(ctx context.Context, actorId int64, name string) (models.App, error)
+	UpdateApp(ctx context.Context, actorId int64, appId int64, name string) (models.App, error)
+	ListApps(ctx context.Context, pageSize int, pageToken string) ([]models.App, string, error)
+	DeleteApp(ctx context.Context, actorId int64, appId int64) error
+	RotateSecret(ctx context.Context, actorId int64, appId int64) (models.App, error)
+	Settings(ctx context.Context, appId int64) (models.AppSettings, error)
+	UpdateSettings(ctx context.Context, actorId int64, settings models.AppSettings) (models.AppSettings, error)
+	AssignOwner(ctx context.Context, actorId int64, appId int64, userId int64) (string, error)
+}
+
+type Authorizer interface {
+	Authorize(ctx context.Context, req authz.Request) (authz.Decision, error)
+}
+
+type serverAPI struct {
+	ssov1.UnimplementedAppAdminServer
+	apps  AppsService
+	authz Authorizer
+}
+
+// Roles are the roles the methods of the AppAdmin service require.
+var Roles = map[string]middleware.Role{
+	ssov1.AppAdmin_CreateApp_FullMethodName:         middleware.RoleAdmin,
+	ssov1.AppAdmin_UpdateApp_FullMethodName:         middleware.RoleAdmin,
+	ssov1.AppAdmin_ListApps_FullMethodName:          middleware.RoleAdmin,
+	ssov1.AppAdmin_DeleteApp_FullMethodName:         middleware.RoleAdmin,
+	ssov1.AppAdmin_RotateAppSecret_FullMethodName:   middleware.RoleAdmin,
+	ssov1.AppAdmin_GetAppSettings_FullMethodName:    middleware.RoleAdmin,
+	ssov1.AppAdmin_UpdateAppSettings_FullMethodName: middleware.RoleAdmin,
+	ssov1.AppAdmin_AssignAppOwner_FullMethodName:    middleware.RoleAdmin,
+}
+
+// Register registers the AppAdmin service. Callers are authorized by the
+// policies of the admin app.
+func Register(gRPC *grpc.Server, apps AppsService, authz Authorizer) {
+	ssov1.RegisterAppAdminServer(gRPC, &serverAPI{
+		apps:  apps,
+		authz: authz,
+	})
+}
+
+func (s *serverAPI) CreateApp(
+	ctx context.Context,
+	req *ssov1.CreateAppRequest,
+) (*ssov1.CreateAppResponse, error) {
+	if err := validateCreateAppRequest(req); err != nil {
+		return nil, err
+	}
+
+	actorId, err := s.authorize(ctx, authz.ActionCreateApp)
+	if err != nil {
+		return nil, err
+	}
+
+	app, err := s.apps.CreateApp(ctx, actorId, req.Name)
+	if err != nil {
+		return nil, grpcerr.FromError("failed to create app", err)
+	}
+
+	return &ssov1.CreateAppResponse{
+		App:    appToProto(app),
+		Secret: app.Secret,
+	}, nil
+}
+
+func (s *serverAPI) UpdateApp(
+	ctx context.Context,
+	req *ssov1.UpdateAppRequest,
+) (*ssov1.UpdateAppResponse, error) {
+	if err := validateUpdateAppRequest(req); err != nil {
+		return nil, err
+	}
+
+	actorId, err := s.authorize(ctx, authz.ActionUpdateApp)
+	if err != nil {
+		return nil, err
+	}
+
+	app, err := s.apps.UpdateApp(ctx, actorId, req.AppId, req.Name)
+	if err != nil {
+		return nil, grpcerr.FromError("failed to update app", err)
+	}
+
+	return &ssov1.UpdateAppResponse{
+		App: appToProto(app),
+	}, nil
+}
+
+func (s *serverAPI) ListApps(
+	ctx context.Context,
+	req *ssov1.ListAppsRequest,
+) (*ssov1.ListAppsResponse, error) {
+	if req.GetPageSize() < 0 {
+		return nil, grpcerr.FieldViolation("page_size", "page_size must not be negative")
+	}
+
+	if _, err := s.authorize(ctx, authz.ActionListApps); err != nil {
+		return nil, err
+	}
+
+	list, nextPageToken, err := s.apps.ListApps(ctx, int(req.PageSize), req.PageToken)
+	if err != nil {
+		return nil, grpcerr.FromError("failed to list apps", err)
+	}
+
+	res := &ssov1.ListAppsResponse{
+		NextPageToken: nextPageToken,
+	}
+	for _, app := range list {
+		res.Apps = append(res.Apps, appToProto(app))
+	}
+	return res, nil
+}
+
+func (s *serverAPI) DeleteApp(
+	ctx context.Context,
+	req *ssov1.DeleteAppRequest,
+) (*ssov1.DeleteAppResponse, error) {
+	if req.GetAppId() == emptyInteger {
+		return nil, grpcerr.FieldViolation("app_id", "app_id is required")
+	}
+	if req.GetAppId() == middleware.Caller(ctx).AppId {
+		return nil, grpcerr.New(codes.FailedPrecondition, grpcerr.ReasonAdminAppProtected, "the admin app cannot be deleted")
+	}
+
+	actorId, err := s.authorize(ctx, authz.ActionDeleteApp)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.apps.DeleteApp(ctx, actorId, req.AppId); err != nil {
+		return nil, grpcerr.FromError("failed to delete app", err)
+	}
+
+	return &ssov1.DeleteAppResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *serverAPI) RotateAppSecret(
+	ctx context.Context,
+	req *ssov1.RotateAppSecretRequest,
+) (*ssov1.RotateAppSecretResponse, error) {
+	if req.GetAppId() == emptyInteger {
+		return nil, grpcerr.FieldViolation("app_id", "app_id is required")
+	}
+
+	actorId, err := s.authorize(ctx, authz.ActionRotateAppSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	app, err := s.apps.RotateSecret(ctx, actorId, req.AppId)
+	if err != nil {
+		return nil, grpcerr.FromError("failed to rotate app secret", err)
+	}
+
+	return &ssov1.RotateAppSecretResponse{
+		App:    appToProto(app),
+		Secret: app.Secret,
+	}, nil
+}
+
+func (s *serverAPI) AssignAppOwner(
+	ctx context.Context,
+	req *ssov1.AssignAppOwnerRequest,
+) (*ssov1.AssignAppOwnerResponse, error) {
+	if req.GetAppId() == emptyInteger {
+		return nil, grpcerr.FieldViolation("app_id", "app_id is required")
+	}
+	if req.GetUserId() == emptyInteger {
+		return nil, grpcerr.FieldViolation("user_id", "user_id is required")
+	}
+
+	actorId, err := s.authorize(ctx, authz.ActionAssignAppOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := s.apps.AssignOwner(ctx, actorId, req.AppId, req.UserId)
+	if err != nil {
+		return nil, grpcerr.FromError("failed to assign app owner", err)
+	}
+
+	return &ssov1.AssignAppOwnerResponse{
+		PreviousPermission: previous,
+	}, nil
+}
+
+func (s *serverAPI) GetAppSettings(
+	ctx context.Context,
+	req *ssov1.GetAppSettingsRequest,
+) (*ssov1.GetAppSettingsResponse, error) {
+	if req.GetAppId() == emptyInteger {
+		return nil, grpcerr.FieldViolation("app_id", "app_id is required")
+	}
+
+	if _, err := s.authorize(ctx, authz.ActionGetAppSettings); err != nil {
+		return nil, err
+	}
+
+	settings, err := s.apps.Settings(ctx, req.AppId)
+	if err != nil {
+		return nil, grpcerr.FromError("failed to get app settings", err)
+	}
+
+	return &ssov1.GetAppSettingsResponse{
+		Settings: settingsToProto(settings),
+	}, nil
+}
+
+func (s *serverAPI) UpdateAppSettings(
+	ctx context.Context,
+	req *ssov1.UpdateAppSettingsRequest,
+) (*ssov1.UpdateAppSettingsResponse, error) {
+	if err := validateUpdateAppSettingsRequest(req); err != nil {
+		return nil, err
+	}
+
+	actorId, err := s.authorize(ctx, authz.ActionUpdateAppSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := s.apps.UpdateSettings(ctx, actorId, models.AppSettings{
+		AppId:              req.Settings.AppId,
+		AccessTokenTTL:     req.Settings.AccessTokenTtl.AsDuration(),
+		RefreshTokenTTL:    req.Settings.RefreshTokenTtl.AsDuration(),
+		LoginMethods:       req.Settings.LoginMethods,
+		RegistrationPolicy: req.Settings.RegistrationPolicy,
+		MembershipPolicy:   req.Settings.MembershipPolicy,
+		AllowedDomains:     req.Settings.AllowedDomains,
+		MFARequired:        req.Settings.MfaRequired,
+	})
+	if err != nil {
+		return nil, grpcerr.FromError("failed to update app settings", err)
+	}
+
+	return &ssov1.UpdateAppSettingsResponse{
+		Settings: settingsToProto(settings),
+	}, nil
+}
+
+// authorize checks the action against the admin app's policies and returns the caller's user id.
+func (s *serverAPI) authorize(ctx context.Context, action string) (int64, error) {
+	caller := middleware.Caller(ctx)
+
+	decision, err := s.authz.Authorize(ctx, authz.Request{
+		AppId:    caller.AppId,
+		UserId:   caller.UserId,
+		Claims:   caller.Claims,
+		ReadOnly: caller.ReadOnly,
+		Action:   action,
+	})
+	if err != nil {
+		return 0, grpcerr.FromError("failed to authorize", err)
+	}
+	if !decision.Allowed {
+		return 0, grpcerr.New(codes.PermissionDenied, grpcerr.ReasonPermissionDenied, "insufficient permissions to manage apps")
+	}
+	return caller.UserId, nil
+}
+
+func appToProto(app models.App) *ssov1.AppInfo {
+	return &ssov1.AppInfo{
+		Id:   int64(app.ID),
+		Name: app.Name,
+	}
+}
+
+func settingsToProto(settings models.AppSettings) *ssov1.AppSettings {
+	return &ssov1.AppSettings{
+		AppId:              settings.AppId,
+		AccessTokenTtl:     durationpb.New(settings.AccessTokenTTL),
+		RefreshTokenTtl:    durationpb.New(settings.RefreshTokenTTL),
+		LoginMethods:       settings.LoginMethods,
+		RegistrationPolicy: settings.RegistrationPolicy,
+		MembershipPolicy:   settings.MembershipPolicy,
+		AllowedDomains:     settings.AllowedDomains,
+		MfaRequired:        settings.MFARequired,
+	}
+}
+
+func validateCreateAppRequest(req *ssov1.CreateAppRequest) error {
+	if strings.TrimSpace(req.GetName()) == "" {
+		return grpcerr.FieldViolation("name", "name is required")
+	}
+	return nil
+}
+
+func validateUpdateAppRequest(req *ssov1.UpdateAppRequest) error {
+	if req.GetAppId() == emptyInteger {
+		return grpcerr.FieldViolation("app_id", "app_id is required")
+	}
+	if strings.TrimSpace(req.GetName()) == "" {
+		return grpcerr.FieldViolation("name", "name is required")
+	}
+	return nil
+}
+
+func validateUpdateAppSettingsRequest(req *ssov1.UpdateAppSettingsRequest) error {
+	if req.GetSettings().GetAppId() == emptyInteger {
+		return grpcerr.FieldViolation("settings.app_id", "settings.app_id is required")
+	}
+	return nil
+}
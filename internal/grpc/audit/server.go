@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/grpc/grpcerr"
+	"github.com/botanikn/go_sso_service/internal/grpc/middleware"
+	"github.com/botanikn/go_sso_service/internal/services/authz"
+	ssov1 "github.com/botanikn/protos/gen/go/sso"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type AuditService interface {
+	Export(ctx context.Context, filter models.AuditFilter, send func(models.AuditEvent) error) error
+}
+
+type Authorizer interface {
+	Authorize(ctx context.Context, req authz.Request) (authz.Decision, error)
+}
+
+type serverAPI struct {
+	ssov1.UnimplementedAuditLogServer
+	audit AuditService
+	authz Authorizer
+}
+
+// Roles are the roles the methods of the AuditLog service require.
+var Roles = map[string]middleware.Role{
+	ssov1.AuditLog_ExportAuditEvents_FullMethodName: middleware.RoleAdmin,
+}
+
+// Register registers the AuditLog service. Callers are authorized by the
+// policies of the admin app.
+func Register(gRPC *grpc.Server, audit AuditService, authz Authorizer) {
+	ssov1.RegisterAuditLogServer(gRPC, &serverAPI{
+		audit: audit,
+		authz: authz,
+	})
+}
+
+func (s *serverAPI) ExportAuditEvents(
+	req *ssov1.ExportAuditEventsRequest,
+	stream grpc.ServerStreamingServer[ssov1.AuditEvent],
+) error {
+	if err := s.authorize(stream.Context(), authz.ActionReadAudit); err != nil {
+		return err
+	}
+
+	filter := models.AuditFilter{
+		AppId:   req.AppId,
+		ActorId: req.ActorId,
+		UserId:  req.UserId,
+		Action:  req.Action,
+	}
+	if req.Since != nil {
+		filter.Since = req.Since.AsTime()
+	}
+	if req.Until != nil {
+		filter.Until = req.Until.AsTime()
+	}
+
+	err := s.audit.Export(stream.Context(), filter, func(event models.AuditEvent) error {
+		msg, err := eventToProto(event)
+		if err != nil {
+			return err
+		}
+		return stream.Send(msg)
+	})
+	if err != nil {
+		return grpcerr.FromError("failed to export audit events", err)
+	}
+	return nil
+}
+
+// authorize checks the action against the admin app's policies.
+func (s *serverAPI) authorize(ctx context.Context, action string) error {
+	caller := middleware.Caller(ctx)
+
+	decision, err := s.authz.Authorize(ctx, authz.Request{
+		AppId:    caller.AppId,
+		UserId:   caller.UserId,
+		Claims:   caller.Claims,
+		ReadOnly: caller.ReadOnly,
+		Action:   action,
+	})
+	if err != nil {
+		return grpcerr.FromError("failed to authorize", err)
+	}
+	if !decision.Allowed {
+		return grpcerr.New(codes.PermissionDenied, grpcerr.ReasonPermissionDenied, "insufficient permissions to read the audit log")
+	}
+	return nil
+}
+
+func eventToProto(event models.AuditEvent) (*ssov1.AuditEvent, error) {
+	details, err := structpb.NewStruct(event.Details)
+	if err != nil {
+		return nil, err
+	}
+	return &ssov1.AuditEvent{
+		Id:        event.ID,
+		AppId:     event.AppId,
+		ActorId:   event.ActorId,
+		UserId:    event.UserId,
+		Action:    event.Action,
+		Details:   details,
+		CreatedAt: timestamppb.New(event.CreatedAt),
+	}, nil
+}
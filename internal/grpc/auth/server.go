@@ -2,22 +2,24 @@ package auth
 
 import (
 	"context"
-	"errors"
-	"strings"
+	"fmt"
 	"time"
 
 	"github.com/botanikn/go_sso_service/internal/domain/models"
-	"github.com/botanikn/go_sso_service/internal/services/auth"
+	"github.com/botanikn/go_sso_service/internal/grpc/grpcerr"
+	"github.com/botanikn/go_sso_service/internal/grpc/middleware"
+	"github.com/botanikn/go_sso_service/internal/services/authz"
+	"github.com/botanikn/go_sso_service/internal/services/delegation"
 	ssov1 "github.com/botanikn/protos/gen/go/sso"
-	"github.com/golang-jwt/jwt/v5"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/metadata"
-	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 const (
 	emptyInteger int64 = 0
+
+	maxBatchSize = 1000
 )
 
 type AuthService interface {
@@ -32,6 +34,12 @@ type AuthService interface {
 		username string,
 		password string,
 	) (userId int64, err error)
+	RegisterInApp(ctx context.Context,
+		appId int64,
+		email string,
+		username string,
+		password string,
+	) (userId int64, err error)
 	CheckPermissions(ctx context.Context,
 		userId int64,
 		appId int64,
@@ -43,16 +51,115 @@ type AuthService interface {
 		permission string,
 	) error
 	NewToken(user models.User, app models.App, duration time.Duration) (string, error)
-	ValidateToken(ctx context.Context, tokenString string, appId int64) (auth.PermissionResponse, error)
+}
+
+type Authorizer interface {
+	Authorize(ctx context.Context, req authz.Request) (authz.Decision, error)
+}
+
+type Granter interface {
+	Grant(ctx context.Context, actorId int64, grant models.PermissionGrant) (models.PermissionGrant, error)
+	BreakGlass(ctx context.Context,
+		actorId int64,
+		userId int64,
+		appId int64,
+		permission string,
+		justification string,
+		duration time.Duration,
+	) (models.PermissionGrant, error)
+}
+
+type Impersonator interface {
+	Impersonate(ctx context.Context,
+		actorId int64,
+		userId int64,
+		appId int64,
+		readOnly bool,
+		ttl time.Duration,
+		reason string,
+	) (string, time.Time, error)
+}
+
+type Delegator interface {
+	CheckRoleChange(ctx context.Context, actorId int64, userId int64, appId int64, role string) error
+	CheckGrant(ctx context.Context, actorId int64, userId int64, appId int64, role string) error
+	BatchRoleChange(ctx context.Context,
+		actorId int64,
+		changes []models.PermissionChange,
+		dryRun bool,
+		authorize delegation.ChangeAuthorizer,
+	) ([]models.PermissionChangeResult, bool, error)
+}
+
+type MemberLister interface {
+	ListMembers(ctx context.Context,
+		appId int64,
+		role string,
+		pageSize int,
+		pageToken string,
+	) ([]models.AppMember, string, error)
+	ExportMembers(ctx context.Context,
+		appId int64,
+		role string,
+		send func(models.AppMember) error,
+	) error
+}
+
+type Bootstrapper interface {
+	Setup(ctx context.Context,
+		setupToken string,
+		email string,
+		username string,
+		password string,
+	) (int64, error)
 }
 
 type serverAPI struct {
 	ssov1.UnimplementedAuthServer
-	auth AuthService
+	auth          AuthService
+	authz         Authorizer
+	grants        Granter
+	impersonation Impersonator
+	delegation    Delegator
+	members       MemberLister
+	bootstrap     Bootstrapper
+}
+
+// Roles are the roles the methods of the Auth service require.
+var Roles = map[string]middleware.Role{
+	ssov1.Auth_Register_FullMethodName:               middleware.RolePublic,
+	ssov1.Auth_Login_FullMethodName:                  middleware.RolePublic,
+	ssov1.Auth_Setup_FullMethodName:                  middleware.RolePublic,
+	ssov1.Auth_CheckPermissionsByJwt_FullMethodName:  middleware.RoleUser,
+	ssov1.Auth_UpdatePermissions_FullMethodName:      middleware.RoleUser,
+	ssov1.Auth_BatchUpdatePermissions_FullMethodName: middleware.RoleUser,
+	ssov1.Auth_GetPermissionsByUserId_FullMethodName: middleware.RoleUser,
+	ssov1.Auth_Authorize_FullMethodName:              middleware.RoleUser,
+	ssov1.Auth_BreakGlassGrant_FullMethodName:        middleware.RoleUser,
+	ssov1.Auth_Impersonate_FullMethodName:            middleware.RoleUser,
+	ssov1.Auth_ListAppMembers_FullMethodName:         middleware.RoleUser,
+	ssov1.Auth_ExportAppMembers_FullMethodName:       middleware.RoleUser,
 }
 
-func Register(gRPC *grpc.Server, auth AuthService) {
-	ssov1.RegisterAuthServer(gRPC, &serverAPI{auth: auth})
+func Register(
+	gRPC *grpc.Server,
+	auth AuthService,
+	authz Authorizer,
+	grants Granter,
+	impersonation Impersonator,
+	delegation Delegator,
+	members MemberLister,
+	bootstrap Bootstrapper,
+) {
+	ssov1.RegisterAuthServer(gRPC, &serverAPI{
+		auth:          auth,
+		authz:         authz,
+		grants:        grants,
+		impersonation: impersonation,
+		delegation:    delegation,
+		members:       members,
+		bootstrap:     bootstrap,
+	})
 }
 
 func (s *serverAPI) Login(
@@ -66,8 +173,7 @@ func (s *serverAPI) Login(
 
 	res, err := s.auth.Login(ctx, req.Email, req.Password, req.AppId)
 	if err != nil {
-		// TODO: use more specific error codes
-		return nil, status.Errorf(codes.InvalidArgument, "failed to login: %v", err)
+		return nil, grpcerr.FromError("failed to login", err)
 	}
 
 	return &ssov1.LoginResponse{
@@ -84,10 +190,17 @@ func (s *serverAPI) Register(
 		return nil, err
 	}
 
-	res, err := s.auth.Register(ctx, req.Email, req.Username, req.Password)
+	var (
+		res int64
+		err error
+	)
+	if req.AppId != emptyInteger {
+		res, err = s.auth.RegisterInApp(ctx, req.AppId, req.Email, req.Username, req.Password)
+	} else {
+		res, err = s.auth.Register(ctx, req.Email, req.Username, req.Password)
+	}
 	if err != nil {
-		// TODO: use more specific error codes
-		return nil, status.Errorf(codes.InvalidArgument, "failed to register: %v", err)
+		return nil, grpcerr.FromError("failed to register", err)
 	}
 
 	return &ssov1.RegisterResponse{
@@ -95,43 +208,42 @@ func (s *serverAPI) Register(
 	}, nil
 }
 
-func (s *serverAPI) CheckPermissionsByJwt(
+func (s *serverAPI) Setup(
 	ctx context.Context,
-	req *ssov1.PermissionsByJwtRequest,
-) (*ssov1.PermissionsByJwtResponse, error) {
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok {
-		return nil, status.Error(codes.Unauthenticated, "missing metadata")
+	req *ssov1.SetupRequest,
+) (*ssov1.SetupResponse, error) {
+	if err := validateSetupRequest(req); err != nil {
+		return nil, err
 	}
-	if _, exists := md["authorization"]; !exists {
-		return nil, status.Error(codes.Unauthenticated, "missing authorization token")
+
+	userId, err := s.bootstrap.Setup(ctx, req.SetupToken, req.Email, req.Username, req.Password)
+	if err != nil {
+		return nil, grpcerr.FromError("failed to set up", err)
 	}
-	tokenValue := md["authorization"][0]
 
-	tokenValue = strings.TrimPrefix(tokenValue, "Bearer ")
-	tokenValue = strings.TrimSpace(tokenValue)
+	return &ssov1.SetupResponse{
+		UserId: userId,
+	}, nil
+}
 
+func (s *serverAPI) CheckPermissionsByJwt(
+	ctx context.Context,
+	req *ssov1.PermissionsByJwtRequest,
+) (*ssov1.PermissionsByJwtResponse, error) {
 	if err := validateCheckPermissionsRequest(req); err != nil {
 		return nil, err
 	}
 
-	valid, err := s.auth.ValidateToken(ctx, tokenValue, req.AppId)
-	if err != nil || !valid.Validated {
-		return nil, status.Error(codes.Unauthenticated, err.Error())
-	}
+	caller := middleware.Caller(ctx)
 
-	permission, err := s.auth.CheckPermissions(ctx, valid.UserId, req.AppId, tokenValue)
+	permission, err := s.auth.CheckPermissions(ctx, caller.UserId, req.AppId, caller.Token)
 	if err != nil {
-		// TODO: use more specific error codes
-		if errors.Is(err, jwt.ErrTokenExpired) {
-			return nil, status.Error(codes.Unauthenticated, jwt.ErrTokenExpired.Error())
-		}
-		return nil, status.Errorf(codes.InvalidArgument, "failed to check permissions: %v", err)
+		return nil, grpcerr.FromError("failed to check permissions", err)
 	}
 
 	return &ssov1.PermissionsByJwtResponse{
 		Permission: permission,
-		UserId:     valid.UserId,
+		UserId:     caller.UserId,
 	}, nil
 }
 
@@ -139,37 +251,63 @@ func (s *serverAPI) UpdatePermissions(
 	ctx context.Context,
 	req *ssov1.UpdatePermissionsRequest,
 ) (*ssov1.UpdatePermissionsResponse, error) {
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok {
-		return nil, status.Error(codes.Unauthenticated, "missing metadata")
+	caller := middleware.Caller(ctx)
+
+	if err := validateUpdatePermissionsRequest(req); err != nil {
+		return nil, err
+	}
+
+	decision, err := s.authz.Authorize(ctx, authz.Request{
+		AppId:    req.AppId,
+		UserId:   caller.UserId,
+		Claims:   caller.Claims,
+		ReadOnly: caller.ReadOnly,
+		Action:   authz.ActionUpdatePermissions,
+		Resource: map[string]any{
+			"user_id":    req.UserId,
+			"permission": req.Permission,
+		},
+	})
+	if err != nil {
+		return nil, grpcerr.FromError("failed to authorize", err)
 	}
-	if _, exists := md["authorization"]; !exists {
-		return nil, status.Error(codes.Unauthenticated, "missing authorization token")
+	if !decision.Allowed {
+		return nil, grpcerr.New(codes.PermissionDenied, grpcerr.ReasonPermissionDenied, "insufficient permissions to update user permissions")
 	}
-	tokenValue := md["authorization"][0]
 
-	tokenValue = strings.TrimPrefix(tokenValue, "Bearer ")
-	tokenValue = strings.TrimSpace(tokenValue)
+	if req.ValidFrom != nil || req.ValidUntil != nil {
+		if err := s.delegation.CheckGrant(ctx, caller.UserId, req.UserId, req.AppId, req.Permission); err != nil {
+			return nil, grpcerr.FromError("failed to update permissions", err)
+		}
 
-	valid, err := s.auth.ValidateToken(ctx, tokenValue, req.AppId)
-	if err != nil || !valid.Validated {
-		return nil, status.Error(codes.Unauthenticated, err.Error())
-	}
+		grant := models.PermissionGrant{
+			UserId:     req.UserId,
+			AppId:      req.AppId,
+			Permission: req.Permission,
+		}
+		if req.ValidFrom != nil {
+			grant.ValidFrom = req.ValidFrom.AsTime()
+		}
+		if req.ValidUntil != nil {
+			grant.ValidUntil = req.ValidUntil.AsTime()
+		}
 
-	permission, err := s.auth.CheckPermissions(ctx, valid.UserId, req.AppId, tokenValue)
-	if permission != "admin" {
-		return nil, status.Error(codes.PermissionDenied, "insufficient permissions to update user permissions")
+		if _, err := s.grants.Grant(ctx, caller.UserId, grant); err != nil {
+			return nil, grpcerr.FromError("failed to grant permissions", err)
+		}
+
+		return &ssov1.UpdatePermissionsResponse{
+			Success: true,
+		}, nil
 	}
 
-	if err := validateUpdatePermissionsRequest(req); err != nil {
-		return nil, err
+	if err := s.delegation.CheckRoleChange(ctx, caller.UserId, req.UserId, req.AppId, req.Permission); err != nil {
+		return nil, grpcerr.FromError("failed to update permissions", err)
 	}
 
 	err = s.auth.UpdatePermissions(ctx, req.UserId, req.AppId, req.Permission)
 	if err != nil {
-		return &ssov1.UpdatePermissionsResponse{
-			Success: false,
-		}, status.Errorf(codes.Internal, "failed to update permissions: %v", err)
+		return nil, grpcerr.FromError("failed to update permissions", err)
 	}
 
 	return &ssov1.UpdatePermissionsResponse{
@@ -177,97 +315,462 @@ func (s *serverAPI) UpdatePermissions(
 	}, nil
 }
 
+func (s *serverAPI) BatchUpdatePermissions(
+	ctx context.Context,
+	req *ssov1.BatchUpdatePermissionsRequest,
+) (*ssov1.BatchUpdatePermissionsResponse, error) {
+	if err := validateBatchUpdatePermissionsRequest(req); err != nil {
+		return nil, err
+	}
+
+	caller := middleware.Caller(ctx)
+
+	changes := make([]models.PermissionChange, len(req.Changes))
+	for i, item := range req.Changes {
+		changes[i] = models.PermissionChange{
+			UserId:     item.UserId,
+			AppId:      item.AppId,
+			Permission: item.Permission,
+		}
+	}
+
+	// Every change is checked the same way UpdatePermissions checks a single one, in the
+	// transaction that writes them. Rejected changes are reported and the rest only
+	// dry-run, so the caller sees everything that is wrong with the batch at once.
+	results, applied, err := s.delegation.BatchRoleChange(ctx, caller.UserId, changes, req.DryRun,
+		func(ctx context.Context, change models.PermissionChange) (bool, error) {
+			decision, err := s.authz.Authorize(ctx, authz.Request{
+				AppId:    change.AppId,
+				UserId:   caller.UserId,
+				Claims:   caller.Claims,
+				ReadOnly: caller.ReadOnly,
+				Action:   authz.ActionUpdatePermissions,
+				Resource: map[string]any{
+					"user_id":    change.UserId,
+					"permission": change.Permission,
+				},
+			})
+			if err != nil {
+				return false, err
+			}
+			return decision.Allowed, nil
+		})
+	if err != nil {
+		return nil, grpcerr.FromError("failed to update permissions", err)
+	}
+
+	res := &ssov1.BatchUpdatePermissionsResponse{
+		Applied: applied,
+	}
+	for _, result := range results {
+		res.Results = append(res.Results, permissionChangeResultToProto(result))
+	}
+	return res, nil
+}
+
 func (s *serverAPI) GetPermissionsByUserId(
 	ctx context.Context,
 	req *ssov1.PermissionsByUserIdRequest,
 ) (*ssov1.PermissionsByUserIdResponse, error) {
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok {
-		return nil, status.Error(codes.Unauthenticated, "missing metadata")
+	caller := middleware.Caller(ctx)
+
+	if err := validateGetPermissionsByUserIdRequest(req); err != nil {
+		return nil, err
 	}
-	if _, exists := md["authorization"]; !exists {
-		return nil, status.Error(codes.Unauthenticated, "missing authorization token")
+
+	decision, err := s.authz.Authorize(ctx, authz.Request{
+		AppId:    req.AppId,
+		UserId:   caller.UserId,
+		Claims:   caller.Claims,
+		ReadOnly: caller.ReadOnly,
+		Action:   authz.ActionReadPermissions,
+		Resource: map[string]any{
+			"user_id": req.UserId,
+		},
+	})
+	if err != nil {
+		return nil, grpcerr.FromError("failed to authorize", err)
+	}
+	if !decision.Allowed {
+		return nil, grpcerr.New(codes.PermissionDenied, grpcerr.ReasonPermissionDenied, "insufficient permissions to read user permissions")
+	}
+	userPermission, err := s.auth.CheckPermissions(ctx, req.UserId, req.AppId, caller.Token)
+	if err != nil {
+		return nil, grpcerr.FromError("failed to get user permissions", err)
 	}
-	tokenValue := md["authorization"][0]
 
-	tokenValue = strings.TrimPrefix(tokenValue, "Bearer ")
-	tokenValue = strings.TrimSpace(tokenValue)
+	return &ssov1.PermissionsByUserIdResponse{
+		Permission: userPermission,
+	}, nil
+}
 
-	valid, err := s.auth.ValidateToken(ctx, tokenValue, req.AppId)
-	if err != nil || !valid.Validated {
-		return nil, status.Error(codes.Unauthenticated, err.Error())
+func (s *serverAPI) Authorize(
+	ctx context.Context,
+	req *ssov1.AuthorizeRequest,
+) (*ssov1.AuthorizeResponse, error) {
+	if err := validateAuthorizeRequest(req); err != nil {
+		return nil, err
 	}
 
-	permission, err := s.auth.CheckPermissions(ctx, valid.UserId, req.AppId, tokenValue)
-	if permission != "admin" {
-		return nil, status.Error(codes.PermissionDenied, "insufficient permissions to update user permissions")
+	caller := middleware.Caller(ctx)
+
+	decision, err := s.authz.Authorize(ctx, authz.Request{
+		AppId:    req.AppId,
+		UserId:   caller.UserId,
+		Claims:   caller.Claims,
+		ReadOnly: caller.ReadOnly,
+		Action:   req.Action,
+		Resource: req.GetResource().AsMap(),
+		Context:  req.GetContext().AsMap(),
+	})
+	if err != nil {
+		return nil, grpcerr.FromError("failed to authorize", err)
 	}
 
-	if err := validateGetPermissionsByUserIdRequest(req); err != nil {
+	return &ssov1.AuthorizeResponse{
+		Allowed: decision.Allowed,
+		Policy:  decision.Policy,
+	}, nil
+}
+
+func (s *serverAPI) BreakGlassGrant(
+	ctx context.Context,
+	req *ssov1.BreakGlassGrantRequest,
+) (*ssov1.BreakGlassGrantResponse, error) {
+	if err := validateBreakGlassGrantRequest(req); err != nil {
 		return nil, err
 	}
-	userPermission, err := s.auth.CheckPermissions(ctx, req.UserId, req.AppId, tokenValue)
+
+	caller := middleware.Caller(ctx)
+
+	decision, err := s.authz.Authorize(ctx, authz.Request{
+		AppId:    req.AppId,
+		UserId:   caller.UserId,
+		Claims:   caller.Claims,
+		ReadOnly: caller.ReadOnly,
+		Action:   authz.ActionBreakGlass,
+		Resource: map[string]any{
+			"user_id":    req.UserId,
+			"permission": req.Permission,
+		},
+	})
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to get user permissions: %v", err)
+		return nil, grpcerr.FromError("failed to authorize", err)
+	}
+	if !decision.Allowed {
+		return nil, grpcerr.New(codes.PermissionDenied, grpcerr.ReasonPermissionDenied, "insufficient permissions to break glass")
 	}
 
-	return &ssov1.PermissionsByUserIdResponse{
-		Permission: userPermission,
+	grant, err := s.grants.BreakGlass(
+		ctx,
+		caller.UserId,
+		req.UserId,
+		req.AppId,
+		req.Permission,
+		req.Justification,
+		req.Duration.AsDuration(),
+	)
+	if err != nil {
+		return nil, grpcerr.FromError("failed to break glass", err)
+	}
+
+	return &ssov1.BreakGlassGrantResponse{
+		ValidUntil: timestamppb.New(grant.ValidUntil),
 	}, nil
 }
 
+func (s *serverAPI) Impersonate(
+	ctx context.Context,
+	req *ssov1.ImpersonateRequest,
+) (*ssov1.ImpersonateResponse, error) {
+	if err := validateImpersonateRequest(req); err != nil {
+		return nil, err
+	}
+
+	caller := middleware.Caller(ctx)
+	if caller.ActorId != 0 {
+		return nil, grpcerr.New(codes.PermissionDenied, grpcerr.ReasonImpersonationToken, "impersonation tokens cannot be used to impersonate")
+	}
+
+	decision, err := s.authz.Authorize(ctx, authz.Request{
+		AppId:    req.AppId,
+		UserId:   caller.UserId,
+		Claims:   caller.Claims,
+		ReadOnly: caller.ReadOnly,
+		Action:   authz.ActionImpersonate,
+		Resource: map[string]any{
+			"user_id":   req.UserId,
+			"read_only": req.ReadOnly,
+		},
+	})
+	if err != nil {
+		return nil, grpcerr.FromError("failed to authorize", err)
+	}
+	if !decision.Allowed {
+		return nil, grpcerr.New(codes.PermissionDenied, grpcerr.ReasonPermissionDenied, "insufficient permissions to impersonate users")
+	}
+
+	token, expiresAt, err := s.impersonation.Impersonate(
+		ctx,
+		caller.UserId,
+		req.UserId,
+		req.AppId,
+		req.ReadOnly,
+		req.GetTtl().AsDuration(),
+		req.Reason,
+	)
+	if err != nil {
+		return nil, grpcerr.FromError("failed to impersonate", err)
+	}
+
+	return &ssov1.ImpersonateResponse{
+		Token:     token,
+		ExpiresAt: timestamppb.New(expiresAt),
+	}, nil
+}
+
+func (s *serverAPI) ListAppMembers(
+	ctx context.Context,
+	req *ssov1.ListAppMembersRequest,
+) (*ssov1.ListAppMembersResponse, error) {
+	if err := validateListAppMembersRequest(req); err != nil {
+		return nil, err
+	}
+
+	if err := s.authorizeMembersRead(ctx, req.AppId); err != nil {
+		return nil, err
+	}
+
+	list, nextPageToken, err := s.members.ListMembers(ctx, req.AppId, req.Role, int(req.PageSize), req.PageToken)
+	if err != nil {
+		return nil, grpcerr.FromError("failed to list app members", err)
+	}
+
+	res := &ssov1.ListAppMembersResponse{
+		NextPageToken: nextPageToken,
+	}
+	for _, member := range list {
+		res.Members = append(res.Members, memberToProto(member))
+	}
+	return res, nil
+}
+
+func (s *serverAPI) ExportAppMembers(
+	req *ssov1.ExportAppMembersRequest,
+	stream grpc.ServerStreamingServer[ssov1.AppMember],
+) error {
+	if req.GetAppId() == emptyInteger {
+		return grpcerr.FieldViolation("app_id", "app_id is required")
+	}
+
+	if err := s.authorizeMembersRead(stream.Context(), req.AppId); err != nil {
+		return err
+	}
+
+	err := s.members.ExportMembers(stream.Context(), req.AppId, req.Role, func(member models.AppMember) error {
+		return stream.Send(memberToProto(member))
+	})
+	if err != nil {
+		return grpcerr.FromError("failed to export app members", err)
+	}
+	return nil
+}
+
+// authorizeMembersRead applies the same check as GetPermissionsByUserId, reading
+// the whole membership is reading the permissions of every member.
+func (s *serverAPI) authorizeMembersRead(ctx context.Context, appId int64) error {
+	caller := middleware.Caller(ctx)
+
+	decision, err := s.authz.Authorize(ctx, authz.Request{
+		AppId:    appId,
+		UserId:   caller.UserId,
+		Claims:   caller.Claims,
+		ReadOnly: caller.ReadOnly,
+		Action:   authz.ActionReadPermissions,
+	})
+	if err != nil {
+		return grpcerr.FromError("failed to authorize", err)
+	}
+	if !decision.Allowed {
+		return grpcerr.New(codes.PermissionDenied, grpcerr.ReasonPermissionDenied, "insufficient permissions to read app members")
+	}
+	return nil
+}
+
+func memberToProto(member models.AppMember) *ssov1.AppMember {
+	return &ssov1.AppMember{
+		UserId:     member.UserId,
+		Email:      member.Email,
+		Username:   member.Username,
+		Permission: member.Permission,
+	}
+}
+
+var permissionChangeOutcomes = map[string]ssov1.PermissionChangeResult_Outcome{
+	models.PermissionChangeCreated:   ssov1.PermissionChangeResult_OUTCOME_CREATED,
+	models.PermissionChangeUpdated:   ssov1.PermissionChangeResult_OUTCOME_UPDATED,
+	models.PermissionChangeUnchanged: ssov1.PermissionChangeResult_OUTCOME_UNCHANGED,
+	models.PermissionChangeRejected:  ssov1.PermissionChangeResult_OUTCOME_REJECTED,
+	models.PermissionChangeFailed:    ssov1.PermissionChangeResult_OUTCOME_FAILED,
+}
+
+func permissionChangeResultToProto(result models.PermissionChangeResult) *ssov1.PermissionChangeResult {
+	res := &ssov1.PermissionChangeResult{
+		Change: &ssov1.PermissionChange{
+			UserId:     result.Change.UserId,
+			AppId:      result.Change.AppId,
+			Permission: result.Change.Permission,
+		},
+		Outcome:            permissionChangeOutcomes[result.Outcome],
+		PreviousPermission: result.PreviousPermission,
+	}
+	if result.Err != nil {
+		res.Error = result.Err.Error()
+	}
+	return res
+}
+
 func validateLoginRequest(req *ssov1.LoginRequest) error {
 	if req.GetEmail() == "" {
-		return status.Errorf(codes.InvalidArgument, "email is required")
+		return grpcerr.FieldViolation("email", "email is required")
 	}
 	if req.GetPassword() == "" {
-		return status.Errorf(codes.InvalidArgument, "password is required")
+		return grpcerr.FieldViolation("password", "password is required")
 	}
 	if req.GetAppId() == emptyInteger {
-		return status.Errorf(codes.InvalidArgument, "app_id is required")
+		return grpcerr.FieldViolation("app_id", "app_id is required")
 	}
 	return nil
 }
 
 func validateRegisterRequest(req *ssov1.RegisterRequest) error {
 	if req.GetEmail() == "" {
-		return status.Errorf(codes.InvalidArgument, "email is required")
+		return grpcerr.FieldViolation("email", "email is required")
+	}
+	if req.GetUsername() == "" {
+		return grpcerr.FieldViolation("username", "username is required")
+	}
+	if req.GetPassword() == "" {
+		return grpcerr.FieldViolation("password", "password is required")
+	}
+	return nil
+}
+
+func validateSetupRequest(req *ssov1.SetupRequest) error {
+	if req.GetSetupToken() == "" {
+		return grpcerr.FieldViolation("setup_token", "setup_token is required")
+	}
+	if req.GetEmail() == "" {
+		return grpcerr.FieldViolation("email", "email is required")
 	}
 	if req.GetUsername() == "" {
-		return status.Errorf(codes.InvalidArgument, "username is required")
+		return grpcerr.FieldViolation("username", "username is required")
 	}
 	if req.GetPassword() == "" {
-		return status.Errorf(codes.InvalidArgument, "password is required")
+		return grpcerr.FieldViolation("password", "password is required")
 	}
 	return nil
 }
 
 func validateCheckPermissionsRequest(req *ssov1.PermissionsByJwtRequest) error {
 	if req.GetAppId() == emptyInteger {
-		return status.Errorf(codes.InvalidArgument, "app_id is required")
+		return grpcerr.FieldViolation("app_id", "app_id is required")
 	}
 	return nil
 }
 
 func validateUpdatePermissionsRequest(req *ssov1.UpdatePermissionsRequest) error {
 	if req.GetUserId() == emptyInteger {
-		return status.Errorf(codes.InvalidArgument, "user_id is required")
+		return grpcerr.FieldViolation("user_id", "user_id is required")
 	}
 	if req.GetAppId() == emptyInteger {
-		return status.Errorf(codes.InvalidArgument, "app_id is required")
+		return grpcerr.FieldViolation("app_id", "app_id is required")
 	}
 	if req.GetPermission() == "" {
-		return status.Errorf(codes.InvalidArgument, "permission is required")
+		return grpcerr.FieldViolation("permission", "permission is required")
+	}
+	return nil
+}
+
+func validateBatchUpdatePermissionsRequest(req *ssov1.BatchUpdatePermissionsRequest) error {
+	if req.GetAppId() == emptyInteger {
+		return grpcerr.FieldViolation("app_id", "app_id is required")
+	}
+	if len(req.GetChanges()) == 0 {
+		return grpcerr.FieldViolation("changes", "changes are required")
+	}
+	if len(req.GetChanges()) > maxBatchSize {
+		return grpcerr.FieldViolation("changes", fmt.Sprintf("at most %d changes are allowed", maxBatchSize))
+	}
+	for i, change := range req.GetChanges() {
+		if change.GetUserId() == emptyInteger {
+			return grpcerr.FieldViolation(fmt.Sprintf("changes[%d].user_id", i), "user_id is required")
+		}
+		if change.GetAppId() == emptyInteger {
+			return grpcerr.FieldViolation(fmt.Sprintf("changes[%d].app_id", i), "app_id is required")
+		}
+		if change.GetPermission() == "" {
+			return grpcerr.FieldViolation(fmt.Sprintf("changes[%d].permission", i), "permission is required")
+		}
 	}
 	return nil
 }
 
 func validateGetPermissionsByUserIdRequest(req *ssov1.PermissionsByUserIdRequest) error {
 	if req.GetAppId() == emptyInteger {
-		return status.Errorf(codes.InvalidArgument, "app_id is required")
+		return grpcerr.FieldViolation("app_id", "app_id is required")
 	}
 	if req.GetUserId() == emptyInteger {
-		return status.Errorf(codes.InvalidArgument, "user_id is required")
+		return grpcerr.FieldViolation("user_id", "user_id is required")
+	}
+	return nil
+}
+
+func validateAuthorizeRequest(req *ssov1.AuthorizeRequest) error {
+	if req.GetAppId() == emptyInteger {
+		return grpcerr.FieldViolation("app_id", "app_id is required")
+	}
+	if req.GetAction() == "" {
+		return grpcerr.FieldViolation("action", "action is required")
+	}
+	return nil
+}
+
+func validateBreakGlassGrantRequest(req *ssov1.BreakGlassGrantRequest) error {
+	if req.GetAppId() == emptyInteger {
+		return grpcerr.FieldViolation("app_id", "app_id is required")
+	}
+	if req.GetUserId() == emptyInteger {
+		return grpcerr.FieldViolation("user_id", "user_id is required")
+	}
+	if req.GetPermission() == "" {
+		return grpcerr.FieldViolation("permission", "permission is required")
+	}
+	if req.GetJustification() == "" {
+		return grpcerr.FieldViolation("justification", "justification is required")
+	}
+	if req.GetDuration() == nil {
+		return grpcerr.FieldViolation("duration", "duration is required")
+	}
+	return nil
+}
+
+func validateImpersonateRequest(req *ssov1.ImpersonateRequest) error {
+	if req.GetAppId() == emptyInteger {
+		return grpcerr.FieldViolation("app_id", "app_id is required")
+	}
+	if req.GetUserId() == emptyInteger {
+		return grpcerr.FieldViolation("user_id", "user_id is required")
+	}
+	return nil
+}
+
+func validateListAppMembersRequest(req *ssov1.ListAppMembersRequest) error {
+	if req.GetAppId() == emptyInteger {
+		return grpcerr.FieldViolation("app_id", "app_id is required")
+	}
+	if req.GetPageSize() < 0 {
+		return grpcerr.FieldViolation("page_size", "page_size must not be negative")
 	}
 	return nil
 }
@@ -0,0 +1,116 @@
+// Package grpcerr turns service errors into gRPC statuses. Every status carries an ErrorInfo
+// with a stable reason clients can switch on, invalid requests also carry a BadRequest naming
+// the field. Errors without a mapping become Internal and their text stays on the server.
+package grpcerr
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// Domain is the ErrorInfo domain of every status the service returns.
+const Domain = "sso"
+
+// Error is the status of a failed call together with the error that caused it,
+// which is kept for the server logs and never sent to the client.
+type Error struct {
+	status *status.Status
+	cause  error
+}
+
+// Error returns the message the client sees.
+func (e *Error) Error() string {
+	return e.status.Message()
+}
+
+func (e *Error) GRPCStatus() *status.Status {
+	return e.status
+}
+
+// Unwrap returns the error that caused the status, nil for statuses the handler made up.
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// New returns a status with code and reason, message is sent as is.
+func New(code codes.Code, reason string, message string) error {
+	return newError(code, reason, message, nil)
+}
+
+// FieldViolation returns an InvalidArgument status for one field of the request,
+// message describes what is wrong with it.
+func FieldViolation(field string, message string) error {
+	return newError(codes.InvalidArgument, ReasonInvalidArgument, message, nil, &errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{{
+			Field:       field,
+			Description: message,
+		}},
+	})
+}
+
+// FromError returns the status for err, whose message is msg followed by the text of
+// the matched error. Service errors keep the detail they were wrapped with, storage
+// errors don't, their detail is the database's. Unmatched errors are Internal.
+func FromError(msg string, err error) error {
+	for _, rule := range rules {
+		if !errors.Is(err, rule.err) {
+			continue
+		}
+
+		text := rule.err.Error()
+		if rule.detail {
+			if i := strings.Index(err.Error(), text); i >= 0 {
+				text = err.Error()[i:]
+			}
+		}
+		var details []*errdetails.BadRequest
+		if field, ok := conflictField(err); ok {
+			details = append(details, &errdetails.BadRequest{
+				FieldViolations: []*errdetails.BadRequest_FieldViolation{{
+					Field:       field,
+					Description: field + " is taken",
+				}},
+			})
+		}
+		return newError(rule.code, rule.reason, msg+": "+text, err, details...)
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return newError(codes.DeadlineExceeded, ReasonDeadlineExceeded, msg+": deadline exceeded", err)
+	case errors.Is(err, context.Canceled):
+		return newError(codes.Canceled, ReasonCanceled, msg+": canceled", err)
+	}
+	return newError(codes.Internal, ReasonInternal, msg+": internal error", err)
+}
+
+// Token returns the status for a token that failed validation. Tokens that are malformed,
+// expired, revoked or belong to a user who can't sign in are Unauthenticated, failures to
+// check them, such as the database being down, map as in FromError.
+func Token(err error) error {
+	for _, rule := range tokenRules {
+		if errors.Is(err, rule.err) {
+			return newError(codes.Unauthenticated, rule.reason, rule.message, err)
+		}
+	}
+	return FromError("failed to validate token", err)
+}
+
+func newError(code codes.Code, reason string, message string, cause error, badRequest ...*errdetails.BadRequest) *Error {
+	st := status.New(code, message)
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: reason, Domain: Domain}}
+	for _, detail := range badRequest {
+		details = append(details, detail)
+	}
+	// The details always marshal, the status without them is only a fallback.
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return &Error{status: st, cause: cause}
+}
@@ -0,0 +1,195 @@
+package grpcerr_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/botanikn/go_sso_service/internal/grpc/grpcerr"
+	"github.com/botanikn/go_sso_service/internal/services/auth"
+	"github.com/botanikn/go_sso_service/internal/services/delegation"
+	"github.com/botanikn/go_sso_service/internal/services/users"
+	"github.com/botanikn/go_sso_service/internal/storage"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFromError(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    codes.Code
+		wantReason  string
+		wantMessage string
+		// wantField is the field of the BadRequest detail, empty for none.
+		wantField string
+	}{
+		{
+			name:        "service error keeps its detail",
+			err:         fmt.Errorf("delegation.CheckRoleChange: %w: manage_roles", delegation.ErrMissingCapability),
+			wantCode:    codes.PermissionDenied,
+			wantReason:  grpcerr.ReasonMissingCapability,
+			wantMessage: "failed: missing capability: manage_roles",
+		},
+		{
+			name:        "last owner",
+			err:         fmt.Errorf("delegation.checkLastOwner: %w", delegation.ErrLastOwner),
+			wantCode:    codes.FailedPrecondition,
+			wantReason:  grpcerr.ReasonLastOwner,
+			wantMessage: "failed: cannot remove the last owner of the app",
+		},
+		{
+			name:        "service error with the detail for the caller",
+			err:         fmt.Errorf("users.DeleteUser: %w: transfer ownership of apps [3] first", users.ErrSoleOwner),
+			wantCode:    codes.FailedPrecondition,
+			wantReason:  grpcerr.ReasonSoleOwner,
+			wantMessage: "failed: user is the only owner of an app: transfer ownership of apps [3] first",
+		},
+		{
+			name:        "storage error drops the database's detail",
+			err:         fmt.Errorf("sqlite.SavePermission: %w: FOREIGN KEY constraint failed", storage.ErrReferenceNotFound),
+			wantCode:    codes.FailedPrecondition,
+			wantReason:  grpcerr.ReasonReferenceNotFound,
+			wantMessage: "failed: " + storage.ErrReferenceNotFound.Error(),
+		},
+		{
+			name:        "conflict names the taken field",
+			err:         fmt.Errorf("users.UpdateUser: %w", &storage.ConflictError{Field: "email", Err: users.ErrUserExists}),
+			wantCode:    codes.AlreadyExists,
+			wantReason:  grpcerr.ReasonUserExists,
+			wantMessage: "failed: user with this email or username already exists: email is taken",
+			wantField:   "email",
+		},
+		{
+			name:        "serialization failure",
+			err:         fmt.Errorf("postgresql.WithTx: %w", storage.ErrSerialization),
+			wantCode:    codes.Aborted,
+			wantReason:  grpcerr.ReasonConcurrentUpdate,
+			wantMessage: "failed: " + storage.ErrSerialization.Error(),
+		},
+		{
+			name:        "deadline",
+			err:         fmt.Errorf("postgresql.UserById: %w", context.DeadlineExceeded),
+			wantCode:    codes.DeadlineExceeded,
+			wantReason:  grpcerr.ReasonDeadlineExceeded,
+			wantMessage: "failed: deadline exceeded",
+		},
+		{
+			name:        "canceled",
+			err:         context.Canceled,
+			wantCode:    codes.Canceled,
+			wantReason:  grpcerr.ReasonCanceled,
+			wantMessage: "failed: canceled",
+		},
+		{
+			name:        "unmatched error stays on the server",
+			err:         errors.New("pq: password authentication failed for user \"sso\""),
+			wantCode:    codes.Internal,
+			wantReason:  grpcerr.ReasonInternal,
+			wantMessage: "failed: internal error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := grpcerr.FromError("failed", tt.err)
+
+			expectStatus(t, err, tt.wantCode, tt.wantReason, tt.wantMessage, tt.wantField)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("FromError: got %v, want it to wrap %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestToken(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    codes.Code
+		wantReason  string
+		wantMessage string
+	}{
+		{
+			name:        "expired",
+			err:         fmt.Errorf("auth.ValidateToken: %w", jwt.ErrTokenExpired),
+			wantCode:    codes.Unauthenticated,
+			wantReason:  grpcerr.ReasonTokenExpired,
+			wantMessage: "token is expired",
+		},
+		{
+			name:        "revoked",
+			err:         fmt.Errorf("auth.ValidateToken: %w", auth.ErrTokenRevoked),
+			wantCode:    codes.Unauthenticated,
+			wantReason:  grpcerr.ReasonTokenRevoked,
+			wantMessage: "token has been revoked",
+		},
+		{
+			name:        "bad signature",
+			err:         fmt.Errorf("auth.ValidateToken: %w", jwt.ErrTokenSignatureInvalid),
+			wantCode:    codes.Unauthenticated,
+			wantReason:  grpcerr.ReasonInvalidToken,
+			wantMessage: "invalid token",
+		},
+		{
+			name:        "user gone",
+			err:         fmt.Errorf("auth.ValidateToken: %w", storage.ErrUserNotFound),
+			wantCode:    codes.Unauthenticated,
+			wantReason:  grpcerr.ReasonInvalidToken,
+			wantMessage: "invalid token",
+		},
+		{
+			name:        "database down",
+			err:         fmt.Errorf("auth.ValidateToken: %w", storage.ErrUnavailable),
+			wantCode:    codes.Unavailable,
+			wantReason:  grpcerr.ReasonUnavailable,
+			wantMessage: "failed to validate token: " + storage.ErrUnavailable.Error(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectStatus(t, grpcerr.Token(tt.err), tt.wantCode, tt.wantReason, tt.wantMessage, "")
+		})
+	}
+}
+
+func TestFieldViolation(t *testing.T) {
+	expectStatus(t, grpcerr.FieldViolation("app_id", "app_id is required"),
+		codes.InvalidArgument, grpcerr.ReasonInvalidArgument, "app_id is required", "app_id")
+}
+
+// expectStatus checks the status of err, want field is the field of the BadRequest detail,
+// empty for none.
+func expectStatus(t *testing.T, err error, wantCode codes.Code, wantReason string, wantMessage string, wantField string) {
+	t.Helper()
+	st, ok := status.FromError(err)
+	if !ok {
+		t.Fatalf("status.FromError: %v is not a status", err)
+	}
+	if st.Code() != wantCode || st.Message() != wantMessage {
+		t.Errorf("status: got %s %q, want %s %q", st.Code(), st.Message(), wantCode, wantMessage)
+	}
+
+	var reason, field string
+	for _, detail := range st.Details() {
+		switch detail := detail.(type) {
+		case *errdetails.ErrorInfo:
+			if detail.Domain != grpcerr.Domain {
+				t.Errorf("ErrorInfo: got domain %q, want %q", detail.Domain, grpcerr.Domain)
+			}
+			reason = detail.Reason
+		case *errdetails.BadRequest:
+			for _, violation := range detail.FieldViolations {
+				field = violation.Field
+			}
+		}
+	}
+	if reason != wantReason {
+		t.Errorf("ErrorInfo: got reason %q, want %q", reason, wantReason)
+	}
+	if field != wantField {
+		t.Errorf("BadRequest: got field %q, want %q", field, wantField)
+	}
+}
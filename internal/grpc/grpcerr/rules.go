@@ -0,0 +1,233 @@
+package grpcerr
+
+import (
+	"errors"
+
+	"github.com/botanikn/go_sso_service/internal/services/account"
+	"github.com/botanikn/go_sso_service/internal/services/apps"
+	"github.com/botanikn/go_sso_service/internal/services/audit"
+	"github.com/botanikn/go_sso_service/internal/services/auth"
+	"github.com/botanikn/go_sso_service/internal/services/authz"
+	"github.com/botanikn/go_sso_service/internal/services/bootstrap"
+	"github.com/botanikn/go_sso_service/internal/services/delegation"
+	"github.com/botanikn/go_sso_service/internal/services/grants"
+	"github.com/botanikn/go_sso_service/internal/services/impersonation"
+	"github.com/botanikn/go_sso_service/internal/services/invitations"
+	"github.com/botanikn/go_sso_service/internal/services/members"
+	"github.com/botanikn/go_sso_service/internal/services/relations"
+	"github.com/botanikn/go_sso_service/internal/services/users"
+	"github.com/botanikn/go_sso_service/internal/storage"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc/codes"
+)
+
+// ErrorInfo reasons. They are part of the API, clients match on them instead of messages,
+// so a reason is never renamed or reused for a different error.
+const (
+	ReasonInvalidArgument  = "INVALID_ARGUMENT"
+	ReasonInternal         = "INTERNAL"
+	ReasonUnavailable      = "UNAVAILABLE"
+	ReasonDeadlineExceeded = "DEADLINE_EXCEEDED"
+	ReasonCanceled         = "CANCELED"
+
+	ReasonMissingToken       = "MISSING_TOKEN"
+	ReasonInvalidToken       = "INVALID_TOKEN"
+	ReasonTokenExpired       = "TOKEN_EXPIRED"
+	ReasonTokenRevoked       = "TOKEN_REVOKED"
+	ReasonImpersonationToken = "IMPERSONATION_TOKEN"
+	ReasonReadOnlyToken      = "READ_ONLY_TOKEN"
+	ReasonPermissionDenied   = "PERMISSION_DENIED"
+
+	ReasonInvalidCredentials     = "INVALID_CREDENTIALS"
+	ReasonUserExists             = "USER_EXISTS"
+	ReasonUserNotFound           = "USER_NOT_FOUND"
+	ReasonUserDisabled           = "USER_DISABLED"
+	ReasonUserDeleted            = "USER_DELETED"
+	ReasonLoginMethodNotAllowed  = "LOGIN_METHOD_NOT_ALLOWED"
+	ReasonMFARequired            = "MFA_REQUIRED"
+	ReasonRegistrationClosed     = "REGISTRATION_CLOSED"
+	ReasonEmailDomainNotAllowed  = "EMAIL_DOMAIN_NOT_ALLOWED"
+	ReasonMembershipPending      = "MEMBERSHIP_PENDING"
+	ReasonMembershipDenied       = "MEMBERSHIP_DENIED"
+	ReasonPermissionNotFound     = "PERMISSION_NOT_FOUND"
+	ReasonAlreadyInitialized     = "ALREADY_INITIALIZED"
+	ReasonInvalidSetupToken      = "INVALID_SETUP_TOKEN"
+	ReasonPasswordRequired       = "PASSWORD_REQUIRED"
+	ReasonInvalidGrant           = "INVALID_GRANT"
+	ReasonInvalidStatus          = "INVALID_STATUS"
+	ReasonSelfAction             = "SELF_ACTION"
+	ReasonInvalidPageToken       = "INVALID_PAGE_TOKEN"
+	ReasonInvalidPolicy          = "INVALID_POLICY"
+	ReasonPolicyNotFound         = "POLICY_NOT_FOUND"
+	ReasonPolicyExists           = "POLICY_EXISTS"
+	ReasonImpersonationForbidden = "IMPERSONATION_FORBIDDEN"
+	ReasonNamespaceNotFound      = "NAMESPACE_NOT_FOUND"
+	ReasonUnknownRelation        = "UNKNOWN_RELATION"
+	ReasonInvalidTuple           = "INVALID_TUPLE"
+	ReasonInvalidConsistency     = "INVALID_CONSISTENCY_TOKEN"
+	ReasonMaxDepthExceeded       = "MAX_DEPTH_EXCEEDED"
+	ReasonInvalidNamespace       = "INVALID_NAMESPACE_CONFIG"
+	ReasonInvalidFilter          = "INVALID_FILTER"
+	ReasonSuperAdmin             = "SUPER_ADMIN"
+	ReasonSoleOwner              = "SOLE_OWNER"
+	ReasonNotDeleted             = "NOT_DELETED"
+	ReasonAlreadyDeleted         = "ALREADY_DELETED"
+	ReasonUnknownRole            = "UNKNOWN_ROLE"
+	ReasonMissingCapability      = "MISSING_CAPABILITY"
+	ReasonInsufficientRank       = "INSUFFICIENT_RANK"
+	ReasonOwnerRoleProtected     = "OWNER_ROLE_PROTECTED"
+	ReasonLastOwner              = "LAST_OWNER"
+	ReasonAppNotFound            = "APP_NOT_FOUND"
+	ReasonAppExists              = "APP_EXISTS"
+	ReasonAdminAppNotConfigured  = "ADMIN_APP_NOT_CONFIGURED"
+	ReasonAdminAppProtected      = "ADMIN_APP_PROTECTED"
+	ReasonInvalidSettings        = "INVALID_SETTINGS"
+	ReasonInvalidInvitation      = "INVALID_INVITATION"
+	ReasonInvitationNotFound     = "INVITATION_NOT_FOUND"
+	ReasonInvitationExpired      = "INVITATION_EXPIRED"
+	ReasonConflict               = "CONFLICT"
+	ReasonReferenceNotFound      = "REFERENCE_NOT_FOUND"
+	ReasonInvalidValue           = "INVALID_VALUE"
+	ReasonConcurrentUpdate       = "CONCURRENT_UPDATE"
+)
+
+type rule struct {
+	err    error
+	code   codes.Code
+	reason string
+	// detail keeps the text the error was wrapped with, services wrap their errors with
+	// details meant for the caller.
+	detail bool
+}
+
+// rules are matched in order with errors.Is. Service errors come first, the storage errors
+// they may wrap only decide the status when the service passed them on as they are.
+var rules = []rule{
+	{auth.ErrInvalidCredentials, codes.Unauthenticated, ReasonInvalidCredentials, true},
+	{account.ErrInvalidCredentials, codes.Unauthenticated, ReasonInvalidCredentials, true},
+	{jwt.ErrTokenExpired, codes.Unauthenticated, ReasonTokenExpired, false},
+	{auth.ErrTokenRevoked, codes.Unauthenticated, ReasonTokenRevoked, true},
+	{auth.ErrInvalidAppID, codes.NotFound, ReasonAppNotFound, true},
+	{auth.ErrUserExists, codes.AlreadyExists, ReasonUserExists, true},
+	{users.ErrUserExists, codes.AlreadyExists, ReasonUserExists, true},
+	{auth.ErrUserDisabled, codes.PermissionDenied, ReasonUserDisabled, true},
+	{invitations.ErrUserDisabled, codes.PermissionDenied, ReasonUserDisabled, true},
+	{auth.ErrUserDeleted, codes.FailedPrecondition, ReasonUserDeleted, true},
+	{auth.ErrPermissionNotFound, codes.NotFound, ReasonPermissionNotFound, true},
+	{auth.ErrLoginMethodNotAllowed, codes.PermissionDenied, ReasonLoginMethodNotAllowed, true},
+	{auth.ErrMFARequired, codes.FailedPrecondition, ReasonMFARequired, true},
+	{auth.ErrRegistrationClosed, codes.PermissionDenied, ReasonRegistrationClosed, true},
+	{auth.ErrEmailDomainNotAllowed, codes.PermissionDenied, ReasonEmailDomainNotAllowed, true},
+	{auth.ErrMembershipPending, codes.FailedPrecondition, ReasonMembershipPending, true},
+	{auth.ErrMembershipDenied, codes.PermissionDenied, ReasonMembershipDenied, true},
+
+	{bootstrap.ErrAlreadyInitialized, codes.FailedPrecondition, ReasonAlreadyInitialized, true},
+	{bootstrap.ErrInvalidSetupToken, codes.PermissionDenied, ReasonInvalidSetupToken, true},
+	{bootstrap.ErrPasswordRequired, codes.FailedPrecondition, ReasonPasswordRequired, true},
+	{invitations.ErrPasswordRequired, codes.FailedPrecondition, ReasonPasswordRequired, true},
+
+	{grants.ErrInvalidValidity, codes.InvalidArgument, ReasonInvalidGrant, true},
+	{grants.ErrJustificationRequired, codes.InvalidArgument, ReasonInvalidGrant, true},
+	{grants.ErrInvalidDuration, codes.InvalidArgument, ReasonInvalidGrant, true},
+
+	{users.ErrUserNotFound, codes.NotFound, ReasonUserNotFound, true},
+	{impersonation.ErrUserNotFound, codes.NotFound, ReasonUserNotFound, true},
+	{account.ErrUserNotFound, codes.NotFound, ReasonUserNotFound, true},
+	{users.ErrInvalidStatus, codes.InvalidArgument, ReasonInvalidStatus, true},
+	{users.ErrSelfAction, codes.FailedPrecondition, ReasonSelfAction, true},
+	{users.ErrInvalidPageToken, codes.InvalidArgument, ReasonInvalidPageToken, true},
+	{users.ErrSuperAdmin, codes.PermissionDenied, ReasonSuperAdmin, true},
+	{users.ErrInsufficientRank, codes.PermissionDenied, ReasonInsufficientRank, true},
+	{users.ErrSoleOwner, codes.FailedPrecondition, ReasonSoleOwner, true},
+	{apps.ErrInvalidPageToken, codes.InvalidArgument, ReasonInvalidPageToken, true},
+	{members.ErrInvalidPageToken, codes.InvalidArgument, ReasonInvalidPageToken, true},
+	{invitations.ErrInvalidPageToken, codes.InvalidArgument, ReasonInvalidPageToken, true},
+
+	{authz.ErrInvalidPolicy, codes.FailedPrecondition, ReasonInvalidPolicy, true},
+	{authz.ErrMalformedPolicy, codes.InvalidArgument, ReasonInvalidPolicy, true},
+	{authz.ErrPolicyNotFound, codes.NotFound, ReasonPolicyNotFound, true},
+	{authz.ErrPolicyExists, codes.AlreadyExists, ReasonPolicyExists, true},
+	{authz.ErrAppNotFound, codes.NotFound, ReasonAppNotFound, true},
+	{authz.ErrNotSuperAdmin, codes.PermissionDenied, ReasonPermissionDenied, true},
+	{impersonation.ErrImpersonationForbidden, codes.PermissionDenied, ReasonImpersonationForbidden, true},
+
+	{relations.ErrNamespaceNotFound, codes.NotFound, ReasonNamespaceNotFound, true},
+	{relations.ErrUnknownRelation, codes.InvalidArgument, ReasonUnknownRelation, true},
+	{relations.ErrInvalidTuple, codes.InvalidArgument, ReasonInvalidTuple, true},
+	{relations.ErrInvalidConsistencyToken, codes.InvalidArgument, ReasonInvalidConsistency, true},
+	{relations.ErrMaxDepthExceeded, codes.FailedPrecondition, ReasonMaxDepthExceeded, true},
+	{relations.ErrInvalidNamespaceConfig, codes.InvalidArgument, ReasonInvalidNamespace, true},
+
+	{audit.ErrInvalidFilter, codes.InvalidArgument, ReasonInvalidFilter, true},
+
+	{account.ErrSuperAdmin, codes.PermissionDenied, ReasonSuperAdmin, true},
+	{account.ErrSoleOwner, codes.FailedPrecondition, ReasonSoleOwner, true},
+	{account.ErrNotDeleted, codes.FailedPrecondition, ReasonNotDeleted, true},
+	{account.ErrAlreadyDeleted, codes.FailedPrecondition, ReasonAlreadyDeleted, true},
+
+	{members.ErrUnknownRole, codes.InvalidArgument, ReasonUnknownRole, true},
+	{delegation.ErrUnknownRole, codes.InvalidArgument, ReasonUnknownRole, true},
+	{delegation.ErrMissingCapability, codes.PermissionDenied, ReasonMissingCapability, true},
+	{delegation.ErrInsufficientRank, codes.PermissionDenied, ReasonInsufficientRank, true},
+	{delegation.ErrOwnerRoleProtected, codes.PermissionDenied, ReasonOwnerRoleProtected, true},
+	{delegation.ErrLastOwner, codes.FailedPrecondition, ReasonLastOwner, true},
+
+	{apps.ErrAppNotFound, codes.NotFound, ReasonAppNotFound, true},
+	{invitations.ErrAppNotFound, codes.NotFound, ReasonAppNotFound, true},
+	{apps.ErrAppExists, codes.AlreadyExists, ReasonAppExists, true},
+	{apps.ErrInvalidSettings, codes.InvalidArgument, ReasonInvalidSettings, true},
+	{apps.ErrUserNotFound, codes.NotFound, ReasonUserNotFound, true},
+	{apps.ErrNotSuperAdmin, codes.PermissionDenied, ReasonPermissionDenied, true},
+
+	{invitations.ErrInvalidInvitation, codes.InvalidArgument, ReasonInvalidInvitation, true},
+	{invitations.ErrInvitationNotFound, codes.NotFound, ReasonInvitationNotFound, true},
+	{invitations.ErrInvitationExpired, codes.FailedPrecondition, ReasonInvitationExpired, true},
+
+	{storage.ErrUserExists, codes.AlreadyExists, ReasonUserExists, false},
+	{storage.ErrUserNotFound, codes.NotFound, ReasonUserNotFound, false},
+	{storage.ErrAppNotFound, codes.NotFound, ReasonAppNotFound, false},
+	{storage.ErrAppExists, codes.AlreadyExists, ReasonAppExists, false},
+	{storage.ErrNoPermissionFound, codes.NotFound, ReasonPermissionNotFound, false},
+	{storage.ErrNamespaceNotFound, codes.NotFound, ReasonNamespaceNotFound, false},
+	{storage.ErrPolicyNotFound, codes.NotFound, ReasonPolicyNotFound, false},
+	{storage.ErrPolicyExists, codes.AlreadyExists, ReasonPolicyExists, false},
+	{storage.ErrInvitationNotFound, codes.NotFound, ReasonInvitationNotFound, false},
+	{storage.ErrConflict, codes.AlreadyExists, ReasonConflict, false},
+	{storage.ErrReferenceNotFound, codes.FailedPrecondition, ReasonReferenceNotFound, false},
+	{storage.ErrInvalidValue, codes.InvalidArgument, ReasonInvalidValue, false},
+	{storage.ErrSerialization, codes.Aborted, ReasonConcurrentUpdate, false},
+	{storage.ErrQueryTimeout, codes.Unavailable, ReasonUnavailable, false},
+	{storage.ErrUnavailable, codes.Unavailable, ReasonUnavailable, false},
+}
+
+type tokenRule struct {
+	err     error
+	reason  string
+	message string
+}
+
+// tokenRules are the reasons a token is rejected. Any other jwt error means the token
+// is malformed or its signature doesn't match.
+var tokenRules = []tokenRule{
+	{jwt.ErrTokenExpired, ReasonTokenExpired, "token is expired"},
+	{auth.ErrTokenRevoked, ReasonTokenRevoked, "token has been revoked"},
+	{auth.ErrUserDisabled, ReasonUserDisabled, "user is disabled"},
+	{auth.ErrUserDeleted, ReasonUserDeleted, "user account is scheduled for deletion"},
+	{storage.ErrUserNotFound, ReasonInvalidToken, "invalid token"},
+	{jwt.ErrTokenMalformed, ReasonInvalidToken, "invalid token"},
+	{jwt.ErrTokenUnverifiable, ReasonInvalidToken, "invalid token"},
+	{jwt.ErrTokenSignatureInvalid, ReasonInvalidToken, "invalid token"},
+	{jwt.ErrTokenInvalidClaims, ReasonInvalidToken, "invalid token"},
+	{jwt.ErrTokenNotValidYet, ReasonInvalidToken, "invalid token"},
+	{jwt.ErrTokenUsedBeforeIssued, ReasonInvalidToken, "invalid token"},
+	{jwt.ErrTokenRequiredClaimMissing, ReasonInvalidToken, "invalid token"},
+}
+
+// conflictField returns the field of a unique violation, so the client knows which value is taken.
+func conflictField(err error) (string, bool) {
+	var conflict *storage.ConflictError
+	if errors.As(err, &conflict) {
+		return conflict.Field, true
+	}
+	return "", false
+}
@@ -0,0 +1,216 @@
+package invitations
+
+import (
+	"context"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/grpc/grpcerr"
+	"github.com/botanikn/go_sso_service/internal/grpc/middleware"
+	"github.com/botanikn/go_sso_service/internal/services/authz"
+	ssov1 "github.com/botanikn/protos/gen/go/sso"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	emptyInteger int64 = 0
+)
+
+type InvitationsService interface {
+	Invite(ctx context.Context, actorId int64, appId int64, email string, role string) (models.Invitation, string, error)
+	ListInvitations(ctx context.Context, appId int64, pendingOnly bool, pageSize int, pageToken string) ([]models.Invitation, string, error)
+	RevokeInvitation(ctx context.Context, actorId int64, appId int64, invitationId int64) error
+	AcceptInvitation(ctx context.Context, token string, username string, password string) (models.Invitation, models.PermissionChangeResult, error)
+}
+
+type Authorizer interface {
+	Authorize(ctx context.Context, req authz.Request) (authz.Decision, error)
+}
+
+type Delegator interface {
+	CheckInvitation(ctx context.Context, actorId int64, appId int64, role string) error
+}
+
+type serverAPI struct {
+	ssov1.UnimplementedInvitationsServer
+	invitations InvitationsService
+	authz       Authorizer
+	delegation  Delegator
+}
+
+// Roles are the roles the methods of the Invitations service require.
+var Roles = map[string]middleware.Role{
+	ssov1.Invitations_CreateInvitation_FullMethodName: middleware.RoleUser,
+	ssov1.Invitations_ListInvitations_FullMethodName:  middleware.RoleUser,
+	ssov1.Invitations_RevokeInvitation_FullMethodName: middleware.RoleUser,
+	ssov1.Invitations_AcceptInvitation_FullMethodName: middleware.RolePublic,
+}
+
+// Register registers the Invitations service.
+func Register(
+	gRPC *grpc.Server,
+	invitations InvitationsService,
+	authz Authorizer,
+	delegation Delegator,
+) {
+	ssov1.RegisterInvitationsServer(gRPC, &serverAPI{
+		invitations: invitations,
+		authz:       authz,
+		delegation:  delegation,
+	})
+}
+
+func (s *serverAPI) CreateInvitation(
+	ctx context.Context,
+	req *ssov1.CreateInvitationRequest,
+) (*ssov1.CreateInvitationResponse, error) {
+	if err := validateCreateInvitationRequest(req); err != nil {
+		return nil, err
+	}
+
+	actorId, err := s.authorize(ctx, req.AppId, authz.ActionCreateInvitation)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.delegation.CheckInvitation(ctx, actorId, req.AppId, req.Role); err != nil {
+		return nil, grpcerr.FromError("failed to create invitation", err)
+	}
+
+	invitation, token, err := s.invitations.Invite(ctx, actorId, req.AppId, req.Email, req.Role)
+	if err != nil {
+		return nil, grpcerr.FromError("failed to create invitation", err)
+	}
+
+	return &ssov1.CreateInvitationResponse{
+		Invitation: invitationToProto(invitation),
+		Token:      token,
+	}, nil
+}
+
+func (s *serverAPI) ListInvitations(
+	ctx context.Context,
+	req *ssov1.ListInvitationsRequest,
+) (*ssov1.ListInvitationsResponse, error) {
+	if req.GetAppId() == emptyInteger {
+		return nil, grpcerr.FieldViolation("app_id", "app_id is required")
+	}
+	if req.GetPageSize() < 0 {
+		return nil, grpcerr.FieldViolation("page_size", "page_size must not be negative")
+	}
+
+	if _, err := s.authorize(ctx, req.AppId, authz.ActionListInvitations); err != nil {
+		return nil, err
+	}
+
+	list, nextPageToken, err := s.invitations.ListInvitations(ctx, req.AppId, req.PendingOnly, int(req.PageSize), req.PageToken)
+	if err != nil {
+		return nil, grpcerr.FromError("failed to list invitations", err)
+	}
+
+	res := &ssov1.ListInvitationsResponse{
+		NextPageToken: nextPageToken,
+	}
+	for _, invitation := range list {
+		res.Invitations = append(res.Invitations, invitationToProto(invitation))
+	}
+	return res, nil
+}
+
+func (s *serverAPI) RevokeInvitation(
+	ctx context.Context,
+	req *ssov1.RevokeInvitationRequest,
+) (*ssov1.RevokeInvitationResponse, error) {
+	if req.GetAppId() == emptyInteger {
+		return nil, grpcerr.FieldViolation("app_id", "app_id is required")
+	}
+	if req.GetInvitationId() == emptyInteger {
+		return nil, grpcerr.FieldViolation("invitation_id", "invitation_id is required")
+	}
+
+	actorId, err := s.authorize(ctx, req.AppId, authz.ActionRevokeInvitation)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.invitations.RevokeInvitation(ctx, actorId, req.AppId, req.InvitationId); err != nil {
+		return nil, grpcerr.FromError("failed to revoke invitation", err)
+	}
+
+	return &ssov1.RevokeInvitationResponse{
+		Success: true,
+	}, nil
+}
+
+// AcceptInvitation is not authenticated, holding the invitation token is what entitles the caller.
+func (s *serverAPI) AcceptInvitation(
+	ctx context.Context,
+	req *ssov1.AcceptInvitationRequest,
+) (*ssov1.AcceptInvitationResponse, error) {
+	if req.GetToken() == "" {
+		return nil, grpcerr.FieldViolation("token", "token is required")
+	}
+
+	invitation, result, err := s.invitations.AcceptInvitation(ctx, req.Token, req.Username, req.Password)
+	if err != nil {
+		return nil, grpcerr.FromError("failed to accept invitation", err)
+	}
+
+	role := invitation.Role
+	if result.Outcome == models.PermissionChangeUnchanged && result.PreviousPermission != "" {
+		role = result.PreviousPermission
+	}
+
+	return &ssov1.AcceptInvitationResponse{
+		UserId: invitation.AcceptedBy,
+		AppId:  invitation.AppId,
+		Role:   role,
+	}, nil
+}
+
+// authorize checks the action against the app's policies and returns the caller's user id.
+func (s *serverAPI) authorize(ctx context.Context, appId int64, action string) (int64, error) {
+	caller := middleware.Caller(ctx)
+
+	decision, err := s.authz.Authorize(ctx, authz.Request{
+		AppId:    appId,
+		UserId:   caller.UserId,
+		Claims:   caller.Claims,
+		ReadOnly: caller.ReadOnly,
+		Action:   action,
+	})
+	if err != nil {
+		return 0, grpcerr.FromError("failed to authorize", err)
+	}
+	if !decision.Allowed {
+		return 0, grpcerr.New(codes.PermissionDenied, grpcerr.ReasonPermissionDenied, "insufficient permissions to manage invitations")
+	}
+	return caller.UserId, nil
+}
+
+func invitationToProto(invitation models.Invitation) *ssov1.Invitation {
+	return &ssov1.Invitation{
+		Id:        invitation.ID,
+		AppId:     invitation.AppId,
+		Email:     invitation.Email,
+		Role:      invitation.Role,
+		InvitedBy: invitation.InvitedBy,
+		Status:    invitation.Status(time.Now()),
+		ExpiresAt: timestamppb.New(invitation.ExpiresAt),
+		CreatedAt: timestamppb.New(invitation.CreatedAt),
+	}
+}
+
+func validateCreateInvitationRequest(req *ssov1.CreateInvitationRequest) error {
+	if req.GetAppId() == emptyInteger {
+		return grpcerr.FieldViolation("app_id", "app_id is required")
+	}
+	if req.GetEmail() == "" {
+		return grpcerr.FieldViolation("email", "email is required")
+	}
+	if req.GetRole() == "" {
+		return grpcerr.FieldViolation("role", "role is required")
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/grpc/grpcerr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryAccessLog logs every call with its code and latency. Failures on the server's side
+// are logged as errors together with their cause, which the client doesn't get.
+func UnaryAccessLog(log *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logAccess(ctx, log, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+func StreamAccessLog(log *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logAccess(ss.Context(), log, info.FullMethod, start, err)
+		return err
+	}
+}
+
+func logAccess(ctx context.Context, log *slog.Logger, method string, start time.Time, err error) {
+	const op = "middleware.logAccess"
+
+	code := status.Code(err)
+	attrs := []slog.Attr{
+		slog.String("op", op),
+		slog.String("method", method),
+		slog.String("code", code.String()),
+		slog.Duration("latency", time.Since(start)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+	}
+
+	level := slog.LevelInfo
+	if serverError(code) {
+		level = slog.LevelError
+		var grpcErr *grpcerr.Error
+		if errors.As(err, &grpcErr) && grpcErr.Unwrap() != nil {
+			attrs = append(attrs, slog.String("cause", grpcErr.Unwrap().Error()))
+		}
+	}
+	log.LogAttrs(ctx, level, "request finished", attrs...)
+}
+
+// serverError reports whether code is a failure of the server rather than of the request.
+func serverError(code codes.Code) bool {
+	switch code {
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable, codes.Aborted:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,207 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/botanikn/go_sso_service/internal/grpc/grpcerr"
+	"github.com/botanikn/go_sso_service/internal/services/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// Role is what a method requires of the caller's token. Every method of the server is
+// annotated with one, the zero Role is a method that was left out and is refused.
+type Role int
+
+const (
+	// RolePublic methods take no token.
+	RolePublic Role = iota + 1
+	// RoleUser methods take a token issued for the app in the app_id of the request.
+	RoleUser
+	// RoleAdmin methods take a token issued for the admin app.
+	RoleAdmin
+)
+
+func (r Role) String() string {
+	switch r {
+	case RolePublic:
+		return "public"
+	case RoleUser:
+		return "user"
+	case RoleAdmin:
+		return "admin"
+	default:
+		return "none"
+	}
+}
+
+// Principal is the caller of a method, as told by their token.
+type Principal struct {
+	// AppId is the app the token was validated for.
+	AppId    int64
+	UserId   int64
+	ActorId  int64
+	ReadOnly bool
+	Claims   map[string]any
+	Token    string
+}
+
+type principalKey struct{}
+
+// Caller returns the principal the auth interceptor put in ctx, the zero Principal
+// for methods with RolePublic.
+func Caller(ctx context.Context) Principal {
+	principal, _ := ctx.Value(principalKey{}).(Principal)
+	return principal
+}
+
+type TokenValidator interface {
+	ValidateToken(ctx context.Context, tokenString string, appId int64) (auth.PermissionResponse, error)
+}
+
+// Auth validates the caller's token once per call, as the method's role requires, and
+// puts the principal in the context of the handler. Handlers authorize the principal.
+type Auth struct {
+	tokens     TokenValidator
+	adminAppId int64
+	roles      map[string]Role
+}
+
+// NewAuth returns Auth for the methods in roles, which are keyed by full method name.
+func NewAuth(tokens TokenValidator, adminAppId int64, roles ...map[string]Role) *Auth {
+	a := &Auth{
+		tokens:     tokens,
+		adminAppId: adminAppId,
+		roles:      make(map[string]Role),
+	}
+	for _, r := range roles {
+		for method, role := range r {
+			a.roles[method] = role
+		}
+	}
+	return a
+}
+
+// Check returns an error naming the methods of services that have no role.
+func (a *Auth) Check(services map[string]grpc.ServiceInfo) error {
+	var missing []string
+	for service, info := range services {
+		for _, method := range info.Methods {
+			name := "/" + service + "/" + method.Name
+			if a.roles[name] == 0 {
+				missing = append(missing, name)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("methods without a role: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func (a *Auth) Unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := a.authenticate(ctx, info.FullMethod, req)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// Stream authenticates server streams when the handler receives the request,
+// the app a RoleUser token is validated for is in it.
+func (a *Auth) Stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if a.roles[info.FullMethod] == RolePublic {
+		return handler(srv, ss)
+	}
+	return handler(srv, &authStream{
+		serverStream: serverStream{ServerStream: ss, ctx: ss.Context()},
+		auth:         a,
+		method:       info.FullMethod,
+	})
+}
+
+type authStream struct {
+	serverStream
+	auth          *Auth
+	method        string
+	authenticated bool
+}
+
+func (s *authStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if s.authenticated {
+		return nil
+	}
+
+	ctx, err := s.auth.authenticate(s.ctx, s.method, m)
+	if err != nil {
+		return err
+	}
+	s.ctx = ctx
+	s.authenticated = true
+	return nil
+}
+
+func (a *Auth) authenticate(ctx context.Context, method string, req any) (context.Context, error) {
+	var appId int64
+	switch a.roles[method] {
+	case RolePublic:
+		return ctx, nil
+	case RoleUser:
+		r, ok := req.(interface{ GetAppId() int64 })
+		if !ok || r.GetAppId() == 0 {
+			return nil, grpcerr.FieldViolation("app_id", "app_id is required")
+		}
+		appId = r.GetAppId()
+	case RoleAdmin:
+		if a.adminAppId == 0 {
+			return nil, grpcerr.New(codes.FailedPrecondition, grpcerr.ReasonAdminAppNotConfigured, "admin app is not configured")
+		}
+		appId = a.adminAppId
+	default:
+		return nil, grpcerr.New(codes.PermissionDenied, grpcerr.ReasonPermissionDenied, "method has no role")
+	}
+
+	token, err := bearerToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	valid, err := a.tokens.ValidateToken(ctx, token, appId)
+	if err != nil {
+		return nil, grpcerr.Token(err)
+	}
+	if !valid.Validated {
+		return nil, grpcerr.New(codes.Unauthenticated, grpcerr.ReasonInvalidToken, "invalid token")
+	}
+
+	return context.WithValue(ctx, principalKey{}, Principal{
+		AppId:    appId,
+		UserId:   valid.UserId,
+		ActorId:  valid.ActorId,
+		ReadOnly: valid.ReadOnly,
+		Claims:   valid.Claims,
+		Token:    token,
+	}), nil
+}
+
+// bearerToken returns the token in the authorization metadata, with or without the Bearer prefix.
+func bearerToken(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", grpcerr.New(codes.Unauthenticated, grpcerr.ReasonMissingToken, "missing metadata")
+	}
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return "", grpcerr.New(codes.Unauthenticated, grpcerr.ReasonMissingToken, "missing authorization token")
+	}
+
+	token := strings.TrimPrefix(values[0], "Bearer ")
+	return strings.TrimSpace(token), nil
+}
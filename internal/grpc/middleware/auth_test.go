@@ -0,0 +1,253 @@
+package middleware_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/botanikn/go_sso_service/internal/grpc/grpcerr"
+	"github.com/botanikn/go_sso_service/internal/grpc/middleware"
+	"github.com/botanikn/go_sso_service/internal/services/auth"
+	ssov1 "github.com/botanikn/protos/gen/go/sso"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	adminAppId = 1
+
+	methodPublic = "/sso.Test/Public"
+	methodUser   = "/sso.Test/User"
+	methodAdmin  = "/sso.Test/Admin"
+	methodNone   = "/sso.Test/None"
+)
+
+var roles = map[string]middleware.Role{
+	methodPublic: middleware.RolePublic,
+	methodUser:   middleware.RoleUser,
+	methodAdmin:  middleware.RoleAdmin,
+}
+
+// tokenValidator accepts the token "valid" for user 7 and records the app it validated for.
+type tokenValidator struct {
+	err   error
+	appId int64
+}
+
+func (v *tokenValidator) ValidateToken(ctx context.Context, tokenString string, appId int64) (auth.PermissionResponse, error) {
+	v.appId = appId
+	if v.err != nil {
+		return auth.PermissionResponse{}, v.err
+	}
+	return auth.PermissionResponse{
+		Validated: tokenString == "valid",
+		UserId:    7,
+		ReadOnly:  true,
+	}, nil
+}
+
+func TestAuthUnary(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		adminAppId int64
+		appId      int64
+		// authorization is the authorization metadata, none when empty.
+		authorization string
+		validatorErr  error
+		wantCode      codes.Code
+		wantReason    string
+		// wantAppId is the app the token is validated for and the principal has.
+		wantAppId int64
+	}{
+		{
+			name:   "public method takes no token",
+			method: methodPublic,
+		},
+		{
+			name:          "user method",
+			method:        methodUser,
+			appId:         5,
+			authorization: "Bearer valid",
+			wantAppId:     5,
+		},
+		{
+			name:          "token without the bearer prefix",
+			method:        methodUser,
+			appId:         5,
+			authorization: "valid",
+			wantAppId:     5,
+		},
+		{
+			name:          "user method needs the app",
+			method:        methodUser,
+			authorization: "Bearer valid",
+			wantCode:      codes.InvalidArgument,
+			wantReason:    grpcerr.ReasonInvalidArgument,
+		},
+		{
+			name:       "missing token",
+			method:     methodUser,
+			appId:      5,
+			wantCode:   codes.Unauthenticated,
+			wantReason: grpcerr.ReasonMissingToken,
+		},
+		{
+			name:          "invalid token",
+			method:        methodUser,
+			appId:         5,
+			authorization: "Bearer forged",
+			wantCode:      codes.Unauthenticated,
+			wantReason:    grpcerr.ReasonInvalidToken,
+		},
+		{
+			name:          "expired token",
+			method:        methodUser,
+			appId:         5,
+			authorization: "Bearer valid",
+			validatorErr:  fmt.Errorf("auth.ValidateToken: %w", jwt.ErrTokenExpired),
+			wantCode:      codes.Unauthenticated,
+			wantReason:    grpcerr.ReasonTokenExpired,
+		},
+		{
+			name:          "admin method validates for the admin app",
+			method:        methodAdmin,
+			adminAppId:    adminAppId,
+			appId:         5,
+			authorization: "Bearer valid",
+			wantAppId:     adminAppId,
+		},
+		{
+			name:          "admin method without an admin app",
+			method:        methodAdmin,
+			authorization: "Bearer valid",
+			wantCode:      codes.FailedPrecondition,
+			wantReason:    grpcerr.ReasonAdminAppNotConfigured,
+		},
+		{
+			name:          "method without a role",
+			method:        methodNone,
+			appId:         5,
+			authorization: "Bearer valid",
+			wantCode:      codes.PermissionDenied,
+			wantReason:    grpcerr.ReasonPermissionDenied,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := &tokenValidator{err: tt.validatorErr}
+			interceptor := middleware.NewAuth(validator, tt.adminAppId, roles)
+			ctx := context.Background()
+			if tt.authorization != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", tt.authorization))
+			}
+
+			var caller middleware.Principal
+			called := false
+			handler := func(ctx context.Context, req any) (any, error) {
+				caller, called = middleware.Caller(ctx), true
+				return nil, nil
+			}
+			_, err := interceptor.Unary(ctx, &ssov1.ListPoliciesRequest{AppId: tt.appId}, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+
+			expectStatus(t, err, tt.wantCode, tt.wantReason)
+			if called != (tt.wantCode == codes.OK) {
+				t.Errorf("Unary: handler called %t, want %t", called, tt.wantCode == codes.OK)
+			}
+			want := middleware.Principal{}
+			if tt.wantAppId != 0 {
+				want = middleware.Principal{AppId: tt.wantAppId, UserId: 7, ReadOnly: true, Token: "valid"}
+			}
+			if caller.AppId != want.AppId || caller.UserId != want.UserId || caller.ReadOnly != want.ReadOnly || caller.Token != want.Token {
+				t.Errorf("Caller: got %+v, want %+v", caller, want)
+			}
+			if validator.appId != tt.wantAppId && tt.wantCode == codes.OK {
+				t.Errorf("ValidateToken: validated for app %d, want %d", validator.appId, tt.wantAppId)
+			}
+		})
+	}
+}
+
+// recvStream hands out one request and reports the context of the call.
+type recvStream struct {
+	grpc.ServerStream
+	ctx   context.Context
+	appId int64
+}
+
+func (s *recvStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *recvStream) RecvMsg(m any) error {
+	m.(*ssov1.ListPoliciesRequest).AppId = s.appId
+	return nil
+}
+
+// TestAuthStream checks that a stream is authenticated with the app of its first request.
+func TestAuthStream(t *testing.T) {
+	validator := &tokenValidator{}
+	interceptor := middleware.NewAuth(validator, adminAppId, roles)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer valid"))
+
+	var caller middleware.Principal
+	handler := func(srv any, ss grpc.ServerStream) error {
+		if got := middleware.Caller(ss.Context()); got.UserId != 0 {
+			t.Errorf("Caller before the request: got %+v, want none", got)
+		}
+		if err := ss.RecvMsg(&ssov1.ListPoliciesRequest{}); err != nil {
+			return err
+		}
+		caller = middleware.Caller(ss.Context())
+		return nil
+	}
+	err := interceptor.Stream(nil, &recvStream{ctx: ctx, appId: 5}, &grpc.StreamServerInfo{FullMethod: methodUser}, handler)
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	if caller.AppId != 5 || caller.UserId != 7 || validator.appId != 5 {
+		t.Errorf("Caller: got %+v validated for app %d, want user 7 in app 5", caller, validator.appId)
+	}
+
+	err = interceptor.Stream(nil, &recvStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: methodUser}, handler)
+	expectStatus(t, err, codes.InvalidArgument, grpcerr.ReasonInvalidArgument)
+}
+
+func TestAuthCheck(t *testing.T) {
+	interceptor := middleware.NewAuth(&tokenValidator{}, adminAppId, roles)
+
+	services := map[string]grpc.ServiceInfo{
+		"sso.Test": {Methods: []grpc.MethodInfo{{Name: "Public"}, {Name: "User"}, {Name: "Admin"}}},
+	}
+	if err := interceptor.Check(services); err != nil {
+		t.Errorf("Check: %v", err)
+	}
+
+	services["sso.Test"] = grpc.ServiceInfo{Methods: []grpc.MethodInfo{{Name: "User"}, {Name: "None"}}}
+	if err := interceptor.Check(services); err == nil || !strings.Contains(err.Error(), methodNone) {
+		t.Errorf("Check: got %v, want %s named", err, methodNone)
+	}
+}
+
+// expectStatus checks the code and the ErrorInfo reason of err, codes.OK expects no error.
+func expectStatus(t *testing.T, err error, wantCode codes.Code, wantReason string) {
+	t.Helper()
+	st := status.Convert(err)
+	if st.Code() != wantCode {
+		t.Fatalf("status: got %s %q, want %s", st.Code(), st.Message(), wantCode)
+	}
+	if wantCode == codes.OK {
+		return
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.Reason == wantReason {
+			return
+		}
+	}
+	t.Errorf("status: got details %v, want reason %q", st.Details(), wantReason)
+}
@@ -0,0 +1,179 @@
+package middleware_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/botanikn/go_sso_service/internal/grpc/grpcerr"
+	"github.com/botanikn/go_sso_service/internal/grpc/middleware"
+	"github.com/botanikn/go_sso_service/internal/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestUnaryRequestID(t *testing.T) {
+	generated := regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+	tests := []struct {
+		name string
+		// sent is the request ID the client sends, none when empty.
+		sent string
+		// want is the ID the handler sees, a generated one when empty.
+		want string
+	}{
+		{
+			name: "client's ID is kept",
+			sent: "req-42.retry_1",
+			want: "req-42.retry_1",
+		},
+		{
+			name: "no ID",
+		},
+		{
+			name: "ID with unprintable characters",
+			sent: "req\n42",
+		},
+		{
+			name: "ID that is too long",
+			sent: strings.Repeat("a", 65),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.sent != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(middleware.RequestIDHeader, tt.sent))
+			}
+
+			var got string
+			_, err := middleware.UnaryRequestID(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req any) (any, error) {
+				got = middleware.RequestID(ctx)
+				return nil, nil
+			})
+			if err != nil {
+				t.Fatalf("UnaryRequestID: %v", err)
+			}
+			if tt.want != "" && got != tt.want || tt.want == "" && !generated.MatchString(got) {
+				t.Errorf("RequestID: got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestLogHandler checks that records logged during a request carry its ID.
+func TestLogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(middleware.NewLogHandler(slog.NewJSONHandler(&buf, nil))).With(slog.String("op", "test"))
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(middleware.RequestIDHeader, "req-42"))
+
+	_, _ = middleware.UnaryRequestID(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req any) (any, error) {
+		log.InfoContext(ctx, "inside")
+		return nil, nil
+	})
+	log.InfoContext(context.Background(), "outside")
+
+	records := decodeRecords(t, &buf)
+	if len(records) != 2 {
+		t.Fatalf("log: got %d records, want 2", len(records))
+	}
+	if records[0]["request_id"] != "req-42" {
+		t.Errorf("log: got %v inside the request, want request_id req-42", records[0])
+	}
+	if _, ok := records[1]["request_id"]; ok {
+		t.Errorf("log: got %v outside of a request, want no request_id", records[1])
+	}
+}
+
+func TestUnaryRecovery(t *testing.T) {
+	var buf bytes.Buffer
+	interceptor := middleware.UnaryRecovery(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: methodUser}, func(ctx context.Context, req any) (any, error) {
+		panic("nil map")
+	})
+
+	expectStatus(t, err, codes.Internal, grpcerr.ReasonInternal)
+	if strings.Contains(err.Error(), "nil map") {
+		t.Errorf("UnaryRecovery: got %q, want the panic kept off the status", err)
+	}
+	records := decodeRecords(t, &buf)
+	if len(records) != 1 || records[0]["panic"] != "nil map" || records[0]["method"] != methodUser {
+		t.Errorf("log: got %v, want the panic of %s", records, methodUser)
+	}
+}
+
+func TestUnaryAccessLog(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantCode  string
+		wantLevel string
+		// wantCause is the cause logged for server errors, none when empty.
+		wantCause string
+	}{
+		{
+			name:      "success",
+			wantCode:  codes.OK.String(),
+			wantLevel: slog.LevelInfo.String(),
+		},
+		{
+			name:      "request error",
+			err:       grpcerr.FromError("failed to get user", storage.ErrUserNotFound),
+			wantCode:  codes.NotFound.String(),
+			wantLevel: slog.LevelInfo.String(),
+		},
+		{
+			name:      "server error logs its cause",
+			err:       grpcerr.FromError("failed to get user", errors.New("connection reset by peer")),
+			wantCode:  codes.Internal.String(),
+			wantLevel: slog.LevelError.String(),
+			wantCause: "connection reset by peer",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			interceptor := middleware.UnaryAccessLog(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+			_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: methodUser}, func(ctx context.Context, req any) (any, error) {
+				return nil, tt.err
+			})
+			if err != tt.err {
+				t.Errorf("UnaryAccessLog: got %v, want the handler's error %v", err, tt.err)
+			}
+
+			records := decodeRecords(t, &buf)
+			if len(records) != 1 {
+				t.Fatalf("log: got %d records, want 1", len(records))
+			}
+			record := records[0]
+			if record["method"] != methodUser || record["code"] != tt.wantCode || record["level"] != tt.wantLevel {
+				t.Errorf("log: got %v, want %s at %s", record, tt.wantCode, tt.wantLevel)
+			}
+			if cause, _ := record["cause"].(string); cause != tt.wantCause {
+				t.Errorf("log: got cause %q, want %q", cause, tt.wantCause)
+			}
+		})
+	}
+}
+
+func decodeRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	decoder := json.NewDecoder(buf)
+	for decoder.More() {
+		var record map[string]any
+		if err := decoder.Decode(&record); err != nil {
+			t.Fatalf("decode log record: %v", err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/botanikn/go_sso_service/internal/storage"
+	"google.golang.org/grpc"
+)
+
+// UnarySession gives every call a storage session, reads that follow a write made by
+// the call see it even when the storage reads from replicas.
+func UnarySession(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	return handler(storage.WithSession(ctx), req)
+}
+
+func StreamSession(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &serverStream{ServerStream: ss, ctx: storage.WithSession(ss.Context())})
+}
@@ -0,0 +1,209 @@
+package policies
+
+import (
+	"context"
+	"strings"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/grpc/grpcerr"
+	"github.com/botanikn/go_sso_service/internal/grpc/middleware"
+	"github.com/botanikn/go_sso_service/internal/services/authz"
+	ssov1 "github.com/botanikn/protos/gen/go/sso"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	emptyInteger int64 = 0
+)
+
+type PoliciesService interface {
+	ListPolicies(ctx context.Context, actorId int64, appId int64) ([]models.Policy, error)
+	CreatePolicy(ctx context.Context, actorId int64, policy models.Policy) (models.Policy, error)
+	UpdatePolicy(ctx context.Context, actorId int64, policy models.Policy) (models.Policy, error)
+	DeletePolicy(ctx context.Context, actorId int64, appId int64, policyId int64) error
+}
+
+type Authorizer interface {
+	Authorize(ctx context.Context, req authz.Request) (authz.Decision, error)
+}
+
+type serverAPI struct {
+	ssov1.UnimplementedPolicyAdminServer
+	policies PoliciesService
+	authz    Authorizer
+}
+
+// Roles are the roles the methods of the PolicyAdmin service require.
+var Roles = map[string]middleware.Role{
+	ssov1.PolicyAdmin_ListPolicies_FullMethodName: middleware.RoleAdmin,
+	ssov1.PolicyAdmin_CreatePolicy_FullMethodName: middleware.RoleAdmin,
+	ssov1.PolicyAdmin_UpdatePolicy_FullMethodName: middleware.RoleAdmin,
+	ssov1.PolicyAdmin_DeletePolicy_FullMethodName: middleware.RoleAdmin,
+}
+
+// Register registers the PolicyAdmin service. Callers are authorized by the
+// policies of the admin app, and the service only serves super-admins.
+func Register(gRPC *grpc.Server, policies PoliciesService, authz Authorizer) {
+	ssov1.RegisterPolicyAdminServer(gRPC, &serverAPI{
+		policies: policies,
+		authz:    authz,
+	})
+}
+
+func (s *serverAPI) ListPolicies(
+	ctx context.Context,
+	req *ssov1.ListPoliciesRequest,
+) (*ssov1.ListPoliciesResponse, error) {
+	if req.GetAppId() == emptyInteger {
+		return nil, grpcerr.FieldViolation("app_id", "app_id is required")
+	}
+
+	actorId, err := s.authorize(ctx, authz.ActionListPolicies)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := s.policies.ListPolicies(ctx, actorId, req.AppId)
+	if err != nil {
+		return nil, grpcerr.FromError("failed to list policies", err)
+	}
+
+	res := &ssov1.ListPoliciesResponse{}
+	for _, policy := range list {
+		res.Policies = append(res.Policies, policyToProto(policy))
+	}
+	return res, nil
+}
+
+func (s *serverAPI) CreatePolicy(
+	ctx context.Context,
+	req *ssov1.CreatePolicyRequest,
+) (*ssov1.CreatePolicyResponse, error) {
+	if err := validatePolicy(req.GetPolicy()); err != nil {
+		return nil, err
+	}
+
+	actorId, err := s.authorize(ctx, authz.ActionCreatePolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := s.policies.CreatePolicy(ctx, actorId, policyFromProto(req.Policy))
+	if err != nil {
+		return nil, grpcerr.FromError("failed to create policy", err)
+	}
+
+	return &ssov1.CreatePolicyResponse{
+		Policy: policyToProto(policy),
+	}, nil
+}
+
+func (s *serverAPI) UpdatePolicy(
+	ctx context.Context,
+	req *ssov1.UpdatePolicyRequest,
+) (*ssov1.UpdatePolicyResponse, error) {
+	if req.GetPolicy().GetId() == emptyInteger {
+		return nil, grpcerr.FieldViolation("policy.id", "policy.id is required")
+	}
+	if err := validatePolicy(req.GetPolicy()); err != nil {
+		return nil, err
+	}
+
+	actorId, err := s.authorize(ctx, authz.ActionUpdatePolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := s.policies.UpdatePolicy(ctx, actorId, policyFromProto(req.Policy))
+	if err != nil {
+		return nil, grpcerr.FromError("failed to update policy", err)
+	}
+
+	return &ssov1.UpdatePolicyResponse{
+		Policy: policyToProto(policy),
+	}, nil
+}
+
+func (s *serverAPI) DeletePolicy(
+	ctx context.Context,
+	req *ssov1.DeletePolicyRequest,
+) (*ssov1.DeletePolicyResponse, error) {
+	if req.GetAppId() == emptyInteger {
+		return nil, grpcerr.FieldViolation("app_id", "app_id is required")
+	}
+	if req.GetPolicyId() == emptyInteger {
+		return nil, grpcerr.FieldViolation("policy_id", "policy_id is required")
+	}
+
+	actorId, err := s.authorize(ctx, authz.ActionDeletePolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.policies.DeletePolicy(ctx, actorId, req.AppId, req.PolicyId); err != nil {
+		return nil, grpcerr.FromError("failed to delete policy", err)
+	}
+
+	return &ssov1.DeletePolicyResponse{
+		Success: true,
+	}, nil
+}
+
+// authorize checks the action against the admin app's policies and returns the caller's user id.
+func (s *serverAPI) authorize(ctx context.Context, action string) (int64, error) {
+	caller := middleware.Caller(ctx)
+
+	decision, err := s.authz.Authorize(ctx, authz.Request{
+		AppId:    caller.AppId,
+		UserId:   caller.UserId,
+		Claims:   caller.Claims,
+		ReadOnly: caller.ReadOnly,
+		Action:   action,
+	})
+	if err != nil {
+		return 0, grpcerr.FromError("failed to authorize", err)
+	}
+	if !decision.Allowed {
+		return 0, grpcerr.New(codes.PermissionDenied, grpcerr.ReasonPermissionDenied, "insufficient permissions to manage policies")
+	}
+	return caller.UserId, nil
+}
+
+func policyToProto(policy models.Policy) *ssov1.Policy {
+	return &ssov1.Policy{
+		Id:         policy.ID,
+		AppId:      policy.AppId,
+		Name:       policy.Name,
+		Action:     policy.Action,
+		Effect:     policy.Effect,
+		Expression: policy.Expression,
+	}
+}
+
+func policyFromProto(policy *ssov1.Policy) models.Policy {
+	return models.Policy{
+		ID:         policy.Id,
+		AppId:      policy.AppId,
+		Name:       policy.Name,
+		Action:     policy.Action,
+		Effect:     policy.Effect,
+		Expression: policy.Expression,
+	}
+}
+
+func validatePolicy(policy *ssov1.Policy) error {
+	if policy.GetAppId() == emptyInteger {
+		return grpcerr.FieldViolation("policy.app_id", "policy.app_id is required")
+	}
+	if strings.TrimSpace(policy.GetName()) == "" {
+		return grpcerr.FieldViolation("policy.name", "policy.name is required")
+	}
+	if strings.TrimSpace(policy.GetAction()) == "" {
+		return grpcerr.FieldViolation("policy.action", "policy.action is required")
+	}
+	if strings.TrimSpace(policy.GetExpression()) == "" {
+		return grpcerr.FieldViolation("policy.expression", "policy.expression is required")
+	}
+	return nil
+}
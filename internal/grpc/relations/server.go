@@ -0,0 +1,384 @@
+package relations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/grpc/grpcerr"
+	"github.com/botanikn/go_sso_service/internal/grpc/middleware"
+	"github.com/botanikn/go_sso_service/internal/services/authz"
+	"github.com/botanikn/go_sso_service/internal/services/relations"
+	ssov1 "github.com/botanikn/protos/gen/go/sso"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	emptyInteger int64 = 0
+)
+
+type RelationsService interface {
+	Check(ctx context.Context,
+		appId int64,
+		object models.Subject,
+		subject models.Subject,
+		consistencyToken string,
+	) (bool, string, error)
+	Expand(ctx context.Context,
+		appId int64,
+		object models.Subject,
+		consistencyToken string,
+	) (relations.Tree, string, error)
+	Write(ctx context.Context,
+		appId int64,
+		inserts []models.RelationTuple,
+		deletes []models.RelationTuple,
+	) (string, error)
+	Read(ctx context.Context,
+		appId int64,
+		filter models.RelationTupleFilter,
+		consistencyToken string,
+	) ([]models.RelationTuple, string, error)
+	WriteNamespace(ctx context.Context,
+		appId int64,
+		name string,
+		config models.NamespaceConfig,
+	) error
+}
+
+type Authorizer interface {
+	Authorize(ctx context.Context, req authz.Request) (authz.Decision, error)
+}
+
+type serverAPI struct {
+	ssov1.UnimplementedRelationsServer
+	relations RelationsService
+	authz     Authorizer
+}
+
+// Roles are the roles the methods of the Relations service require.
+var Roles = map[string]middleware.Role{
+	ssov1.Relations_Check_FullMethodName:          middleware.RoleUser,
+	ssov1.Relations_Expand_FullMethodName:         middleware.RoleUser,
+	ssov1.Relations_Write_FullMethodName:          middleware.RoleUser,
+	ssov1.Relations_Read_FullMethodName:           middleware.RoleUser,
+	ssov1.Relations_WriteNamespace_FullMethodName: middleware.RoleUser,
+}
+
+func Register(gRPC *grpc.Server, relations RelationsService, authz Authorizer) {
+	ssov1.RegisterRelationsServer(gRPC, &serverAPI{relations: relations, authz: authz})
+}
+
+func (s *serverAPI) Check(
+	ctx context.Context,
+	req *ssov1.CheckRequest,
+) (*ssov1.CheckResponse, error) {
+	if err := validateCheckRequest(req); err != nil {
+		return nil, err
+	}
+
+	if err := s.authorize(ctx, req.AppId, authz.ActionReadRelations); err != nil {
+		return nil, err
+	}
+
+	object := models.Subject{
+		Namespace: req.Namespace,
+		ObjectId:  req.ObjectId,
+		Relation:  req.Relation,
+	}
+	allowed, token, err := s.relations.Check(ctx, req.AppId, object, subjectFromProto(req.Subject), req.ConsistencyToken)
+	if err != nil {
+		return nil, grpcerr.FromError("failed to check relation", err)
+	}
+
+	return &ssov1.CheckResponse{
+		Allowed:          allowed,
+		ConsistencyToken: token,
+	}, nil
+}
+
+func (s *serverAPI) Expand(
+	ctx context.Context,
+	req *ssov1.ExpandRequest,
+) (*ssov1.ExpandResponse, error) {
+	if err := validateExpandRequest(req); err != nil {
+		return nil, err
+	}
+
+	if err := s.authorize(ctx, req.AppId, authz.ActionReadRelations); err != nil {
+		return nil, err
+	}
+
+	object := models.Subject{
+		Namespace: req.Namespace,
+		ObjectId:  req.ObjectId,
+		Relation:  req.Relation,
+	}
+	tree, token, err := s.relations.Expand(ctx, req.AppId, object, req.ConsistencyToken)
+	if err != nil {
+		return nil, grpcerr.FromError("failed to expand relation", err)
+	}
+
+	return &ssov1.ExpandResponse{
+		Tree:             treeToProto(tree),
+		ConsistencyToken: token,
+	}, nil
+}
+
+func (s *serverAPI) Write(
+	ctx context.Context,
+	req *ssov1.WriteRequest,
+) (*ssov1.WriteResponse, error) {
+	if err := validateWriteRequest(req); err != nil {
+		return nil, err
+	}
+
+	if err := s.authorize(ctx, req.AppId, authz.ActionWriteRelations); err != nil {
+		return nil, err
+	}
+
+	var inserts, deletes []models.RelationTuple
+	for _, update := range req.Updates {
+		tuple := tupleFromProto(update.Tuple)
+		if update.Operation == ssov1.RelationTupleUpdate_OPERATION_DELETE {
+			deletes = append(deletes, tuple)
+		} else {
+			inserts = append(inserts, tuple)
+		}
+	}
+
+	token, err := s.relations.Write(ctx, req.AppId, inserts, deletes)
+	if err != nil {
+		return nil, grpcerr.FromError("failed to write relation tuples", err)
+	}
+
+	return &ssov1.WriteResponse{
+		ConsistencyToken: token,
+	}, nil
+}
+
+func (s *serverAPI) Read(
+	ctx context.Context,
+	req *ssov1.ReadRequest,
+) (*ssov1.ReadResponse, error) {
+	if err := validateReadRequest(req); err != nil {
+		return nil, err
+	}
+
+	if err := s.authorize(ctx, req.AppId, authz.ActionReadRelations); err != nil {
+		return nil, err
+	}
+
+	filter := models.RelationTupleFilter{
+		Namespace: req.Namespace,
+		ObjectId:  req.ObjectId,
+		Relation:  req.Relation,
+	}
+	if req.Subject != nil {
+		subject := subjectFromProto(req.Subject)
+		filter.Subject = &subject
+	}
+
+	tuples, token, err := s.relations.Read(ctx, req.AppId, filter, req.ConsistencyToken)
+	if err != nil {
+		return nil, grpcerr.FromError("failed to read relation tuples", err)
+	}
+
+	res := &ssov1.ReadResponse{
+		ConsistencyToken: token,
+	}
+	for _, tuple := range tuples {
+		res.Tuples = append(res.Tuples, tupleToProto(tuple))
+	}
+	return res, nil
+}
+
+func (s *serverAPI) WriteNamespace(
+	ctx context.Context,
+	req *ssov1.WriteNamespaceRequest,
+) (*ssov1.WriteNamespaceResponse, error) {
+	if err := validateWriteNamespaceRequest(req); err != nil {
+		return nil, err
+	}
+
+	if err := s.authorize(ctx, req.AppId, authz.ActionWriteNamespaces); err != nil {
+		return nil, err
+	}
+
+	config, err := namespaceConfigFromProto(req)
+	if err != nil {
+		return nil, grpcerr.FieldViolation("config", err.Error())
+	}
+
+	if err := s.relations.WriteNamespace(ctx, req.AppId, req.Name, config); err != nil {
+		return nil, grpcerr.FromError("failed to write namespace", err)
+	}
+
+	return &ssov1.WriteNamespaceResponse{
+		Success: true,
+	}, nil
+}
+
+// authorize checks the action against app policies.
+func (s *serverAPI) authorize(ctx context.Context, appId int64, action string) error {
+	caller := middleware.Caller(ctx)
+
+	decision, err := s.authz.Authorize(ctx, authz.Request{
+		AppId:    appId,
+		UserId:   caller.UserId,
+		Claims:   caller.Claims,
+		ReadOnly: caller.ReadOnly,
+		Action:   action,
+	})
+	if err != nil {
+		return grpcerr.FromError("failed to authorize", err)
+	}
+	if !decision.Allowed {
+		return grpcerr.New(codes.PermissionDenied, grpcerr.ReasonPermissionDenied, "insufficient permissions to access relations")
+	}
+	return nil
+}
+
+func subjectFromProto(subject *ssov1.Subject) models.Subject {
+	return models.Subject{
+		Namespace: subject.GetNamespace(),
+		ObjectId:  subject.GetObjectId(),
+		Relation:  subject.GetRelation(),
+	}
+}
+
+func subjectToProto(subject models.Subject) *ssov1.Subject {
+	return &ssov1.Subject{
+		Namespace: subject.Namespace,
+		ObjectId:  subject.ObjectId,
+		Relation:  subject.Relation,
+	}
+}
+
+func tupleFromProto(tuple *ssov1.RelationTuple) models.RelationTuple {
+	return models.RelationTuple{
+		Namespace: tuple.GetNamespace(),
+		ObjectId:  tuple.GetObjectId(),
+		Relation:  tuple.GetRelation(),
+		Subject:   subjectFromProto(tuple.GetSubject()),
+	}
+}
+
+func tupleToProto(tuple models.RelationTuple) *ssov1.RelationTuple {
+	return &ssov1.RelationTuple{
+		Namespace: tuple.Namespace,
+		ObjectId:  tuple.ObjectId,
+		Relation:  tuple.Relation,
+		Subject:   subjectToProto(tuple.Subject),
+	}
+}
+
+// namespaceConfigFromProto decodes the config the way it is stored, fields the config
+// doesn't have are refused rather than dropped.
+func namespaceConfigFromProto(req *ssov1.WriteNamespaceRequest) (models.NamespaceConfig, error) {
+	raw, err := json.Marshal(req.GetConfig().AsMap())
+	if err != nil {
+		return models.NamespaceConfig{}, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	var config models.NamespaceConfig
+	if err := decoder.Decode(&config); err != nil {
+		return models.NamespaceConfig{}, err
+	}
+	return config, nil
+}
+
+func treeToProto(tree relations.Tree) *ssov1.SubjectTree {
+	res := &ssov1.SubjectTree{
+		Operation: tree.Operation,
+		Namespace: tree.Namespace,
+		ObjectId:  tree.ObjectId,
+		Relation:  tree.Relation,
+	}
+	for _, subject := range tree.Subjects {
+		res.Subjects = append(res.Subjects, subjectToProto(subject))
+	}
+	for _, child := range tree.Children {
+		res.Children = append(res.Children, treeToProto(child))
+	}
+	return res
+}
+
+func validateObject(namespace string, objectId string, relation string) error {
+	if namespace == "" {
+		return grpcerr.FieldViolation("namespace", "namespace is required")
+	}
+	if objectId == "" {
+		return grpcerr.FieldViolation("object_id", "object_id is required")
+	}
+	if relation == "" {
+		return grpcerr.FieldViolation("relation", "relation is required")
+	}
+	return nil
+}
+
+func validateCheckRequest(req *ssov1.CheckRequest) error {
+	if req.GetAppId() == emptyInteger {
+		return grpcerr.FieldViolation("app_id", "app_id is required")
+	}
+	if err := validateObject(req.GetNamespace(), req.GetObjectId(), req.GetRelation()); err != nil {
+		return err
+	}
+	if req.GetSubject().GetNamespace() == "" || req.GetSubject().GetObjectId() == "" {
+		return grpcerr.FieldViolation("subject", "subject is required")
+	}
+	return nil
+}
+
+func validateExpandRequest(req *ssov1.ExpandRequest) error {
+	if req.GetAppId() == emptyInteger {
+		return grpcerr.FieldViolation("app_id", "app_id is required")
+	}
+	return validateObject(req.GetNamespace(), req.GetObjectId(), req.GetRelation())
+}
+
+func validateWriteRequest(req *ssov1.WriteRequest) error {
+	if req.GetAppId() == emptyInteger {
+		return grpcerr.FieldViolation("app_id", "app_id is required")
+	}
+	if len(req.GetUpdates()) == 0 {
+		return grpcerr.FieldViolation("updates", "updates are required")
+	}
+	for i, update := range req.GetUpdates() {
+		if update.GetOperation() == ssov1.RelationTupleUpdate_OPERATION_UNSPECIFIED {
+			return grpcerr.FieldViolation(fmt.Sprintf("updates[%d].operation", i), "update operation is required")
+		}
+		if update.GetTuple() == nil {
+			return grpcerr.FieldViolation(fmt.Sprintf("updates[%d].tuple", i), "update tuple is required")
+		}
+	}
+	return nil
+}
+
+func validateReadRequest(req *ssov1.ReadRequest) error {
+	if req.GetAppId() == emptyInteger {
+		return grpcerr.FieldViolation("app_id", "app_id is required")
+	}
+	if req.GetNamespace() == "" {
+		return grpcerr.FieldViolation("namespace", "namespace is required")
+	}
+	return nil
+}
+
+func validateWriteNamespaceRequest(req *ssov1.WriteNamespaceRequest) error {
+	if req.GetAppId() == emptyInteger {
+		return grpcerr.FieldViolation("app_id", "app_id is required")
+	}
+	if req.GetName() == "" {
+		return grpcerr.FieldViolation("name", "name is required")
+	}
+	if req.GetConfig() == nil {
+		return grpcerr.FieldViolation("config", "config is required")
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+package users
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/grpc/grpcerr"
+	"github.com/botanikn/go_sso_service/internal/grpc/middleware"
+	"github.com/botanikn/go_sso_service/internal/services/authz"
+	ssov1 "github.com/botanikn/protos/gen/go/sso"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	emptyInteger int64 = 0
+)
+
+type UsersService interface {
+	ListUsers(ctx context.Context, filter models.UserFilter, pageSize int, pageToken string) ([]models.User, string, error)
+	User(ctx context.Context, userId int64) (models.User, error)
+	UpdateUser(ctx context.Context, actorId int64, userId int64, email string, username string) (models.User, error)
+	SetDisabled(ctx context.Context, actorId int64, userId int64, disabled bool, reason string) error
+	DeleteUser(ctx context.Context, actorId int64, userId int64) error
+	RevokeSessions(ctx context.Context, actorId int64, userId int64) (time.Time, error)
+}
+
+type Authorizer interface {
+	Authorize(ctx context.Context, req authz.Request) (authz.Decision, error)
+}
+
+type serverAPI struct {
+	ssov1.UnimplementedUserAdminServer
+	users UsersService
+	authz Authorizer
+}
+
+// Roles are the roles the methods of the UserAdmin service require.
+var Roles = map[string]middleware.Role{
+	ssov1.UserAdmin_ListUsers_FullMethodName:      middleware.RoleAdmin,
+	ssov1.UserAdmin_GetUser_FullMethodName:        middleware.RoleAdmin,
+	ssov1.UserAdmin_UpdateUser_FullMethodName:     middleware.RoleAdmin,
+	ssov1.UserAdmin_DisableUser_FullMethodName:    middleware.RoleAdmin,
+	ssov1.UserAdmin_EnableUser_FullMethodName:     middleware.RoleAdmin,
+	ssov1.UserAdmin_DeleteUser_FullMethodName:     middleware.RoleAdmin,
+	ssov1.UserAdmin_RevokeSessions_FullMethodName: middleware.RoleAdmin,
+}
+
+// Register registers the UserAdmin service. Callers are authorized by the
+// policies of the admin app.
+func Register(gRPC *grpc.Server, users UsersService, authz Authorizer) {
+	ssov1.RegisterUserAdminServer(gRPC, &serverAPI{
+		users: users,
+		authz: authz,
+	})
+}
+
+func (s *serverAPI) ListUsers(
+	ctx context.Context,
+	req *ssov1.ListUsersRequest,
+) (*ssov1.ListUsersResponse, error) {
+	if req.GetPageSize() < 0 {
+		return nil, grpcerr.FieldViolation("page_size", "page_size must not be negative")
+	}
+
+	if _, err := s.authorize(ctx, authz.ActionListUsers); err != nil {
+		return nil, err
+	}
+
+	filter := models.UserFilter{
+		EmailPrefix: req.EmailPrefix,
+		Username:    req.Username,
+		AppId:       req.AppId,
+		Status:      req.Status,
+	}
+	list, nextPageToken, err := s.users.ListUsers(ctx, filter, int(req.PageSize), req.PageToken)
+	if err != nil {
+		return nil, grpcerr.FromError("failed to list users", err)
+	}
+
+	res := &ssov1.ListUsersResponse{
+		NextPageToken: nextPageToken,
+	}
+	for _, user := range list {
+		res.Users = append(res.Users, userToProto(user))
+	}
+	return res, nil
+}
+
+func (s *serverAPI) GetUser(
+	ctx context.Context,
+	req *ssov1.GetUserRequest,
+) (*ssov1.GetUserResponse, error) {
+	if req.GetUserId() == emptyInteger {
+		return nil, grpcerr.FieldViolation("user_id", "user_id is required")
+	}
+
+	if _, err := s.authorize(ctx, authz.ActionGetUser); err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.User(ctx, req.UserId)
+	if err != nil {
+		return nil, grpcerr.FromError("failed to get user", err)
+	}
+
+	return &ssov1.GetUserResponse{
+		User: userToProto(user),
+	}, nil
+}
+
+func (s *serverAPI) UpdateUser(
+	ctx context.Context,
+	req *ssov1.UpdateUserRequest,
+) (*ssov1.UpdateUserResponse, error) {
+	if err := validateUpdateUserRequest(req); err != nil {
+		return nil, err
+	}
+
+	actorId, err := s.authorize(ctx, authz.ActionUpdateUser)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.UpdateUser(ctx, actorId, req.UserId, req.Email, req.Username)
+	if err != nil {
+		return nil, grpcerr.FromError("failed to update user", err)
+	}
+
+	return &ssov1.UpdateUserResponse{
+		User: userToProto(user),
+	}, nil
+}
+
+func (s *serverAPI) DisableUser(
+	ctx context.Context,
+	req *ssov1.DisableUserRequest,
+) (*ssov1.DisableUserResponse, error) {
+	if req.GetUserId() == emptyInteger {
+		return nil, grpcerr.FieldViolation("user_id", "user_id is required")
+	}
+
+	actorId, err := s.authorize(ctx, authz.ActionDisableUser)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.users.SetDisabled(ctx, actorId, req.UserId, true, req.Reason); err != nil {
+		return nil, grpcerr.FromError("failed to disable user", err)
+	}
+
+	return &ssov1.DisableUserResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *serverAPI) EnableUser(
+	ctx context.Context,
+	req *ssov1.EnableUserRequest,
+) (*ssov1.EnableUserResponse, error) {
+	if req.GetUserId() == emptyInteger {
+		return nil, grpcerr.FieldViolation("user_id", "user_id is required")
+	}
+
+	actorId, err := s.authorize(ctx, authz.ActionEnableUser)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.users.SetDisabled(ctx, actorId, req.UserId, false, ""); err != nil {
+		return nil, grpcerr.FromError("failed to enable user", err)
+	}
+
+	return &ssov1.EnableUserResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *serverAPI) DeleteUser(
+	ctx context.Context,
+	req *ssov1.DeleteUserRequest,
+) (*ssov1.DeleteUserResponse, error) {
+	if req.GetUserId() == emptyInteger {
+		return nil, grpcerr.FieldViolation("user_id", "user_id is required")
+	}
+
+	actorId, err := s.authorize(ctx, authz.ActionDeleteUser)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.users.DeleteUser(ctx, actorId, req.UserId); err != nil {
+		return nil, grpcerr.FromError("failed to delete user", err)
+	}
+
+	return &ssov1.DeleteUserResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *serverAPI) RevokeSessions(
+	ctx context.Context,
+	req *ssov1.RevokeSessionsRequest,
+) (*ssov1.RevokeSessionsResponse, error) {
+	if req.GetUserId() == emptyInteger {
+		return nil, grpcerr.FieldViolation("user_id", "user_id is required")
+	}
+
+	actorId, err := s.authorize(ctx, authz.ActionRevokeSessions)
+	if err != nil {
+		return nil, err
+	}
+
+	revokedAt, err := s.users.RevokeSessions(ctx, actorId, req.UserId)
+	if err != nil {
+		return nil, grpcerr.FromError("failed to revoke sessions", err)
+	}
+
+	return &ssov1.RevokeSessionsResponse{
+		RevokedAt: timestamppb.New(revokedAt),
+	}, nil
+}
+
+// authorize checks the action against the admin app's policies and returns the caller's user id.
+func (s *serverAPI) authorize(ctx context.Context, action string) (int64, error) {
+	caller := middleware.Caller(ctx)
+
+	decision, err := s.authz.Authorize(ctx, authz.Request{
+		AppId:    caller.AppId,
+		UserId:   caller.UserId,
+		Claims:   caller.Claims,
+		ReadOnly: caller.ReadOnly,
+		Action:   action,
+	})
+	if err != nil {
+		return 0, grpcerr.FromError("failed to authorize", err)
+	}
+	if !decision.Allowed {
+		return 0, grpcerr.New(codes.PermissionDenied, grpcerr.ReasonPermissionDenied, "insufficient permissions to manage users")
+	}
+	return caller.UserId, nil
+}
+
+func userToProto(user models.User) *ssov1.UserInfo {
+	// IDs come from the database and are always numeric.
+	id, _ := strconv.ParseInt(user.ID, 10, 64)
+	return &ssov1.UserInfo{
+		Id:       id,
+		Email:    user.Email,
+		Username: user.Username,
+		Status:   user.Status,
+	}
+}
+
+func validateUpdateUserRequest(req *ssov1.UpdateUserRequest) error {
+	if req.GetUserId() == emptyInteger {
+		return grpcerr.FieldViolation("user_id", "user_id is required")
+	}
+	if req.GetEmail() == "" && req.GetUsername() == "" {
+		return grpcerr.FieldViolation("email", "email or username is required")
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+)
+
+// Mailer sends the service's emails over SMTP.
+type Mailer struct {
+	addr      string
+	auth      smtp.Auth
+	from      string
+	acceptURL string
+}
+
+// New returns a Mailer for the SMTP server at host:port. Without a username the
+// server is used unauthenticated. acceptURL is the page where invitees accept invitations.
+func New(host string, port int, username string, password string, from string, acceptURL string) *Mailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &Mailer{
+		addr:      net.JoinHostPort(host, strconv.Itoa(port)),
+		auth:      auth,
+		from:      from,
+		acceptURL: acceptURL,
+	}
+}
+
+// SendInvitation emails the invitation token to the invitee.
+func (m *Mailer) SendInvitation(ctx context.Context, invitation models.Invitation, token string) error {
+	const op = "mail.SendInvitation"
+
+	link, err := m.acceptLink(token)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	body := fmt.Sprintf(
+		"You have been invited to join an application as %s.\r\n\r\n"+
+			"Accept the invitation here:\r\n%s\r\n\r\n"+
+			"The invitation expires on %s.\r\n",
+		invitation.Role,
+		link,
+		invitation.ExpiresAt.UTC().Format(time.RFC1123),
+	)
+
+	if err := m.send(ctx, invitation.Email, "You have been invited", body); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (m *Mailer) acceptLink(token string) (string, error) {
+	if m.acceptURL == "" {
+		return token, nil
+	}
+	u, err := url.Parse(m.acceptURL)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// send delivers a plain text email. net/smtp takes no context, so ctx only
+// stops the message from being sent when it is already done.
+func (m *Mailer) send(ctx context.Context, to string, subject string, body string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if strings.ContainsAny(to, "\r\n") || strings.ContainsAny(subject, "\r\n") {
+		return fmt.Errorf("invalid header value")
+	}
+
+	msg := "From: " + m.from + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" +
+		body
+
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{to}, []byte(msg))
+}
@@ -0,0 +1,396 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/storage"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AuditActionDeletionRequested = "user.deletion_requested"
+	AuditActionRestored          = "user.restored"
+	AuditActionPurged            = "user.purged"
+	AuditActionDataExported      = "user.data_exported"
+
+	// auditExportBatch is how many audit events are read per query while exporting.
+	auditExportBatch = 500
+)
+
+type Account struct {
+	log          *slog.Logger
+	userProvider UserProvider
+	accountStore AccountStore
+	auditStore   AuditStore
+	gracePeriod  time.Duration
+}
+
+type UserProvider interface {
+	User(ctx context.Context, email string) (models.User, error)
+	UserById(ctx context.Context, userId int64) (models.User, error)
+}
+
+type AccountStore interface {
+	MarkUserDeleted(ctx context.Context, userId int64) (time.Time, error)
+	RestoreUser(ctx context.Context, userId int64) error
+	PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) ([]models.PurgedUser, error)
+	UserPermissions(ctx context.Context, userId int64) ([]models.PermissionGrant, error)
+	SoleOwnedAppIds(ctx context.Context, userId int64) ([]int64, error)
+}
+
+type AuditStore interface {
+	SaveAuditEvent(ctx context.Context, event models.AuditEvent) error
+	AuditEvents(ctx context.Context, filter models.AuditFilter, afterId int64, limit int) ([]models.AuditEvent, error)
+}
+
+var (
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrSuperAdmin         = errors.New("super-admin accounts can't be deleted")
+	ErrSoleOwner          = errors.New("user is the only owner of an app")
+	ErrNotDeleted         = errors.New("account is not scheduled for deletion")
+	ErrAlreadyDeleted     = errors.New("account is already scheduled for deletion")
+)
+
+// New returns a new instance of Account service. Deleted accounts can be restored
+// for gracePeriod, after that they are purged.
+func New(
+	log *slog.Logger,
+	userProvider UserProvider,
+	accountStore AccountStore,
+	auditStore AuditStore,
+	gracePeriod time.Duration,
+) *Account {
+	return &Account{
+		log:          log,
+		userProvider: userProvider,
+		accountStore: accountStore,
+		auditStore:   auditStore,
+		gracePeriod:  gracePeriod,
+	}
+}
+
+// DeleteAccount soft-deletes the user after checking their password and ends all of their sessions.
+// It returns the time after which the account is purged.
+func (a *Account) DeleteAccount(ctx context.Context, userId int64, password string) (time.Time, error) {
+	const op = "account.DeleteAccount"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("userId", userId),
+	)
+
+	log.InfoContext(ctx, "deleting account")
+
+	user, err := a.userProvider.UserById(ctx, userId)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return time.Time{}, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+		log.ErrorContext(ctx, "failed to get user", slog.String("error", err.Error()))
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := bcrypt.CompareHashAndPassword(user.PassHash, []byte(password)); err != nil {
+		log.InfoContext(ctx, "invalid credentials for account deletion")
+		return time.Time{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	}
+	if user.SuperAdmin {
+		return time.Time{}, fmt.Errorf("%s: %w", op, ErrSuperAdmin)
+	}
+
+	// Purging the only owner would leave the app without anyone able to manage it.
+	appIds, err := a.accountStore.SoleOwnedAppIds(ctx, userId)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get owned apps", slog.String("error", err.Error()))
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(appIds) > 0 {
+		return time.Time{}, fmt.Errorf("%s: %w: transfer ownership of apps %v first", op, ErrSoleOwner, appIds)
+	}
+
+	deletedAt, err := a.accountStore.MarkUserDeleted(ctx, userId)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return time.Time{}, fmt.Errorf("%s: %w", op, ErrAlreadyDeleted)
+		}
+		log.ErrorContext(ctx, "failed to delete account", slog.String("error", err.Error()))
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+	purgeAfter := deletedAt.Add(a.gracePeriod)
+
+	a.audit(ctx, log, models.AuditEvent{
+		ActorId: userId,
+		UserId:  userId,
+		Action:  AuditActionDeletionRequested,
+		Details: map[string]any{
+			"purge_after": purgeAfter,
+		},
+	})
+
+	log.InfoContext(ctx, "account scheduled for deletion", slog.Time("purgeAfter", purgeAfter))
+	return purgeAfter, nil
+}
+
+// RestoreAccount cancels the deletion of the account with email during the grace period.
+// Sessions ended by the deletion stay ended.
+func (a *Account) RestoreAccount(ctx context.Context, email string, password string) error {
+	const op = "account.RestoreAccount"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("email", email),
+	)
+
+	log.InfoContext(ctx, "restoring account")
+
+	user, err := a.userProvider.User(ctx, email)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		}
+		log.ErrorContext(ctx, "failed to get user", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := bcrypt.CompareHashAndPassword(user.PassHash, []byte(password)); err != nil {
+		log.InfoContext(ctx, "invalid credentials for account restore")
+		return fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	}
+	// Accounts past the grace period are only waiting for the next purge run.
+	if user.DeletedAt.IsZero() || time.Since(user.DeletedAt) >= a.gracePeriod {
+		return fmt.Errorf("%s: %w", op, ErrNotDeleted)
+	}
+
+	userId, err := strconv.ParseInt(user.ID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := a.accountStore.RestoreUser(ctx, userId); err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrNotDeleted)
+		}
+		log.ErrorContext(ctx, "failed to restore account", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	a.audit(ctx, log, models.AuditEvent{
+		ActorId: userId,
+		UserId:  userId,
+		Action:  AuditActionRestored,
+	})
+
+	log.InfoContext(ctx, "account restored", slog.Int64("userId", userId))
+	return nil
+}
+
+// Export is the archive returned by ExportData.
+type Export struct {
+	ExportedAt  time.Time          `json:"exported_at"`
+	Profile     ExportProfile      `json:"profile"`
+	Memberships []ExportMembership `json:"memberships"`
+	Sessions    ExportSessions     `json:"sessions"`
+	AuditEvents []ExportEvent      `json:"audit_events"`
+}
+
+type ExportProfile struct {
+	ID         string         `json:"id"`
+	Email      string         `json:"email"`
+	Username   string         `json:"username"`
+	Status     string         `json:"status"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+	DeletedAt  *time.Time     `json:"deleted_at,omitempty"`
+}
+
+type ExportMembership struct {
+	AppId         int64      `json:"app_id"`
+	Permission    string     `json:"permission"`
+	ValidFrom     *time.Time `json:"valid_from,omitempty"`
+	ValidUntil    *time.Time `json:"valid_until,omitempty"`
+	GrantedBy     int64      `json:"granted_by,omitempty"`
+	Justification string     `json:"justification,omitempty"`
+}
+
+// ExportSessions describes the user's sessions. Tokens are stateless and not stored,
+// so the only session state kept is the time up to which issued tokens are revoked.
+type ExportSessions struct {
+	TokensRevokedAt *time.Time `json:"tokens_revoked_at,omitempty"`
+}
+
+type ExportEvent struct {
+	ID        int64          `json:"id"`
+	AppId     int64          `json:"app_id,omitempty"`
+	ActorId   int64          `json:"actor_id,omitempty"`
+	UserId    int64          `json:"user_id,omitempty"`
+	Action    string         `json:"action"`
+	Details   map[string]any `json:"details,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// ExportData returns a JSON archive of the user's profile, app memberships, sessions
+// and the audit events about the user. Events the user only acted in are about other
+// users and are left out.
+func (a *Account) ExportData(ctx context.Context, userId int64) ([]byte, error) {
+	const op = "account.ExportData"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("userId", userId),
+	)
+
+	log.InfoContext(ctx, "exporting user data")
+
+	user, err := a.userProvider.UserById(ctx, userId)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+		log.ErrorContext(ctx, "failed to get user", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	permissions, err := a.accountStore.UserPermissions(ctx, userId)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get permissions", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	events, err := a.userAuditEvents(ctx, userId)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get audit events", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	export := Export{
+		ExportedAt: time.Now().UTC(),
+		Profile: ExportProfile{
+			ID:         user.ID,
+			Email:      user.Email,
+			Username:   user.Username,
+			Status:     user.Status,
+			Attributes: user.Attributes,
+			DeletedAt:  optionalTime(user.DeletedAt),
+		},
+		Memberships: []ExportMembership{},
+		Sessions: ExportSessions{
+			TokensRevokedAt: optionalTime(user.TokensRevokedAt),
+		},
+		AuditEvents: events,
+	}
+	for _, permission := range permissions {
+		export.Memberships = append(export.Memberships, ExportMembership{
+			AppId:         permission.AppId,
+			Permission:    permission.Permission,
+			ValidFrom:     optionalTime(permission.ValidFrom),
+			ValidUntil:    optionalTime(permission.ValidUntil),
+			GrantedBy:     permission.GrantedBy,
+			Justification: permission.Justification,
+		})
+	}
+
+	archive, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	a.audit(ctx, log, models.AuditEvent{
+		ActorId: userId,
+		UserId:  userId,
+		Action:  AuditActionDataExported,
+	})
+
+	log.InfoContext(ctx, "user data exported", slog.Int("auditEvents", len(events)))
+	return archive, nil
+}
+
+// PurgeDeleted removes the accounts whose grace period is over and records each removal in the audit log.
+// Accounts that became the only owner of an app during the grace period are kept until the app has
+// another owner.
+func (a *Account) PurgeDeleted(ctx context.Context) (int, error) {
+	const op = "account.PurgeDeleted"
+
+	log := a.log.With(slog.String("op", op))
+
+	purged, err := a.accountStore.PurgeDeletedUsers(ctx, time.Now().Add(-a.gracePeriod))
+	if err != nil {
+		log.ErrorContext(ctx, "failed to purge deleted accounts", slog.String("error", err.Error()))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, user := range purged {
+		// The user row is gone, so the id is kept in the details instead of UserId.
+		a.audit(ctx, log, models.AuditEvent{
+			Action: AuditActionPurged,
+			Details: map[string]any{
+				"user_id": user.ID,
+			},
+		})
+	}
+
+	if len(purged) > 0 {
+		log.InfoContext(ctx, "deleted accounts purged", slog.Int("count", len(purged)))
+	}
+	return len(purged), nil
+}
+
+// RunPurger calls PurgeDeleted every interval until ctx is cancelled.
+func (a *Account) RunPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Errors are already logged, the next tick retries.
+			_, _ = a.PurgeDeleted(ctx)
+		}
+	}
+}
+
+// userAuditEvents returns the events about the user, ordered by id.
+func (a *Account) userAuditEvents(ctx context.Context, userId int64) ([]ExportEvent, error) {
+	var exported []ExportEvent
+	var afterId int64
+	for {
+		batch, err := a.auditStore.AuditEvents(ctx, models.AuditFilter{UserId: userId}, afterId, auditExportBatch)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range batch {
+			exported = append(exported, ExportEvent{
+				ID:        event.ID,
+				AppId:     event.AppId,
+				ActorId:   event.ActorId,
+				UserId:    event.UserId,
+				Action:    event.Action,
+				Details:   event.Details,
+				CreatedAt: event.CreatedAt,
+			})
+		}
+		if len(batch) < auditExportBatch {
+			break
+		}
+		afterId = batch[len(batch)-1].ID
+	}
+	return exported, nil
+}
+
+func (a *Account) audit(ctx context.Context, log *slog.Logger, event models.AuditEvent) {
+	if err := a.auditStore.SaveAuditEvent(ctx, event); err != nil {
+		log.ErrorContext(ctx, "failed to save audit event", slog.String("error", err.Error()))
+	}
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
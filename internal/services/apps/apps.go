@@ -0,0 +1,499 @@
+package apps
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/storage"
+	"github.com/botanikn/go_sso_service/pkg/pagination"
+)
+
+const (
+	AuditActionAppCreated = "app.created"
+	AuditActionAppUpdated = "app.updated"
+	AuditActionAppDeleted = "app.deleted"
+	AuditActionAppRotated = "app.secret_rotated"
+	AuditActionAppOwner   = "app.owner_assigned"
+
+	AuditActionAppSettingsUpdated = "app.settings_updated"
+
+	defaultPageSize = 50
+	maxPageSize     = 500
+	secretSize      = 32
+)
+
+type Apps struct {
+	log               *slog.Logger
+	appProvider       AppProvider
+	settingsStore     SettingsStore
+	auditSaver        AuditSaver
+	userProvider      UserProvider
+	permissionUpdater PermissionUpdater
+}
+
+type AppProvider interface {
+	App(ctx context.Context, appId int64) (models.App, error)
+	Apps(ctx context.Context, afterId int64, limit int) ([]models.App, error)
+	SaveApp(ctx context.Context, name string, secret string) (int64, error)
+	UpdateApp(ctx context.Context, appId int64, name string) error
+	DeleteApp(ctx context.Context, appId int64) error
+	UpdateAppSecret(ctx context.Context, appId int64, secret string) (models.App, error)
+}
+
+type SettingsStore interface {
+	AppSettings(ctx context.Context, appId int64) (models.AppSettings, error)
+	SaveAppSettings(ctx context.Context, settings models.AppSettings) error
+}
+
+type AuditSaver interface {
+	SaveAuditEvent(ctx context.Context, event models.AuditEvent) error
+}
+
+type UserProvider interface {
+	UserById(ctx context.Context, userId int64) (models.User, error)
+}
+
+type PermissionUpdater interface {
+	BatchUpdatePermissions(ctx context.Context,
+		changes []models.PermissionChange,
+		dryRun bool,
+	) ([]models.PermissionChangeResult, bool, error)
+}
+
+var (
+	ErrAppNotFound      = errors.New("app not found")
+	ErrAppExists        = errors.New("app already exists")
+	ErrInvalidPageToken = errors.New("invalid page token")
+	ErrInvalidSettings  = errors.New("invalid app settings")
+	ErrUserNotFound     = errors.New("user not found")
+	ErrNotSuperAdmin    = errors.New("only super-admins can assign app owners")
+)
+
+// New returns a new instance of Apps service.
+func New(
+	log *slog.Logger,
+	appProvider AppProvider,
+	settingsStore SettingsStore,
+	auditSaver AuditSaver,
+	userProvider UserProvider,
+	permissionUpdater PermissionUpdater,
+) *Apps {
+	return &Apps{
+		log:               log,
+		appProvider:       appProvider,
+		settingsStore:     settingsStore,
+		auditSaver:        auditSaver,
+		userProvider:      userProvider,
+		permissionUpdater: permissionUpdater,
+	}
+}
+
+// CreateApp registers a new app with a generated secret. The secret is returned
+// to the caller here and is never exposed by the other methods.
+func (a *Apps) CreateApp(ctx context.Context, actorId int64, name string) (models.App, error) {
+	const op = "apps.CreateApp"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("actorId", actorId),
+		slog.String("name", name),
+	)
+
+	log.InfoContext(ctx, "creating app")
+
+	secret, err := generateSecret()
+	if err != nil {
+		log.ErrorContext(ctx, "failed to generate secret", slog.String("error", err.Error()))
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	appId, err := a.appProvider.SaveApp(ctx, name, secret)
+	if err != nil {
+		if errors.Is(err, storage.ErrAppExists) {
+			log.WarnContext(ctx, "app already exists", slog.String("error", err.Error()))
+			return models.App{}, fmt.Errorf("%s: %w", op, ErrAppExists)
+		}
+		log.ErrorContext(ctx, "failed to save app", slog.String("error", err.Error()))
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	a.audit(ctx, log, models.AuditEvent{
+		AppId:   appId,
+		ActorId: actorId,
+		Action:  AuditActionAppCreated,
+		Details: map[string]any{"app_id": appId, "name": name},
+	})
+
+	log.InfoContext(ctx, "app created", slog.Int64("appId", appId))
+	return models.App{
+		ID:     int(appId),
+		Name:   name,
+		Secret: secret,
+	}, nil
+}
+
+func (a *Apps) UpdateApp(ctx context.Context, actorId int64, appId int64, name string) (models.App, error) {
+	const op = "apps.UpdateApp"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("actorId", actorId),
+		slog.Int64("appId", appId),
+	)
+
+	log.InfoContext(ctx, "updating app")
+
+	if err := a.appProvider.UpdateApp(ctx, appId, name); err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			log.WarnContext(ctx, "app not found", slog.String("error", err.Error()))
+			return models.App{}, fmt.Errorf("%s: %w", op, ErrAppNotFound)
+		}
+		if errors.Is(err, storage.ErrAppExists) {
+			log.WarnContext(ctx, "app name is taken", slog.String("error", err.Error()))
+			return models.App{}, fmt.Errorf("%s: %w", op, ErrAppExists)
+		}
+		log.ErrorContext(ctx, "failed to update app", slog.String("error", err.Error()))
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	a.audit(ctx, log, models.AuditEvent{
+		AppId:   appId,
+		ActorId: actorId,
+		Action:  AuditActionAppUpdated,
+		Details: map[string]any{"app_id": appId, "name": name},
+	})
+
+	log.InfoContext(ctx, "app updated")
+	return models.App{
+		ID:   int(appId),
+		Name: name,
+	}, nil
+}
+
+// ListApps returns a page of apps without their secrets and the token of the next page,
+// which is empty on the last page.
+func (a *Apps) ListApps(ctx context.Context, pageSize int, pageToken string) ([]models.App, string, error) {
+	const op = "apps.ListApps"
+
+	log := a.log.With(slog.String("op", op))
+
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	afterId, err := pagination.DecodeToken(pageToken)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, ErrInvalidPageToken)
+	}
+
+	// One extra row tells whether there is a next page.
+	apps, err := a.appProvider.Apps(ctx, afterId, pageSize+1)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to list apps", slog.String("error", err.Error()))
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	nextPageToken := ""
+	if len(apps) > pageSize {
+		apps = apps[:pageSize]
+		nextPageToken = pagination.EncodeToken(int64(apps[len(apps)-1].ID))
+	}
+
+	return apps, nextPageToken, nil
+}
+
+func (a *Apps) DeleteApp(ctx context.Context, actorId int64, appId int64) error {
+	const op = "apps.DeleteApp"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("actorId", actorId),
+		slog.Int64("appId", appId),
+	)
+
+	log.InfoContext(ctx, "deleting app")
+
+	if err := a.appProvider.DeleteApp(ctx, appId); err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			log.WarnContext(ctx, "app not found", slog.String("error", err.Error()))
+			return fmt.Errorf("%s: %w", op, ErrAppNotFound)
+		}
+		log.ErrorContext(ctx, "failed to delete app", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The app row is gone, so the event only references it in details.
+	a.audit(ctx, log, models.AuditEvent{
+		ActorId: actorId,
+		Action:  AuditActionAppDeleted,
+		Details: map[string]any{"app_id": appId},
+	})
+
+	log.InfoContext(ctx, "app deleted")
+	return nil
+}
+
+// RotateSecret replaces the app's secret with a newly generated one and returns the app
+// with it. Tokens signed with the old secret stop validating immediately.
+func (a *Apps) RotateSecret(ctx context.Context, actorId int64, appId int64) (models.App, error) {
+	const op = "apps.RotateSecret"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("actorId", actorId),
+		slog.Int64("appId", appId),
+	)
+
+	log.InfoContext(ctx, "rotating app secret")
+
+	secret, err := generateSecret()
+	if err != nil {
+		log.ErrorContext(ctx, "failed to generate secret", slog.String("error", err.Error()))
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	app, err := a.appProvider.UpdateAppSecret(ctx, appId, secret)
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			log.WarnContext(ctx, "app not found", slog.String("error", err.Error()))
+			return models.App{}, fmt.Errorf("%s: %w", op, ErrAppNotFound)
+		}
+		log.ErrorContext(ctx, "failed to update app secret", slog.String("error", err.Error()))
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	a.audit(ctx, log, models.AuditEvent{
+		AppId:   appId,
+		ActorId: actorId,
+		Action:  AuditActionAppRotated,
+		Details: map[string]any{"app_id": appId},
+	})
+
+	log.InfoContext(ctx, "app secret rotated")
+	return app, nil
+}
+
+// AssignOwner makes the user an owner of the app and returns their permanent permission
+// before, "" if they had none. Membership policies don't apply, it is how super-admins
+// give an app its first owner, so only super-admins may call it.
+func (a *Apps) AssignOwner(ctx context.Context, actorId int64, appId int64, userId int64) (string, error) {
+	const op = "apps.AssignOwner"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("actorId", actorId),
+		slog.Int64("appId", appId),
+		slog.Int64("userId", userId),
+	)
+
+	log.InfoContext(ctx, "assigning app owner")
+
+	actor, err := a.userProvider.UserById(ctx, actorId)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get actor", slog.String("error", err.Error()))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if !actor.SuperAdmin {
+		log.WarnContext(ctx, "refused owner assignment by a non super-admin")
+		return "", fmt.Errorf("%s: %w", op, ErrNotSuperAdmin)
+	}
+
+	if _, err := a.appProvider.App(ctx, appId); err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return "", fmt.Errorf("%s: %w", op, ErrAppNotFound)
+		}
+		log.ErrorContext(ctx, "failed to get app", slog.String("error", err.Error()))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := a.userProvider.UserById(ctx, userId); err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return "", fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+		log.ErrorContext(ctx, "failed to get user", slog.String("error", err.Error()))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The batch upsert creates the permission when the user has none in the app yet.
+	results, applied, err := a.permissionUpdater.BatchUpdatePermissions(ctx, []models.PermissionChange{{
+		UserId:     userId,
+		AppId:      appId,
+		Permission: models.RoleOwner,
+	}}, false)
+	if err == nil && !applied {
+		err = results[0].Err
+	}
+	if err != nil {
+		log.ErrorContext(ctx, "failed to assign app owner", slog.String("error", err.Error()))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	previous := results[0].PreviousPermission
+
+	a.audit(ctx, log, models.AuditEvent{
+		AppId:   appId,
+		ActorId: actorId,
+		UserId:  userId,
+		Action:  AuditActionAppOwner,
+		Details: map[string]any{"previous_permission": previous},
+	})
+
+	log.InfoContext(ctx, "app owner assigned", slog.String("previousPermission", previous))
+	return previous, nil
+}
+
+// Settings returns the app's settings, the defaults if it has none stored.
+func (a *Apps) Settings(ctx context.Context, appId int64) (models.AppSettings, error) {
+	const op = "apps.Settings"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("appId", appId),
+	)
+
+	// Settings of a missing app would be the defaults, so check the app itself.
+	if _, err := a.appProvider.App(ctx, appId); err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			log.WarnContext(ctx, "app not found", slog.String("error", err.Error()))
+			return models.AppSettings{}, fmt.Errorf("%s: %w", op, ErrAppNotFound)
+		}
+		log.ErrorContext(ctx, "failed to get app", slog.String("error", err.Error()))
+		return models.AppSettings{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	settings, err := a.settingsStore.AppSettings(ctx, appId)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get app settings", slog.String("error", err.Error()))
+		return models.AppSettings{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return settings, nil
+}
+
+// UpdateSettings replaces the app's settings and returns them as stored.
+func (a *Apps) UpdateSettings(ctx context.Context, actorId int64, settings models.AppSettings) (models.AppSettings, error) {
+	const op = "apps.UpdateSettings"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("actorId", actorId),
+		slog.Int64("appId", settings.AppId),
+	)
+
+	log.InfoContext(ctx, "updating app settings")
+
+	settings, err := normalizeSettings(settings)
+	if err != nil {
+		return models.AppSettings{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.settingsStore.SaveAppSettings(ctx, settings); err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			log.WarnContext(ctx, "app not found", slog.String("error", err.Error()))
+			return models.AppSettings{}, fmt.Errorf("%s: %w", op, ErrAppNotFound)
+		}
+		log.ErrorContext(ctx, "failed to save app settings", slog.String("error", err.Error()))
+		return models.AppSettings{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	a.audit(ctx, log, models.AuditEvent{
+		AppId:   settings.AppId,
+		ActorId: actorId,
+		Action:  AuditActionAppSettingsUpdated,
+		Details: map[string]any{
+			"access_token_ttl":    settings.AccessTokenTTL.String(),
+			"refresh_token_ttl":   settings.RefreshTokenTTL.String(),
+			"login_methods":       settings.LoginMethods,
+			"registration_policy": settings.RegistrationPolicy,
+			"membership_policy":   settings.MembershipPolicy,
+			"allowed_domains":     settings.AllowedDomains,
+			"mfa_required":        settings.MFARequired,
+		},
+	})
+
+	log.InfoContext(ctx, "app settings updated")
+	return settings, nil
+}
+
+func (a *Apps) audit(ctx context.Context, log *slog.Logger, event models.AuditEvent) {
+	if err := a.auditSaver.SaveAuditEvent(ctx, event); err != nil {
+		log.ErrorContext(ctx, "failed to save audit event", slog.String("error", err.Error()))
+	}
+}
+
+// normalizeSettings validates the settings and returns them with domains lowercased
+// and duplicate login methods dropped.
+func normalizeSettings(settings models.AppSettings) (models.AppSettings, error) {
+	if settings.AccessTokenTTL < 0 || settings.RefreshTokenTTL < 0 {
+		return models.AppSettings{}, fmt.Errorf("%w: token TTLs must not be negative", ErrInvalidSettings)
+	}
+	if settings.AccessTokenTTL%time.Second != 0 || settings.RefreshTokenTTL%time.Second != 0 {
+		return models.AppSettings{}, fmt.Errorf("%w: token TTLs must be whole seconds", ErrInvalidSettings)
+	}
+
+	if len(settings.LoginMethods) == 0 {
+		return models.AppSettings{}, fmt.Errorf("%w: at least one login method is required", ErrInvalidSettings)
+	}
+	methods := make([]string, 0, len(settings.LoginMethods))
+	for _, method := range settings.LoginMethods {
+		switch method {
+		case models.LoginMethodPassword, models.LoginMethodPasskey, models.LoginMethodExternalIdP:
+		default:
+			return models.AppSettings{}, fmt.Errorf("%w: unknown login method %q", ErrInvalidSettings, method)
+		}
+		if !slices.Contains(methods, method) {
+			methods = append(methods, method)
+		}
+	}
+	settings.LoginMethods = methods
+
+	switch settings.RegistrationPolicy {
+	case "":
+		settings.RegistrationPolicy = models.RegistrationOpen
+	case models.RegistrationOpen, models.RegistrationInviteOnly, models.RegistrationDomainRestricted:
+	default:
+		return models.AppSettings{}, fmt.Errorf("%w: unknown registration policy %q", ErrInvalidSettings, settings.RegistrationPolicy)
+	}
+
+	switch settings.MembershipPolicy {
+	case "":
+		settings.MembershipPolicy = models.MembershipOpen
+	case models.MembershipOpen, models.MembershipDomainRestricted, models.MembershipApproval, models.MembershipClosed:
+	default:
+		return models.AppSettings{}, fmt.Errorf("%w: unknown membership policy %q", ErrInvalidSettings, settings.MembershipPolicy)
+	}
+
+	domains := make([]string, 0, len(settings.AllowedDomains))
+	for _, domain := range settings.AllowedDomains {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if domain == "" || strings.Contains(domain, "@") {
+			return models.AppSettings{}, fmt.Errorf("%w: invalid domain %q", ErrInvalidSettings, domain)
+		}
+		domains = append(domains, domain)
+	}
+	if settings.RegistrationPolicy == models.RegistrationDomainRestricted && len(domains) == 0 {
+		return models.AppSettings{}, fmt.Errorf("%w: domain restricted registration needs allowed domains", ErrInvalidSettings)
+	}
+	if settings.MembershipPolicy == models.MembershipDomainRestricted && len(domains) == 0 {
+		return models.AppSettings{}, fmt.Errorf("%w: domain restricted membership needs allowed domains", ErrInvalidSettings)
+	}
+	settings.AllowedDomains = domains
+
+	return settings, nil
+}
+
+func generateSecret() (string, error) {
+	buf := make([]byte, secretSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,76 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+)
+
+const exportBatchSize = 500
+
+type Audit struct {
+	log           *slog.Logger
+	eventProvider EventProvider
+}
+
+type EventProvider interface {
+	AuditEvents(ctx context.Context, filter models.AuditFilter, afterId int64, limit int) ([]models.AuditEvent, error)
+}
+
+var (
+	ErrInvalidFilter = errors.New("invalid audit filter")
+)
+
+// New returns a new instance of Audit service.
+func New(
+	log *slog.Logger,
+	eventProvider EventProvider,
+) *Audit {
+	return &Audit{
+		log:           log,
+		eventProvider: eventProvider,
+	}
+}
+
+// Export calls send for every event matching the filter, oldest first.
+// Events are read in batches and export stops at the first error returned by send.
+func (a *Audit) Export(ctx context.Context, filter models.AuditFilter, send func(models.AuditEvent) error) error {
+	const op = "audit.Export"
+
+	log := a.log.With(slog.String("op", op))
+
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && !filter.Until.After(filter.Since) {
+		return fmt.Errorf("%s: %w: until must be after since", op, ErrInvalidFilter)
+	}
+
+	log.InfoContext(ctx, "exporting audit events")
+
+	var afterId int64
+	exported := 0
+	for {
+		events, err := a.eventProvider.AuditEvents(ctx, filter, afterId, exportBatchSize)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to list audit events", slog.String("error", err.Error()))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		for _, event := range events {
+			if err := send(event); err != nil {
+				log.WarnContext(ctx, "export interrupted", slog.Int("exported", exported), slog.String("error", err.Error()))
+				return fmt.Errorf("%s: %w", op, err)
+			}
+			exported++
+		}
+
+		if len(events) < exportBatchSize {
+			break
+		}
+		afterId = events[len(events)-1].ID
+	}
+
+	log.InfoContext(ctx, "audit events exported", slog.Int("count", exported))
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"strconv"
+	"sync"
 
 	"time"
 
@@ -23,6 +24,8 @@ type Auth struct {
 	permissionProvider PermissionProvider
 	PermissionCreator  PermissionCreator
 	PermissionUpdater  PermissionUpdater
+	settingsProvider   SettingsProvider
+	transactor         Transactor
 	tokenTTL           time.Duration
 }
 
@@ -32,6 +35,7 @@ type UserSaver interface {
 
 type UserProvider interface {
 	User(ctx context.Context, email string) (models.User, error)
+	UserById(ctx context.Context, userId int64) (models.User, error)
 }
 
 type AppProvider interface {
@@ -44,21 +48,66 @@ type PermissionCreator interface {
 
 type PermissionUpdater interface {
 	UpdatePermission(ctx context.Context, userId int64, appId int64, permission string) error
+	BatchUpdatePermissions(ctx context.Context,
+		changes []models.PermissionChange,
+		dryRun bool,
+	) ([]models.PermissionChangeResult, bool, error)
+}
+
+type SettingsProvider interface {
+	AppSettings(ctx context.Context, appId int64) (models.AppSettings, error)
 }
 
 type PermissionProvider interface {
 	Permission(ctx context.Context, userId int64, appId int64) (string, error)
 }
 
+// Transactor runs fn in a storage transaction, storage calls made with the context fn gets
+// are atomic together. fn may run more than once when the transaction is retried.
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrInvalidAppID       = errors.New("invalid app ID")
 	ErrUserExists         = errors.New("user already exists")
+	ErrUserDisabled       = errors.New("user is disabled")
+	ErrUserDeleted        = errors.New("user account is scheduled for deletion")
+	ErrPermissionNotFound = errors.New("user has no permission in the app")
+	ErrTokenRevoked       = errors.New("token has been revoked")
+
+	ErrLoginMethodNotAllowed = errors.New("login method is not allowed for the app")
+	ErrMFARequired           = errors.New("app requires multi-factor authentication")
+	ErrRegistrationClosed    = errors.New("app does not accept open registration")
+	ErrEmailDomainNotAllowed = errors.New("email domain is not allowed to register in the app")
+	ErrMembershipPending     = errors.New("membership of the app is waiting for approval")
+	ErrMembershipDenied      = errors.New("app does not accept new members")
 )
 
+// dummyPassHash is checked against when there is no user with the email, so a login with
+// an unknown email takes as long as one with a wrong password.
+var dummyPassHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+	return hash
+})
+
+const (
+	// ClaimActor identifies who is acting on behalf of the subject (RFC 8693).
+	ClaimActor = "act"
+	ClaimScope = "scope"
+
+	ScopeReadOnly = "read-only"
+)
+
+// PermissionResponse is the result of token validation. ActorId is set for
+// impersonation tokens and holds the id of the user acting as UserId.
 type PermissionResponse struct {
 	Validated bool
 	UserId    int64
+	ActorId   int64
+	ReadOnly  bool
+	Claims    map[string]any
 }
 
 // New returns a new instance of Auth service.
@@ -70,6 +119,8 @@ func New(
 	permissionProvider PermissionProvider,
 	PermissionCreator PermissionCreator,
 	PermissionUpdater PermissionUpdater,
+	settingsProvider SettingsProvider,
+	transactor Transactor,
 	tokenTTL time.Duration,
 ) *Auth {
 	return &Auth{
@@ -80,6 +131,8 @@ func New(
 		permissionProvider: permissionProvider,
 		PermissionCreator:  PermissionCreator,
 		PermissionUpdater:  PermissionUpdater,
+		settingsProvider:   settingsProvider,
+		transactor:         transactor,
 		tokenTTL:           tokenTTL,
 	}
 }
@@ -99,56 +152,89 @@ func (a *Auth) Login(
 		slog.Int64("appId", appId),
 	)
 
-	log.Info("attempting to login")
+	log.InfoContext(ctx, "attempting to login")
 
 	user, err := a.userProvider.User(ctx, email)
 	if err != nil {
 		if errors.Is(err, storage.ErrUserNotFound) {
-			a.log.Warn("user not found", slog.String("error", err.Error()))
+			a.log.WarnContext(ctx, "user not found", slog.String("error", err.Error()))
 
-			return "", fmt.Errorf("%s: %w", op, err)
+			// Callers can't tell an unknown email from a wrong password.
+			_ = bcrypt.CompareHashAndPassword(dummyPassHash(), []byte(password))
+			return "", fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
 		}
 
-		a.log.Error("failed to get user", slog.String("error", err.Error()))
+		a.log.ErrorContext(ctx, "failed to get user", slog.String("error", err.Error()))
 		return "", fmt.Errorf("%s: %w", op, err)
 	}
 
 	if err := bcrypt.CompareHashAndPassword(user.PassHash, []byte(password)); err != nil {
-		a.log.Info("invalid credentials for user", slog.String("error", err.Error()))
+		a.log.InfoContext(ctx, "invalid credentials for user", slog.String("error", err.Error()))
 		return "", fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
 	}
 
+	if user.Status == models.UserStatusDisabled {
+		log.WarnContext(ctx, "login attempt of disabled user")
+		return "", fmt.Errorf("%s: %w", op, ErrUserDisabled)
+	}
+	if !user.DeletedAt.IsZero() {
+		log.WarnContext(ctx, "login attempt of deleted user")
+		return "", fmt.Errorf("%s: %w", op, ErrUserDeleted)
+	}
+
 	app, err := a.appProvider.App(ctx, appId)
 	if err != nil {
-		a.log.Error("failed to get app", slog.String("error", err.Error()))
+		a.log.ErrorContext(ctx, "failed to get app", slog.String("error", err.Error()))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	settings, err := a.settingsProvider.AppSettings(ctx, appId)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get app settings", slog.String("error", err.Error()))
 		return "", fmt.Errorf("%s: %w", op, err)
 	}
+	if !settings.AllowsLoginMethod(models.LoginMethodPassword) {
+		log.WarnContext(ctx, "password login is not allowed for the app")
+		return "", fmt.Errorf("%s: %w", op, ErrLoginMethodNotAllowed)
+	}
+	// There is no second factor to check yet, so a password alone never satisfies MFA.
+	if settings.MFARequired {
+		log.WarnContext(ctx, "app requires MFA")
+		return "", fmt.Errorf("%s: %w", op, ErrMFARequired)
+	}
 
 	userId, err := strconv.ParseInt(user.ID, 10, 64)
 	if err != nil {
-		a.log.Error("failed to parse user ID", slog.String("error", err.Error()))
+		a.log.ErrorContext(ctx, "failed to parse user ID", slog.String("error", err.Error()))
 		return "", fmt.Errorf("%s: %w", op, err)
 	}
-	_, err = a.permissionProvider.Permission(ctx, userId, appId)
-	if errors.Is(err, storage.ErrNoPermissionFound) {
-		_, err = a.PermissionCreator.CreatePermission(ctx, userId, appId, "user")
-		if err != nil {
-			a.log.Error("failed to create permission", slog.String("error", err.Error()))
-			return "", fmt.Errorf("%s: %w", op, err)
+	// Read and create in one transaction, concurrent first logins would create two permissions otherwise.
+	var permission string
+	err = a.transactor.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		permission, err = a.permissionProvider.Permission(ctx, userId, appId)
+		if errors.Is(err, storage.ErrNoPermissionFound) {
+			permission, err = a.join(ctx, log, userId, user.Email, settings)
 		}
-		a.log.Debug("permission was successfully made for user", slog.Int64("userId", userId), slog.Int64("appId", appId))
-	}
+		return err
+	})
 	if err != nil {
-		a.log.Error("failed to get user permission", slog.String("error", err.Error()))
+		if !errors.Is(err, ErrMembershipPending) && !errors.Is(err, ErrMembershipDenied) {
+			log.ErrorContext(ctx, "failed to get user permission", slog.String("error", err.Error()))
+		}
 		return "", fmt.Errorf("%s: %w", op, err)
 	}
+	if permission == models.RolePending {
+		log.InfoContext(ctx, "membership is waiting for approval")
+		return "", fmt.Errorf("%s: %w", op, ErrMembershipPending)
+	}
 
-	token, err := a.NewToken(user, app, a.tokenTTL)
+	token, err := a.NewToken(user, app, a.accessTokenTTL(settings))
 	if err != nil {
-		log.Error("failed to create token", slog.String("error", err.Error()))
+		log.ErrorContext(ctx, "failed to create token", slog.String("error", err.Error()))
 		return "", fmt.Errorf("%s: %w", op, err)
 	}
-	log.Info("user logged in successfully")
+	log.InfoContext(ctx, "user logged in successfully")
 	return token, nil
 }
 
@@ -166,25 +252,84 @@ func (a *Auth) Register(
 		slog.String("email", email),
 	)
 
-	log.Info("registering user")
+	log.InfoContext(ctx, "registering user")
 
 	passHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		log.Error("failed to hash password", slog.String("error", err.Error()))
+		log.ErrorContext(ctx, "failed to hash password", slog.String("error", err.Error()))
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	userId, err := a.userSaver.SaveUser(ctx, email, username, passHash)
+	userId, err := a.saveUser(ctx, log, email, username, passHash)
 	if err != nil {
-		if errors.Is(err, storage.ErrUserExists) {
-			log.Warn("user already exists", slog.String("error", err.Error()))
-			return 0, fmt.Errorf("%s: %w", op, ErrUserExists)
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.InfoContext(ctx, "user registered")
+	return userId, nil
+}
+
+// RegisterInApp registers a user through the app, subject to the app's registration
+// policy, and makes them a member of the app as far as its membership policy allows.
+func (a *Auth) RegisterInApp(
+	ctx context.Context,
+	appId int64,
+	email string,
+	username string,
+	password string,
+) (int64, error) {
+	const op = "auth.RegisterInApp"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("email", email),
+		slog.Int64("appId", appId),
+	)
+
+	if _, err := a.appProvider.App(ctx, appId); err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			log.WarnContext(ctx, "app not found", slog.String("error", err.Error()))
+			return 0, fmt.Errorf("%s: %w", op, ErrInvalidAppID)
+		}
+		log.ErrorContext(ctx, "failed to get app", slog.String("error", err.Error()))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	settings, err := a.settingsProvider.AppSettings(ctx, appId)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get app settings", slog.String("error", err.Error()))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := checkRegistration(settings, email); err != nil {
+		log.WarnContext(ctx, "registration refused by app policy", slog.String("error", err.Error()))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Hashed before the transaction, bcrypt is slow on purpose.
+	passHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to hash password", slog.String("error", err.Error()))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var userId int64
+	err = a.transactor.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		if userId, err = a.saveUser(ctx, log, email, username, passHash); err != nil {
+			return err
 		}
-		log.Error("failed to save user", slog.String("error", err.Error()))
+		// The account is created either way, a user the membership policy refuses just isn't a member.
+		if _, err := a.join(ctx, log, userId, email, settings); err != nil && !errors.Is(err, ErrMembershipDenied) {
+			log.ErrorContext(ctx, "failed to create permission", slog.String("error", err.Error()))
+			return err
+		}
+		return nil
+	})
+	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	log.Info("user registered")
+	log.InfoContext(ctx, "user registered")
 	return userId, nil
 }
 
@@ -203,19 +348,19 @@ func (a *Auth) CheckPermissions(
 		slog.Int64("appId", appId),
 	)
 
-	log.Info("checking user's permissions")
+	log.InfoContext(ctx, "checking user's permissions")
 
 	permission, err := a.permissionProvider.Permission(ctx, userId, appId)
 	if err != nil {
 		if errors.Is(err, storage.ErrAppNotFound) {
-			log.Warn("app not found", slog.String("error", err.Error()))
+			log.WarnContext(ctx, "app not found", slog.String("error", err.Error()))
 			return "", fmt.Errorf("%s: %w", op, ErrInvalidAppID)
 		}
-		log.Error("failed to check user's permissions", slog.String("error", err.Error()))
+		log.ErrorContext(ctx, "failed to check user's permissions", slog.String("error", err.Error()))
 		return "", fmt.Errorf("%s: %w", op, err)
 	}
 
-	log.Info("checked user's permissions", slog.String("permission", permission))
+	log.InfoContext(ctx, "checked user's permissions", slog.String("permission", permission))
 	return permission, nil
 }
 
@@ -233,19 +378,60 @@ func (a *Auth) UpdatePermissions(
 		slog.String("permission", permission),
 	)
 
-	log.Info("updating user's permissions")
+	log.InfoContext(ctx, "updating user's permissions")
 
 	err := a.PermissionUpdater.UpdatePermission(ctx, userId, appId, permission)
 	if err != nil {
-		log.Error("failed to update user's permissions", slog.String("error", err.Error()))
+		if errors.Is(err, storage.ErrNoPermissionFound) {
+			log.WarnContext(ctx, "user has no permission to update", slog.String("error", err.Error()))
+			return fmt.Errorf("%s: %w", op, ErrPermissionNotFound)
+		}
+		log.ErrorContext(ctx, "failed to update user's permissions", slog.String("error", err.Error()))
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	log.Info("user's permissions updated successfully")
+	log.InfoContext(ctx, "user's permissions updated successfully")
 	return nil
 }
 
+// BatchUpdatePermissions applies all changes atomically, creating missing permissions.
+// It returns the outcome of every change and whether the changes were applied,
+// which they are not on a dry run or when any change failed.
+func (a *Auth) BatchUpdatePermissions(
+	ctx context.Context,
+	changes []models.PermissionChange,
+	dryRun bool,
+) ([]models.PermissionChangeResult, bool, error) {
+	const op = "auth.BatchUpdatePermissions"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int("changes", len(changes)),
+		slog.Bool("dryRun", dryRun),
+	)
+
+	log.InfoContext(ctx, "updating permissions in batch")
+
+	results, applied, err := a.PermissionUpdater.BatchUpdatePermissions(ctx, changes, dryRun)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to update permissions in batch", slog.String("error", err.Error()))
+		return nil, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !applied && !dryRun {
+		log.WarnContext(ctx, "batch rolled back because some changes failed")
+	} else {
+		log.InfoContext(ctx, "batch processed", slog.Bool("applied", applied))
+	}
+	return results, applied, nil
+}
+
 func (a *Auth) NewToken(user models.User, app models.App, duration time.Duration) (string, error) {
+	return a.NewTokenWithClaims(user, app, duration, nil)
+}
+
+// NewTokenWithClaims works like NewToken and adds extra claims to the token,
+// extra claims never override the standard ones.
+func (a *Auth) NewTokenWithClaims(user models.User, app models.App, duration time.Duration, extra map[string]any) (string, error) {
 	if duration <= 0 {
 		return "", errors.New("duration must be positive")
 	}
@@ -253,12 +439,16 @@ func (a *Auth) NewToken(user models.User, app models.App, duration time.Duration
 		return "", errors.New("app secret is required")
 	}
 
-	claims := jwt.MapClaims{
-		"uid":    user.ID,
-		"email":  user.Email,
-		"exp":    time.Now().Add(duration).Unix(),
-		"app_id": app.ID,
+	claims := jwt.MapClaims{}
+	for key, value := range extra {
+		claims[key] = value
 	}
+	claims["uid"] = user.ID
+	claims["email"] = user.Email
+	now := time.Now()
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(duration).Unix()
+	claims["app_id"] = app.ID
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString([]byte(app.Secret))
@@ -274,7 +464,7 @@ func (a *Auth) ValidateToken(ctx context.Context, tokenString string, appId int6
 
 	app, err := a.appProvider.App(ctx, appId)
 	if err != nil {
-		a.log.Error("failed to find app",
+		a.log.ErrorContext(ctx, "failed to find app",
 			slog.String("op", op),
 			slog.String("error", err.Error()),
 			slog.Int64("appId", appId))
@@ -293,7 +483,7 @@ func (a *Auth) ValidateToken(ctx context.Context, tokenString string, appId int6
 	})
 
 	if err != nil {
-		a.log.Error("failed to parse token",
+		a.log.ErrorContext(ctx, "failed to parse token",
 			slog.String("op", op),
 			slog.String("error", err.Error()))
 		return PermissionResponse{}, fmt.Errorf("%s: %w", op, err)
@@ -303,7 +493,7 @@ func (a *Auth) ValidateToken(ctx context.Context, tokenString string, appId int6
 	if exp, ok := mapClaims["exp"].(float64); ok {
 		expTime := time.Unix(int64(exp), 0)
 		if expTime.Before(time.Now()) {
-			a.log.Info("token has expired",
+			a.log.InfoContext(ctx, "token has expired",
 				slog.String("op", op),
 				slog.Time("exp", expTime))
 			return PermissionResponse{}, jwt.ErrTokenExpired
@@ -316,25 +506,143 @@ func (a *Auth) ValidateToken(ctx context.Context, tokenString string, appId int6
 		return PermissionResponse{}, fmt.Errorf("%s: %w", op, jwt.ErrTokenMalformed)
 	}
 
-	var userId int64
-	switch v := uidRaw.(type) {
-	case string:
-		userId, err = strconv.ParseInt(v, 10, 64)
+	userId, err := parseUserId(uidRaw)
+	if err != nil {
+		return PermissionResponse{}, fmt.Errorf("%s: %w: %w", op, jwt.ErrTokenMalformed, err)
+	}
+
+	var actorId int64
+	if actRaw, ok := mapClaims[ClaimActor]; ok {
+		act, ok := actRaw.(map[string]any)
+		if !ok {
+			return PermissionResponse{}, fmt.Errorf("%s: %w", op, jwt.ErrTokenMalformed)
+		}
+		actorId, err = parseUserId(act["sub"])
 		if err != nil {
-			return PermissionResponse{}, fmt.Errorf("%s: failed to parse user ID: %w", op, err)
+			return PermissionResponse{}, fmt.Errorf("%s: invalid actor: %w: %w", op, jwt.ErrTokenMalformed, err)
 		}
-	case float64:
-		userId = int64(v)
-	case int64:
-		userId = v
-	case int:
-		userId = int64(v)
-	default:
-		return PermissionResponse{}, fmt.Errorf("%s: invalid user ID type: %T", op, uidRaw)
 	}
 
+	// Tokens of disabled users and revoked sessions stop working right away, not when they expire.
+	issuedAt, _ := mapClaims["iat"].(float64)
+	if err := a.checkUser(ctx, userId, int64(issuedAt)); err != nil {
+		return PermissionResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if actorId != 0 {
+		if err := a.checkUser(ctx, actorId, int64(issuedAt)); err != nil {
+			return PermissionResponse{}, fmt.Errorf("%s: actor: %w", op, err)
+		}
+	}
+
+	scope, _ := mapClaims[ClaimScope].(string)
+
 	return PermissionResponse{
 		Validated: true,
 		UserId:    userId,
+		ActorId:   actorId,
+		ReadOnly:  scope == ScopeReadOnly,
+		Claims:    mapClaims,
 	}, nil
 }
+
+// checkUser rejects tokens of disabled or deleted users and tokens issued before the user's sessions were revoked.
+// Tokens without an issue time predate revocation support and are treated as issued at the epoch.
+func (a *Auth) checkUser(ctx context.Context, userId int64, issuedAt int64) error {
+	user, err := a.userProvider.UserById(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if user.Status == models.UserStatusDisabled {
+		return ErrUserDisabled
+	}
+	if !user.DeletedAt.IsZero() {
+		return ErrUserDeleted
+	}
+	if !user.TokensRevokedAt.IsZero() && issuedAt <= user.TokensRevokedAt.Unix() {
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
+// saveUser stores a new user, telling which field is taken when the email or username is.
+func (a *Auth) saveUser(ctx context.Context, log *slog.Logger, email string, username string, passHash []byte) (int64, error) {
+	userId, err := a.userSaver.SaveUser(ctx, email, username, passHash)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserExists) {
+			log.WarnContext(ctx, "user already exists", slog.String("error", err.Error()))
+			var conflict *storage.ConflictError
+			if errors.As(err, &conflict) {
+				return 0, &storage.ConflictError{Field: conflict.Field, Err: ErrUserExists}
+			}
+			return 0, ErrUserExists
+		}
+		log.ErrorContext(ctx, "failed to save user", slog.String("error", err.Error()))
+		return 0, err
+	}
+	return userId, nil
+}
+
+// join makes the user a member of the app on their first login, as far as the app's
+// membership policy allows, and returns their new permission.
+func (a *Auth) join(ctx context.Context, log *slog.Logger, userId int64, email string, settings models.AppSettings) (string, error) {
+	permission := models.RoleUser
+	switch settings.MembershipPolicy {
+	case models.MembershipDomainRestricted:
+		if !settings.AllowsEmailDomain(email) {
+			log.WarnContext(ctx, "email domain is not allowed to join the app")
+			return "", ErrMembershipDenied
+		}
+	case models.MembershipApproval:
+		permission = models.RolePending
+	case models.MembershipClosed:
+		log.WarnContext(ctx, "app does not accept new members")
+		return "", ErrMembershipDenied
+	}
+
+	if _, err := a.PermissionCreator.CreatePermission(ctx, userId, settings.AppId, permission); err != nil {
+		return "", err
+	}
+	log.DebugContext(ctx, "permission was successfully made for user", slog.String("permission", permission))
+	return permission, nil
+}
+
+// accessTokenTTL returns the lifetime of tokens issued for the app.
+func (a *Auth) accessTokenTTL(settings models.AppSettings) time.Duration {
+	if settings.AccessTokenTTL > 0 {
+		return settings.AccessTokenTTL
+	}
+	return a.tokenTTL
+}
+
+func checkRegistration(settings models.AppSettings, email string) error {
+	switch settings.RegistrationPolicy {
+	case models.RegistrationInviteOnly:
+		return ErrRegistrationClosed
+	case models.RegistrationDomainRestricted:
+		if !settings.AllowsEmailDomain(email) {
+			return ErrEmailDomainNotAllowed
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+func parseUserId(raw any) (int64, error) {
+	switch v := raw.(type) {
+	case string:
+		userId, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse user ID: %w", err)
+		}
+		return userId, nil
+	case float64:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	default:
+		return 0, fmt.Errorf("invalid user ID type: %T", raw)
+	}
+}
@@ -0,0 +1,473 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/storage"
+	"github.com/google/cel-go/cel"
+)
+
+const (
+	ActionUpdatePermissions = "permissions.update"
+	ActionReadPermissions   = "permissions.read"
+	ActionBreakGlass        = "permissions.break_glass"
+	ActionImpersonate       = "users.impersonate"
+	ActionReadRelations     = "relations.read"
+	ActionWriteRelations    = "relations.write"
+	ActionWriteNamespaces   = "relations.write_namespaces"
+	ActionCreateApp         = "apps.create"
+	ActionUpdateApp         = "apps.update"
+	ActionListApps          = "apps.list"
+	ActionDeleteApp         = "apps.delete"
+	ActionRotateAppSecret   = "apps.rotate_secret"
+	ActionGetAppSettings    = "apps.get_settings"
+	ActionUpdateAppSettings = "apps.update_settings"
+	ActionAssignAppOwner    = "apps.assign_owner"
+	ActionListUsers         = "users.list"
+	ActionGetUser           = "users.get"
+	ActionUpdateUser        = "users.update"
+	ActionDisableUser       = "users.disable"
+	ActionEnableUser        = "users.enable"
+	ActionDeleteUser        = "users.delete"
+	ActionRevokeSessions    = "users.revoke_sessions"
+	ActionReadAudit         = "audit.read"
+	ActionCreateInvitation  = "invitations.create"
+	ActionListInvitations   = "invitations.list"
+	ActionRevokeInvitation  = "invitations.revoke"
+	ActionListPolicies      = "policies.list"
+	ActionCreatePolicy      = "policies.create"
+	ActionUpdatePolicy      = "policies.update"
+	ActionDeletePolicy      = "policies.delete"
+)
+
+type Authz struct {
+	log                *slog.Logger
+	policyProvider     PolicyProvider
+	userProvider       UserProvider
+	permissionProvider PermissionProvider
+	policyStore        PolicyStore
+	auditSaver         AuditSaver
+	transactor         Transactor
+	env                *cel.Env
+	programs           sync.Map
+}
+
+type PolicyProvider interface {
+	Policies(ctx context.Context, appId int64, action string) ([]models.Policy, error)
+}
+
+type UserProvider interface {
+	UserById(ctx context.Context, userId int64) (models.User, error)
+}
+
+type PermissionProvider interface {
+	Permission(ctx context.Context, userId int64, appId int64) (string, error)
+}
+
+var (
+	ErrInvalidPolicy = errors.New("invalid policy")
+)
+
+// defaultPolicies are used for an action when the app has no policies of its own,
+// so that apps keep the behaviour they had before policies were introduced.
+var defaultPolicies = map[string][]models.Policy{
+	ActionUpdatePermissions: {{
+		Name:       "default-managers-update-permissions",
+		Action:     ActionUpdatePermissions,
+		Effect:     models.PolicyEffectAllow,
+		Expression: `"manage_users" in principal.capabilities || "manage_roles" in principal.capabilities`,
+	}},
+	ActionReadPermissions: {{
+		Name:       "default-managers-read-permissions",
+		Action:     ActionReadPermissions,
+		Effect:     models.PolicyEffectAllow,
+		Expression: `"manage_users" in principal.capabilities || "manage_roles" in principal.capabilities`,
+	}},
+	ActionBreakGlass: {{
+		Name:       "default-role-managers-break-glass",
+		Action:     ActionBreakGlass,
+		Effect:     models.PolicyEffectAllow,
+		Expression: `"manage_roles" in principal.capabilities`,
+	}},
+	ActionImpersonate: {{
+		Name:       "default-impersonator-impersonate",
+		Action:     ActionImpersonate,
+		Effect:     models.PolicyEffectAllow,
+		Expression: `"impersonator" in principal.roles`,
+	}},
+	ActionReadRelations: {{
+		Name:       "default-members-read-relations",
+		Action:     ActionReadRelations,
+		Effect:     models.PolicyEffectAllow,
+		Expression: `size(principal.roles) > 0 && !("banned" in principal.roles)`,
+	}},
+	ActionWriteRelations: {{
+		Name:       "default-role-managers-write-relations",
+		Action:     ActionWriteRelations,
+		Effect:     models.PolicyEffectAllow,
+		Expression: `"manage_roles" in principal.capabilities`,
+	}},
+	ActionWriteNamespaces: {{
+		Name:       "default-settings-managers-write-namespaces",
+		Action:     ActionWriteNamespaces,
+		Effect:     models.PolicyEffectAllow,
+		Expression: `"manage_settings" in principal.capabilities`,
+	}},
+	// App management is authorized against the admin app.
+	ActionCreateApp: {{
+		Name:       "default-settings-managers-create-apps",
+		Action:     ActionCreateApp,
+		Effect:     models.PolicyEffectAllow,
+		Expression: `"manage_settings" in principal.capabilities`,
+	}},
+	ActionUpdateApp: {{
+		Name:       "default-settings-managers-update-apps",
+		Action:     ActionUpdateApp,
+		Effect:     models.PolicyEffectAllow,
+		Expression: `"manage_settings" in principal.capabilities`,
+	}},
+	ActionListApps: {{
+		Name:       "default-settings-managers-list-apps",
+		Action:     ActionListApps,
+		Effect:     models.PolicyEffectAllow,
+		Expression: `"manage_settings" in principal.capabilities`,
+	}},
+	ActionDeleteApp: {{
+		Name:       "default-settings-managers-delete-apps",
+		Action:     ActionDeleteApp,
+		Effect:     models.PolicyEffectAllow,
+		Expression: `"manage_settings" in principal.capabilities`,
+	}},
+	ActionRotateAppSecret: {{
+		Name:       "default-settings-managers-rotate-app-secrets",
+		Action:     ActionRotateAppSecret,
+		Effect:     models.PolicyEffectAllow,
+		Expression: `"manage_settings" in principal.capabilities`,
+	}},
+	ActionGetAppSettings: {{
+		Name:       "default-settings-managers-get-app-settings",
+		Action:     ActionGetAppSettings,
+		Effect:     models.PolicyEffectAllow,
+		Expression: `"manage_settings" in principal.capabilities`,
+	}},
+	ActionUpdateAppSettings: {{
+		Name:       "default-settings-managers-update-app-settings",
+		Action:     ActionUpdateAppSettings,
+		Effect:     models.PolicyEffectAllow,
+		Expression: `"manage_settings" in principal.capabilities`,
+	}},
+	// ActionAssignAppOwner has no default policy, only super-admins assign app owners.
+	// User administration is authorized against the admin app.
+	ActionListUsers: {{
+		Name:       "default-user-managers-list-users",
+		Action:     ActionListUsers,
+		Effect:     models.PolicyEffectAllow,
+		Expression: `"manage_users" in principal.capabilities`,
+	}},
+	ActionGetUser: {{
+		Name:       "default-user-managers-get-users",
+		Action:     ActionGetUser,
+		Effect:     models.PolicyEffectAllow,
+		Expression: `"manage_users" in principal.capabilities`,
+	}},
+	ActionUpdateUser: {{
+		Name:       "default-user-managers-update-users",
+		Action:     ActionUpdateUser,
+		Effect:     models.PolicyEffectAllow,
+		Expression: `"manage_users" in principal.capabilities`,
+	}},
+	ActionDisableUser: {{
+		Name:       "default-user-managers-disable-users",
+		Action:     ActionDisableUser,
+		Effect:     models.PolicyEffectAllow,
+		Expression: `"manage_users" in principal.capabilities`,
+	}},
+	ActionEnableUser: {{
+		Name:       "default-user-managers-enable-users",
+		Action:     ActionEnableUser,
+		Effect:     models.PolicyEffectAllow,
+		Expression: `"manage_users" in principal.capabilities`,
+	}},
+	ActionDeleteUser: {{
+		Name:       "default-user-managers-delete-users",
+		Action:     ActionDeleteUser,
+		Effect:     models.PolicyEffectAllow,
+		Expression: `"manage_users" in principal.capabilities`,
+	}},
+	ActionRevokeSessions: {{
+		Name:       "default-user-managers-revoke-sessions",
+		Action:     ActionRevokeSessions,
+		Effect:     models.PolicyEffectAllow,
+		Expression: `"manage_users" in principal.capabilities`,
+	}},
+	ActionReadAudit: {{
+		Name:       "default-settings-managers-read-audit",
+		Action:     ActionReadAudit,
+		Effect:     models.PolicyEffectAllow,
+		Expression: `"manage_settings" in principal.capabilities`,
+	}},
+	// Invitations are authorized against the app they invite to, the invited role
+	// is further limited by the delegation rules.
+	ActionCreateInvitation: {{
+		Name:       "default-user-managers-create-invitations",
+		Action:     ActionCreateInvitation,
+		Effect:     models.PolicyEffectAllow,
+		Expression: `"manage_users" in principal.capabilities`,
+	}},
+	ActionListInvitations: {{
+		Name:       "default-user-managers-list-invitations",
+		Action:     ActionListInvitations,
+		Effect:     models.PolicyEffectAllow,
+		Expression: `"manage_users" in principal.capabilities`,
+	}},
+	ActionRevokeInvitation: {{
+		Name:       "default-user-managers-revoke-invitations",
+		Action:     ActionRevokeInvitation,
+		Effect:     models.PolicyEffectAllow,
+		Expression: `"manage_users" in principal.capabilities`,
+	}},
+	// The policy actions have no default policies either, only super-admins manage policies.
+}
+
+const (
+	// readOnlyPolicy is reported as the deciding policy when a read-only token
+	// asks for an action that is not a read.
+	readOnlyPolicy = "read-only-token"
+	// superAdminPolicy is reported when a request is allowed because the user is a super-admin.
+	superAdminPolicy = "super-admin"
+)
+
+// Request describes who wants to perform which action on which resource.
+// ReadOnly requests are only allowed to perform read actions.
+type Request struct {
+	AppId    int64
+	UserId   int64
+	Claims   map[string]any
+	ReadOnly bool
+	Action   string
+	Resource map[string]any
+	Context  map[string]any
+}
+
+// Decision is the outcome of policy evaluation. Policy holds the name of the
+// policy that decided it and is empty when no policy matched.
+type Decision struct {
+	Allowed bool
+	Policy  string
+}
+
+// New returns a new instance of Authz service.
+func New(
+	log *slog.Logger,
+	policyProvider PolicyProvider,
+	userProvider UserProvider,
+	permissionProvider PermissionProvider,
+	policyStore PolicyStore,
+	auditSaver AuditSaver,
+	transactor Transactor,
+) (*Authz, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("principal", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("resource", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("request", cel.MapType(cel.StringType, cel.DynType)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("authz.New: %w", err)
+	}
+
+	return &Authz{
+		log:                log,
+		policyProvider:     policyProvider,
+		userProvider:       userProvider,
+		permissionProvider: permissionProvider,
+		policyStore:        policyStore,
+		auditSaver:         auditSaver,
+		transactor:         transactor,
+		env:                env,
+	}, nil
+}
+
+// Authorize evaluates the app's policies for the requested action.
+// Deny policies take precedence over allow policies, and a request that
+// matches no policy is denied.
+func (a *Authz) Authorize(ctx context.Context, req Request) (Decision, error) {
+	const op = "authz.Authorize"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("userId", req.UserId),
+		slog.Int64("appId", req.AppId),
+		slog.String("action", req.Action),
+	)
+
+	if req.ReadOnly && !isReadAction(req.Action) {
+		log.InfoContext(ctx, "request denied for read-only token")
+		return Decision{Allowed: false, Policy: readOnlyPolicy}, nil
+	}
+
+	principal, err := a.principal(ctx, req)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to build principal", slog.String("error", err.Error()))
+		return Decision{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Super-admins administer every app, so app policies can't lock them out.
+	if principal["super_admin"] == true {
+		log.InfoContext(ctx, "request authorized for super-admin")
+		return Decision{Allowed: true, Policy: superAdminPolicy}, nil
+	}
+
+	policies, err := a.policyProvider.Policies(ctx, req.AppId, req.Action)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get policies", slog.String("error", err.Error()))
+		return Decision{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(policies) == 0 {
+		policies = defaultPolicies[req.Action]
+	}
+
+	resource := req.Resource
+	if resource == nil {
+		resource = map[string]any{}
+	}
+	requestContext := req.Context
+	if requestContext == nil {
+		requestContext = map[string]any{}
+	}
+
+	vars := map[string]any{
+		"principal": principal,
+		"resource":  resource,
+		"request": map[string]any{
+			"app_id":  req.AppId,
+			"action":  req.Action,
+			"time":    time.Now(),
+			"context": requestContext,
+		},
+	}
+
+	decision := Decision{}
+	for _, policy := range policies {
+		matched, err := a.eval(policy, vars)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to evaluate policy",
+				slog.String("policy", policy.Name),
+				slog.String("error", err.Error()))
+			return Decision{}, fmt.Errorf("%s: %w", op, err)
+		}
+		if !matched {
+			continue
+		}
+		if policy.Effect == models.PolicyEffectDeny {
+			log.InfoContext(ctx, "request denied by policy", slog.String("policy", policy.Name))
+			return Decision{Allowed: false, Policy: policy.Name}, nil
+		}
+		if !decision.Allowed {
+			decision = Decision{Allowed: true, Policy: policy.Name}
+		}
+	}
+
+	log.InfoContext(ctx, "request authorized", slog.Bool("allowed", decision.Allowed), slog.String("policy", decision.Policy))
+	return decision, nil
+}
+
+func (a *Authz) principal(ctx context.Context, req Request) (map[string]any, error) {
+	user, err := a.userProvider.UserById(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := []string{}
+	capabilities := []string{}
+	permission, err := a.permissionProvider.Permission(ctx, req.UserId, req.AppId)
+	switch {
+	case err == nil:
+		roles = append(roles, permission)
+		capabilities = append(capabilities, models.RoleCapabilities(permission)...)
+	case !errors.Is(err, storage.ErrNoPermissionFound):
+		return nil, err
+	}
+
+	id, err := strconv.ParseInt(user.ID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	attributes := user.Attributes
+	if attributes == nil {
+		attributes = map[string]any{}
+	}
+	claims := req.Claims
+	if claims == nil {
+		claims = map[string]any{}
+	}
+
+	return map[string]any{
+		"id":           id,
+		"email":        user.Email,
+		"username":     user.Username,
+		"super_admin":  user.SuperAdmin,
+		"attributes":   attributes,
+		"roles":        roles,
+		"capabilities": capabilities,
+		"claims":       claims,
+	}, nil
+}
+
+func (a *Authz) eval(policy models.Policy, vars map[string]any) (bool, error) {
+	program, err := a.program(policy.Expression)
+	if err != nil {
+		return false, fmt.Errorf("policy %q: %w", policy.Name, err)
+	}
+
+	out, _, err := program.Eval(vars)
+	if err != nil {
+		return false, fmt.Errorf("policy %q: %w", policy.Name, err)
+	}
+
+	matched, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("policy %q: %w: expression must return bool, got %s", policy.Name, ErrInvalidPolicy, out.Type())
+	}
+	return matched, nil
+}
+
+// program compiles the expression once and caches the result,
+// expressions are the cache key since the same text always compiles the same way.
+func (a *Authz) program(expression string) (cel.Program, error) {
+	if cached, ok := a.programs.Load(expression); ok {
+		return cached.(cel.Program), nil
+	}
+
+	ast, issues := a.env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPolicy, issues.Err())
+	}
+	program, err := a.env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPolicy, err)
+	}
+
+	a.programs.Store(expression, program)
+	return program, nil
+}
+
+// isReadAction reports whether the action only reads data,
+// actions are named "<resource>.<verb>" and read verbs are read, list and get.
+func isReadAction(action string) bool {
+	verb := action[strings.LastIndex(action, ".")+1:]
+	switch verb {
+	case "read", "list", "get":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,405 @@
+package authz_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/services/authz"
+	"github.com/botanikn/go_sso_service/internal/storage/memory"
+)
+
+type fixture struct {
+	storage *memory.Repository
+	authz   *authz.Authz
+	// defaultsApp has no policies of its own, customApp has the policies of newFixture.
+	defaultsApp int64
+	customApp   int64
+	users       map[string]int64
+}
+
+// newFixture stores a user per role, holding it in both apps, a super-admin without
+// any role and a user without any role.
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	ctx := context.Background()
+	storage := memory.New()
+	service, err := authz.New(slog.New(slog.NewTextHandler(io.Discard, nil)), storage, storage, storage, storage, storage, storage)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	f := &fixture{
+		storage:     storage,
+		authz:       service,
+		defaultsApp: mustSaveApp(t, storage, "defaults"),
+		customApp:   mustSaveApp(t, storage, "custom"),
+		users:       make(map[string]int64),
+	}
+	for _, role := range []string{models.RoleBanned, models.RoleUser, models.RoleImpersonator, models.RoleUserManager, models.RoleAdmin, models.RoleOwner} {
+		userId := mustSaveUser(t, storage, role)
+		for _, appId := range []int64{f.defaultsApp, f.customApp} {
+			if _, err := storage.CreatePermission(ctx, userId, appId, role); err != nil {
+				t.Fatalf("CreatePermission: %v", err)
+			}
+		}
+		f.users[role] = userId
+	}
+	f.users["none"] = mustSaveUser(t, storage, "none")
+	f.users["super-admin"] = mustSaveUser(t, storage, "super-admin")
+	if err := storage.SetSuperAdmin(ctx, f.users["super-admin"], true); err != nil {
+		t.Fatalf("SetSuperAdmin: %v", err)
+	}
+
+	for _, policy := range []models.Policy{
+		{Name: "members-edit-docs", Action: "docs.edit", Effect: models.PolicyEffectAllow, Expression: `"user" in principal.roles || "admin" in principal.roles`},
+		{Name: "deny-locked-docs", Action: "docs.edit", Effect: models.PolicyEffectDeny, Expression: `has(resource.locked) && resource.locked == true`},
+		{Name: "deny-outside-office", Action: "docs.edit", Effect: models.PolicyEffectDeny, Expression: `has(request.context.ip) && request.context.ip != "10.0.0.1"`},
+		{Name: "owners-update-permissions", Action: authz.ActionUpdatePermissions, Effect: models.PolicyEffectAllow, Expression: `"owner" in principal.roles`},
+		{Name: "broken", Action: "docs.broken", Effect: models.PolicyEffectAllow, Expression: `principal.id`},
+	} {
+		policy.AppId = f.customApp
+		if _, err := storage.SavePolicy(ctx, policy); err != nil {
+			t.Fatalf("SavePolicy: %v", err)
+		}
+	}
+	return f
+}
+
+func TestAuthorize(t *testing.T) {
+	f := newFixture(t)
+
+	tests := []struct {
+		name     string
+		user     string
+		custom   bool
+		action   string
+		readOnly bool
+		resource map[string]any
+		context  map[string]any
+		want     authz.Decision
+	}{
+		{
+			name:   "default policy allows admins",
+			user:   models.RoleAdmin,
+			action: authz.ActionUpdatePermissions,
+			want:   authz.Decision{Allowed: true, Policy: "default-managers-update-permissions"},
+		},
+		{
+			name:   "default policy allows user managers",
+			user:   models.RoleUserManager,
+			action: authz.ActionUpdatePermissions,
+			want:   authz.Decision{Allowed: true, Policy: "default-managers-update-permissions"},
+		},
+		{
+			name:   "default policy denies users",
+			user:   models.RoleUser,
+			action: authz.ActionUpdatePermissions,
+			want:   authz.Decision{Allowed: false},
+		},
+		{
+			name:   "default impersonation policy allows impersonators",
+			user:   models.RoleImpersonator,
+			action: authz.ActionImpersonate,
+			want:   authz.Decision{Allowed: true, Policy: "default-impersonator-impersonate"},
+		},
+		{
+			name:   "banned users can't read relations",
+			user:   models.RoleBanned,
+			action: authz.ActionReadRelations,
+			want:   authz.Decision{Allowed: false},
+		},
+		{
+			name:   "users without a role can't read relations",
+			user:   "none",
+			action: authz.ActionReadRelations,
+			want:   authz.Decision{Allowed: false},
+		},
+		{
+			name:   "actions without a default policy are denied",
+			user:   models.RoleOwner,
+			action: authz.ActionAssignAppOwner,
+			want:   authz.Decision{Allowed: false},
+		},
+		{
+			name:   "super-admins bypass policies",
+			user:   "super-admin",
+			action: authz.ActionAssignAppOwner,
+			want:   authz.Decision{Allowed: true, Policy: "super-admin"},
+		},
+		{
+			name:     "read-only tokens can read",
+			user:     models.RoleAdmin,
+			action:   authz.ActionReadPermissions,
+			readOnly: true,
+			want:     authz.Decision{Allowed: true, Policy: "default-managers-read-permissions"},
+		},
+		{
+			name:     "read-only tokens can't write, even for super-admins",
+			user:     "super-admin",
+			action:   authz.ActionUpdatePermissions,
+			readOnly: true,
+			want:     authz.Decision{Allowed: false, Policy: "read-only-token"},
+		},
+		{
+			name:   "app policies replace the defaults",
+			user:   models.RoleAdmin,
+			custom: true,
+			action: authz.ActionUpdatePermissions,
+			want:   authz.Decision{Allowed: false},
+		},
+		{
+			name:   "app policy allows",
+			user:   models.RoleOwner,
+			custom: true,
+			action: authz.ActionUpdatePermissions,
+			want:   authz.Decision{Allowed: true, Policy: "owners-update-permissions"},
+		},
+		{
+			name:     "allow policy on the resource",
+			user:     models.RoleUser,
+			custom:   true,
+			action:   "docs.edit",
+			resource: map[string]any{"locked": false},
+			want:     authz.Decision{Allowed: true, Policy: "members-edit-docs"},
+		},
+		{
+			name:     "deny policy wins over allow",
+			user:     models.RoleUser,
+			custom:   true,
+			action:   "docs.edit",
+			resource: map[string]any{"locked": true},
+			want:     authz.Decision{Allowed: false, Policy: "deny-locked-docs"},
+		},
+		{
+			name:    "deny policy on the request context",
+			user:    models.RoleAdmin,
+			custom:  true,
+			action:  "docs.edit",
+			context: map[string]any{"ip": "192.168.1.1"},
+			want:    authz.Decision{Allowed: false, Policy: "deny-outside-office"},
+		},
+		{
+			name:    "request context that passes the deny policy",
+			user:    models.RoleAdmin,
+			custom:  true,
+			action:  "docs.edit",
+			context: map[string]any{"ip": "10.0.0.1"},
+			want:    authz.Decision{Allowed: true, Policy: "members-edit-docs"},
+		},
+		{
+			name:   "no policy matches",
+			user:   models.RoleOwner,
+			custom: true,
+			action: "docs.edit",
+			want:   authz.Decision{Allowed: false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appId := f.defaultsApp
+			if tt.custom {
+				appId = f.customApp
+			}
+			got, err := f.authz.Authorize(context.Background(), authz.Request{
+				AppId:    appId,
+				UserId:   f.users[tt.user],
+				ReadOnly: tt.readOnly,
+				Action:   tt.action,
+				Resource: tt.resource,
+				Context:  tt.context,
+			})
+			if err != nil {
+				t.Fatalf("Authorize: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Authorize: got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizeErrors(t *testing.T) {
+	f := newFixture(t)
+
+	_, err := f.authz.Authorize(context.Background(), authz.Request{
+		AppId:  f.customApp,
+		UserId: f.users[models.RoleUser],
+		Action: "docs.broken",
+	})
+	if !errors.Is(err, authz.ErrInvalidPolicy) {
+		t.Errorf("Authorize with a policy that returns an int: got %v, want %v", err, authz.ErrInvalidPolicy)
+	}
+
+	if _, err := f.authz.Authorize(context.Background(), authz.Request{
+		AppId:  f.customApp,
+		UserId: -1,
+		Action: "docs.edit",
+	}); err == nil {
+		t.Error("Authorize for a missing user: got no error")
+	}
+}
+
+func TestPolicyManagement(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	valid := models.Policy{
+		AppId:      f.defaultsApp,
+		Name:       "owners-update-permissions",
+		Action:     authz.ActionUpdatePermissions,
+		Expression: `"owner" in principal.roles`,
+	}
+	with := func(change func(policy *models.Policy)) models.Policy {
+		policy := valid
+		change(&policy)
+		return policy
+	}
+
+	tests := []struct {
+		name    string
+		actor   string
+		policy  models.Policy
+		wantErr error
+	}{
+		{
+			name:    "app owners can't create policies",
+			actor:   models.RoleOwner,
+			policy:  valid,
+			wantErr: authz.ErrNotSuperAdmin,
+		},
+		{
+			name:    "name is required",
+			actor:   "super-admin",
+			policy:  with(func(policy *models.Policy) { policy.Name = " " }),
+			wantErr: authz.ErrMalformedPolicy,
+		},
+		{
+			name:    "unknown effect",
+			actor:   "super-admin",
+			policy:  with(func(policy *models.Policy) { policy.Effect = "maybe" }),
+			wantErr: authz.ErrMalformedPolicy,
+		},
+		{
+			name:    "expression doesn't compile",
+			actor:   "super-admin",
+			policy:  with(func(policy *models.Policy) { policy.Expression = `"owner" in` }),
+			wantErr: authz.ErrMalformedPolicy,
+		},
+		{
+			name:    "expression doesn't return a bool",
+			actor:   "super-admin",
+			policy:  with(func(policy *models.Policy) { policy.Expression = `size(principal.roles)` }),
+			wantErr: authz.ErrMalformedPolicy,
+		},
+		{
+			name:    "missing app",
+			actor:   "super-admin",
+			policy:  with(func(policy *models.Policy) { policy.AppId = -1 }),
+			wantErr: authz.ErrAppNotFound,
+		},
+		{
+			name:    "name taken in the app",
+			actor:   "super-admin",
+			policy:  with(func(policy *models.Policy) { policy.AppId, policy.Name = f.customApp, "members-edit-docs" }),
+			wantErr: authz.ErrPolicyExists,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := f.authz.CreatePolicy(ctx, f.users[tt.actor], tt.policy); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CreatePolicy: got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	superAdmin := f.users["super-admin"]
+	policies, err := f.authz.ListPolicies(ctx, superAdmin, f.defaultsApp)
+	if err != nil {
+		t.Fatalf("ListPolicies: %v", err)
+	}
+	if len(policies) != 0 {
+		t.Fatalf("ListPolicies after refused creations: got %+v, want none", policies)
+	}
+
+	created, err := f.authz.CreatePolicy(ctx, superAdmin, valid)
+	if err != nil {
+		t.Fatalf("CreatePolicy: %v", err)
+	}
+	if created.ID == 0 || created.Effect != models.PolicyEffectAllow {
+		t.Errorf("CreatePolicy: got %+v, want an id and the allow effect", created)
+	}
+	expectDecision(t, f, f.users[models.RoleAdmin], false)
+
+	created.Expression = `"admin" in principal.roles`
+	if _, err := f.authz.UpdatePolicy(ctx, f.users[models.RoleOwner], created); !errors.Is(err, authz.ErrNotSuperAdmin) {
+		t.Errorf("UpdatePolicy by an owner: got %v, want %v", err, authz.ErrNotSuperAdmin)
+	}
+	if _, err := f.authz.UpdatePolicy(ctx, superAdmin, created); err != nil {
+		t.Fatalf("UpdatePolicy: %v", err)
+	}
+	expectDecision(t, f, f.users[models.RoleAdmin], true)
+
+	if err := f.authz.DeletePolicy(ctx, f.users[models.RoleOwner], f.defaultsApp, created.ID); !errors.Is(err, authz.ErrNotSuperAdmin) {
+		t.Errorf("DeletePolicy by an owner: got %v, want %v", err, authz.ErrNotSuperAdmin)
+	}
+	if err := f.authz.DeletePolicy(ctx, superAdmin, f.customApp, created.ID); !errors.Is(err, authz.ErrPolicyNotFound) {
+		t.Errorf("DeletePolicy in another app: got %v, want %v", err, authz.ErrPolicyNotFound)
+	}
+	if err := f.authz.DeletePolicy(ctx, superAdmin, f.defaultsApp, created.ID); err != nil {
+		t.Fatalf("DeletePolicy: %v", err)
+	}
+	// Without policies of its own the action is back on the defaults, which allow user managers.
+	expectDecision(t, f, f.users[models.RoleUserManager], true)
+
+	events, err := f.storage.AuditEvents(ctx, models.AuditFilter{AppId: f.defaultsApp}, 0, 10)
+	if err != nil {
+		t.Fatalf("AuditEvents: %v", err)
+	}
+	var actions []string
+	for _, event := range events {
+		actions = append(actions, event.Action)
+	}
+	want := []string{authz.AuditActionPolicyCreated, authz.AuditActionPolicyUpdated, authz.AuditActionPolicyDeleted}
+	if !slices.Equal(actions, want) {
+		t.Errorf("AuditEvents: got %v, want %v", actions, want)
+	}
+}
+
+// expectDecision checks whether the user may update permissions in the app without policies of its own.
+func expectDecision(t *testing.T, f *fixture, userId int64, allowed bool) {
+	t.Helper()
+	decision, err := f.authz.Authorize(context.Background(), authz.Request{
+		AppId:  f.defaultsApp,
+		UserId: userId,
+		Action: authz.ActionUpdatePermissions,
+	})
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	if decision.Allowed != allowed {
+		t.Errorf("Authorize: got %+v, want allowed %t", decision, allowed)
+	}
+}
+
+func mustSaveUser(t *testing.T, storage *memory.Repository, name string) int64 {
+	t.Helper()
+	userId, err := storage.SaveUser(context.Background(), name+"@example.com", name, []byte("hash"))
+	if err != nil {
+		t.Fatalf("SaveUser: %v", err)
+	}
+	return userId
+}
+
+func mustSaveApp(t *testing.T, storage *memory.Repository, name string) int64 {
+	t.Helper()
+	appId, err := storage.SaveApp(context.Background(), name, name+"-secret")
+	if err != nil {
+		t.Fatalf("SaveApp: %v", err)
+	}
+	return appId
+}
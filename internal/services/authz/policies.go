@@ -0,0 +1,257 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/storage"
+	"github.com/google/cel-go/cel"
+)
+
+const (
+	AuditActionPolicyCreated = "policy.created"
+	AuditActionPolicyUpdated = "policy.updated"
+	AuditActionPolicyDeleted = "policy.deleted"
+)
+
+type PolicyStore interface {
+	AppPolicies(ctx context.Context, appId int64) ([]models.Policy, error)
+	SavePolicy(ctx context.Context, policy models.Policy) (int64, error)
+	UpdatePolicy(ctx context.Context, policy models.Policy) error
+	DeletePolicy(ctx context.Context, appId int64, policyId int64) (models.Policy, error)
+}
+
+type AuditSaver interface {
+	SaveAuditEvent(ctx context.Context, event models.AuditEvent) error
+}
+
+// Transactor runs fn in a storage transaction, fn may run more than once when it is retried.
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+var (
+	ErrMalformedPolicy = errors.New("malformed policy")
+	ErrPolicyNotFound  = errors.New("policy not found")
+	ErrPolicyExists    = errors.New("policy already exists")
+	ErrAppNotFound     = errors.New("app not found")
+	ErrNotSuperAdmin   = errors.New("only super-admins can manage policies")
+)
+
+// ListPolicies returns every policy of the app, ordered by action. Actions without
+// policies of their own fall back to the defaults, which are not listed.
+func (a *Authz) ListPolicies(ctx context.Context, actorId int64, appId int64) ([]models.Policy, error) {
+	const op = "authz.ListPolicies"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("actorId", actorId),
+		slog.Int64("appId", appId),
+	)
+
+	if err := a.requireSuperAdmin(ctx, log, actorId); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	policies, err := a.policyStore.AppPolicies(ctx, appId)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to list policies", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return policies, nil
+}
+
+// CreatePolicy validates the policy and stores it, it is in effect for the next request.
+// A policy with no effect allows.
+func (a *Authz) CreatePolicy(ctx context.Context, actorId int64, policy models.Policy) (models.Policy, error) {
+	const op = "authz.CreatePolicy"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("actorId", actorId),
+		slog.Int64("appId", policy.AppId),
+		slog.String("policy", policy.Name),
+	)
+
+	log.InfoContext(ctx, "creating policy")
+
+	if err := a.requireSuperAdmin(ctx, log, actorId); err != nil {
+		return models.Policy{}, fmt.Errorf("%s: %w", op, err)
+	}
+	policy, err := a.validatePolicy(policy)
+	if err != nil {
+		return models.Policy{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = a.transactor.WithTx(ctx, func(ctx context.Context) error {
+		id, err := a.policyStore.SavePolicy(ctx, policy)
+		if err != nil {
+			return err
+		}
+		policy.ID = id
+		return a.auditPolicy(ctx, actorId, AuditActionPolicyCreated, policy)
+	})
+	if err != nil {
+		if known := policyStoreError(err); known != nil {
+			log.WarnContext(ctx, "policy not created", slog.String("error", err.Error()))
+			return models.Policy{}, fmt.Errorf("%s: %w", op, known)
+		}
+		log.ErrorContext(ctx, "failed to create policy", slog.String("error", err.Error()))
+		return models.Policy{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.InfoContext(ctx, "policy created", slog.Int64("policyId", policy.ID))
+	return policy, nil
+}
+
+// UpdatePolicy validates the policy and replaces the stored one with the same id in the app.
+func (a *Authz) UpdatePolicy(ctx context.Context, actorId int64, policy models.Policy) (models.Policy, error) {
+	const op = "authz.UpdatePolicy"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("actorId", actorId),
+		slog.Int64("appId", policy.AppId),
+		slog.Int64("policyId", policy.ID),
+	)
+
+	log.InfoContext(ctx, "updating policy")
+
+	if err := a.requireSuperAdmin(ctx, log, actorId); err != nil {
+		return models.Policy{}, fmt.Errorf("%s: %w", op, err)
+	}
+	policy, err := a.validatePolicy(policy)
+	if err != nil {
+		return models.Policy{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = a.transactor.WithTx(ctx, func(ctx context.Context) error {
+		if err := a.policyStore.UpdatePolicy(ctx, policy); err != nil {
+			return err
+		}
+		return a.auditPolicy(ctx, actorId, AuditActionPolicyUpdated, policy)
+	})
+	if err != nil {
+		if known := policyStoreError(err); known != nil {
+			log.WarnContext(ctx, "policy not updated", slog.String("error", err.Error()))
+			return models.Policy{}, fmt.Errorf("%s: %w", op, known)
+		}
+		log.ErrorContext(ctx, "failed to update policy", slog.String("error", err.Error()))
+		return models.Policy{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.InfoContext(ctx, "policy updated")
+	return policy, nil
+}
+
+// DeletePolicy removes the app's policy. Once the last policy of an action is gone,
+// the action falls back to its default policies.
+func (a *Authz) DeletePolicy(ctx context.Context, actorId int64, appId int64, policyId int64) error {
+	const op = "authz.DeletePolicy"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("actorId", actorId),
+		slog.Int64("appId", appId),
+		slog.Int64("policyId", policyId),
+	)
+
+	log.InfoContext(ctx, "deleting policy")
+
+	if err := a.requireSuperAdmin(ctx, log, actorId); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err := a.transactor.WithTx(ctx, func(ctx context.Context) error {
+		policy, err := a.policyStore.DeletePolicy(ctx, appId, policyId)
+		if err != nil {
+			return err
+		}
+		return a.auditPolicy(ctx, actorId, AuditActionPolicyDeleted, policy)
+	})
+	if err != nil {
+		if known := policyStoreError(err); known != nil {
+			log.WarnContext(ctx, "policy not deleted", slog.String("error", err.Error()))
+			return fmt.Errorf("%s: %w", op, known)
+		}
+		log.ErrorContext(ctx, "failed to delete policy", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.InfoContext(ctx, "policy deleted")
+	return nil
+}
+
+// requireSuperAdmin refuses actors that are not super-admins. Policies decide what everybody
+// else may do, so letting a policy grant its own management would let it escalate itself.
+func (a *Authz) requireSuperAdmin(ctx context.Context, log *slog.Logger, actorId int64) error {
+	actor, err := a.userProvider.UserById(ctx, actorId)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get actor", slog.String("error", err.Error()))
+		return err
+	}
+	if !actor.SuperAdmin {
+		log.WarnContext(ctx, "refused policy management by a non super-admin")
+		return ErrNotSuperAdmin
+	}
+	return nil
+}
+
+// validatePolicy checks the policy and returns it with the default effect filled in.
+// The expression has to compile and return a bool, dynamic results are checked on evaluation.
+func (a *Authz) validatePolicy(policy models.Policy) (models.Policy, error) {
+	policy.Name = strings.TrimSpace(policy.Name)
+	policy.Action = strings.TrimSpace(policy.Action)
+	if policy.Name == "" || policy.Action == "" {
+		return models.Policy{}, fmt.Errorf("%w: name and action are required", ErrMalformedPolicy)
+	}
+	if policy.Effect == "" {
+		policy.Effect = models.PolicyEffectAllow
+	}
+	if policy.Effect != models.PolicyEffectAllow && policy.Effect != models.PolicyEffectDeny {
+		return models.Policy{}, fmt.Errorf("%w: unknown effect %q", ErrMalformedPolicy, policy.Effect)
+	}
+
+	ast, issues := a.env.Compile(policy.Expression)
+	if issues != nil && issues.Err() != nil {
+		return models.Policy{}, fmt.Errorf("%w: %s", ErrMalformedPolicy, issues.Err())
+	}
+	if outputType := ast.OutputType(); !outputType.IsExactType(cel.BoolType) && !outputType.IsExactType(cel.DynType) {
+		return models.Policy{}, fmt.Errorf("%w: expression must return bool, got %s", ErrMalformedPolicy, outputType)
+	}
+	return policy, nil
+}
+
+func (a *Authz) auditPolicy(ctx context.Context, actorId int64, action string, policy models.Policy) error {
+	return a.auditSaver.SaveAuditEvent(ctx, models.AuditEvent{
+		AppId:   policy.AppId,
+		ActorId: actorId,
+		Action:  action,
+		Details: map[string]any{
+			"policy_id":  policy.ID,
+			"name":       policy.Name,
+			"action":     policy.Action,
+			"effect":     policy.Effect,
+			"expression": policy.Expression,
+		},
+	})
+}
+
+// policyStoreError returns the service error for the storage errors a caller can fix,
+// nil for the others.
+func policyStoreError(err error) error {
+	switch {
+	case errors.Is(err, storage.ErrPolicyNotFound):
+		return ErrPolicyNotFound
+	case errors.Is(err, storage.ErrPolicyExists):
+		return ErrPolicyExists
+	case errors.Is(err, storage.ErrAppNotFound):
+		return ErrAppNotFound
+	default:
+		return nil
+	}
+}
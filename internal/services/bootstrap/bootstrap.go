@@ -0,0 +1,232 @@
+package bootstrap
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/storage"
+)
+
+const (
+	AuditActionSuperAdminCreated = "super_admin.created"
+
+	setupTokenSize = 32
+)
+
+// Bootstrap makes sure a fresh deployment gets its first super-admin, either from
+// configuration or through a one-time setup token.
+type Bootstrap struct {
+	log             *slog.Logger
+	userRegisterer  UserRegisterer
+	userProvider    UserProvider
+	superAdminStore SuperAdminStore
+	auditSaver      AuditSaver
+	transactor      Transactor
+
+	mu         sync.Mutex
+	setupToken string
+}
+
+type UserRegisterer interface {
+	Register(ctx context.Context, email string, username string, password string) (int64, error)
+}
+
+type UserProvider interface {
+	User(ctx context.Context, email string) (models.User, error)
+}
+
+type SuperAdminStore interface {
+	HasSuperAdmin(ctx context.Context) (bool, error)
+	SetSuperAdmin(ctx context.Context, userId int64, superAdmin bool) error
+}
+
+type AuditSaver interface {
+	SaveAuditEvent(ctx context.Context, event models.AuditEvent) error
+}
+
+// Transactor runs fn in a storage transaction, fn may run more than once when it is retried.
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+var (
+	ErrAlreadyInitialized = errors.New("super-admin already exists")
+	ErrInvalidSetupToken  = errors.New("invalid setup token")
+	ErrPasswordRequired   = errors.New("password is required to create the super-admin")
+)
+
+// New returns a new instance of Bootstrap service.
+func New(
+	log *slog.Logger,
+	userRegisterer UserRegisterer,
+	userProvider UserProvider,
+	superAdminStore SuperAdminStore,
+	auditSaver AuditSaver,
+	transactor Transactor,
+) *Bootstrap {
+	return &Bootstrap{
+		log:             log,
+		userRegisterer:  userRegisterer,
+		userProvider:    userProvider,
+		superAdminStore: superAdminStore,
+		auditSaver:      auditSaver,
+		transactor:      transactor,
+	}
+}
+
+// Init runs on startup. When there is no super-admin yet it makes the configured user one,
+// registering them first if needed. Without a configured user it returns a setup token
+// that Setup accepts once. The token is empty when nothing is left to set up.
+func (b *Bootstrap) Init(ctx context.Context, email string, username string, password string) (string, error) {
+	const op = "bootstrap.Init"
+
+	log := b.log.With(slog.String("op", op))
+
+	exists, err := b.superAdminStore.HasSuperAdmin(ctx)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to check for super-admin", slog.String("error", err.Error()))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if exists {
+		return "", nil
+	}
+
+	if email != "" {
+		var userId int64
+		err := b.transactor.WithTx(ctx, func(ctx context.Context) error {
+			var err error
+			userId, err = b.configuredUser(ctx, email, username, password)
+			if err != nil {
+				log.ErrorContext(ctx, "failed to prepare configured super-admin", slog.String("error", err.Error()))
+				return err
+			}
+			if err := b.promote(ctx, userId, "config"); err != nil {
+				log.ErrorContext(ctx, "failed to promote configured super-admin", slog.String("error", err.Error()))
+				return err
+			}
+			return nil
+		})
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+		log.InfoContext(ctx, "super-admin created from configuration", slog.Int64("userId", userId))
+		return "", nil
+	}
+
+	buf := make([]byte, setupTokenSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	b.mu.Lock()
+	b.setupToken = token
+	b.mu.Unlock()
+
+	return token, nil
+}
+
+// Setup creates the first super-admin using the setup token issued by Init.
+// The token stops working once it has been used.
+func (b *Bootstrap) Setup(
+	ctx context.Context,
+	setupToken string,
+	email string,
+	username string,
+	password string,
+) (int64, error) {
+	const op = "bootstrap.Setup"
+
+	log := b.log.With(
+		slog.String("op", op),
+		slog.String("email", email),
+	)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.setupToken == "" {
+		return 0, fmt.Errorf("%s: %w", op, ErrAlreadyInitialized)
+	}
+	if subtle.ConstantTimeCompare([]byte(setupToken), []byte(b.setupToken)) != 1 {
+		log.WarnContext(ctx, "setup attempted with invalid token")
+		return 0, fmt.Errorf("%s: %w", op, ErrInvalidSetupToken)
+	}
+
+	// Another instance could have finished the setup in the meantime.
+	exists, err := b.superAdminStore.HasSuperAdmin(ctx)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to check for super-admin", slog.String("error", err.Error()))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if exists {
+		b.setupToken = ""
+		return 0, fmt.Errorf("%s: %w", op, ErrAlreadyInitialized)
+	}
+
+	// Registered in the same transaction, so a failed promotion doesn't leave a user behind
+	// whose email would block the next attempt.
+	var userId int64
+	err = b.transactor.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		userId, err = b.userRegisterer.Register(ctx, email, username, password)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to register super-admin", slog.String("error", err.Error()))
+			return err
+		}
+		if err := b.promote(ctx, userId, "setup_token"); err != nil {
+			log.ErrorContext(ctx, "failed to promote super-admin", slog.String("error", err.Error()))
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	b.setupToken = ""
+	log.InfoContext(ctx, "super-admin created with setup token", slog.Int64("userId", userId))
+	return userId, nil
+}
+
+// configuredUser returns the id of the user with email, registering them when they don't exist.
+func (b *Bootstrap) configuredUser(ctx context.Context, email string, username string, password string) (int64, error) {
+	user, err := b.userProvider.User(ctx, email)
+	if err == nil {
+		return strconv.ParseInt(user.ID, 10, 64)
+	}
+	if !errors.Is(err, storage.ErrUserNotFound) {
+		return 0, err
+	}
+
+	if password == "" {
+		return 0, ErrPasswordRequired
+	}
+	if username == "" {
+		username, _, _ = strings.Cut(email, "@")
+	}
+	return b.userRegisterer.Register(ctx, email, username, password)
+}
+
+func (b *Bootstrap) promote(ctx context.Context, userId int64, source string) error {
+	if err := b.superAdminStore.SetSuperAdmin(ctx, userId, true); err != nil {
+		return err
+	}
+	if err := b.auditSaver.SaveAuditEvent(ctx, models.AuditEvent{
+		UserId:  userId,
+		Action:  AuditActionSuperAdminCreated,
+		Details: map[string]any{"source": source},
+	}); err != nil {
+		b.log.ErrorContext(ctx, "failed to save audit event", slog.String("error", err.Error()))
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package bootstrap_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strconv"
+	"testing"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/services/bootstrap"
+	"github.com/botanikn/go_sso_service/internal/storage"
+	"github.com/botanikn/go_sso_service/internal/storage/memory"
+)
+
+// registerer saves users straight to the repository, the password stands in for its hash.
+type registerer struct {
+	repository *memory.Repository
+}
+
+func (r registerer) Register(ctx context.Context, email string, username string, password string) (int64, error) {
+	return r.repository.SaveUser(ctx, email, username, []byte(password))
+}
+
+// failingSuperAdminStore fails to promote anyone.
+type failingSuperAdminStore struct {
+	*memory.Repository
+	err error
+}
+
+func (s failingSuperAdminStore) SetSuperAdmin(ctx context.Context, userId int64, superAdmin bool) error {
+	return s.err
+}
+
+func newBootstrap(repository *memory.Repository) *bootstrap.Bootstrap {
+	return bootstrap.New(discardLogger(), registerer{repository}, repository, repository, repository, repository)
+}
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		name string
+		// existing is the user stored before Init, superAdmin makes them one.
+		existing   string
+		superAdmin bool
+		email      string
+		username   string
+		password   string
+		wantErr    error
+		wantToken  bool
+		// wantUsername is the username of the created super-admin, empty for none.
+		wantUsername string
+	}{
+		{
+			name:       "super-admin exists",
+			existing:   "root",
+			superAdmin: true,
+			email:      "admin@example.com",
+			password:   "secret",
+		},
+		{
+			name:         "configured user is registered",
+			email:        "admin@example.com",
+			username:     "admin",
+			password:     "secret",
+			wantUsername: "admin",
+		},
+		{
+			name:         "username defaults to the email's local part",
+			email:        "ops@example.com",
+			password:     "secret",
+			wantUsername: "ops",
+		},
+		{
+			name:         "configured user exists",
+			existing:     "admin",
+			email:        "admin@example.com",
+			wantUsername: "admin",
+		},
+		{
+			name:    "new configured user needs a password",
+			email:   "admin@example.com",
+			wantErr: bootstrap.ErrPasswordRequired,
+		},
+		{
+			name:      "without a configured user",
+			wantToken: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository := memory.New()
+			ctx := context.Background()
+			if tt.existing != "" {
+				userId := mustSaveUser(t, repository, tt.existing)
+				if err := repository.SetSuperAdmin(ctx, userId, tt.superAdmin); err != nil {
+					t.Fatalf("SetSuperAdmin: %v", err)
+				}
+			}
+
+			token, err := newBootstrap(repository).Init(ctx, tt.email, tt.username, tt.password)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Init: got %v, want %v", err, tt.wantErr)
+			}
+			if (token != "") != tt.wantToken {
+				t.Errorf("Init: got token %q, want one %t", token, tt.wantToken)
+			}
+
+			events, err := repository.AuditEvents(ctx, models.AuditFilter{Action: bootstrap.AuditActionSuperAdminCreated}, 0, 10)
+			if err != nil {
+				t.Fatalf("AuditEvents: %v", err)
+			}
+			if tt.wantUsername == "" {
+				if len(events) != 0 {
+					t.Errorf("AuditEvents: got %+v, want none", events)
+				}
+				if tt.existing == "" {
+					if _, err := repository.User(ctx, tt.email); !errors.Is(err, storage.ErrUserNotFound) {
+						t.Errorf("User: got %v, want %v", err, storage.ErrUserNotFound)
+					}
+				}
+				return
+			}
+
+			// User looks up what login needs, UserById has the rest.
+			user, err := repository.User(ctx, tt.email)
+			if err != nil {
+				t.Fatalf("User: %v", err)
+			}
+			userId, _ := strconv.ParseInt(user.ID, 10, 64)
+			if user, err = repository.UserById(ctx, userId); err != nil {
+				t.Fatalf("UserById: %v", err)
+			}
+			if !user.SuperAdmin || user.Username != tt.wantUsername {
+				t.Errorf("User: got %+v, want super-admin %q", user, tt.wantUsername)
+			}
+			if len(events) != 1 || events[0].UserId != userId || events[0].Details["source"] != "config" {
+				t.Errorf("AuditEvents: got %+v, want one promotion of %d from config", events, userId)
+			}
+		})
+	}
+}
+
+func TestSetup(t *testing.T) {
+	repository := memory.New()
+	ctx := context.Background()
+	service := newBootstrap(repository)
+
+	if _, err := service.Setup(ctx, "token", "admin@example.com", "admin", "secret"); !errors.Is(err, bootstrap.ErrAlreadyInitialized) {
+		t.Errorf("Setup before Init: got %v, want %v", err, bootstrap.ErrAlreadyInitialized)
+	}
+
+	token, err := service.Init(ctx, "", "", "")
+	if err != nil || token == "" {
+		t.Fatalf("Init: got token %q, %v", token, err)
+	}
+	if _, err := service.Setup(ctx, token+"x", "admin@example.com", "admin", "secret"); !errors.Is(err, bootstrap.ErrInvalidSetupToken) {
+		t.Errorf("Setup with a wrong token: got %v, want %v", err, bootstrap.ErrInvalidSetupToken)
+	}
+
+	userId, err := service.Setup(ctx, token, "admin@example.com", "admin", "secret")
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	user, err := repository.UserById(ctx, userId)
+	if err != nil {
+		t.Fatalf("UserById: %v", err)
+	}
+	if !user.SuperAdmin {
+		t.Errorf("UserById: got %+v, want a super-admin", user)
+	}
+
+	if _, err := service.Setup(ctx, token, "other@example.com", "other", "secret"); !errors.Is(err, bootstrap.ErrAlreadyInitialized) {
+		t.Errorf("Setup with a used token: got %v, want %v", err, bootstrap.ErrAlreadyInitialized)
+	}
+}
+
+// TestSetupAfterAnotherInstance checks that a token stops working once any instance created the super-admin.
+func TestSetupAfterAnotherInstance(t *testing.T) {
+	repository := memory.New()
+	ctx := context.Background()
+	service := newBootstrap(repository)
+
+	token, err := service.Init(ctx, "", "", "")
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	if _, err := newBootstrap(repository).Init(ctx, "root@example.com", "root", "secret"); err != nil {
+		t.Fatalf("Init of another instance: %v", err)
+	}
+
+	if _, err := service.Setup(ctx, token, "admin@example.com", "admin", "secret"); !errors.Is(err, bootstrap.ErrAlreadyInitialized) {
+		t.Errorf("Setup: got %v, want %v", err, bootstrap.ErrAlreadyInitialized)
+	}
+	if _, err := repository.User(ctx, "admin@example.com"); !errors.Is(err, storage.ErrUserNotFound) {
+		t.Errorf("User: got %v, want %v", err, storage.ErrUserNotFound)
+	}
+}
+
+// TestSetupRollsBack checks that a failed promotion leaves no user behind and keeps the token.
+func TestSetupRollsBack(t *testing.T) {
+	repository := memory.New()
+	ctx := context.Background()
+	errPromote := errors.New("promotion failed")
+	service := bootstrap.New(discardLogger(), registerer{repository}, repository,
+		failingSuperAdminStore{repository, errPromote}, repository, repository)
+
+	token, err := service.Init(ctx, "", "", "")
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	if _, err := service.Setup(ctx, token, "admin@example.com", "admin", "secret"); !errors.Is(err, errPromote) {
+		t.Fatalf("Setup: got %v, want %v", err, errPromote)
+	}
+	if _, err := repository.User(ctx, "admin@example.com"); !errors.Is(err, storage.ErrUserNotFound) {
+		t.Errorf("User: got %v, want %v", err, storage.ErrUserNotFound)
+	}
+
+	if _, err := newBootstrap(repository).Setup(ctx, token, "admin@example.com", "admin", "secret"); !errors.Is(err, bootstrap.ErrAlreadyInitialized) {
+		t.Errorf("Setup on another instance: got %v, want %v", err, bootstrap.ErrAlreadyInitialized)
+	}
+	if _, err := service.Setup(ctx, token, "admin@example.com", "admin", "secret"); !errors.Is(err, errPromote) {
+		t.Errorf("Setup retried: got %v, want the token accepted again and %v", err, errPromote)
+	}
+}
+
+func mustSaveUser(t *testing.T, repository *memory.Repository, name string) int64 {
+	t.Helper()
+	userId, err := repository.SaveUser(context.Background(), name+"@example.com", name, []byte("hash"))
+	if err != nil {
+		t.Fatalf("SaveUser: %v", err)
+	}
+	return userId
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
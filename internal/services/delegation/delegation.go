@@ -0,0 +1,374 @@
+package delegation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/storage"
+)
+
+type Delegation struct {
+	log                *slog.Logger
+	userProvider       UserProvider
+	permissionProvider PermissionProvider
+	roleMemberProvider RoleMemberProvider
+	permissionUpdater  PermissionUpdater
+	transactor         Transactor
+}
+
+type UserProvider interface {
+	UserById(ctx context.Context, userId int64) (models.User, error)
+}
+
+type PermissionProvider interface {
+	Permission(ctx context.Context, userId int64, appId int64) (string, error)
+}
+
+type RoleMemberProvider interface {
+	RoleMemberIds(ctx context.Context, appId int64, role string) ([]int64, error)
+}
+
+type PermissionUpdater interface {
+	BatchUpdatePermissions(ctx context.Context,
+		changes []models.PermissionChange,
+		dryRun bool,
+	) ([]models.PermissionChangeResult, bool, error)
+}
+
+// Transactor runs fn in a storage transaction, fn may run more than once when it is retried.
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// ChangeAuthorizer reports whether the policies of the app allow the actor to make the change.
+type ChangeAuthorizer func(ctx context.Context, change models.PermissionChange) (bool, error)
+
+var (
+	ErrUnknownRole        = errors.New("unknown role")
+	ErrMissingCapability  = errors.New("missing capability")
+	ErrInsufficientRank   = errors.New("role is above your own")
+	ErrLastOwner          = errors.New("cannot remove the last owner of the app")
+	ErrOwnerRoleProtected = errors.New("owner role can only be changed permanently")
+	ErrChangeNotAllowed   = errors.New("insufficient permissions to update user permissions")
+)
+
+// errRollback rolls back the transaction of a batch that is not applied.
+var errRollback = errors.New("batch is not applied")
+
+// New returns a new instance of Delegation service.
+func New(
+	log *slog.Logger,
+	userProvider UserProvider,
+	permissionProvider PermissionProvider,
+	roleMemberProvider RoleMemberProvider,
+	permissionUpdater PermissionUpdater,
+	transactor Transactor,
+) *Delegation {
+	return &Delegation{
+		log:                log,
+		userProvider:       userProvider,
+		permissionProvider: permissionProvider,
+		roleMemberProvider: roleMemberProvider,
+		permissionUpdater:  permissionUpdater,
+		transactor:         transactor,
+	}
+}
+
+// CheckRoleChange verifies that the actor may set the user's role in the app to role.
+//
+// Moving a user between banned and user needs the manage_users capability, any other
+// change needs manage_roles. Nobody can grant a role above their own or change the role
+// of someone ranked above them, and the last owner of an app keeps the owner role.
+// Super-admins are exempt from the capability and rank rules, so they can assign owners.
+func (d *Delegation) CheckRoleChange(
+	ctx context.Context,
+	actorId int64,
+	userId int64,
+	appId int64,
+	role string,
+) error {
+	const op = "delegation.CheckRoleChange"
+
+	log := d.log.With(
+		slog.String("op", op),
+		slog.Int64("actorId", actorId),
+		slog.Int64("userId", userId),
+		slog.Int64("appId", appId),
+		slog.String("role", role),
+	)
+
+	if err := d.checkRank(ctx, log, actorId, userId, appId, role); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return d.checkLastOwner(ctx, log, userId, appId, role)
+}
+
+// checkRank is CheckRoleChange without the last owner rule.
+func (d *Delegation) checkRank(
+	ctx context.Context,
+	log *slog.Logger,
+	actorId int64,
+	userId int64,
+	appId int64,
+	role string,
+) error {
+	newRank, ok := models.RoleRank(role)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownRole, role)
+	}
+
+	actor, err := d.userProvider.UserById(ctx, actorId)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get actor", slog.String("error", err.Error()))
+		return err
+	}
+	if actor.SuperAdmin {
+		return nil
+	}
+
+	actorRole, err := d.role(ctx, actorId, appId)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get actor role", slog.String("error", err.Error()))
+		return err
+	}
+	currentRole, err := d.role(ctx, userId, appId)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get user role", slog.String("error", err.Error()))
+		return err
+	}
+
+	actorRank, _ := models.RoleRank(actorRole)
+	currentRank, _ := models.RoleRank(currentRole)
+	userRank, _ := models.RoleRank(models.RoleUser)
+
+	capability := models.CapabilityManageRoles
+	if newRank <= userRank && currentRank <= userRank {
+		capability = models.CapabilityManageUsers
+	}
+	if !models.RoleHasCapability(actorRole, capability) {
+		log.WarnContext(ctx, "actor lacks capability", slog.String("actorRole", actorRole), slog.String("capability", capability))
+		return fmt.Errorf("%w: %s", ErrMissingCapability, capability)
+	}
+
+	if newRank > actorRank || currentRank > actorRank {
+		log.WarnContext(ctx, "role change above actor's rank", slog.String("actorRole", actorRole), slog.String("currentRole", currentRole))
+		return ErrInsufficientRank
+	}
+	return nil
+}
+
+// BatchRoleChange makes the changes the actor may make in one transaction. Every change
+// has to be allowed by authorize and by the rules of CheckRoleChange, and once they are
+// written every app an owner was removed from has to have an owner left, so a batch can
+// hand ownership over but not remove all owners. The checks run in the transaction of the
+// changes and see what concurrent transactions commit before it.
+//
+// Like BatchUpdatePermissions of the storage it returns the outcome of every change and
+// whether they were applied, which they are not on a dry run or when any change was
+// rejected or failed. Rejected changes have the reason in Err.
+func (d *Delegation) BatchRoleChange(
+	ctx context.Context,
+	actorId int64,
+	changes []models.PermissionChange,
+	dryRun bool,
+	authorize ChangeAuthorizer,
+) ([]models.PermissionChangeResult, bool, error) {
+	const op = "delegation.BatchRoleChange"
+
+	log := d.log.With(
+		slog.String("op", op),
+		slog.Int64("actorId", actorId),
+		slog.Int("changes", len(changes)),
+		slog.Bool("dryRun", dryRun),
+	)
+
+	var results []models.PermissionChangeResult
+	err := d.transactor.WithTx(ctx, func(ctx context.Context) error {
+		results = make([]models.PermissionChangeResult, len(changes))
+		rejected := false
+		reject := func(i int, err error) {
+			results[i] = models.PermissionChangeResult{
+				Change:  changes[i],
+				Outcome: models.PermissionChangeRejected,
+				Err:     err,
+			}
+			rejected = true
+		}
+
+		var accepted []models.PermissionChange
+		var acceptedIdx []int
+		for i, change := range changes {
+			allowed, err := authorize(ctx, change)
+			if err != nil {
+				return err
+			}
+			if !allowed {
+				reject(i, ErrChangeNotAllowed)
+				continue
+			}
+			if err := d.checkRank(ctx, log, actorId, change.UserId, change.AppId, change.Permission); err != nil {
+				reject(i, fmt.Errorf("%s: %w", op, err))
+				continue
+			}
+			accepted = append(accepted, change)
+			acceptedIdx = append(acceptedIdx, i)
+		}
+		if len(accepted) == 0 {
+			return errRollback
+		}
+
+		accResults, applied, err := d.permissionUpdater.BatchUpdatePermissions(ctx, accepted, false)
+		if err != nil {
+			return err
+		}
+		for i, result := range accResults {
+			results[acceptedIdx[i]] = result
+		}
+		if !applied {
+			return errRollback
+		}
+
+		// The last owner rule is checked against the state after the batch, changes that
+		// promote a new owner count.
+		demoted := map[int64][]int{}
+		for _, i := range acceptedIdx {
+			if results[i].PreviousPermission == models.RoleOwner && results[i].Change.Permission != models.RoleOwner {
+				demoted[results[i].Change.AppId] = append(demoted[results[i].Change.AppId], i)
+			}
+		}
+		for appId, idx := range demoted {
+			owners, err := d.roleMemberProvider.RoleMemberIds(ctx, appId, models.RoleOwner)
+			if err != nil {
+				log.ErrorContext(ctx, "failed to get app owners", slog.String("error", err.Error()))
+				return err
+			}
+			if len(owners) == 0 {
+				log.WarnContext(ctx, "refused to remove every owner", slog.Int64("appId", appId))
+				for _, i := range idx {
+					reject(i, fmt.Errorf("%s: %w", op, ErrLastOwner))
+				}
+			}
+		}
+
+		if rejected || dryRun {
+			return errRollback
+		}
+		return nil
+	})
+	if errors.Is(err, errRollback) {
+		return results, false, nil
+	}
+	if err != nil {
+		log.ErrorContext(ctx, "failed to change roles", slog.String("error", err.Error()))
+		return nil, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.InfoContext(ctx, "roles changed")
+	return results, true, nil
+}
+
+func (d *Delegation) checkLastOwner(ctx context.Context, log *slog.Logger, userId int64, appId int64, role string) error {
+	const op = "delegation.checkLastOwner"
+
+	if role == models.RoleOwner {
+		return nil
+	}
+	owners, err := d.roleMemberProvider.RoleMemberIds(ctx, appId, models.RoleOwner)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get app owners", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if len(owners) == 1 && owners[0] == userId {
+		log.WarnContext(ctx, "refused to remove last owner")
+		return fmt.Errorf("%s: %w", op, ErrLastOwner)
+	}
+	return nil
+}
+
+// CheckGrant verifies that the actor may give the user a time-bound role.
+// Owner is excluded because ownership has to be explicit and permanent.
+func (d *Delegation) CheckGrant(
+	ctx context.Context,
+	actorId int64,
+	userId int64,
+	appId int64,
+	role string,
+) error {
+	const op = "delegation.CheckGrant"
+
+	if role == models.RoleOwner {
+		return fmt.Errorf("%s: %w", op, ErrOwnerRoleProtected)
+	}
+
+	if err := d.CheckRoleChange(ctx, actorId, userId, appId, role); err != nil {
+		// A time-bound grant never replaces the base role, so the last owner is not affected by it.
+		if !errors.Is(err, ErrLastOwner) {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	return nil
+}
+
+// CheckInvitation verifies that the actor may invite someone to the app with role.
+// The same rules apply as for changing the role of a user who has none yet.
+func (d *Delegation) CheckInvitation(ctx context.Context, actorId int64, appId int64, role string) error {
+	const op = "delegation.CheckInvitation"
+
+	log := d.log.With(
+		slog.String("op", op),
+		slog.Int64("actorId", actorId),
+		slog.Int64("appId", appId),
+		slog.String("role", role),
+	)
+
+	newRank, ok := models.RoleRank(role)
+	if !ok {
+		return fmt.Errorf("%s: %w: %s", op, ErrUnknownRole, role)
+	}
+
+	actor, err := d.userProvider.UserById(ctx, actorId)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get actor", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if actor.SuperAdmin {
+		return nil
+	}
+
+	actorRole, err := d.role(ctx, actorId, appId)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get actor role", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	actorRank, _ := models.RoleRank(actorRole)
+	userRank, _ := models.RoleRank(models.RoleUser)
+
+	capability := models.CapabilityManageRoles
+	if newRank <= userRank {
+		capability = models.CapabilityManageUsers
+	}
+	if !models.RoleHasCapability(actorRole, capability) {
+		log.WarnContext(ctx, "actor lacks capability", slog.String("actorRole", actorRole), slog.String("capability", capability))
+		return fmt.Errorf("%s: %w: %s", op, ErrMissingCapability, capability)
+	}
+	if newRank > actorRank {
+		log.WarnContext(ctx, "invitation above actor's rank", slog.String("actorRole", actorRole))
+		return fmt.Errorf("%s: %w", op, ErrInsufficientRank)
+	}
+	return nil
+}
+
+// role returns the user's effective role, users without a permission are treated as banned.
+func (d *Delegation) role(ctx context.Context, userId int64, appId int64) (string, error) {
+	role, err := d.permissionProvider.Permission(ctx, userId, appId)
+	if err != nil {
+		if errors.Is(err, storage.ErrNoPermissionFound) {
+			return models.RoleBanned, nil
+		}
+		return "", err
+	}
+	return role, nil
+}
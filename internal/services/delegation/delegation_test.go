@@ -0,0 +1,335 @@
+package delegation_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/services/delegation"
+	"github.com/botanikn/go_sso_service/internal/storage/memory"
+)
+
+type fixture struct {
+	storage    *memory.Repository
+	delegation *delegation.Delegation
+	appId      int64
+	// ids holds the stored users by name, the users named after a role hold it in the app.
+	ids map[string]int64
+}
+
+// newFixture stores a user per role held in the app with one owner, a user without a role
+// and a super-admin without a role.
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	ctx := context.Background()
+	repository := memory.New()
+
+	f := &fixture{
+		storage:    repository,
+		delegation: delegation.New(discardLogger(), repository, repository, repository, repository, repository),
+		ids:        make(map[string]int64),
+	}
+	var err error
+	if f.appId, err = repository.SaveApp(ctx, "app", "secret"); err != nil {
+		t.Fatalf("SaveApp: %v", err)
+	}
+	for _, role := range []string{models.RoleBanned, models.RoleUser, models.RoleUserManager, models.RoleAdmin, models.RoleOwner} {
+		f.ids[role] = mustSaveUser(t, repository, role)
+		f.setRole(t, role, role)
+	}
+	f.ids["none"] = mustSaveUser(t, repository, "none")
+	f.ids["super-admin"] = mustSaveUser(t, repository, "super-admin")
+	if err := repository.SetSuperAdmin(ctx, f.ids["super-admin"], true); err != nil {
+		t.Fatalf("SetSuperAdmin: %v", err)
+	}
+	return f
+}
+
+func TestCheckRoleChange(t *testing.T) {
+	tests := []struct {
+		name    string
+		actor   string
+		user    string
+		role    string
+		wantErr error
+	}{
+		{
+			name:  "user manager bans a user",
+			actor: models.RoleUserManager,
+			user:  models.RoleUser,
+			role:  models.RoleBanned,
+		},
+		{
+			name:  "user manager unbans a user",
+			actor: models.RoleUserManager,
+			user:  models.RoleBanned,
+			role:  models.RoleUser,
+		},
+		{
+			name:    "user manager can't promote",
+			actor:   models.RoleUserManager,
+			user:    models.RoleUser,
+			role:    models.RoleImpersonator,
+			wantErr: delegation.ErrMissingCapability,
+		},
+		{
+			name:    "user can't ban",
+			actor:   models.RoleUser,
+			user:    models.RoleBanned,
+			role:    models.RoleBanned,
+			wantErr: delegation.ErrMissingCapability,
+		},
+		{
+			name:    "user without a role is treated as banned",
+			actor:   "none",
+			user:    models.RoleUser,
+			role:    models.RoleBanned,
+			wantErr: delegation.ErrMissingCapability,
+		},
+		{
+			name:  "admin promotes to their own rank",
+			actor: models.RoleAdmin,
+			user:  models.RoleUser,
+			role:  models.RoleAdmin,
+		},
+		{
+			name:    "admin can't promote above their rank",
+			actor:   models.RoleAdmin,
+			user:    models.RoleUser,
+			role:    models.RoleOwner,
+			wantErr: delegation.ErrInsufficientRank,
+		},
+		{
+			name:    "admin can't demote someone ranked above",
+			actor:   models.RoleAdmin,
+			user:    models.RoleOwner,
+			role:    models.RoleUser,
+			wantErr: delegation.ErrInsufficientRank,
+		},
+		{
+			name:    "unknown role",
+			actor:   models.RoleOwner,
+			user:    models.RoleUser,
+			role:    "root",
+			wantErr: delegation.ErrUnknownRole,
+		},
+		{
+			name:  "owner makes another owner",
+			actor: models.RoleOwner,
+			user:  models.RoleAdmin,
+			role:  models.RoleOwner,
+		},
+		{
+			name:    "last owner can't step down",
+			actor:   models.RoleOwner,
+			user:    models.RoleOwner,
+			role:    models.RoleAdmin,
+			wantErr: delegation.ErrLastOwner,
+		},
+		{
+			name:  "super-admin without a role makes an owner",
+			actor: "super-admin",
+			user:  models.RoleUser,
+			role:  models.RoleOwner,
+		},
+		{
+			name:    "super-admin can't demote the last owner",
+			actor:   "super-admin",
+			user:    models.RoleOwner,
+			role:    models.RoleUser,
+			wantErr: delegation.ErrLastOwner,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+
+			err := f.delegation.CheckRoleChange(context.Background(), f.ids[tt.actor], f.ids[tt.user], f.appId, tt.role)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckRoleChange: got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckGrant(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	if err := f.delegation.CheckGrant(ctx, f.ids["super-admin"], f.ids[models.RoleUser], f.appId, models.RoleOwner); !errors.Is(err, delegation.ErrOwnerRoleProtected) {
+		t.Errorf("CheckGrant of owner: got %v, want %v", err, delegation.ErrOwnerRoleProtected)
+	}
+	// A grant doesn't replace the base role, so the last owner may get one.
+	if err := f.delegation.CheckGrant(ctx, f.ids[models.RoleOwner], f.ids[models.RoleOwner], f.appId, models.RoleAdmin); err != nil {
+		t.Errorf("CheckGrant to the last owner: %v", err)
+	}
+	if err := f.delegation.CheckGrant(ctx, f.ids[models.RoleUserManager], f.ids[models.RoleUser], f.appId, models.RoleAdmin); !errors.Is(err, delegation.ErrMissingCapability) {
+		t.Errorf("CheckGrant above the actor: got %v, want %v", err, delegation.ErrMissingCapability)
+	}
+}
+
+func TestBatchRoleChange(t *testing.T) {
+	tests := []struct {
+		name string
+		// coOwner makes the admin a second owner before the batch.
+		coOwner bool
+		actor   string
+		changes []change
+		dryRun  bool
+		// denied is the user whose changes authorize refuses.
+		denied      string
+		wantApplied bool
+		wantErrs    []error
+	}{
+		{
+			name:  "ownership handed over",
+			actor: models.RoleOwner,
+			changes: []change{
+				{user: models.RoleOwner, role: models.RoleAdmin},
+				{user: models.RoleAdmin, role: models.RoleOwner},
+			},
+			wantApplied: true,
+			wantErrs:    []error{nil, nil},
+		},
+		{
+			name:    "batch demotes every owner",
+			coOwner: true,
+			actor:   "super-admin",
+			changes: []change{
+				{user: models.RoleOwner, role: models.RoleAdmin},
+				{user: models.RoleAdmin, role: models.RoleUser},
+				{user: models.RoleUser, role: models.RoleBanned},
+			},
+			wantErrs: []error{delegation.ErrLastOwner, delegation.ErrLastOwner, nil},
+		},
+		{
+			name:    "batch demotes one of two owners",
+			coOwner: true,
+			actor:   models.RoleOwner,
+			changes: []change{
+				{user: models.RoleAdmin, role: models.RoleUser},
+			},
+			wantApplied: true,
+			wantErrs:    []error{nil},
+		},
+		{
+			name:  "one change above the actor's rank rejects the batch",
+			actor: models.RoleAdmin,
+			changes: []change{
+				{user: models.RoleUser, role: models.RoleUserManager},
+				{user: models.RoleOwner, role: models.RoleUser},
+			},
+			wantErrs: []error{nil, delegation.ErrInsufficientRank},
+		},
+		{
+			name:   "change refused by the policies",
+			actor:  models.RoleOwner,
+			denied: models.RoleBanned,
+			changes: []change{
+				{user: models.RoleUser, role: models.RoleUserManager},
+				{user: models.RoleBanned, role: models.RoleUser},
+			},
+			wantErrs: []error{nil, delegation.ErrChangeNotAllowed},
+		},
+		{
+			name:   "dry run",
+			actor:  models.RoleOwner,
+			dryRun: true,
+			changes: []change{
+				{user: models.RoleUser, role: models.RoleUserManager},
+			},
+			wantErrs: []error{nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			ctx := context.Background()
+			if tt.coOwner {
+				f.setRole(t, models.RoleAdmin, models.RoleOwner)
+			}
+			before := f.roles(t)
+
+			changes := make([]models.PermissionChange, len(tt.changes))
+			for i, change := range tt.changes {
+				changes[i] = models.PermissionChange{UserId: f.ids[change.user], AppId: f.appId, Permission: change.role}
+			}
+			authorize := func(ctx context.Context, change models.PermissionChange) (bool, error) {
+				return tt.denied == "" || change.UserId != f.ids[tt.denied], nil
+			}
+
+			results, applied, err := f.delegation.BatchRoleChange(ctx, f.ids[tt.actor], changes, tt.dryRun, authorize)
+			if err != nil {
+				t.Fatalf("BatchRoleChange: %v", err)
+			}
+			if applied != tt.wantApplied {
+				t.Errorf("BatchRoleChange: got applied %t, want %t", applied, tt.wantApplied)
+			}
+			if len(results) != len(changes) {
+				t.Fatalf("BatchRoleChange: got %d results, want %d", len(results), len(changes))
+			}
+			for i, result := range results {
+				if !errors.Is(result.Err, tt.wantErrs[i]) {
+					t.Errorf("BatchRoleChange: got %v for change %d, want %v", result.Err, i, tt.wantErrs[i])
+				}
+				if (tt.wantErrs[i] != nil) != (result.Outcome == models.PermissionChangeRejected) {
+					t.Errorf("BatchRoleChange: got outcome %q for change %d", result.Outcome, i)
+				}
+			}
+
+			after := f.roles(t)
+			for i, change := range tt.changes {
+				want := before[change.user]
+				if tt.wantApplied {
+					want = change.role
+				}
+				if after[change.user] != want {
+					t.Errorf("Permission: got %q for change %d, want %q", after[change.user], i, want)
+				}
+			}
+		})
+	}
+}
+
+type change struct {
+	user string
+	role string
+}
+
+// setRole sets the permanent role of the named user in the app.
+func (f *fixture) setRole(t *testing.T, user string, role string) {
+	t.Helper()
+	change := models.PermissionChange{UserId: f.ids[user], AppId: f.appId, Permission: role}
+	if _, applied, err := f.storage.BatchUpdatePermissions(context.Background(), []models.PermissionChange{change}, false); err != nil || !applied {
+		t.Fatalf("BatchUpdatePermissions: applied %t, %v", applied, err)
+	}
+}
+
+// roles returns the roles the named users hold in the app.
+func (f *fixture) roles(t *testing.T) map[string]string {
+	t.Helper()
+	roles := make(map[string]string)
+	for name, userId := range f.ids {
+		role, err := f.storage.Permission(context.Background(), userId, f.appId)
+		if err == nil {
+			roles[name] = role
+		}
+	}
+	return roles
+}
+
+func mustSaveUser(t *testing.T, repository *memory.Repository, name string) int64 {
+	t.Helper()
+	userId, err := repository.SaveUser(context.Background(), name+"@example.com", name, []byte("hash"))
+	if err != nil {
+		t.Fatalf("SaveUser: %v", err)
+	}
+	return userId
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
@@ -0,0 +1,253 @@
+package grants
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+)
+
+const (
+	AuditActionGranted    = "permission.granted"
+	AuditActionBreakGlass = "permission.break_glass"
+	AuditActionExpired    = "permission.expired"
+)
+
+type Grants struct {
+	log                   *slog.Logger
+	permissionGranter     PermissionGranter
+	permissionExpirer     PermissionExpirer
+	auditSaver            AuditSaver
+	transactor            Transactor
+	breakGlassMaxDuration time.Duration
+}
+
+type PermissionGranter interface {
+	GrantPermission(ctx context.Context, grant models.PermissionGrant) (int64, error)
+}
+
+type PermissionExpirer interface {
+	DeleteExpiredPermissions(ctx context.Context, now time.Time) ([]models.PermissionGrant, error)
+}
+
+type AuditSaver interface {
+	SaveAuditEvent(ctx context.Context, event models.AuditEvent) error
+}
+
+// Transactor runs fn in a storage transaction, fn may run more than once when it is retried.
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+var (
+	ErrInvalidValidity       = errors.New("invalid validity window")
+	ErrJustificationRequired = errors.New("justification is required")
+	ErrInvalidDuration       = errors.New("invalid break-glass duration")
+)
+
+// New returns a new instance of Grants service.
+func New(
+	log *slog.Logger,
+	permissionGranter PermissionGranter,
+	permissionExpirer PermissionExpirer,
+	auditSaver AuditSaver,
+	transactor Transactor,
+	breakGlassMaxDuration time.Duration,
+) *Grants {
+	return &Grants{
+		log:                   log,
+		permissionGranter:     permissionGranter,
+		permissionExpirer:     permissionExpirer,
+		auditSaver:            auditSaver,
+		transactor:            transactor,
+		breakGlassMaxDuration: breakGlassMaxDuration,
+	}
+}
+
+// Grant gives the user a permission that is only in effect within the grant's validity window.
+func (g *Grants) Grant(ctx context.Context, actorId int64, grant models.PermissionGrant) (models.PermissionGrant, error) {
+	const op = "grants.Grant"
+
+	log := g.log.With(
+		slog.String("op", op),
+		slog.Int64("actorId", actorId),
+		slog.Int64("userId", grant.UserId),
+		slog.Int64("appId", grant.AppId),
+		slog.String("permission", grant.Permission),
+	)
+
+	log.InfoContext(ctx, "granting time-bound permission")
+
+	if err := validateValidity(grant.ValidFrom, grant.ValidUntil); err != nil {
+		log.WarnContext(ctx, "invalid grant", slog.String("error", err.Error()))
+		return models.PermissionGrant{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	grant.GrantedBy = actorId
+	grant, err := g.grant(ctx, AuditActionGranted, grant)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to grant permission", slog.String("error", err.Error()))
+		return models.PermissionGrant{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.InfoContext(ctx, "time-bound permission granted", slog.Int64("grantId", grant.ID))
+	return grant, nil
+}
+
+// BreakGlass grants a permission for a short, bounded period in an emergency.
+// The justification is mandatory and is kept both on the grant and in the audit log.
+func (g *Grants) BreakGlass(
+	ctx context.Context,
+	actorId int64,
+	userId int64,
+	appId int64,
+	permission string,
+	justification string,
+	duration time.Duration,
+) (models.PermissionGrant, error) {
+	const op = "grants.BreakGlass"
+
+	log := g.log.With(
+		slog.String("op", op),
+		slog.Int64("actorId", actorId),
+		slog.Int64("userId", userId),
+		slog.Int64("appId", appId),
+		slog.String("permission", permission),
+		slog.Duration("duration", duration),
+	)
+
+	log.WarnContext(ctx, "break-glass grant requested", slog.String("justification", justification))
+
+	if justification == "" {
+		return models.PermissionGrant{}, fmt.Errorf("%s: %w", op, ErrJustificationRequired)
+	}
+	if duration <= 0 || duration > g.breakGlassMaxDuration {
+		return models.PermissionGrant{}, fmt.Errorf("%s: %w: must be between 0 and %s", op, ErrInvalidDuration, g.breakGlassMaxDuration)
+	}
+
+	now := time.Now()
+	grant, err := g.grant(ctx, AuditActionBreakGlass, models.PermissionGrant{
+		UserId:        userId,
+		AppId:         appId,
+		Permission:    permission,
+		ValidFrom:     now,
+		ValidUntil:    now.Add(duration),
+		GrantedBy:     actorId,
+		Justification: justification,
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to grant break-glass permission", slog.String("error", err.Error()))
+		return models.PermissionGrant{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.WarnContext(ctx, "break-glass permission granted", slog.Int64("grantId", grant.ID), slog.Time("validUntil", grant.ValidUntil))
+	return grant, nil
+}
+
+// ReapExpired deletes grants that are no longer valid and records each removal in the audit log.
+func (g *Grants) ReapExpired(ctx context.Context) (int, error) {
+	const op = "grants.ReapExpired"
+
+	log := g.log.With(slog.String("op", op))
+
+	expired, err := g.permissionExpirer.DeleteExpiredPermissions(ctx, time.Now())
+	if err != nil {
+		log.ErrorContext(ctx, "failed to delete expired grants", slog.String("error", err.Error()))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, grant := range expired {
+		event := models.AuditEvent{
+			AppId:  grant.AppId,
+			UserId: grant.UserId,
+			Action: AuditActionExpired,
+			Details: map[string]any{
+				"grant_id":    grant.ID,
+				"permission":  grant.Permission,
+				"valid_until": grant.ValidUntil,
+				"granted_by":  grant.GrantedBy,
+			},
+		}
+		if err := g.auditSaver.SaveAuditEvent(ctx, event); err != nil {
+			log.ErrorContext(ctx, "failed to save audit event",
+				slog.Int64("grantId", grant.ID),
+				slog.String("error", err.Error()))
+		}
+	}
+
+	if len(expired) > 0 {
+		log.InfoContext(ctx, "expired grants removed", slog.Int("count", len(expired)))
+	}
+	return len(expired), nil
+}
+
+// RunReaper calls ReapExpired every interval until ctx is cancelled.
+func (g *Grants) RunReaper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Errors are already logged, the next tick retries.
+			_, _ = g.ReapExpired(ctx)
+		}
+	}
+}
+
+// grant saves the grant and its audit event in one transaction, so no grant is in effect
+// without a record of who gave it.
+func (g *Grants) grant(ctx context.Context, action string, grant models.PermissionGrant) (models.PermissionGrant, error) {
+	err := g.transactor.WithTx(ctx, func(ctx context.Context) error {
+		id, err := g.permissionGranter.GrantPermission(ctx, grant)
+		if err != nil {
+			return err
+		}
+		grant.ID = id
+
+		details := map[string]any{
+			"grant_id":   grant.ID,
+			"permission": grant.Permission,
+		}
+		if !grant.ValidFrom.IsZero() {
+			details["valid_from"] = grant.ValidFrom
+		}
+		if !grant.ValidUntil.IsZero() {
+			details["valid_until"] = grant.ValidUntil
+		}
+		if grant.Justification != "" {
+			details["justification"] = grant.Justification
+		}
+
+		return g.auditSaver.SaveAuditEvent(ctx, models.AuditEvent{
+			AppId:   grant.AppId,
+			ActorId: grant.GrantedBy,
+			UserId:  grant.UserId,
+			Action:  action,
+			Details: details,
+		})
+	})
+	if err != nil {
+		return models.PermissionGrant{}, err
+	}
+
+	return grant, nil
+}
+
+func validateValidity(validFrom time.Time, validUntil time.Time) error {
+	if validFrom.IsZero() && validUntil.IsZero() {
+		return fmt.Errorf("%w: valid_from or valid_until is required", ErrInvalidValidity)
+	}
+	if !validUntil.IsZero() && !validUntil.After(time.Now()) {
+		return fmt.Errorf("%w: valid_until must be in the future", ErrInvalidValidity)
+	}
+	if !validFrom.IsZero() && !validUntil.IsZero() && !validUntil.After(validFrom) {
+		return fmt.Errorf("%w: valid_until must be after valid_from", ErrInvalidValidity)
+	}
+	return nil
+}
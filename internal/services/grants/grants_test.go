@@ -0,0 +1,291 @@
+package grants_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/services/grants"
+	"github.com/botanikn/go_sso_service/internal/storage"
+	"github.com/botanikn/go_sso_service/internal/storage/memory"
+)
+
+const breakGlassMaxDuration = time.Hour
+
+type fixture struct {
+	storage *memory.Repository
+	grants  *grants.Grants
+	actorId int64
+	userId  int64
+	appId   int64
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	ctx := context.Background()
+	repository := memory.New()
+
+	f := &fixture{
+		storage: repository,
+		grants:  grants.New(discardLogger(), repository, repository, repository, repository, breakGlassMaxDuration),
+	}
+	var err error
+	if f.actorId, err = repository.SaveUser(ctx, "admin@example.com", "admin", []byte("hash")); err != nil {
+		t.Fatalf("SaveUser: %v", err)
+	}
+	if f.userId, err = repository.SaveUser(ctx, "user@example.com", "user", []byte("hash")); err != nil {
+		t.Fatalf("SaveUser: %v", err)
+	}
+	if f.appId, err = repository.SaveApp(ctx, "app", "secret"); err != nil {
+		t.Fatalf("SaveApp: %v", err)
+	}
+	return f
+}
+
+func TestGrant(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		validFrom  time.Time
+		validUntil time.Time
+		wantErr    error
+		// wantPermission is the user's permission right after the grant, empty for none.
+		wantPermission string
+	}{
+		{
+			name:    "validity window is required",
+			wantErr: grants.ErrInvalidValidity,
+		},
+		{
+			name:       "window that already ended",
+			validUntil: now.Add(-time.Minute),
+			wantErr:    grants.ErrInvalidValidity,
+		},
+		{
+			name:       "window that ends before it starts",
+			validFrom:  now.Add(2 * time.Hour),
+			validUntil: now.Add(time.Hour),
+			wantErr:    grants.ErrInvalidValidity,
+		},
+		{
+			name:           "window that started",
+			validFrom:      now.Add(-time.Minute),
+			validUntil:     now.Add(time.Hour),
+			wantPermission: models.RoleAdmin,
+		},
+		{
+			name:           "window without an end",
+			validFrom:      now.Add(-time.Minute),
+			wantPermission: models.RoleAdmin,
+		},
+		{
+			name:       "window that starts later",
+			validFrom:  now.Add(time.Hour),
+			validUntil: now.Add(2 * time.Hour),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			ctx := context.Background()
+
+			grant, err := f.grants.Grant(ctx, f.actorId, models.PermissionGrant{
+				UserId:     f.userId,
+				AppId:      f.appId,
+				Permission: models.RoleAdmin,
+				ValidFrom:  tt.validFrom,
+				ValidUntil: tt.validUntil,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Grant: got %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				expectAuditActions(t, f, nil)
+				return
+			}
+			if grant.ID == 0 || grant.GrantedBy != f.actorId {
+				t.Errorf("Grant: got %+v, want an id and granted by %d", grant, f.actorId)
+			}
+			expectPermission(t, f, tt.wantPermission)
+			expectAuditActions(t, f, []string{grants.AuditActionGranted})
+		})
+	}
+}
+
+func TestGrantMissingUser(t *testing.T) {
+	f := newFixture(t)
+
+	_, err := f.grants.Grant(context.Background(), f.actorId, models.PermissionGrant{
+		UserId:     -1,
+		AppId:      f.appId,
+		Permission: models.RoleAdmin,
+		ValidUntil: time.Now().Add(time.Hour),
+	})
+	if !errors.Is(err, storage.ErrUserNotFound) {
+		t.Errorf("Grant: got %v, want %v", err, storage.ErrUserNotFound)
+	}
+	expectAuditActions(t, f, nil)
+}
+
+// TestGrantWithoutAudit checks that a grant whose audit event can't be saved is not kept.
+func TestGrantWithoutAudit(t *testing.T) {
+	f := newFixture(t)
+	errAudit := errors.New("audit log is down")
+	service := grants.New(discardLogger(), f.storage, f.storage, failingAuditSaver{errAudit}, f.storage, breakGlassMaxDuration)
+
+	_, err := service.BreakGlass(context.Background(), f.actorId, f.userId, f.appId, models.RoleAdmin, "incident 42", time.Minute)
+	if !errors.Is(err, errAudit) {
+		t.Fatalf("BreakGlass: got %v, want %v", err, errAudit)
+	}
+	expectPermission(t, f, "")
+}
+
+func TestBreakGlass(t *testing.T) {
+	tests := []struct {
+		name          string
+		justification string
+		duration      time.Duration
+		wantErr       error
+	}{
+		{
+			name:     "justification is required",
+			duration: time.Minute,
+			wantErr:  grants.ErrJustificationRequired,
+		},
+		{
+			name:          "duration is required",
+			justification: "incident 42",
+			wantErr:       grants.ErrInvalidDuration,
+		},
+		{
+			name:          "duration above the maximum",
+			justification: "incident 42",
+			duration:      breakGlassMaxDuration + time.Minute,
+			wantErr:       grants.ErrInvalidDuration,
+		},
+		{
+			name:          "justified and short",
+			justification: "incident 42",
+			duration:      breakGlassMaxDuration,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			ctx := context.Background()
+
+			grant, err := f.grants.BreakGlass(ctx, f.actorId, f.userId, f.appId, models.RoleOwner, tt.justification, tt.duration)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("BreakGlass: got %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				expectPermission(t, f, "")
+				expectAuditActions(t, f, nil)
+				return
+			}
+			if got := grant.ValidUntil.Sub(grant.ValidFrom); got != tt.duration {
+				t.Errorf("BreakGlass: got a window of %s, want %s", got, tt.duration)
+			}
+			if grant.Justification != tt.justification {
+				t.Errorf("BreakGlass: got justification %q, want %q", grant.Justification, tt.justification)
+			}
+			expectPermission(t, f, models.RoleOwner)
+
+			events := expectAuditActions(t, f, []string{grants.AuditActionBreakGlass})
+			if got := events[0].Details["justification"]; got != tt.justification {
+				t.Errorf("audit event: got justification %v, want %q", got, tt.justification)
+			}
+		})
+	}
+}
+
+func TestReapExpired(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	for _, validUntil := range []time.Time{now.Add(-time.Hour), now.Add(-time.Minute), now.Add(time.Hour)} {
+		if _, err := f.storage.GrantPermission(ctx, models.PermissionGrant{
+			UserId:     f.userId,
+			AppId:      f.appId,
+			Permission: models.RoleAdmin,
+			ValidFrom:  now.Add(-2 * time.Hour),
+			ValidUntil: validUntil,
+			GrantedBy:  f.actorId,
+		}); err != nil {
+			t.Fatalf("GrantPermission: %v", err)
+		}
+	}
+
+	reaped, err := f.grants.ReapExpired(ctx)
+	if err != nil {
+		t.Fatalf("ReapExpired: %v", err)
+	}
+	if reaped != 2 {
+		t.Errorf("ReapExpired: got %d, want 2", reaped)
+	}
+	events := expectAuditActions(t, f, []string{grants.AuditActionExpired, grants.AuditActionExpired})
+	for _, event := range events {
+		if event.ActorId != 0 || event.UserId != f.userId {
+			t.Errorf("audit event: got %+v, want the service acting on user %d", event, f.userId)
+		}
+	}
+	expectPermission(t, f, models.RoleAdmin)
+
+	if reaped, err := f.grants.ReapExpired(ctx); err != nil || reaped != 0 {
+		t.Errorf("ReapExpired again: got %d, %v, want 0", reaped, err)
+	}
+}
+
+type failingAuditSaver struct {
+	err error
+}
+
+func (s failingAuditSaver) SaveAuditEvent(ctx context.Context, event models.AuditEvent) error {
+	return s.err
+}
+
+// expectPermission checks the user's permission in the app, empty want means none.
+func expectPermission(t *testing.T, f *fixture, want string) {
+	t.Helper()
+	got, err := f.storage.Permission(context.Background(), f.userId, f.appId)
+	if want == "" {
+		if !errors.Is(err, storage.ErrNoPermissionFound) {
+			t.Errorf("Permission: got %q, %v, want none", got, err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("Permission: %v", err)
+	}
+	if got != want {
+		t.Errorf("Permission: got %q, want %q", got, want)
+	}
+}
+
+// expectAuditActions checks the actions of the app's audit events and returns the events.
+func expectAuditActions(t *testing.T, f *fixture, want []string) []models.AuditEvent {
+	t.Helper()
+	events, err := f.storage.AuditEvents(context.Background(), models.AuditFilter{AppId: f.appId}, 0, 100)
+	if err != nil {
+		t.Fatalf("AuditEvents: %v", err)
+	}
+	if len(events) != len(want) {
+		t.Fatalf("AuditEvents: got %d events, want %v", len(events), want)
+	}
+	for i, event := range events {
+		if event.Action != want[i] {
+			t.Errorf("AuditEvents: got %q at %d, want %q", event.Action, i, want[i])
+		}
+	}
+	return events
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
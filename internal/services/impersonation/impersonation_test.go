@@ -0,0 +1,250 @@
+package impersonation_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/services/auth"
+	"github.com/botanikn/go_sso_service/internal/services/impersonation"
+	"github.com/botanikn/go_sso_service/internal/storage/memory"
+)
+
+const maxTTL = 15 * time.Minute
+
+// tokenIssuer records the tokens it is asked for instead of signing them.
+type tokenIssuer struct {
+	issued []issuedToken
+}
+
+type issuedToken struct {
+	user     models.User
+	app      models.App
+	duration time.Duration
+	extra    map[string]any
+}
+
+func (i *tokenIssuer) NewTokenWithClaims(user models.User, app models.App, duration time.Duration, extra map[string]any) (string, error) {
+	i.issued = append(i.issued, issuedToken{user: user, app: app, duration: duration, extra: extra})
+	return "token", nil
+}
+
+type failingAuditSaver struct {
+	err error
+}
+
+func (s failingAuditSaver) SaveAuditEvent(ctx context.Context, event models.AuditEvent) error {
+	return s.err
+}
+
+type fixture struct {
+	storage *memory.Repository
+	issuer  *tokenIssuer
+	service *impersonation.Impersonation
+	appId   int64
+	actorId int64
+	users   map[string]int64
+}
+
+// newFixture stores the actor, a user per role held in the app, a user without any role
+// and a super-admin.
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	ctx := context.Background()
+	repository := memory.New()
+	issuer := &tokenIssuer{}
+
+	f := &fixture{
+		storage: repository,
+		issuer:  issuer,
+		service: impersonation.New(discardLogger(), repository, repository, repository, repository, issuer, maxTTL),
+		appId:   mustSaveApp(t, repository),
+		users:   make(map[string]int64),
+	}
+	f.actorId = mustSaveUser(t, repository, "actor")
+	if _, err := repository.CreatePermission(ctx, f.actorId, f.appId, models.RoleImpersonator); err != nil {
+		t.Fatalf("CreatePermission: %v", err)
+	}
+	for _, role := range []string{models.RoleBanned, models.RoleUser, models.RoleImpersonator, models.RoleAdmin, models.RoleOwner} {
+		userId := mustSaveUser(t, repository, role)
+		if _, err := repository.CreatePermission(ctx, userId, f.appId, role); err != nil {
+			t.Fatalf("CreatePermission: %v", err)
+		}
+		f.users[role] = userId
+	}
+	f.users["none"] = mustSaveUser(t, repository, "none")
+	f.users["super-admin"] = mustSaveUser(t, repository, "super-admin")
+	if err := repository.SetSuperAdmin(ctx, f.users["super-admin"], true); err != nil {
+		t.Fatalf("SetSuperAdmin: %v", err)
+	}
+	return f
+}
+
+func TestImpersonate(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     string
+		self     bool
+		missing  bool
+		readOnly bool
+		ttl      time.Duration
+		wantErr  error
+		wantTTL  time.Duration
+	}{
+		{
+			name:    "yourself",
+			self:    true,
+			wantErr: impersonation.ErrImpersonationForbidden,
+		},
+		{
+			name:    "missing user",
+			missing: true,
+			wantErr: impersonation.ErrUserNotFound,
+		},
+		{
+			name:    "super-admin",
+			user:    "super-admin",
+			wantErr: impersonation.ErrImpersonationForbidden,
+		},
+		{
+			name:    "another impersonator",
+			user:    models.RoleImpersonator,
+			wantErr: impersonation.ErrImpersonationForbidden,
+		},
+		{
+			name:    "admin",
+			user:    models.RoleAdmin,
+			wantErr: impersonation.ErrImpersonationForbidden,
+		},
+		{
+			name:    "owner",
+			user:    models.RoleOwner,
+			wantErr: impersonation.ErrImpersonationForbidden,
+		},
+		{
+			name:    "user with the requested ttl",
+			user:    models.RoleUser,
+			ttl:     5 * time.Minute,
+			wantTTL: 5 * time.Minute,
+		},
+		{
+			name:     "user read-only",
+			user:     models.RoleUser,
+			readOnly: true,
+			ttl:      5 * time.Minute,
+			wantTTL:  5 * time.Minute,
+		},
+		{
+			name:    "banned user",
+			user:    models.RoleBanned,
+			ttl:     5 * time.Minute,
+			wantTTL: 5 * time.Minute,
+		},
+		{
+			name:    "user without a role gets the maximum ttl by default",
+			user:    "none",
+			wantTTL: maxTTL,
+		},
+		{
+			name:    "ttl above the maximum is capped",
+			user:    models.RoleUser,
+			ttl:     maxTTL + time.Hour,
+			wantTTL: maxTTL,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			ctx := context.Background()
+			userId := f.users[tt.user]
+			switch {
+			case tt.self:
+				userId = f.actorId
+			case tt.missing:
+				userId = -1
+			}
+
+			token, expiresAt, err := f.service.Impersonate(ctx, f.actorId, userId, f.appId, tt.readOnly, tt.ttl, "support ticket 7")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Impersonate: got %v, want %v", err, tt.wantErr)
+			}
+			events, err := f.storage.AuditEvents(ctx, models.AuditFilter{Action: impersonation.AuditActionImpersonated}, 0, 10)
+			if err != nil {
+				t.Fatalf("AuditEvents: %v", err)
+			}
+			if tt.wantErr != nil {
+				if len(f.issuer.issued) != 0 || len(events) != 0 {
+					t.Errorf("Impersonate: issued %d tokens and saved %d audit events, want none", len(f.issuer.issued), len(events))
+				}
+				return
+			}
+
+			if token == "" || time.Until(expiresAt) > tt.wantTTL || time.Until(expiresAt) < tt.wantTTL-time.Minute {
+				t.Errorf("Impersonate: got token %q expiring at %s, want one expiring in %s", token, expiresAt, tt.wantTTL)
+			}
+			if len(f.issuer.issued) != 1 {
+				t.Fatalf("Impersonate: issued %d tokens, want 1", len(f.issuer.issued))
+			}
+			issued := f.issuer.issued[0]
+			if issued.user.ID != strconv.FormatInt(userId, 10) || int64(issued.app.ID) != f.appId || issued.duration != tt.wantTTL {
+				t.Errorf("Impersonate: issued a token for user %s in app %d for %s, want user %d in app %d for %s",
+					issued.user.ID, issued.app.ID, issued.duration, userId, f.appId, tt.wantTTL)
+			}
+			actor, _ := issued.extra[auth.ClaimActor].(map[string]any)
+			if actor["sub"] != strconv.FormatInt(f.actorId, 10) {
+				t.Errorf("Impersonate: got act claim %v, want the actor %d", issued.extra[auth.ClaimActor], f.actorId)
+			}
+			if scope, ok := issued.extra[auth.ClaimScope]; ok != tt.readOnly || (ok && scope != auth.ScopeReadOnly) {
+				t.Errorf("Impersonate: got scope %v, want read-only %t", scope, tt.readOnly)
+			}
+
+			if len(events) != 1 || events[0].ActorId != f.actorId || events[0].UserId != userId {
+				t.Fatalf("AuditEvents: got %+v, want one impersonation of %d by %d", events, userId, f.actorId)
+			}
+			if events[0].Details["reason"] != "support ticket 7" || events[0].Details["read_only"] != tt.readOnly {
+				t.Errorf("AuditEvents: got details %v", events[0].Details)
+			}
+		})
+	}
+}
+
+// TestImpersonateWithoutAudit checks that no token is issued when the impersonation can't be audited.
+func TestImpersonateWithoutAudit(t *testing.T) {
+	f := newFixture(t)
+	errAudit := errors.New("audit log is down")
+	service := impersonation.New(discardLogger(), f.storage, f.storage, f.storage, failingAuditSaver{errAudit}, f.issuer, maxTTL)
+
+	if _, _, err := service.Impersonate(context.Background(), f.actorId, f.users[models.RoleUser], f.appId, false, 0, ""); !errors.Is(err, errAudit) {
+		t.Errorf("Impersonate: got %v, want %v", err, errAudit)
+	}
+	if len(f.issuer.issued) != 0 {
+		t.Errorf("Impersonate: issued %d tokens, want none", len(f.issuer.issued))
+	}
+}
+
+func mustSaveUser(t *testing.T, repository *memory.Repository, name string) int64 {
+	t.Helper()
+	userId, err := repository.SaveUser(context.Background(), name+"@example.com", name, []byte("hash"))
+	if err != nil {
+		t.Fatalf("SaveUser: %v", err)
+	}
+	return userId
+}
+
+func mustSaveApp(t *testing.T, repository *memory.Repository) int64 {
+	t.Helper()
+	appId, err := repository.SaveApp(context.Background(), "app", "secret")
+	if err != nil {
+		t.Fatalf("SaveApp: %v", err)
+	}
+	return appId
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
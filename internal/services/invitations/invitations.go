@@ -0,0 +1,381 @@
+package invitations
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/storage"
+	"github.com/botanikn/go_sso_service/pkg/pagination"
+)
+
+const (
+	AuditActionInvitationCreated  = "invitation.created"
+	AuditActionInvitationRevoked  = "invitation.revoked"
+	AuditActionInvitationAccepted = "invitation.accepted"
+
+	defaultPageSize = 50
+	maxPageSize     = 500
+	tokenSize       = 32
+)
+
+type Invitations struct {
+	log             *slog.Logger
+	invitationStore InvitationStore
+	userProvider    UserProvider
+	userRegisterer  UserRegisterer
+	auditSaver      AuditSaver
+	transactor      Transactor
+	sender          Sender
+	ttl             time.Duration
+}
+
+type InvitationStore interface {
+	SaveInvitation(ctx context.Context, invitation models.Invitation, tokenHash []byte) (models.Invitation, error)
+	InvitationByTokenHash(ctx context.Context, tokenHash []byte) (models.Invitation, error)
+	Invitations(ctx context.Context, appId int64, pendingOnly bool, afterId int64, limit int) ([]models.Invitation, error)
+	RevokeInvitation(ctx context.Context, appId int64, invitationId int64) (models.Invitation, error)
+	AcceptInvitation(ctx context.Context, invitationId int64, userId int64) (models.PermissionChangeResult, error)
+}
+
+type UserProvider interface {
+	User(ctx context.Context, email string) (models.User, error)
+}
+
+type UserRegisterer interface {
+	Register(ctx context.Context, email string, username string, password string) (int64, error)
+}
+
+type AuditSaver interface {
+	SaveAuditEvent(ctx context.Context, event models.AuditEvent) error
+}
+
+// Transactor runs fn in a storage transaction, fn may run more than once when it is retried.
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Sender delivers invitation tokens to invitees.
+type Sender interface {
+	SendInvitation(ctx context.Context, invitation models.Invitation, token string) error
+}
+
+var (
+	ErrInvalidInvitation  = errors.New("invalid invitation")
+	ErrInvitationNotFound = errors.New("invitation not found or no longer pending")
+	ErrInvitationExpired  = errors.New("invitation has expired")
+	ErrAppNotFound        = errors.New("app not found")
+	ErrUserDisabled       = errors.New("user is disabled")
+	ErrPasswordRequired   = errors.New("password is required to register")
+	ErrInvalidPageToken   = errors.New("invalid page token")
+)
+
+// New returns a new instance of Invitations service. Without a sender invitations
+// are not emailed and the token returned by Invite has to be delivered by the caller.
+func New(
+	log *slog.Logger,
+	invitationStore InvitationStore,
+	userProvider UserProvider,
+	userRegisterer UserRegisterer,
+	auditSaver AuditSaver,
+	transactor Transactor,
+	sender Sender,
+	ttl time.Duration,
+) *Invitations {
+	return &Invitations{
+		log:             log,
+		invitationStore: invitationStore,
+		userProvider:    userProvider,
+		userRegisterer:  userRegisterer,
+		auditSaver:      auditSaver,
+		transactor:      transactor,
+		sender:          sender,
+		ttl:             ttl,
+	}
+}
+
+// Invite creates an invitation to join the app with role and emails it to the invitee.
+// It returns the invitation and its token, which is not stored and can't be retrieved later.
+func (i *Invitations) Invite(
+	ctx context.Context,
+	actorId int64,
+	appId int64,
+	email string,
+	role string,
+) (models.Invitation, string, error) {
+	const op = "invitations.Invite"
+
+	log := i.log.With(
+		slog.String("op", op),
+		slog.Int64("actorId", actorId),
+		slog.Int64("appId", appId),
+		slog.String("role", role),
+	)
+
+	email = strings.TrimSpace(email)
+	if err := validateInvitation(email, role); err != nil {
+		return models.Invitation{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.InfoContext(ctx, "creating invitation")
+
+	token, tokenHash, err := generateToken()
+	if err != nil {
+		log.ErrorContext(ctx, "failed to generate token", slog.String("error", err.Error()))
+		return models.Invitation{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	invitation, err := i.invitationStore.SaveInvitation(ctx, models.Invitation{
+		AppId:     appId,
+		Email:     email,
+		Role:      role,
+		InvitedBy: actorId,
+		ExpiresAt: time.Now().Add(i.ttl),
+	}, tokenHash)
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			log.WarnContext(ctx, "app not found", slog.String("error", err.Error()))
+			return models.Invitation{}, "", fmt.Errorf("%s: %w", op, ErrAppNotFound)
+		}
+		log.ErrorContext(ctx, "failed to save invitation", slog.String("error", err.Error()))
+		return models.Invitation{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	i.audit(ctx, log, models.AuditEvent{
+		AppId:   appId,
+		ActorId: actorId,
+		Action:  AuditActionInvitationCreated,
+		Details: map[string]any{"invitation_id": invitation.ID, "email": email, "role": role},
+	})
+
+	// The invitation stands even if the email fails, the caller still gets the token.
+	if i.sender != nil {
+		if err := i.sender.SendInvitation(ctx, invitation, token); err != nil {
+			log.ErrorContext(ctx, "failed to send invitation", slog.String("error", err.Error()))
+		}
+	}
+
+	log.InfoContext(ctx, "invitation created", slog.Int64("invitationId", invitation.ID))
+	return invitation, token, nil
+}
+
+// ListInvitations returns a page of the app's invitations and the token of the next page,
+// which is empty on the last page.
+func (i *Invitations) ListInvitations(
+	ctx context.Context,
+	appId int64,
+	pendingOnly bool,
+	pageSize int,
+	pageToken string,
+) ([]models.Invitation, string, error) {
+	const op = "invitations.ListInvitations"
+
+	log := i.log.With(
+		slog.String("op", op),
+		slog.Int64("appId", appId),
+	)
+
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	afterId, err := pagination.DecodeToken(pageToken)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, ErrInvalidPageToken)
+	}
+
+	// One extra row tells whether there is a next page.
+	invitations, err := i.invitationStore.Invitations(ctx, appId, pendingOnly, afterId, pageSize+1)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to list invitations", slog.String("error", err.Error()))
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	nextPageToken := ""
+	if len(invitations) > pageSize {
+		invitations = invitations[:pageSize]
+		nextPageToken = pagination.EncodeToken(invitations[len(invitations)-1].ID)
+	}
+
+	return invitations, nextPageToken, nil
+}
+
+// RevokeInvitation revokes a pending invitation of the app, its token stops working.
+func (i *Invitations) RevokeInvitation(ctx context.Context, actorId int64, appId int64, invitationId int64) error {
+	const op = "invitations.RevokeInvitation"
+
+	log := i.log.With(
+		slog.String("op", op),
+		slog.Int64("actorId", actorId),
+		slog.Int64("appId", appId),
+		slog.Int64("invitationId", invitationId),
+	)
+
+	log.InfoContext(ctx, "revoking invitation")
+
+	if _, err := i.invitationStore.RevokeInvitation(ctx, appId, invitationId); err != nil {
+		if errors.Is(err, storage.ErrInvitationNotFound) {
+			log.WarnContext(ctx, "invitation not found", slog.String("error", err.Error()))
+			return fmt.Errorf("%s: %w", op, ErrInvitationNotFound)
+		}
+		log.ErrorContext(ctx, "failed to revoke invitation", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	i.audit(ctx, log, models.AuditEvent{
+		AppId:   appId,
+		ActorId: actorId,
+		Action:  AuditActionInvitationRevoked,
+		Details: map[string]any{"invitation_id": invitationId},
+	})
+
+	log.InfoContext(ctx, "invitation revoked")
+	return nil
+}
+
+// AcceptInvitation redeems the invitation token. The user with the invited email joins the
+// app with the invited role, a new user is registered first with username and password.
+// It returns the accepted invitation and the resulting permission change.
+func (i *Invitations) AcceptInvitation(
+	ctx context.Context,
+	token string,
+	username string,
+	password string,
+) (models.Invitation, models.PermissionChangeResult, error) {
+	const op = "invitations.AcceptInvitation"
+
+	log := i.log.With(slog.String("op", op))
+
+	invitation, err := i.invitationStore.InvitationByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, storage.ErrInvitationNotFound) {
+			log.WarnContext(ctx, "invitation not found")
+			return models.Invitation{}, models.PermissionChangeResult{}, fmt.Errorf("%s: %w", op, ErrInvitationNotFound)
+		}
+		log.ErrorContext(ctx, "failed to get invitation", slog.String("error", err.Error()))
+		return models.Invitation{}, models.PermissionChangeResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(
+		slog.Int64("invitationId", invitation.ID),
+		slog.Int64("appId", invitation.AppId),
+	)
+
+	switch invitation.Status(time.Now()) {
+	case models.InvitationPending:
+	case models.InvitationExpired:
+		log.WarnContext(ctx, "invitation has expired")
+		return models.Invitation{}, models.PermissionChangeResult{}, fmt.Errorf("%s: %w", op, ErrInvitationExpired)
+	default:
+		log.WarnContext(ctx, "invitation is no longer pending")
+		return models.Invitation{}, models.PermissionChangeResult{}, fmt.Errorf("%s: %w", op, ErrInvitationNotFound)
+	}
+
+	// A new user is registered in the same transaction, so they don't stay behind when
+	// the invitation turns out to be used or revoked concurrently.
+	var userId int64
+	var result models.PermissionChangeResult
+	err = i.transactor.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		userId, err = i.invitee(ctx, invitation.Email, username, password)
+		if err != nil {
+			log.WarnContext(ctx, "failed to resolve invitee", slog.String("error", err.Error()))
+			return err
+		}
+		result, err = i.invitationStore.AcceptInvitation(ctx, invitation.ID, userId)
+		if err != nil {
+			if errors.Is(err, storage.ErrInvitationNotFound) {
+				log.WarnContext(ctx, "invitation was used or revoked concurrently", slog.String("error", err.Error()))
+				return ErrInvitationNotFound
+			}
+			log.ErrorContext(ctx, "failed to accept invitation", slog.String("error", err.Error()))
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Invitation{}, models.PermissionChangeResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+	invitation.AcceptedBy = userId
+	invitation.AcceptedAt = time.Now()
+
+	i.audit(ctx, log, models.AuditEvent{
+		AppId:   invitation.AppId,
+		ActorId: userId,
+		UserId:  userId,
+		Action:  AuditActionInvitationAccepted,
+		Details: map[string]any{
+			"invitation_id": invitation.ID,
+			"role":          invitation.Role,
+			"outcome":       result.Outcome,
+		},
+	})
+
+	log.InfoContext(ctx, "invitation accepted", slog.Int64("userId", userId), slog.String("outcome", result.Outcome))
+	return invitation, result, nil
+}
+
+// invitee returns the id of the user with email, registering them when they don't exist.
+func (i *Invitations) invitee(ctx context.Context, email string, username string, password string) (int64, error) {
+	user, err := i.userProvider.User(ctx, email)
+	if err == nil {
+		if user.Status == models.UserStatusDisabled || !user.DeletedAt.IsZero() {
+			return 0, ErrUserDisabled
+		}
+		return strconv.ParseInt(user.ID, 10, 64)
+	}
+	if !errors.Is(err, storage.ErrUserNotFound) {
+		return 0, err
+	}
+
+	if password == "" {
+		return 0, ErrPasswordRequired
+	}
+	if username == "" {
+		username, _, _ = strings.Cut(email, "@")
+	}
+	return i.userRegisterer.Register(ctx, email, username, password)
+}
+
+func (i *Invitations) audit(ctx context.Context, log *slog.Logger, event models.AuditEvent) {
+	if err := i.auditSaver.SaveAuditEvent(ctx, event); err != nil {
+		log.ErrorContext(ctx, "failed to save audit event", slog.String("error", err.Error()))
+	}
+}
+
+func validateInvitation(email string, role string) error {
+	if local, domain, ok := strings.Cut(email, "@"); !ok || local == "" || domain == "" {
+		return fmt.Errorf("%w: invalid email %q", ErrInvalidInvitation, email)
+	}
+	if _, ok := models.RoleRank(role); !ok || role == models.RoleBanned || role == models.RolePending {
+		return fmt.Errorf("%w: cannot invite with role %q", ErrInvalidInvitation, role)
+	}
+	return nil
+}
+
+func generateToken() (string, []byte, error) {
+	buf := make([]byte, tokenSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, hashToken(token), nil
+}
+
+// hashToken is enough for tokens with 256 bits of entropy, unlike passwords they
+// can't be guessed, so a slow hash would add nothing.
+func hashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
@@ -0,0 +1,151 @@
+package members
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/pkg/pagination"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+	exportBatchSize = 500
+)
+
+type Members struct {
+	log            *slog.Logger
+	memberProvider MemberProvider
+}
+
+type MemberProvider interface {
+	AppMembers(ctx context.Context, appId int64, role string, afterUserId int64, limit int) ([]models.AppMember, error)
+}
+
+var (
+	ErrUnknownRole      = errors.New("unknown role")
+	ErrInvalidPageToken = errors.New("invalid page token")
+)
+
+// New returns a new instance of Members service.
+func New(
+	log *slog.Logger,
+	memberProvider MemberProvider,
+) *Members {
+	return &Members{
+		log:            log,
+		memberProvider: memberProvider,
+	}
+}
+
+// ListMembers returns a page of the app's members, optionally only those with role,
+// and the token of the next page, which is empty on the last page.
+func (m *Members) ListMembers(
+	ctx context.Context,
+	appId int64,
+	role string,
+	pageSize int,
+	pageToken string,
+) ([]models.AppMember, string, error) {
+	const op = "members.ListMembers"
+
+	log := m.log.With(
+		slog.String("op", op),
+		slog.Int64("appId", appId),
+		slog.String("role", role),
+	)
+
+	if err := validateRole(role); err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	afterUserId, err := pagination.DecodeToken(pageToken)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, ErrInvalidPageToken)
+	}
+
+	// One extra row tells whether there is a next page.
+	members, err := m.memberProvider.AppMembers(ctx, appId, role, afterUserId, pageSize+1)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to list app members", slog.String("error", err.Error()))
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	nextPageToken := ""
+	if len(members) > pageSize {
+		members = members[:pageSize]
+		nextPageToken = pagination.EncodeToken(members[len(members)-1].UserId)
+	}
+
+	return members, nextPageToken, nil
+}
+
+// ExportMembers calls send for every member of the app, optionally only those with role.
+// Members are read in batches, so the whole membership is never held in memory.
+// Export stops at the first error returned by send.
+func (m *Members) ExportMembers(
+	ctx context.Context,
+	appId int64,
+	role string,
+	send func(models.AppMember) error,
+) error {
+	const op = "members.ExportMembers"
+
+	log := m.log.With(
+		slog.String("op", op),
+		slog.Int64("appId", appId),
+		slog.String("role", role),
+	)
+
+	if err := validateRole(role); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.InfoContext(ctx, "exporting app members")
+
+	var afterUserId int64
+	exported := 0
+	for {
+		members, err := m.memberProvider.AppMembers(ctx, appId, role, afterUserId, exportBatchSize)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to list app members", slog.String("error", err.Error()))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		for _, member := range members {
+			if err := send(member); err != nil {
+				log.WarnContext(ctx, "export interrupted", slog.Int("exported", exported), slog.String("error", err.Error()))
+				return fmt.Errorf("%s: %w", op, err)
+			}
+			exported++
+		}
+
+		if len(members) < exportBatchSize {
+			break
+		}
+		afterUserId = members[len(members)-1].UserId
+	}
+
+	log.InfoContext(ctx, "app members exported", slog.Int("count", exported))
+	return nil
+}
+
+func validateRole(role string) error {
+	if role == "" {
+		return nil
+	}
+	if _, ok := models.RoleRank(role); !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownRole, role)
+	}
+	return nil
+}
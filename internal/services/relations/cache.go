@@ -0,0 +1,88 @@
+package relations
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// cache is a small TTL cache for check results and namespace configs.
+// Check results are keyed by revision, so tuple writes never have to invalidate them,
+// namespace writes drop the entries of the app.
+type cache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	maxSize int
+	items   map[string]cacheItem
+}
+
+type cacheItem struct {
+	value     any
+	expiresAt time.Time
+}
+
+func newCache(ttl time.Duration, maxSize int) *cache {
+	return &cache{
+		ttl:     ttl,
+		maxSize: maxSize,
+		items:   make(map[string]cacheItem),
+	}
+}
+
+func (c *cache) get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(item.expiresAt) {
+		delete(c.items, key)
+		return nil, false
+	}
+	return item.value, true
+}
+
+func (c *cache) set(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.items) >= c.maxSize {
+		c.evictExpired()
+	}
+	// Still full: drop everything rather than tracking recency,
+	// entries are cheap to recompute.
+	if len(c.items) >= c.maxSize {
+		c.items = make(map[string]cacheItem)
+	}
+
+	c.items[key] = cacheItem{value: value, expiresAt: time.Now().Add(c.ttl)}
+}
+
+func (c *cache) delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.items, key)
+}
+
+func (c *cache) deletePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.items, key)
+		}
+	}
+}
+
+func (c *cache) evictExpired() {
+	now := time.Now()
+	for key, item := range c.items {
+		if now.After(item.expiresAt) {
+			delete(c.items, key)
+		}
+	}
+}
@@ -0,0 +1,660 @@
+package relations
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/storage"
+)
+
+const (
+	// maxDepth bounds rewrite recursion, which also protects against cyclic configs.
+	maxDepth = 25
+	// revisionQuantum is how long a fetched revision is reused for requests without
+	// a consistency token, so that concurrent checks can share cache entries.
+	revisionQuantum = time.Second
+	cacheTTL        = time.Minute
+	cacheSize       = 10000
+
+	OperationUnion        = "union"
+	OperationIntersection = "intersection"
+	OperationExclusion    = "exclusion"
+	OperationLeaf         = "leaf"
+)
+
+type Relations struct {
+	log               *slog.Logger
+	namespaceProvider NamespaceProvider
+	namespaceWriter   NamespaceWriter
+	tupleProvider     TupleProvider
+	tupleWriter       TupleWriter
+	cache             *cache
+
+	mu              sync.Mutex
+	revision        int64
+	revisionFetched time.Time
+}
+
+type NamespaceProvider interface {
+	Namespace(ctx context.Context, appId int64, name string) (models.Namespace, error)
+}
+
+type NamespaceWriter interface {
+	SaveNamespace(ctx context.Context, namespace models.Namespace) error
+}
+
+type TupleProvider interface {
+	RelationTuples(ctx context.Context, appId int64, filter models.RelationTupleFilter, revision int64) ([]models.RelationTuple, error)
+	RelationRevision(ctx context.Context) (int64, error)
+}
+
+type TupleWriter interface {
+	WriteRelationTuples(ctx context.Context, appId int64, inserts []models.RelationTuple, deletes []models.RelationTuple) (int64, error)
+}
+
+var (
+	ErrNamespaceNotFound       = errors.New("namespace not found")
+	ErrUnknownRelation         = errors.New("unknown relation")
+	ErrInvalidTuple            = errors.New("invalid relation tuple")
+	ErrInvalidConsistencyToken = errors.New("invalid consistency token")
+	ErrMaxDepthExceeded        = errors.New("max rewrite depth exceeded")
+	ErrInvalidNamespaceConfig  = errors.New("invalid namespace config")
+)
+
+// Tree is the result of Expand. Relation nodes carry the userset they expand,
+// leaves carry the subjects stored directly in tuples.
+type Tree struct {
+	Operation string
+	Namespace string
+	ObjectId  string
+	Relation  string
+	Subjects  []models.Subject
+	Children  []Tree
+}
+
+// New returns a new instance of Relations service.
+func New(
+	log *slog.Logger,
+	namespaceProvider NamespaceProvider,
+	namespaceWriter NamespaceWriter,
+	tupleProvider TupleProvider,
+	tupleWriter TupleWriter,
+) *Relations {
+	return &Relations{
+		log:               log,
+		namespaceProvider: namespaceProvider,
+		namespaceWriter:   namespaceWriter,
+		tupleProvider:     tupleProvider,
+		tupleWriter:       tupleWriter,
+		cache:             newCache(cacheTTL, cacheSize),
+	}
+}
+
+// Check tells whether subject has relation to the object, directly or through rewrite rules.
+func (r *Relations) Check(
+	ctx context.Context,
+	appId int64,
+	object models.Subject,
+	subject models.Subject,
+	consistencyToken string,
+) (bool, string, error) {
+	const op = "relations.Check"
+
+	log := r.log.With(
+		slog.String("op", op),
+		slog.Int64("appId", appId),
+		slog.String("object", formatSubject(object)),
+		slog.String("subject", formatSubject(subject)),
+	)
+
+	revision, err := r.snapshot(ctx, consistencyToken)
+	if err != nil {
+		log.WarnContext(ctx, "failed to pick snapshot", slog.String("error", err.Error()))
+		return false, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	allowed, err := r.check(ctx, appId, revision, object, subject, 0)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to check relation", slog.String("error", err.Error()))
+		return false, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.DebugContext(ctx, "relation checked", slog.Bool("allowed", allowed), slog.Int64("revision", revision))
+	return allowed, encodeToken(revision), nil
+}
+
+// Expand returns the tree of subjects that have relation to the object.
+func (r *Relations) Expand(
+	ctx context.Context,
+	appId int64,
+	object models.Subject,
+	consistencyToken string,
+) (Tree, string, error) {
+	const op = "relations.Expand"
+
+	log := r.log.With(
+		slog.String("op", op),
+		slog.Int64("appId", appId),
+		slog.String("object", formatSubject(object)),
+	)
+
+	revision, err := r.snapshot(ctx, consistencyToken)
+	if err != nil {
+		log.WarnContext(ctx, "failed to pick snapshot", slog.String("error", err.Error()))
+		return Tree{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	tree, err := r.expand(ctx, appId, revision, object, 0)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to expand relation", slog.String("error", err.Error()))
+		return Tree{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tree, encodeToken(revision), nil
+}
+
+// Write validates tuples against namespace configs and stores them under a new revision.
+func (r *Relations) Write(
+	ctx context.Context,
+	appId int64,
+	inserts []models.RelationTuple,
+	deletes []models.RelationTuple,
+) (string, error) {
+	const op = "relations.Write"
+
+	log := r.log.With(
+		slog.String("op", op),
+		slog.Int64("appId", appId),
+		slog.Int("inserts", len(inserts)),
+		slog.Int("deletes", len(deletes)),
+	)
+
+	log.InfoContext(ctx, "writing relation tuples")
+
+	for _, tuple := range append(append([]models.RelationTuple{}, inserts...), deletes...) {
+		if err := r.validateTuple(ctx, appId, tuple); err != nil {
+			log.WarnContext(ctx, "invalid relation tuple", slog.String("error", err.Error()))
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	revision, err := r.tupleWriter.WriteRelationTuples(ctx, appId, inserts, deletes)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to write relation tuples", slog.String("error", err.Error()))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	r.observeRevision(revision)
+
+	log.InfoContext(ctx, "relation tuples written", slog.Int64("revision", revision))
+	return encodeToken(revision), nil
+}
+
+// WriteNamespace validates config and creates or replaces the namespace with it. The cached
+// config and check results of the app are dropped, other instances of the service pick the
+// new config up once their entries expire.
+func (r *Relations) WriteNamespace(
+	ctx context.Context,
+	appId int64,
+	name string,
+	config models.NamespaceConfig,
+) error {
+	const op = "relations.WriteNamespace"
+
+	log := r.log.With(
+		slog.String("op", op),
+		slog.Int64("appId", appId),
+		slog.String("namespace", name),
+	)
+
+	log.InfoContext(ctx, "writing namespace")
+
+	if err := validateNamespaceConfig(config); err != nil {
+		log.WarnContext(ctx, "invalid namespace config", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err := r.namespaceWriter.SaveNamespace(ctx, models.Namespace{AppId: appId, Name: name, Config: config})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to save namespace", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	r.cache.delete(fmt.Sprintf("namespace|%d|%s", appId, name))
+	r.cache.deletePrefix(fmt.Sprintf("check|%d|", appId))
+
+	log.InfoContext(ctx, "namespace written")
+	return nil
+}
+
+// Read returns stored tuples matching the filter without applying rewrite rules.
+func (r *Relations) Read(
+	ctx context.Context,
+	appId int64,
+	filter models.RelationTupleFilter,
+	consistencyToken string,
+) ([]models.RelationTuple, string, error) {
+	const op = "relations.Read"
+
+	log := r.log.With(
+		slog.String("op", op),
+		slog.Int64("appId", appId),
+	)
+
+	revision, err := r.snapshot(ctx, consistencyToken)
+	if err != nil {
+		log.WarnContext(ctx, "failed to pick snapshot", slog.String("error", err.Error()))
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	tuples, err := r.tupleProvider.RelationTuples(ctx, appId, filter, revision)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to read relation tuples", slog.String("error", err.Error()))
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tuples, encodeToken(revision), nil
+}
+
+func (r *Relations) check(
+	ctx context.Context,
+	appId int64,
+	revision int64,
+	object models.Subject,
+	subject models.Subject,
+	depth int,
+) (bool, error) {
+	if depth > maxDepth {
+		return false, ErrMaxDepthExceeded
+	}
+
+	key := fmt.Sprintf("check|%d|%d|%s|%s", appId, revision, formatSubject(object), formatSubject(subject))
+	if cached, ok := r.cache.get(key); ok {
+		return cached.(bool), nil
+	}
+
+	rewrite, err := r.rewrite(ctx, appId, object.Namespace, object.Relation)
+	if err != nil {
+		return false, err
+	}
+
+	allowed, err := r.checkRewrite(ctx, appId, revision, object, rewrite, subject, depth)
+	if err != nil {
+		return false, err
+	}
+
+	r.cache.set(key, allowed)
+	return allowed, nil
+}
+
+func (r *Relations) checkRewrite(
+	ctx context.Context,
+	appId int64,
+	revision int64,
+	object models.Subject,
+	rewrite models.Rewrite,
+	subject models.Subject,
+	depth int,
+) (bool, error) {
+	switch {
+	case rewrite.ComputedUserset != nil:
+		computed := object
+		computed.Relation = rewrite.ComputedUserset.Relation
+		return r.check(ctx, appId, revision, computed, subject, depth+1)
+
+	case rewrite.TupleToUserset != nil:
+		tupleset, err := r.tupleProvider.RelationTuples(ctx, appId, models.RelationTupleFilter{
+			Namespace: object.Namespace,
+			ObjectId:  object.ObjectId,
+			Relation:  rewrite.TupleToUserset.Tupleset,
+		}, revision)
+		if err != nil {
+			return false, err
+		}
+		for _, tuple := range tupleset {
+			related := models.Subject{
+				Namespace: tuple.Subject.Namespace,
+				ObjectId:  tuple.Subject.ObjectId,
+				Relation:  rewrite.TupleToUserset.ComputedUserset,
+			}
+			allowed, err := r.check(ctx, appId, revision, related, subject, depth+1)
+			if err != nil || allowed {
+				return allowed, err
+			}
+		}
+		return false, nil
+
+	case len(rewrite.Union) > 0:
+		for _, child := range rewrite.Union {
+			allowed, err := r.checkRewrite(ctx, appId, revision, object, child, subject, depth)
+			if err != nil || allowed {
+				return allowed, err
+			}
+		}
+		return false, nil
+
+	case len(rewrite.Intersection) > 0:
+		for _, child := range rewrite.Intersection {
+			allowed, err := r.checkRewrite(ctx, appId, revision, object, child, subject, depth)
+			if err != nil || !allowed {
+				return false, err
+			}
+		}
+		return true, nil
+
+	case rewrite.Exclusion != nil:
+		allowed, err := r.checkRewrite(ctx, appId, revision, object, rewrite.Exclusion.Base, subject, depth)
+		if err != nil || !allowed {
+			return false, err
+		}
+		excluded, err := r.checkRewrite(ctx, appId, revision, object, rewrite.Exclusion.Subtract, subject, depth)
+		if err != nil {
+			return false, err
+		}
+		return !excluded, nil
+
+	default:
+		tuples, err := r.tupleProvider.RelationTuples(ctx, appId, models.RelationTupleFilter{
+			Namespace: object.Namespace,
+			ObjectId:  object.ObjectId,
+			Relation:  object.Relation,
+		}, revision)
+		if err != nil {
+			return false, err
+		}
+		for _, tuple := range tuples {
+			if tuple.Subject == subject {
+				return true, nil
+			}
+			if tuple.Subject.Relation == "" {
+				continue
+			}
+			allowed, err := r.check(ctx, appId, revision, tuple.Subject, subject, depth+1)
+			if err != nil || allowed {
+				return allowed, err
+			}
+		}
+		return false, nil
+	}
+}
+
+func (r *Relations) expand(
+	ctx context.Context,
+	appId int64,
+	revision int64,
+	object models.Subject,
+	depth int,
+) (Tree, error) {
+	if depth > maxDepth {
+		return Tree{}, ErrMaxDepthExceeded
+	}
+
+	rewrite, err := r.rewrite(ctx, appId, object.Namespace, object.Relation)
+	if err != nil {
+		return Tree{}, err
+	}
+
+	tree, err := r.expandRewrite(ctx, appId, revision, object, rewrite, depth)
+	if err != nil {
+		return Tree{}, err
+	}
+	tree.Namespace = object.Namespace
+	tree.ObjectId = object.ObjectId
+	tree.Relation = object.Relation
+	return tree, nil
+}
+
+func (r *Relations) expandRewrite(
+	ctx context.Context,
+	appId int64,
+	revision int64,
+	object models.Subject,
+	rewrite models.Rewrite,
+	depth int,
+) (Tree, error) {
+	expandChildren := func(operation string, rewrites []models.Rewrite) (Tree, error) {
+		tree := Tree{Operation: operation}
+		for _, child := range rewrites {
+			childTree, err := r.expandRewrite(ctx, appId, revision, object, child, depth)
+			if err != nil {
+				return Tree{}, err
+			}
+			tree.Children = append(tree.Children, childTree)
+		}
+		return tree, nil
+	}
+
+	switch {
+	case rewrite.ComputedUserset != nil:
+		computed := object
+		computed.Relation = rewrite.ComputedUserset.Relation
+		return r.expand(ctx, appId, revision, computed, depth+1)
+
+	case rewrite.TupleToUserset != nil:
+		tupleset, err := r.tupleProvider.RelationTuples(ctx, appId, models.RelationTupleFilter{
+			Namespace: object.Namespace,
+			ObjectId:  object.ObjectId,
+			Relation:  rewrite.TupleToUserset.Tupleset,
+		}, revision)
+		if err != nil {
+			return Tree{}, err
+		}
+		tree := Tree{Operation: OperationUnion}
+		for _, tuple := range tupleset {
+			related := models.Subject{
+				Namespace: tuple.Subject.Namespace,
+				ObjectId:  tuple.Subject.ObjectId,
+				Relation:  rewrite.TupleToUserset.ComputedUserset,
+			}
+			childTree, err := r.expand(ctx, appId, revision, related, depth+1)
+			if err != nil {
+				return Tree{}, err
+			}
+			tree.Children = append(tree.Children, childTree)
+		}
+		return tree, nil
+
+	case len(rewrite.Union) > 0:
+		return expandChildren(OperationUnion, rewrite.Union)
+
+	case len(rewrite.Intersection) > 0:
+		return expandChildren(OperationIntersection, rewrite.Intersection)
+
+	case rewrite.Exclusion != nil:
+		return expandChildren(OperationExclusion, []models.Rewrite{rewrite.Exclusion.Base, rewrite.Exclusion.Subtract})
+
+	default:
+		tuples, err := r.tupleProvider.RelationTuples(ctx, appId, models.RelationTupleFilter{
+			Namespace: object.Namespace,
+			ObjectId:  object.ObjectId,
+			Relation:  object.Relation,
+		}, revision)
+		if err != nil {
+			return Tree{}, err
+		}
+		tree := Tree{Operation: OperationLeaf}
+		for _, tuple := range tuples {
+			tree.Subjects = append(tree.Subjects, tuple.Subject)
+		}
+		return tree, nil
+	}
+}
+
+func (r *Relations) rewrite(ctx context.Context, appId int64, namespaceName string, relation string) (models.Rewrite, error) {
+	key := fmt.Sprintf("namespace|%d|%s", appId, namespaceName)
+
+	var namespace models.Namespace
+	if cached, ok := r.cache.get(key); ok {
+		namespace = cached.(models.Namespace)
+	} else {
+		var err error
+		namespace, err = r.namespaceProvider.Namespace(ctx, appId, namespaceName)
+		if err != nil {
+			if errors.Is(err, storage.ErrNamespaceNotFound) {
+				return models.Rewrite{}, fmt.Errorf("%w: %s", ErrNamespaceNotFound, namespaceName)
+			}
+			return models.Rewrite{}, err
+		}
+		r.cache.set(key, namespace)
+	}
+
+	rewrite, ok := namespace.Config.Relations[relation]
+	if !ok {
+		return models.Rewrite{}, fmt.Errorf("%w: %s#%s", ErrUnknownRelation, namespaceName, relation)
+	}
+	return rewrite, nil
+}
+
+func (r *Relations) validateTuple(ctx context.Context, appId int64, tuple models.RelationTuple) error {
+	if tuple.Namespace == "" || tuple.ObjectId == "" || tuple.Relation == "" {
+		return fmt.Errorf("%w: namespace, object_id and relation are required", ErrInvalidTuple)
+	}
+	if tuple.Subject.Namespace == "" || tuple.Subject.ObjectId == "" {
+		return fmt.Errorf("%w: subject namespace and object_id are required", ErrInvalidTuple)
+	}
+	_, err := r.rewrite(ctx, appId, tuple.Namespace, tuple.Relation)
+	return err
+}
+
+// validateNamespaceConfig checks that every rewrite sets at most one field and that the
+// relations it computes from are declared in the same config.
+func validateNamespaceConfig(config models.NamespaceConfig) error {
+	if len(config.Relations) == 0 {
+		return fmt.Errorf("%w: at least one relation is required", ErrInvalidNamespaceConfig)
+	}
+	for relation, rewrite := range config.Relations {
+		if relation == "" {
+			return fmt.Errorf("%w: relation name is required", ErrInvalidNamespaceConfig)
+		}
+		if err := validateRewrite(config, relation, rewrite); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateRewrite(config models.NamespaceConfig, relation string, rewrite models.Rewrite) error {
+	set := 0
+	for _, ok := range []bool{
+		rewrite.This != nil,
+		rewrite.ComputedUserset != nil,
+		rewrite.TupleToUserset != nil,
+		len(rewrite.Union) > 0,
+		len(rewrite.Intersection) > 0,
+		rewrite.Exclusion != nil,
+	} {
+		if ok {
+			set++
+		}
+	}
+	if set > 1 {
+		return fmt.Errorf("%w: %s: a rewrite sets more than one field", ErrInvalidNamespaceConfig, relation)
+	}
+
+	declared := func(name string) error {
+		if _, ok := config.Relations[name]; !ok {
+			return fmt.Errorf("%w: %s: relation %q is not declared", ErrInvalidNamespaceConfig, relation, name)
+		}
+		return nil
+	}
+
+	switch {
+	case rewrite.ComputedUserset != nil:
+		return declared(rewrite.ComputedUserset.Relation)
+
+	case rewrite.TupleToUserset != nil:
+		// The computed userset is a relation of the related objects, which may be
+		// in another namespace, so only the tupleset can be checked here.
+		if rewrite.TupleToUserset.ComputedUserset == "" {
+			return fmt.Errorf("%w: %s: tuple_to_userset needs a computed_userset", ErrInvalidNamespaceConfig, relation)
+		}
+		return declared(rewrite.TupleToUserset.Tupleset)
+
+	case len(rewrite.Union) > 0 || len(rewrite.Intersection) > 0:
+		for _, child := range append(append([]models.Rewrite{}, rewrite.Union...), rewrite.Intersection...) {
+			if err := validateRewrite(config, relation, child); err != nil {
+				return err
+			}
+		}
+
+	case rewrite.Exclusion != nil:
+		if err := validateRewrite(config, relation, rewrite.Exclusion.Base); err != nil {
+			return err
+		}
+		return validateRewrite(config, relation, rewrite.Exclusion.Subtract)
+	}
+	return nil
+}
+
+// snapshot picks the revision to evaluate at. Without a token a recently fetched
+// revision is reused, with a token the revision is at least as new as the token.
+func (r *Relations) snapshot(ctx context.Context, consistencyToken string) (int64, error) {
+	var atLeast int64
+	if consistencyToken != "" {
+		var err error
+		atLeast, err = decodeToken(consistencyToken)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	r.mu.Lock()
+	revision, fetched := r.revision, r.revisionFetched
+	r.mu.Unlock()
+
+	if time.Since(fetched) < revisionQuantum && revision >= atLeast {
+		return revision, nil
+	}
+
+	revision, err := r.tupleProvider.RelationRevision(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if revision < atLeast {
+		return 0, ErrInvalidConsistencyToken
+	}
+	r.observeRevision(revision)
+
+	return revision, nil
+}
+
+func (r *Relations) observeRevision(revision int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if revision >= r.revision {
+		r.revision = revision
+		r.revisionFetched = time.Now()
+	}
+}
+
+const tokenPrefix = "rev:"
+
+func encodeToken(revision int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(tokenPrefix + strconv.FormatInt(revision, 10)))
+}
+
+func decodeToken(token string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || !strings.HasPrefix(string(raw), tokenPrefix) {
+		return 0, ErrInvalidConsistencyToken
+	}
+	revision, err := strconv.ParseInt(strings.TrimPrefix(string(raw), tokenPrefix), 10, 64)
+	if err != nil || revision < 0 {
+		return 0, ErrInvalidConsistencyToken
+	}
+	return revision, nil
+}
+
+func formatSubject(subject models.Subject) string {
+	if subject.Relation == "" {
+		return subject.Namespace + ":" + subject.ObjectId
+	}
+	return subject.Namespace + ":" + subject.ObjectId + "#" + subject.Relation
+}
@@ -0,0 +1,419 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/storage"
+	"github.com/botanikn/go_sso_service/pkg/pagination"
+)
+
+const (
+	AuditActionUserUpdated  = "user.updated"
+	AuditActionUserDisabled = "user.disabled"
+	AuditActionUserEnabled  = "user.enabled"
+	AuditActionUserDeleted  = "user.deleted"
+	AuditActionUserRevoked  = "user.sessions_revoked"
+
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+type Users struct {
+	log          *slog.Logger
+	userProvider UserProvider
+	userUpdater  UserUpdater
+	auditSaver   AuditSaver
+	roleProvider RoleProvider
+	adminAppId   int64
+}
+
+type UserProvider interface {
+	UserById(ctx context.Context, userId int64) (models.User, error)
+	Users(ctx context.Context, filter models.UserFilter, afterId int64, limit int) ([]models.User, error)
+}
+
+type UserUpdater interface {
+	UpdateUser(ctx context.Context, userId int64, email string, username string) (models.User, error)
+	SetUserStatus(ctx context.Context, userId int64, status string) error
+	DeleteUser(ctx context.Context, userId int64) error
+	RevokeUserTokens(ctx context.Context, userId int64) (time.Time, error)
+}
+
+type AuditSaver interface {
+	SaveAuditEvent(ctx context.Context, event models.AuditEvent) error
+}
+
+// RoleProvider tells the roles of users, which decide whom an administrator may manage.
+type RoleProvider interface {
+	Permission(ctx context.Context, userId int64, appId int64) (string, error)
+	UserPermissions(ctx context.Context, userId int64) ([]models.PermissionGrant, error)
+	SoleOwnedAppIds(ctx context.Context, userId int64) ([]int64, error)
+}
+
+var (
+	ErrUserNotFound     = errors.New("user not found")
+	ErrUserExists       = errors.New("user with this email or username already exists")
+	ErrInvalidStatus    = errors.New("invalid user status")
+	ErrInvalidPageToken = errors.New("invalid page token")
+	ErrSelfAction       = errors.New("cannot disable or delete yourself")
+	ErrSuperAdmin       = errors.New("only super-admins can manage super-admins")
+	ErrInsufficientRank = errors.New("user has a role above your own")
+	ErrSoleOwner        = errors.New("user is the only owner of an app")
+)
+
+// New returns a new instance of Users service. Administrators manage users with the
+// role they have in the admin app.
+func New(
+	log *slog.Logger,
+	userProvider UserProvider,
+	userUpdater UserUpdater,
+	auditSaver AuditSaver,
+	roleProvider RoleProvider,
+	adminAppId int64,
+) *Users {
+	return &Users{
+		log:          log,
+		userProvider: userProvider,
+		userUpdater:  userUpdater,
+		auditSaver:   auditSaver,
+		roleProvider: roleProvider,
+		adminAppId:   adminAppId,
+	}
+}
+
+// ListUsers returns a page of users matching the filter and the token of the next page,
+// which is empty on the last page.
+func (u *Users) ListUsers(
+	ctx context.Context,
+	filter models.UserFilter,
+	pageSize int,
+	pageToken string,
+) ([]models.User, string, error) {
+	const op = "users.ListUsers"
+
+	log := u.log.With(slog.String("op", op))
+
+	if filter.Status != "" && filter.Status != models.UserStatusActive && filter.Status != models.UserStatusDisabled {
+		return nil, "", fmt.Errorf("%s: %w: %s", op, ErrInvalidStatus, filter.Status)
+	}
+
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	afterId, err := pagination.DecodeToken(pageToken)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, ErrInvalidPageToken)
+	}
+
+	// One extra row tells whether there is a next page.
+	users, err := u.userProvider.Users(ctx, filter, afterId, pageSize+1)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to list users", slog.String("error", err.Error()))
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	nextPageToken := ""
+	if len(users) > pageSize {
+		users = users[:pageSize]
+		lastId, err := strconv.ParseInt(users[len(users)-1].ID, 10, 64)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to parse user ID", slog.String("error", err.Error()))
+			return nil, "", fmt.Errorf("%s: %w", op, err)
+		}
+		nextPageToken = pagination.EncodeToken(lastId)
+	}
+
+	return users, nextPageToken, nil
+}
+
+func (u *Users) User(ctx context.Context, userId int64) (models.User, error) {
+	const op = "users.User"
+
+	user, err := u.userProvider.UserById(ctx, userId)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return models.User{}, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+		u.log.ErrorContext(ctx, "failed to get user", slog.String("op", op), slog.String("error", err.Error()))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return user, nil
+}
+
+// UpdateUser changes the user's email and username, empty values keep the current ones.
+func (u *Users) UpdateUser(
+	ctx context.Context,
+	actorId int64,
+	userId int64,
+	email string,
+	username string,
+) (models.User, error) {
+	const op = "users.UpdateUser"
+
+	log := u.log.With(
+		slog.String("op", op),
+		slog.Int64("actorId", actorId),
+		slog.Int64("userId", userId),
+	)
+
+	log.InfoContext(ctx, "updating user")
+
+	if err := u.checkTarget(ctx, log, actorId, userId); err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := u.userUpdater.UpdateUser(ctx, userId, email, username)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.WarnContext(ctx, "user not found", slog.String("error", err.Error()))
+			return models.User{}, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+		if errors.Is(err, storage.ErrUserExists) {
+			log.WarnContext(ctx, "email or username is taken", slog.String("error", err.Error()))
+			var conflict *storage.ConflictError
+			if errors.As(err, &conflict) {
+				return models.User{}, fmt.Errorf("%s: %w", op, &storage.ConflictError{Field: conflict.Field, Err: ErrUserExists})
+			}
+			return models.User{}, fmt.Errorf("%s: %w", op, ErrUserExists)
+		}
+		log.ErrorContext(ctx, "failed to update user", slog.String("error", err.Error()))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	details := map[string]any{}
+	if email != "" {
+		details["email"] = email
+	}
+	if username != "" {
+		details["username"] = username
+	}
+	u.audit(ctx, log, models.AuditEvent{
+		ActorId: actorId,
+		UserId:  userId,
+		Action:  AuditActionUserUpdated,
+		Details: details,
+	})
+
+	log.InfoContext(ctx, "user updated")
+	return user, nil
+}
+
+// SetDisabled disables or enables the user's account. Disabled users can't log in
+// and their tokens are rejected.
+func (u *Users) SetDisabled(
+	ctx context.Context,
+	actorId int64,
+	userId int64,
+	disabled bool,
+	reason string,
+) error {
+	const op = "users.SetDisabled"
+
+	log := u.log.With(
+		slog.String("op", op),
+		slog.Int64("actorId", actorId),
+		slog.Int64("userId", userId),
+		slog.Bool("disabled", disabled),
+	)
+
+	log.InfoContext(ctx, "changing user status")
+
+	if disabled && actorId == userId {
+		return fmt.Errorf("%s: %w", op, ErrSelfAction)
+	}
+	if err := u.checkTarget(ctx, log, actorId, userId); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	status, action := models.UserStatusActive, AuditActionUserEnabled
+	if disabled {
+		status, action = models.UserStatusDisabled, AuditActionUserDisabled
+	}
+
+	if err := u.userUpdater.SetUserStatus(ctx, userId, status); err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.WarnContext(ctx, "user not found", slog.String("error", err.Error()))
+			return fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+		log.ErrorContext(ctx, "failed to change user status", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	details := map[string]any{}
+	if reason != "" {
+		details["reason"] = reason
+	}
+	u.audit(ctx, log, models.AuditEvent{
+		ActorId: actorId,
+		UserId:  userId,
+		Action:  action,
+		Details: details,
+	})
+
+	log.InfoContext(ctx, "user status changed", slog.String("status", status))
+	return nil
+}
+
+// RevokeSessions invalidates every token issued to the user so far, the user has to log in again.
+func (u *Users) RevokeSessions(ctx context.Context, actorId int64, userId int64) (time.Time, error) {
+	const op = "users.RevokeSessions"
+
+	log := u.log.With(
+		slog.String("op", op),
+		slog.Int64("actorId", actorId),
+		slog.Int64("userId", userId),
+	)
+
+	log.InfoContext(ctx, "revoking user sessions")
+
+	if err := u.checkTarget(ctx, log, actorId, userId); err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	revokedAt, err := u.userUpdater.RevokeUserTokens(ctx, userId)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.WarnContext(ctx, "user not found", slog.String("error", err.Error()))
+			return time.Time{}, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+		log.ErrorContext(ctx, "failed to revoke user sessions", slog.String("error", err.Error()))
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	u.audit(ctx, log, models.AuditEvent{
+		ActorId: actorId,
+		UserId:  userId,
+		Action:  AuditActionUserRevoked,
+		Details: map[string]any{"revoked_at": revokedAt},
+	})
+
+	log.InfoContext(ctx, "user sessions revoked")
+	return revokedAt, nil
+}
+
+// DeleteUser removes the user's account together with their permissions.
+func (u *Users) DeleteUser(ctx context.Context, actorId int64, userId int64) error {
+	const op = "users.DeleteUser"
+
+	log := u.log.With(
+		slog.String("op", op),
+		slog.Int64("actorId", actorId),
+		slog.Int64("userId", userId),
+	)
+
+	log.InfoContext(ctx, "deleting user")
+
+	if actorId == userId {
+		return fmt.Errorf("%s: %w", op, ErrSelfAction)
+	}
+	if err := u.checkTarget(ctx, log, actorId, userId); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Deleting the only owner would leave the app without anyone able to manage it.
+	appIds, err := u.roleProvider.SoleOwnedAppIds(ctx, userId)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get owned apps", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if len(appIds) > 0 {
+		log.WarnContext(ctx, "refused to delete the only owner of apps", slog.Any("appIds", appIds))
+		return fmt.Errorf("%s: %w: transfer ownership of apps %v first", op, ErrSoleOwner, appIds)
+	}
+
+	if err := u.userUpdater.DeleteUser(ctx, userId); err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.WarnContext(ctx, "user not found", slog.String("error", err.Error()))
+			return fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+		log.ErrorContext(ctx, "failed to delete user", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The user row is gone, so the event only references it in details.
+	u.audit(ctx, log, models.AuditEvent{
+		ActorId: actorId,
+		Action:  AuditActionUserDeleted,
+		Details: map[string]any{"user_id": userId},
+	})
+
+	log.InfoContext(ctx, "user deleted")
+	return nil
+}
+
+// checkTarget verifies that the actor may manage the user. Only super-admins manage
+// super-admins, and nobody manages a user who has a role above the actor's role in the
+// admin app in any app, the way nobody changes the role of someone ranked above them.
+// Super-admins and the service itself, actor 0, may manage everyone.
+func (u *Users) checkTarget(ctx context.Context, log *slog.Logger, actorId int64, userId int64) error {
+	if actorId == 0 {
+		return nil
+	}
+	actor, err := u.userProvider.UserById(ctx, actorId)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get actor", slog.String("error", err.Error()))
+		return err
+	}
+	if actor.SuperAdmin {
+		return nil
+	}
+
+	user, err := u.userProvider.UserById(ctx, userId)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return ErrUserNotFound
+		}
+		log.ErrorContext(ctx, "failed to get user", slog.String("error", err.Error()))
+		return err
+	}
+	if user.SuperAdmin {
+		log.WarnContext(ctx, "refused to manage a super-admin")
+		return ErrSuperAdmin
+	}
+
+	actorRole, err := u.roleProvider.Permission(ctx, actorId, u.adminAppId)
+	if err != nil && !errors.Is(err, storage.ErrNoPermissionFound) {
+		log.ErrorContext(ctx, "failed to get actor role", slog.String("error", err.Error()))
+		return err
+	}
+	actorRank, _ := models.RoleRank(actorRole)
+
+	permissions, err := u.roleProvider.UserPermissions(ctx, userId)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get user roles", slog.String("error", err.Error()))
+		return err
+	}
+	now := time.Now()
+	for _, permission := range permissions {
+		if !permission.ValidFrom.IsZero() && permission.ValidFrom.After(now) {
+			continue
+		}
+		if !permission.ValidUntil.IsZero() && !permission.ValidUntil.After(now) {
+			continue
+		}
+		if rank, _ := models.RoleRank(permission.Permission); rank > actorRank {
+			log.WarnContext(ctx, "user ranked above actor",
+				slog.String("actorRole", actorRole),
+				slog.Int64("appId", permission.AppId),
+				slog.String("userRole", permission.Permission))
+			return ErrInsufficientRank
+		}
+	}
+	return nil
+}
+
+func (u *Users) audit(ctx context.Context, log *slog.Logger, event models.AuditEvent) {
+	if err := u.auditSaver.SaveAuditEvent(ctx, event); err != nil {
+		log.ErrorContext(ctx, "failed to save audit event", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,306 @@
+package users_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/services/users"
+	"github.com/botanikn/go_sso_service/internal/storage"
+	"github.com/botanikn/go_sso_service/internal/storage/memory"
+)
+
+type fixture struct {
+	storage    *memory.Repository
+	users      *users.Users
+	adminAppId int64
+	appId      int64
+	// ids holds the stored users by name.
+	ids map[string]int64
+}
+
+// newFixture stores a user manager and an admin of the admin app, a super-admin, and
+// users holding a role in another app: a user, a user manager, an admin, an owner and
+// a user whose admin grant expired.
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	ctx := context.Background()
+	repository := memory.New()
+
+	f := &fixture{
+		storage:    repository,
+		adminAppId: mustSaveApp(t, repository, "admin"),
+		appId:      mustSaveApp(t, repository, "app"),
+		ids:        make(map[string]int64),
+	}
+	f.users = users.New(discardLogger(), repository, repository, repository, repository, f.adminAppId)
+
+	for name, role := range map[string]string{"manager": models.RoleUserManager, "administrator": models.RoleAdmin} {
+		f.ids[name] = mustSaveUser(t, repository, name)
+		mustCreatePermission(t, repository, f.ids[name], f.adminAppId, role)
+	}
+	for _, role := range []string{models.RoleUser, models.RoleUserManager, models.RoleAdmin, models.RoleOwner} {
+		f.ids[role] = mustSaveUser(t, repository, role)
+		mustCreatePermission(t, repository, f.ids[role], f.appId, role)
+	}
+
+	f.ids["super-admin"] = mustSaveUser(t, repository, "super-admin")
+	if err := repository.SetSuperAdmin(ctx, f.ids["super-admin"], true); err != nil {
+		t.Fatalf("SetSuperAdmin: %v", err)
+	}
+
+	f.ids["expired"] = mustSaveUser(t, repository, "expired")
+	if _, err := repository.GrantPermission(ctx, models.PermissionGrant{
+		UserId:     f.ids["expired"],
+		AppId:      f.appId,
+		Permission: models.RoleOwner,
+		ValidFrom:  time.Now().Add(-2 * time.Hour),
+		ValidUntil: time.Now().Add(-time.Hour),
+	}); err != nil {
+		t.Fatalf("GrantPermission: %v", err)
+	}
+	return f
+}
+
+func TestSetDisabled(t *testing.T) {
+	tests := []struct {
+		name    string
+		actor   string
+		user    string
+		wantErr error
+	}{
+		{
+			name:  "manager disables a user",
+			actor: "manager",
+			user:  models.RoleUser,
+		},
+		{
+			name:  "manager disables a user manager",
+			actor: "manager",
+			user:  models.RoleUserManager,
+		},
+		{
+			name:    "manager can't disable an admin",
+			actor:   "manager",
+			user:    models.RoleAdmin,
+			wantErr: users.ErrInsufficientRank,
+		},
+		{
+			name:    "admin can't disable an owner",
+			actor:   "administrator",
+			user:    models.RoleOwner,
+			wantErr: users.ErrInsufficientRank,
+		},
+		{
+			name:  "expired grants don't rank the user",
+			actor: "manager",
+			user:  "expired",
+		},
+		{
+			name:    "admin can't disable a super-admin",
+			actor:   "administrator",
+			user:    "super-admin",
+			wantErr: users.ErrSuperAdmin,
+		},
+		{
+			name:  "super-admin disables an owner",
+			actor: "super-admin",
+			user:  models.RoleOwner,
+		},
+		{
+			name:  "service disables a super-admin",
+			actor: "service",
+			user:  "super-admin",
+		},
+		{
+			name:    "nobody disables themselves",
+			actor:   "administrator",
+			user:    "administrator",
+			wantErr: users.ErrSelfAction,
+		},
+		{
+			name:    "missing user",
+			actor:   "administrator",
+			user:    "missing",
+			wantErr: users.ErrUserNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			ctx := context.Background()
+			actorId, userId := f.id(tt.actor), f.id(tt.user)
+
+			err := f.users.SetDisabled(ctx, actorId, userId, true, "policy violation")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SetDisabled: got %v, want %v", err, tt.wantErr)
+			}
+			events, err := f.storage.AuditEvents(ctx, models.AuditFilter{Action: users.AuditActionUserDisabled}, 0, 10)
+			if err != nil {
+				t.Fatalf("AuditEvents: %v", err)
+			}
+			if tt.wantErr != nil {
+				if len(events) != 0 {
+					t.Errorf("AuditEvents: got %+v, want none", events)
+				}
+				if user, err := f.storage.UserById(ctx, userId); err == nil && user.Status == models.UserStatusDisabled {
+					t.Errorf("UserById: user %d was disabled", userId)
+				}
+				return
+			}
+
+			user, err := f.storage.UserById(ctx, userId)
+			if err != nil {
+				t.Fatalf("UserById: %v", err)
+			}
+			if user.Status != models.UserStatusDisabled {
+				t.Errorf("UserById: got status %q, want %q", user.Status, models.UserStatusDisabled)
+			}
+			if len(events) != 1 || events[0].ActorId != actorId || events[0].UserId != userId {
+				t.Errorf("AuditEvents: got %+v, want one disabling of %d by %d", events, userId, actorId)
+			}
+		})
+	}
+}
+
+// TestCheckTargetGuardsEveryAction checks that the rank rules apply to every action on a user,
+// not only to disabling.
+func TestCheckTargetGuardsEveryAction(t *testing.T) {
+	actions := map[string]func(f *fixture, actorId, userId int64) error{
+		"UpdateUser": func(f *fixture, actorId, userId int64) error {
+			_, err := f.users.UpdateUser(context.Background(), actorId, userId, "renamed@example.com", "")
+			return err
+		},
+		"RevokeSessions": func(f *fixture, actorId, userId int64) error {
+			_, err := f.users.RevokeSessions(context.Background(), actorId, userId)
+			return err
+		},
+		"DeleteUser": func(f *fixture, actorId, userId int64) error {
+			return f.users.DeleteUser(context.Background(), actorId, userId)
+		},
+	}
+	for name, action := range actions {
+		t.Run(name, func(t *testing.T) {
+			f := newFixture(t)
+
+			if err := action(f, f.ids["manager"], f.ids[models.RoleAdmin]); !errors.Is(err, users.ErrInsufficientRank) {
+				t.Errorf("%s on an admin: got %v, want %v", name, err, users.ErrInsufficientRank)
+			}
+			if err := action(f, f.ids["administrator"], f.ids["super-admin"]); !errors.Is(err, users.ErrSuperAdmin) {
+				t.Errorf("%s on a super-admin: got %v, want %v", name, err, users.ErrSuperAdmin)
+			}
+			if err := action(f, f.ids["manager"], f.ids[models.RoleUser]); err != nil {
+				t.Errorf("%s on a user: %v", name, err)
+			}
+		})
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    string
+		coOwner bool
+		wantErr error
+	}{
+		{
+			name: "user",
+			user: models.RoleUser,
+		},
+		{
+			name:    "sole owner",
+			user:    models.RoleOwner,
+			wantErr: users.ErrSoleOwner,
+		},
+		{
+			name:    "owner with a co-owner",
+			user:    models.RoleOwner,
+			coOwner: true,
+		},
+		{
+			name:    "super-admin deletes themselves",
+			user:    "super-admin",
+			wantErr: users.ErrSelfAction,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			ctx := context.Background()
+			actorId, userId := f.ids["super-admin"], f.id(tt.user)
+			if tt.coOwner {
+				coOwnerId := mustSaveUser(t, f.storage, "co-owner")
+				mustCreatePermission(t, f.storage, coOwnerId, f.appId, models.RoleOwner)
+			}
+
+			err := f.users.DeleteUser(ctx, actorId, userId)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DeleteUser: got %v, want %v", err, tt.wantErr)
+			}
+			_, err = f.storage.UserById(ctx, userId)
+			if tt.wantErr != nil {
+				if err != nil {
+					t.Errorf("UserById: got %v, want the user kept", err)
+				}
+				return
+			}
+			if !errors.Is(err, storage.ErrUserNotFound) {
+				t.Errorf("UserById: got %v, want %v", err, storage.ErrUserNotFound)
+			}
+
+			events, err := f.storage.AuditEvents(ctx, models.AuditFilter{Action: users.AuditActionUserDeleted}, 0, 10)
+			if err != nil {
+				t.Fatalf("AuditEvents: %v", err)
+			}
+			// Details are decoded from JSON, so numbers come back as float64.
+			if len(events) != 1 || events[0].ActorId != actorId || events[0].Details["user_id"] != float64(userId) {
+				t.Errorf("AuditEvents: got %+v, want one deletion of %d by %d", events, userId, actorId)
+			}
+		})
+	}
+}
+
+// id returns the id of the named user, 0 for the service and -1 for a user that doesn't exist.
+func (f *fixture) id(name string) int64 {
+	switch name {
+	case "service":
+		return 0
+	case "missing":
+		return -1
+	default:
+		return f.ids[name]
+	}
+}
+
+func mustSaveUser(t *testing.T, repository *memory.Repository, name string) int64 {
+	t.Helper()
+	userId, err := repository.SaveUser(context.Background(), name+"@example.com", name, []byte("hash"))
+	if err != nil {
+		t.Fatalf("SaveUser: %v", err)
+	}
+	return userId
+}
+
+func mustSaveApp(t *testing.T, repository *memory.Repository, name string) int64 {
+	t.Helper()
+	appId, err := repository.SaveApp(context.Background(), name, name+"-secret")
+	if err != nil {
+		t.Fatalf("SaveApp: %v", err)
+	}
+	return appId
+}
+
+func mustCreatePermission(t *testing.T, repository *memory.Repository, userId int64, appId int64, role string) {
+	t.Helper()
+	if _, err := repository.CreatePermission(context.Background(), userId, appId, role); err != nil {
+		t.Fatalf("CreatePermission: %v", err)
+	}
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
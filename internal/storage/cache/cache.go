@@ -0,0 +1,229 @@
+// Package cache is a read-through cache of apps and permissions in front of a storage
+// backend. Token validation reads both on every call and they rarely change.
+//
+// Writes made through the service drop the cached value. A permission expires no later
+// than the time-bound grant in effect ends or the next one starts. Anything else, such as
+// a user being deleted, shows up once the cached value expires, so the TTL bounds how
+// stale a value can get.
+//
+// App secrets are cached encrypted the way the SQL backends store them, a cache shared
+// with other services only holds them in plaintext when the database does too.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/storage"
+	"github.com/botanikn/go_sso_service/pkg/envelope"
+)
+
+// Cache stores values by key until they expire. Errors are not fatal to callers,
+// a value that can't be read or written is loaded from the backend instead.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// Store is the backend the cache reads through to. It is read with storage.WithPrimary,
+// a value cached from a lagging replica would outlive an invalidation.
+type Store interface {
+	App(ctx context.Context, appId int64) (models.App, error)
+	// PermissionUntil is the permission and the time it may change next, zero for never.
+	PermissionUntil(ctx context.Context, userId int64, appId int64) (string, time.Time, error)
+}
+
+// Repository caches App and Permission of a Store. Errors, including not found ones,
+// are not cached.
+type Repository struct {
+	log     *slog.Logger
+	store   Store
+	cache   Cache
+	keyring *envelope.Keyring
+	ttl     time.Duration
+	metrics *Metrics
+}
+
+// cachedApp is an app as it is cached, with the secret sealed by storage.SealSecret.
+type cachedApp struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Secret     string `json:"secret"`
+	KeyVersion int64  `json:"key_version"`
+}
+
+// New returns a Repository reading through to store. App secrets are cached encrypted
+// with keyring, which may be nil when the database keeps them in plaintext. metrics may be nil.
+func New(log *slog.Logger, store Store, cache Cache, keyring *envelope.Keyring, ttl time.Duration, metrics *Metrics) *Repository {
+	return &Repository{
+		log:     log,
+		store:   store,
+		cache:   cache,
+		keyring: keyring,
+		ttl:     ttl,
+		metrics: metrics,
+	}
+}
+
+func (r *Repository) App(ctx context.Context, appId int64) (models.App, error) {
+	key := appKey(appId)
+	if value, ok := r.get(ctx, kindApp, key); ok {
+		if app, err := r.openApp(appId, value); err == nil {
+			return app, nil
+		}
+		r.log.WarnContext(ctx, "dropping undecodable cached app", slog.Int64("appId", appId))
+	}
+
+	app, err := r.store.App(storage.WithPrimary(ctx), appId)
+	if err != nil {
+		return models.App{}, err
+	}
+	if value, err := r.sealApp(appId, app); err == nil {
+		r.set(ctx, key, value)
+	} else {
+		r.log.WarnContext(ctx, "failed to encrypt app for the cache", slog.Int64("appId", appId), slog.String("error", err.Error()))
+	}
+	return app, nil
+}
+
+func (r *Repository) Permission(ctx context.Context, userId int64, appId int64) (string, error) {
+	key := permissionKey(userId, appId)
+	if value, ok := r.get(ctx, kindPermission, key); ok {
+		return string(value), nil
+	}
+
+	permission, until, err := r.store.PermissionUntil(storage.WithPrimary(ctx), userId, appId)
+	if err != nil {
+		return "", err
+	}
+	ttl := r.ttl
+	if !until.IsZero() {
+		// A grant that ends must not outlive its validity in the cache.
+		ttl = min(ttl, time.Until(until))
+	}
+	r.setTTL(ctx, key, []byte(permission), ttl)
+	return permission, nil
+}
+
+// InvalidateApp drops the cached app, call it after the app changes.
+func (r *Repository) InvalidateApp(ctx context.Context, appId int64) {
+	r.delete(ctx, appKey(appId))
+}
+
+// InvalidatePermission drops the cached permission of the user in the app, call it
+// after the permission changes.
+func (r *Repository) InvalidatePermission(ctx context.Context, userId int64, appId int64) {
+	r.delete(ctx, permissionKey(userId, appId))
+}
+
+func (r *Repository) sealApp(appId int64, app models.App) ([]byte, error) {
+	secret, keyVersion, err := storage.SealSecret(r.keyring, appId, app.Secret)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(cachedApp{ID: app.ID, Name: app.Name, Secret: secret, KeyVersion: keyVersion})
+}
+
+func (r *Repository) openApp(appId int64, value []byte) (models.App, error) {
+	var cached cachedApp
+	if err := json.Unmarshal(value, &cached); err != nil {
+		return models.App{}, err
+	}
+	secret, err := storage.OpenSecret(r.keyring, appId, cached.Secret, cached.KeyVersion)
+	if err != nil {
+		return models.App{}, err
+	}
+	return models.App{ID: cached.ID, Name: cached.Name, Secret: secret}, nil
+}
+
+type txKey struct{}
+
+// txKeys collects the keys invalidated in a transaction.
+type txKeys struct {
+	mu   sync.Mutex
+	keys []string
+}
+
+// WithTx runs fn in the transaction withTx starts. Reads in it skip the cache, they
+// have to see what the transaction itself wrote. Keys invalidated in it are invalidated
+// again once it has ended, a value cached by someone else before the commit would be stale.
+func (r *Repository) WithTx(
+	ctx context.Context,
+	withTx func(ctx context.Context, fn func(ctx context.Context) error) error,
+	fn func(ctx context.Context) error,
+) error {
+	if _, ok := ctx.Value(txKey{}).(*txKeys); ok {
+		return withTx(ctx, fn)
+	}
+
+	tx := &txKeys{}
+	err := withTx(context.WithValue(ctx, txKey{}, tx), fn)
+	tx.mu.Lock()
+	keys := slices.Clone(tx.keys)
+	tx.mu.Unlock()
+	// The request may be canceled by now, the stale values have to go regardless.
+	r.delete(context.WithoutCancel(ctx), keys...)
+	return err
+}
+
+func (r *Repository) get(ctx context.Context, kind string, key string) ([]byte, bool) {
+	if _, ok := ctx.Value(txKey{}).(*txKeys); ok {
+		return nil, false
+	}
+
+	value, ok, err := r.cache.Get(ctx, key)
+	switch {
+	case err != nil:
+		r.log.WarnContext(ctx, "failed to read cache", slog.String("key", key), slog.String("error", err.Error()))
+		r.metrics.request(kind, resultError)
+	case ok:
+		r.metrics.request(kind, resultHit)
+	default:
+		r.metrics.request(kind, resultMiss)
+	}
+	return value, ok
+}
+
+func (r *Repository) set(ctx context.Context, key string, value []byte) {
+	r.setTTL(ctx, key, value, r.ttl)
+}
+
+// setTTL caches value for ttl, nothing is cached when ttl has already run out.
+func (r *Repository) setTTL(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	if _, ok := ctx.Value(txKey{}).(*txKeys); ok || ttl <= 0 {
+		return
+	}
+	if err := r.cache.Set(ctx, key, value, ttl); err != nil {
+		r.log.WarnContext(ctx, "failed to write cache", slog.String("key", key), slog.String("error", err.Error()))
+	}
+}
+
+func (r *Repository) delete(ctx context.Context, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	if tx, ok := ctx.Value(txKey{}).(*txKeys); ok {
+		tx.mu.Lock()
+		tx.keys = append(tx.keys, keys...)
+		tx.mu.Unlock()
+	}
+	if err := r.cache.Delete(ctx, keys...); err != nil {
+		// The value stays until it expires, which is as stale as the cache can get anyway.
+		r.log.ErrorContext(ctx, "failed to invalidate cache", slog.Any("keys", keys), slog.String("error", err.Error()))
+	}
+}
+
+func appKey(appId int64) string {
+	return fmt.Sprintf("app:%d", appId)
+}
+
+func permissionKey(userId int64, appId int64) string {
+	return fmt.Sprintf("permission:%d:%d", userId, appId)
+}
@@ -0,0 +1,53 @@
+package cache
+
+import "github.com/prometheus/client_golang/prometheus"
+
+const (
+	kindApp        = "app"
+	kindPermission = "permission"
+
+	resultHit   = "hit"
+	resultMiss  = "miss"
+	resultError = "error"
+)
+
+// Metrics counts cache lookups and LRU evictions. A nil *Metrics counts nothing.
+type Metrics struct {
+	requests  *prometheus.CounterVec
+	evictions prometheus.Counter
+}
+
+// NewMetrics returns Metrics registered in registerer.
+func NewMetrics(registerer prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "sso",
+			Subsystem: "cache",
+			Name:      "requests_total",
+			Help:      "Cache lookups by kind of value and result, hit, miss or error.",
+		}, []string{"kind", "result"}),
+		evictions: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "sso",
+			Subsystem: "cache",
+			Name:      "evictions_total",
+			Help:      "Values the in-process cache evicted to stay within its size.",
+		}),
+	}
+	registerer.MustRegister(m.requests, m.evictions)
+	return m
+}
+
+func (m *Metrics) request(kind string, result string) {
+	if m == nil {
+		return
+	}
+	m.requests.WithLabelValues(kind, result).Inc()
+}
+
+// Evicted counts an eviction, it is the onEvict of NewLRU.
+func (m *Metrics) Evicted() {
+	if m == nil {
+		return
+	}
+	m.evictions.Inc()
+}
@@ -0,0 +1,12 @@
+package storage
+
+import "time"
+
+// Earliest is the earlier of two times where a zero time means never, for the
+// validity bounds of permissions.
+func Earliest(a time.Time, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
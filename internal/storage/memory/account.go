@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/storage"
+)
+
+// MarkUserDeleted soft-deletes the user and revokes their tokens, it returns the deletion time.
+// Users that are already deleted are reported as not found.
+func (r *Repository) MarkUserDeleted(ctx context.Context, userId int64) (time.Time, error) {
+	const op = "memory.Repository.MarkUserDeleted"
+	defer r.lock(ctx)()
+
+	user, ok := r.users[userId]
+	if !ok || !user.DeletedAt.IsZero() {
+		return time.Time{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	now := time.Now()
+	user.DeletedAt = now
+	user.TokensRevokedAt = now
+	r.users[userId] = user
+	return now, nil
+}
+
+// RestoreUser undoes a soft delete that has not been purged yet.
+func (r *Repository) RestoreUser(ctx context.Context, userId int64) error {
+	const op = "memory.Repository.RestoreUser"
+	defer r.lock(ctx)()
+
+	user, ok := r.users[userId]
+	if !ok || user.DeletedAt.IsZero() {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	user.DeletedAt = time.Time{}
+	r.users[userId] = user
+	return nil
+}
+
+// PurgeDeletedUsers removes users soft-deleted before deletedBefore and returns them with
+// the apps they had permissions in. Users that are still the only owner of an app are kept,
+// owners that are deleted themselves don't count.
+// Permissions go with the users, audit events keep their rows without the user.
+func (r *Repository) PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) ([]models.PurgedUser, error) {
+	defer r.lock(ctx)()
+
+	liveOwners := make(map[int64]int)
+	for _, permission := range r.permissions {
+		if permission.Permission == models.RoleOwner && isPermanent(permission) && r.users[permission.UserId].DeletedAt.IsZero() {
+			liveOwners[permission.AppId]++
+		}
+	}
+
+	var purged []models.PurgedUser
+	for _, userId := range slices.Sorted(maps.Keys(r.users)) {
+		deletedAt := r.users[userId].DeletedAt
+		if deletedAt.IsZero() || !deletedAt.Before(deletedBefore) {
+			continue
+		}
+
+		var appIds []int64
+		soleOwner := false
+		for _, permission := range r.permissions {
+			if permission.UserId != userId {
+				continue
+			}
+			if !slices.Contains(appIds, permission.AppId) {
+				appIds = append(appIds, permission.AppId)
+			}
+			if permission.Permission == models.RoleOwner && isPermanent(permission) && liveOwners[permission.AppId] == 0 {
+				soleOwner = true
+			}
+		}
+		if soleOwner {
+			continue
+		}
+		slices.Sort(appIds)
+		purged = append(purged, models.PurgedUser{ID: userId, AppIds: appIds})
+	}
+	for _, user := range purged {
+		r.deleteUser(user.ID)
+	}
+	return purged, nil
+}
+
+// UserPermissions returns all permissions of the user, permanent and time-bound, ordered by app.
+func (r *Repository) UserPermissions(ctx context.Context, userId int64) ([]models.PermissionGrant, error) {
+	defer r.rlock(ctx)()
+
+	var permissions []models.PermissionGrant
+	for _, permission := range r.permissions {
+		if permission.UserId == userId {
+			permissions = append(permissions, permission)
+		}
+	}
+	slices.SortFunc(permissions, func(a, b models.PermissionGrant) int {
+		return cmp.Or(cmp.Compare(a.AppId, b.AppId), cmp.Compare(a.ID, b.ID))
+	})
+	return permissions, nil
+}
+
+// SoleOwnedAppIds returns the apps in which the user is the only permanent owner.
+func (r *Repository) SoleOwnedAppIds(ctx context.Context, userId int64) ([]int64, error) {
+	defer r.rlock(ctx)()
+
+	owners := make(map[int64][]int64)
+	for _, permission := range r.permissions {
+		if permission.Permission == models.RoleOwner && isPermanent(permission) {
+			owners[permission.AppId] = append(owners[permission.AppId], permission.UserId)
+		}
+	}
+
+	var appIds []int64
+	for appId, userIds := range owners {
+		if len(userIds) == 1 && userIds[0] == userId {
+			appIds = append(appIds, appId)
+		}
+	}
+	slices.Sort(appIds)
+	return appIds, nil
+}
@@ -0,0 +1,136 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/storage"
+)
+
+func (r *Repository) SaveApp(ctx context.Context, name string, secret string) (int64, error) {
+	const op = "memory.Repository.SaveApp"
+	defer r.lock(ctx)()
+
+	for _, app := range r.apps {
+		if app.Name == name {
+			return 0, fmt.Errorf("%s: %w", op, &storage.ConflictError{Field: "name", Err: storage.ErrAppExists})
+		}
+		if app.Secret == secret {
+			return 0, fmt.Errorf("%s: %w", op, &storage.ConflictError{Field: "secret", Err: storage.ErrAppExists})
+		}
+	}
+
+	r.lastAppId++
+	r.apps[r.lastAppId] = models.App{
+		ID:     int(r.lastAppId),
+		Name:   name,
+		Secret: secret,
+	}
+	return r.lastAppId, nil
+}
+
+func (r *Repository) UpdateApp(ctx context.Context, appId int64, name string) error {
+	const op = "memory.Repository.UpdateApp"
+	defer r.lock(ctx)()
+
+	app, ok := r.apps[appId]
+	if !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrAppNotFound)
+	}
+	for id, other := range r.apps {
+		if id != appId && other.Name == name {
+			return fmt.Errorf("%s: %w", op, &storage.ConflictError{Field: "name", Err: storage.ErrAppExists})
+		}
+	}
+	app.Name = name
+	r.apps[appId] = app
+	return nil
+}
+
+func (r *Repository) UpdateAppSecret(ctx context.Context, appId int64, secret string) (models.App, error) {
+	const op = "memory.Repository.UpdateAppSecret"
+	defer r.lock(ctx)()
+
+	app, ok := r.apps[appId]
+	if !ok {
+		return models.App{}, fmt.Errorf("%s: %w", op, storage.ErrAppNotFound)
+	}
+	for id, other := range r.apps {
+		if id != appId && other.Secret == secret {
+			return models.App{}, fmt.Errorf("%s: %w", op, &storage.ConflictError{Field: "secret", Err: storage.ErrAppExists})
+		}
+	}
+	app.Secret = secret
+	r.apps[appId] = app
+	return app, nil
+}
+
+// Apps returns up to limit apps with id greater than afterId, ordered by id.
+// Secrets are not loaded.
+func (r *Repository) Apps(ctx context.Context, afterId int64, limit int) ([]models.App, error) {
+	defer r.rlock(ctx)()
+
+	var apps []models.App
+	for id, app := range r.apps {
+		if id > afterId {
+			apps = append(apps, models.App{ID: app.ID, Name: app.Name})
+		}
+	}
+	slices.SortFunc(apps, func(a, b models.App) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return limitSlice(apps, limit), nil
+}
+
+// DeleteApp removes the app with everything that belongs to it, audit events are kept without the app.
+func (r *Repository) DeleteApp(ctx context.Context, appId int64) error {
+	const op = "memory.Repository.DeleteApp"
+	defer r.lock(ctx)()
+
+	if _, ok := r.apps[appId]; !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrAppNotFound)
+	}
+
+	delete(r.apps, appId)
+	delete(r.settings, appId)
+	for id, permission := range r.permissions {
+		if permission.AppId == appId {
+			delete(r.permissions, id)
+		}
+	}
+	for id, policy := range r.policies {
+		if policy.AppId == appId {
+			delete(r.policies, id)
+		}
+	}
+	for id, row := range r.invitations {
+		if row.AppId == appId {
+			delete(r.invitations, id)
+		}
+	}
+	for key := range r.namespaces {
+		if key.appId == appId {
+			delete(r.namespaces, key)
+		}
+	}
+	r.tuples = slices.DeleteFunc(r.tuples, func(row tupleRow) bool {
+		return row.appId == appId
+	})
+	for i := range r.auditEvents {
+		if r.auditEvents[i].AppId == appId {
+			r.auditEvents[i].AppId = 0
+		}
+	}
+	return nil
+}
+
+// limitSlice cuts s down to limit elements, like LIMIT does.
+func limitSlice[T any](s []T, limit int) []T {
+	if limit >= 0 && len(s) > limit {
+		return s[:limit]
+	}
+	return s
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+)
+
+func (r *Repository) SaveAuditEvent(ctx context.Context, event models.AuditEvent) error {
+	const op = "memory.Repository.SaveAuditEvent"
+	defer r.lock(ctx)()
+
+	details, err := cloneJSON(event.Details)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	r.lastAuditEventId++
+	event.ID = r.lastAuditEventId
+	event.Details = details
+	event.CreatedAt = time.Now()
+	r.auditEvents = append(r.auditEvents, event)
+	return nil
+}
+
+// AuditEvents returns up to limit events with id greater than afterId that match the filter, ordered by id.
+func (r *Repository) AuditEvents(ctx context.Context, filter models.AuditFilter, afterId int64, limit int) ([]models.AuditEvent, error) {
+	const op = "memory.Repository.AuditEvents"
+	defer r.rlock(ctx)()
+
+	var events []models.AuditEvent
+	// Events are appended with increasing ids, so they are already in order.
+	for _, event := range r.auditEvents {
+		if len(events) == limit {
+			break
+		}
+		if event.ID <= afterId ||
+			(filter.AppId != 0 && event.AppId != filter.AppId) ||
+			(filter.ActorId != 0 && event.ActorId != filter.ActorId) ||
+			(filter.UserId != 0 && event.UserId != filter.UserId) ||
+			(filter.Action != "" && event.Action != filter.Action) ||
+			(!filter.Since.IsZero() && event.CreatedAt.Before(filter.Since)) ||
+			(!filter.Until.IsZero() && !event.CreatedAt.Before(filter.Until)) {
+			continue
+		}
+		details, err := cloneJSON(event.Details)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		event.Details = details
+		events = append(events, event)
+	}
+	return events, nil
+}
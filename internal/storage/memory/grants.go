@@ -0,0 +1,43 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/storage"
+)
+
+func (r *Repository) GrantPermission(ctx context.Context, grant models.PermissionGrant) (int64, error) {
+	const op = "memory.Repository.GrantPermission"
+	defer r.lock(ctx)()
+
+	if _, ok := r.users[grant.GrantedBy]; grant.GrantedBy != 0 && !ok {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	id, err := r.insertPermission(grant)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return id, nil
+}
+
+// DeleteExpiredPermissions removes grants whose validity ended before now and returns them.
+func (r *Repository) DeleteExpiredPermissions(ctx context.Context, now time.Time) ([]models.PermissionGrant, error) {
+	defer r.lock(ctx)()
+
+	var grants []models.PermissionGrant
+	for id, permission := range r.permissions {
+		if !permission.ValidUntil.IsZero() && !permission.ValidUntil.After(now) {
+			grants = append(grants, permission)
+			delete(r.permissions, id)
+		}
+	}
+	slices.SortFunc(grants, func(a, b models.PermissionGrant) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return grants, nil
+}
@@ -0,0 +1,146 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/storage"
+)
+
+type invitationRow struct {
+	models.Invitation
+	tokenHash []byte
+}
+
+func (r *Repository) SaveInvitation(ctx context.Context, invitation models.Invitation, tokenHash []byte) (models.Invitation, error) {
+	const op = "memory.Repository.SaveInvitation"
+	defer r.lock(ctx)()
+
+	if _, ok := r.apps[invitation.AppId]; !ok {
+		return models.Invitation{}, fmt.Errorf("%s: %w", op, storage.ErrAppNotFound)
+	}
+	for _, row := range r.invitations {
+		if bytes.Equal(row.tokenHash, tokenHash) {
+			return models.Invitation{}, fmt.Errorf("%s: %w", op, errors.New("token hash is already in use"))
+		}
+	}
+	if _, ok := r.users[invitation.InvitedBy]; invitation.InvitedBy != 0 && !ok {
+		return models.Invitation{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+
+	r.lastInvitationId++
+	saved := models.Invitation{
+		ID:        r.lastInvitationId,
+		AppId:     invitation.AppId,
+		Email:     invitation.Email,
+		Role:      invitation.Role,
+		InvitedBy: invitation.InvitedBy,
+		ExpiresAt: invitation.ExpiresAt,
+		CreatedAt: time.Now(),
+	}
+	r.invitations[saved.ID] = invitationRow{
+		Invitation: saved,
+		tokenHash:  slices.Clone(tokenHash),
+	}
+	return saved, nil
+}
+
+// InvitationByTokenHash returns the invitation whatever its status.
+func (r *Repository) InvitationByTokenHash(ctx context.Context, tokenHash []byte) (models.Invitation, error) {
+	const op = "memory.Repository.InvitationByTokenHash"
+	defer r.rlock(ctx)()
+
+	for _, row := range r.invitations {
+		if bytes.Equal(row.tokenHash, tokenHash) {
+			return row.Invitation, nil
+		}
+	}
+	return models.Invitation{}, fmt.Errorf("%s: %w", op, storage.ErrInvitationNotFound)
+}
+
+// Invitations returns up to limit invitations of the app with id greater than afterId, ordered by id.
+// With pendingOnly, accepted, revoked and expired invitations are left out.
+func (r *Repository) Invitations(ctx context.Context, appId int64, pendingOnly bool, afterId int64, limit int) ([]models.Invitation, error) {
+	defer r.rlock(ctx)()
+
+	now := time.Now()
+	var invitations []models.Invitation
+	for _, id := range slices.Sorted(maps.Keys(r.invitations)) {
+		invitation := r.invitations[id].Invitation
+		if invitation.AppId != appId || id <= afterId {
+			continue
+		}
+		if pendingOnly && invitation.Status(now) != models.InvitationPending {
+			continue
+		}
+		invitations = append(invitations, invitation)
+	}
+	return limitSlice(invitations, limit), nil
+}
+
+// RevokeInvitation revokes a pending invitation of the app.
+func (r *Repository) RevokeInvitation(ctx context.Context, appId int64, invitationId int64) (models.Invitation, error) {
+	const op = "memory.Repository.RevokeInvitation"
+	defer r.lock(ctx)()
+
+	row, ok := r.invitations[invitationId]
+	if !ok || row.AppId != appId || !row.AcceptedAt.IsZero() || !row.RevokedAt.IsZero() {
+		return models.Invitation{}, fmt.Errorf("%s: %w", op, storage.ErrInvitationNotFound)
+	}
+	row.RevokedAt = time.Now()
+	r.invitations[invitationId] = row
+	return row.Invitation, nil
+}
+
+// AcceptInvitation marks the pending invitation as accepted by the user and gives them
+// the invited role. A user who already has the role or a higher one keeps it, and a
+// banned user stays banned.
+func (r *Repository) AcceptInvitation(ctx context.Context, invitationId int64, userId int64) (models.PermissionChangeResult, error) {
+	const op = "memory.Repository.AcceptInvitation"
+	defer r.lock(ctx)()
+
+	now := time.Now()
+	row, ok := r.invitations[invitationId]
+	if !ok || row.Status(now) != models.InvitationPending {
+		return models.PermissionChangeResult{}, fmt.Errorf("%s: %w", op, storage.ErrInvitationNotFound)
+	}
+	if _, ok := r.users[userId]; !ok {
+		return models.PermissionChangeResult{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+
+	change := models.PermissionChange{
+		UserId:     userId,
+		AppId:      row.AppId,
+		Permission: row.Role,
+	}
+	var current string
+	if base, ok := r.basePermission(userId, row.AppId); ok {
+		current = base.Permission
+	}
+
+	result := models.PermissionChangeResult{
+		Change:             change,
+		Outcome:            models.PermissionChangeUnchanged,
+		PreviousPermission: current,
+	}
+	currentRank, _ := models.RoleRank(current)
+	invitedRank, _ := models.RoleRank(change.Permission)
+	if current == "" || (current != models.RoleBanned && invitedRank > currentRank) {
+		var err error
+		result, err = r.upsertPermission(change)
+		if err != nil {
+			return models.PermissionChangeResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	row.AcceptedAt = now
+	row.AcceptedBy = userId
+	r.invitations[invitationId] = row
+	return result, nil
+}
@@ -0,0 +1,37 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+)
+
+// AppMembers returns up to limit members of the app with user id greater than afterUserId,
+// ordered by user id. The permission is the effective one, so a valid time-bound grant
+// wins over the base permission the same way it does in Permission. An empty role matches any.
+func (r *Repository) AppMembers(ctx context.Context, appId int64, role string, afterUserId int64, limit int) ([]models.AppMember, error) {
+	defer r.rlock(ctx)()
+
+	now := time.Now()
+	var members []models.AppMember
+	for _, userId := range slices.Sorted(maps.Keys(r.users)) {
+		if userId <= afterUserId {
+			continue
+		}
+		permission, ok := r.effectivePermission(userId, appId, now)
+		if !ok || (role != "" && permission != role) {
+			continue
+		}
+		user := r.users[userId]
+		members = append(members, models.AppMember{
+			UserId:     userId,
+			Email:      user.Email,
+			Username:   user.Username,
+			Permission: permission,
+		})
+	}
+	return limitSlice(members, limit), nil
+}
@@ -0,0 +1,394 @@
+// Package memory is a storage backend that keeps everything in process memory.
+// It mirrors the postgresql package, including its errors, and is meant for tests
+// and local development: nothing survives a restart.
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/storage"
+)
+
+// Repository is safe for concurrent use, every method holds mu for its whole duration
+// so each call is atomic the way a transaction is in PostgreSQL. WithTx holds it across calls.
+type Repository struct {
+	mu sync.RWMutex
+
+	users       map[int64]models.User
+	apps        map[int64]models.App
+	permissions map[int64]models.PermissionGrant
+	policies    map[int64]models.Policy
+	settings    map[int64]models.AppSettings
+	invitations map[int64]invitationRow
+	namespaces  map[namespaceKey]models.Namespace
+	tuples      []tupleRow
+	auditEvents []models.AuditEvent
+
+	revision         int64
+	lastUserId       int64
+	lastAppId        int64
+	lastPermissionId int64
+	lastPolicyId     int64
+	lastInvitationId int64
+	lastAuditEventId int64
+}
+
+func New() *Repository {
+	return &Repository{
+		users:       make(map[int64]models.User),
+		apps:        make(map[int64]models.App),
+		permissions: make(map[int64]models.PermissionGrant),
+		policies:    make(map[int64]models.Policy),
+		settings:    make(map[int64]models.AppSettings),
+		invitations: make(map[int64]invitationRow),
+		namespaces:  make(map[namespaceKey]models.Namespace),
+	}
+}
+
+func (r *Repository) SaveUser(ctx context.Context, email string, username string, passHash []byte) (int64, error) {
+	const op = "memory.Repository.SaveUser"
+	defer r.lock(ctx)()
+
+	if err := r.userConflict(0, email, username); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	r.lastUserId++
+	r.users[r.lastUserId] = models.User{
+		ID:         fmt.Sprint(r.lastUserId),
+		Email:      email,
+		Username:   username,
+		PassHash:   slices.Clone(passHash),
+		Status:     models.UserStatusActive,
+		Attributes: map[string]any{},
+	}
+	return r.lastUserId, nil
+}
+
+func (r *Repository) User(ctx context.Context, email string) (models.User, error) {
+	const op = "memory.Repository.User"
+	defer r.rlock(ctx)()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			return models.User{
+				ID:        user.ID,
+				Email:     user.Email,
+				PassHash:  slices.Clone(user.PassHash),
+				Status:    user.Status,
+				DeletedAt: user.DeletedAt,
+			}, nil
+		}
+	}
+	return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+}
+
+func (r *Repository) UserById(ctx context.Context, userId int64) (models.User, error) {
+	const op = "memory.Repository.UserById"
+	defer r.rlock(ctx)()
+
+	user, ok := r.users[userId]
+	if !ok {
+		return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	attributes, err := cloneJSON(user.Attributes)
+	if err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+	user.PassHash = slices.Clone(user.PassHash)
+	user.Attributes = attributes
+	return user, nil
+}
+
+func (r *Repository) Permission(ctx context.Context, userId int64, appId int64) (string, error) {
+	permission, _, err := r.PermissionUntil(ctx, userId, appId)
+	return permission, err
+}
+
+// PermissionUntil is Permission and the time it may change next, when the grant in
+// effect ends or the next grant starts. The time is zero when neither is going to happen.
+func (r *Repository) PermissionUntil(ctx context.Context, userId int64, appId int64) (string, time.Time, error) {
+	const op = "memory.Repository.PermissionUntil"
+	defer r.rlock(ctx)()
+
+	now := time.Now()
+	effective, ok := r.effectiveGrant(userId, appId, now)
+	if !ok {
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, storage.ErrNoPermissionFound)
+	}
+	until := effective.ValidUntil
+	for _, permission := range r.permissions {
+		if permission.UserId == userId && permission.AppId == appId && permission.ValidFrom.After(now) {
+			until = storage.Earliest(until, permission.ValidFrom)
+		}
+	}
+	return effective.Permission, until, nil
+}
+
+func (r *Repository) App(ctx context.Context, appId int64) (models.App, error) {
+	const op = "memory.Repository.App"
+	defer r.rlock(ctx)()
+
+	app, ok := r.apps[appId]
+	if !ok {
+		return models.App{}, fmt.Errorf("%s: %w", op, storage.ErrAppNotFound)
+	}
+	return app, nil
+}
+
+func (r *Repository) CreatePermission(ctx context.Context, userId int64, appId int64, permission string) (bool, error) {
+	const op = "memory.Repository.CreatePermission"
+	defer r.lock(ctx)()
+
+	if _, err := r.insertPermission(models.PermissionGrant{
+		UserId:     userId,
+		AppId:      appId,
+		Permission: permission,
+	}); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return true, nil
+}
+
+func (r *Repository) UpdatePermission(ctx context.Context, userId int64, appId int64, permission string) error {
+	const op = "memory.Repository.UpdatePermission"
+	defer r.lock(ctx)()
+
+	if err := validatePermission(permission); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	base, ok := r.basePermission(userId, appId)
+	if !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrNoPermissionFound)
+	}
+	base.Permission = permission
+	r.permissions[base.ID] = base
+	return nil
+}
+
+// BatchUpdatePermissions sets the permanent permission of every change, creating the
+// permission when the user has none in the app yet. The changes are only kept if no
+// change failed and dryRun is false, otherwise the permissions are restored as they were.
+func (r *Repository) BatchUpdatePermissions(
+	ctx context.Context,
+	changes []models.PermissionChange,
+	dryRun bool,
+) ([]models.PermissionChangeResult, bool, error) {
+	defer r.lock(ctx)()
+
+	permissions, lastPermissionId := maps.Clone(r.permissions), r.lastPermissionId
+
+	results := make([]models.PermissionChangeResult, 0, len(changes))
+	failed := false
+	for _, change := range changes {
+		result, err := r.upsertPermission(change)
+		if err != nil {
+			failed = true
+			result = models.PermissionChangeResult{
+				Change:  change,
+				Outcome: models.PermissionChangeFailed,
+				Err:     err,
+			}
+		}
+		results = append(results, result)
+	}
+
+	if failed || dryRun {
+		r.permissions, r.lastPermissionId = permissions, lastPermissionId
+		return results, false, nil
+	}
+	return results, true, nil
+}
+
+// upsertPermission fails before changing anything, so a failed change needs no rollback.
+func (r *Repository) upsertPermission(change models.PermissionChange) (models.PermissionChangeResult, error) {
+	result := models.PermissionChangeResult{Change: change}
+
+	base, ok := r.basePermission(change.UserId, change.AppId)
+	switch {
+	case !ok:
+		if _, err := r.insertPermission(models.PermissionGrant{
+			UserId:     change.UserId,
+			AppId:      change.AppId,
+			Permission: change.Permission,
+		}); err != nil {
+			return result, err
+		}
+		result.Outcome = models.PermissionChangeCreated
+	case base.Permission == change.Permission:
+		result.PreviousPermission = base.Permission
+		result.Outcome = models.PermissionChangeUnchanged
+	default:
+		if err := validatePermission(change.Permission); err != nil {
+			return result, err
+		}
+		result.PreviousPermission = base.Permission
+		base.Permission = change.Permission
+		r.permissions[base.ID] = base
+		result.Outcome = models.PermissionChangeUpdated
+	}
+	return result, nil
+}
+
+// RoleMemberIds returns users holding role permanently in the app, time-bound grants are not included.
+func (r *Repository) RoleMemberIds(ctx context.Context, appId int64, role string) ([]int64, error) {
+	defer r.rlock(ctx)()
+
+	var userIds []int64
+	for _, permission := range r.permissions {
+		if permission.AppId == appId && permission.Permission == role && isPermanent(permission) {
+			userIds = append(userIds, permission.UserId)
+		}
+	}
+	slices.Sort(userIds)
+	return slices.Compact(userIds), nil
+}
+
+// insertPermission checks what the permissions table's foreign keys and enum would.
+func (r *Repository) insertPermission(permission models.PermissionGrant) (int64, error) {
+	if _, ok := r.users[permission.UserId]; !ok {
+		return 0, storage.ErrUserNotFound
+	}
+	if _, ok := r.apps[permission.AppId]; !ok {
+		return 0, storage.ErrAppNotFound
+	}
+	if err := validatePermission(permission.Permission); err != nil {
+		return 0, err
+	}
+	if _, ok := r.basePermission(permission.UserId, permission.AppId); ok && isPermanent(permission) {
+		return 0, storage.ErrConflict
+	}
+
+	r.lastPermissionId++
+	permission.ID = r.lastPermissionId
+	r.permissions[permission.ID] = permission
+	return permission.ID, nil
+}
+
+// basePermission returns the user's permanent permission in the app.
+func (r *Repository) basePermission(userId int64, appId int64) (models.PermissionGrant, bool) {
+	for _, permission := range r.permissions {
+		if permission.UserId == userId && permission.AppId == appId && isPermanent(permission) {
+			return permission, true
+		}
+	}
+	return models.PermissionGrant{}, false
+}
+
+// effectivePermission picks the permission in effect at now: the latest valid time-bound
+// grant, or the base permission when there is none.
+func (r *Repository) effectivePermission(userId int64, appId int64, now time.Time) (string, bool) {
+	effective, ok := r.effectiveGrant(userId, appId, now)
+	return effective.Permission, ok
+}
+
+// effectiveGrant is the row effectivePermission takes the permission from.
+func (r *Repository) effectiveGrant(userId int64, appId int64, now time.Time) (models.PermissionGrant, bool) {
+	var effective models.PermissionGrant
+	found := false
+	for _, permission := range r.permissions {
+		if permission.UserId != userId || permission.AppId != appId || !isValidAt(permission, now) {
+			continue
+		}
+		if !found || outranks(permission, effective) {
+			effective, found = permission, true
+		}
+	}
+	return effective, found
+}
+
+// userTaken reports whether a user other than exceptId already has the email or username.
+// userConflict returns the unique violation the email or username would cause, as
+// the unique constraints do in PostgreSQL.
+func (r *Repository) userConflict(exceptId int64, email string, username string) error {
+	for id, user := range r.users {
+		switch {
+		case id == exceptId:
+		case user.Email == email:
+			return &storage.ConflictError{Field: "email", Err: storage.ErrUserExists}
+		case user.Username == username:
+			return &storage.ConflictError{Field: "username", Err: storage.ErrUserExists}
+		}
+	}
+	return nil
+}
+
+// deleteUser removes the user with their permissions and clears references to them,
+// as the foreign keys do in PostgreSQL.
+func (r *Repository) deleteUser(userId int64) {
+	delete(r.users, userId)
+	for id, permission := range r.permissions {
+		switch {
+		case permission.UserId == userId:
+			delete(r.permissions, id)
+		case permission.GrantedBy == userId:
+			permission.GrantedBy = 0
+			r.permissions[id] = permission
+		}
+	}
+	for id, row := range r.invitations {
+		if row.InvitedBy == userId {
+			row.InvitedBy = 0
+		}
+		if row.AcceptedBy == userId {
+			row.AcceptedBy = 0
+		}
+		r.invitations[id] = row
+	}
+	for i := range r.auditEvents {
+		if r.auditEvents[i].ActorId == userId {
+			r.auditEvents[i].ActorId = 0
+		}
+		if r.auditEvents[i].UserId == userId {
+			r.auditEvents[i].UserId = 0
+		}
+	}
+}
+
+func isPermanent(permission models.PermissionGrant) bool {
+	return permission.ValidFrom.IsZero() && permission.ValidUntil.IsZero()
+}
+
+func isValidAt(permission models.PermissionGrant, now time.Time) bool {
+	return (permission.ValidFrom.IsZero() || !permission.ValidFrom.After(now)) &&
+		(permission.ValidUntil.IsZero() || permission.ValidUntil.After(now))
+}
+
+// outranks orders permissions the way Permission does in PostgreSQL:
+// time-bound grants before the base permission, newer before older.
+func outranks(a models.PermissionGrant, b models.PermissionGrant) bool {
+	if isPermanent(a) != isPermanent(b) {
+		return !isPermanent(a)
+	}
+	return a.ID > b.ID
+}
+
+func validatePermission(permission string) error {
+	if _, ok := models.RoleRank(permission); !ok {
+		return fmt.Errorf("invalid permission %q", permission)
+	}
+	return nil
+}
+
+// cloneJSON copies v through JSON, so callers get the same types back as from a JSONB column.
+func cloneJSON(v map[string]any) (map[string]any, error) {
+	if v == nil {
+		v = map[string]any{}
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var clone map[string]any
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return nil, err
+	}
+	return clone, nil
+}
@@ -0,0 +1,14 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/botanikn/go_sso_service/internal/storage/memory"
+	"github.com/botanikn/go_sso_service/internal/storage/storagetest"
+)
+
+func TestStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Repository {
+		return memory.New()
+	})
+}
@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/storage"
+)
+
+func (r *Repository) Policies(ctx context.Context, appId int64, action string) ([]models.Policy, error) {
+	defer r.rlock(ctx)()
+
+	var policies []models.Policy
+	for _, policy := range r.policies {
+		if policy.AppId == appId && policy.Action == action {
+			policies = append(policies, policy)
+		}
+	}
+	slices.SortFunc(policies, func(a, b models.Policy) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return policies, nil
+}
+
+// AppPolicies returns every policy of the app, ordered by action and id.
+func (r *Repository) AppPolicies(ctx context.Context, appId int64) ([]models.Policy, error) {
+	defer r.rlock(ctx)()
+
+	var policies []models.Policy
+	for _, policy := range r.policies {
+		if policy.AppId == appId {
+			policies = append(policies, policy)
+		}
+	}
+	slices.SortFunc(policies, func(a, b models.Policy) int {
+		return cmp.Or(cmp.Compare(a.Action, b.Action), cmp.Compare(a.ID, b.ID))
+	})
+	return policies, nil
+}
+
+// SavePolicy stores a new policy of the app and returns its id.
+func (r *Repository) SavePolicy(ctx context.Context, policy models.Policy) (int64, error) {
+	const op = "memory.Repository.SavePolicy"
+	defer r.lock(ctx)()
+
+	if _, ok := r.apps[policy.AppId]; !ok {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrAppNotFound)
+	}
+	if policy.Effect == "" {
+		policy.Effect = models.PolicyEffectAllow
+	}
+	if err := r.checkPolicy(policy); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	r.lastPolicyId++
+	policy.ID = r.lastPolicyId
+	r.policies[policy.ID] = policy
+	return policy.ID, nil
+}
+
+// UpdatePolicy replaces the name, action, effect and expression of the app's policy.
+func (r *Repository) UpdatePolicy(ctx context.Context, policy models.Policy) error {
+	const op = "memory.Repository.UpdatePolicy"
+	defer r.lock(ctx)()
+
+	if stored, ok := r.policies[policy.ID]; !ok || stored.AppId != policy.AppId {
+		return fmt.Errorf("%s: %w", op, storage.ErrPolicyNotFound)
+	}
+	if err := r.checkPolicy(policy); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	r.policies[policy.ID] = policy
+	return nil
+}
+
+// DeletePolicy removes the app's policy and returns it as it was.
+func (r *Repository) DeletePolicy(ctx context.Context, appId int64, policyId int64) (models.Policy, error) {
+	const op = "memory.Repository.DeletePolicy"
+	defer r.lock(ctx)()
+
+	policy, ok := r.policies[policyId]
+	if !ok || policy.AppId != appId {
+		return models.Policy{}, fmt.Errorf("%s: %w", op, storage.ErrPolicyNotFound)
+	}
+	delete(r.policies, policyId)
+	return policy, nil
+}
+
+// checkPolicy checks what the policies table's unique key and effect enum would.
+func (r *Repository) checkPolicy(policy models.Policy) error {
+	if policy.Effect != models.PolicyEffectAllow && policy.Effect != models.PolicyEffectDeny {
+		return storage.ErrInvalidValue
+	}
+	for _, stored := range r.policies {
+		if stored.ID != policy.ID && stored.AppId == policy.AppId && stored.Name == policy.Name {
+			return &storage.ConflictError{Field: "name", Err: storage.ErrPolicyExists}
+		}
+	}
+	return nil
+}
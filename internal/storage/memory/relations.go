@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/storage"
+)
+
+type namespaceKey struct {
+	appId int64
+	name  string
+}
+
+// tupleRow is a tuple with the revisions it was live in, deletedRevision is zero while it still is.
+type tupleRow struct {
+	appId           int64
+	tuple           models.RelationTuple
+	createdRevision int64
+	deletedRevision int64
+}
+
+func (row tupleRow) visibleAt(revision int64) bool {
+	return row.createdRevision <= revision && (row.deletedRevision == 0 || row.deletedRevision > revision)
+}
+
+// SaveNamespace creates or replaces a namespace of the app.
+func (r *Repository) SaveNamespace(ctx context.Context, namespace models.Namespace) error {
+	const op = "memory.Repository.SaveNamespace"
+	defer r.lock(ctx)()
+
+	if _, ok := r.apps[namespace.AppId]; !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrAppNotFound)
+	}
+	r.namespaces[namespaceKey{appId: namespace.AppId, name: namespace.Name}] = namespace
+	return nil
+}
+
+func (r *Repository) Namespace(ctx context.Context, appId int64, name string) (models.Namespace, error) {
+	const op = "memory.Repository.Namespace"
+	defer r.rlock(ctx)()
+
+	namespace, ok := r.namespaces[namespaceKey{appId: appId, name: name}]
+	if !ok {
+		return models.Namespace{}, fmt.Errorf("%s: %w", op, storage.ErrNamespaceNotFound)
+	}
+	return namespace, nil
+}
+
+// RelationRevision returns the latest committed revision.
+func (r *Repository) RelationRevision(ctx context.Context) (int64, error) {
+	defer r.rlock(ctx)()
+
+	return r.revision, nil
+}
+
+// RelationTuples returns the tuples matching filter as they were at the given revision.
+func (r *Repository) RelationTuples(ctx context.Context, appId int64, filter models.RelationTupleFilter, revision int64) ([]models.RelationTuple, error) {
+	defer r.rlock(ctx)()
+
+	var tuples []models.RelationTuple
+	for _, row := range r.tuples {
+		if row.appId != appId || !row.visibleAt(revision) || !matches(row.tuple, filter) {
+			continue
+		}
+		tuples = append(tuples, row.tuple)
+	}
+	return tuples, nil
+}
+
+// WriteRelationTuples applies inserts and deletes under a new revision and returns that revision.
+func (r *Repository) WriteRelationTuples(ctx context.Context, appId int64, inserts []models.RelationTuple, deletes []models.RelationTuple) (int64, error) {
+	defer r.lock(ctx)()
+
+	r.revision++
+	for _, tuple := range deletes {
+		for i, row := range r.tuples {
+			if row.appId == appId && row.deletedRevision == 0 && row.tuple == tuple {
+				r.tuples[i].deletedRevision = r.revision
+			}
+		}
+	}
+	for _, tuple := range inserts {
+		if !r.isLive(appId, tuple) {
+			r.tuples = append(r.tuples, tupleRow{
+				appId:           appId,
+				tuple:           tuple,
+				createdRevision: r.revision,
+			})
+		}
+	}
+	return r.revision, nil
+}
+
+func (r *Repository) isLive(appId int64, tuple models.RelationTuple) bool {
+	for _, row := range r.tuples {
+		if row.appId == appId && row.deletedRevision == 0 && row.tuple == tuple {
+			return true
+		}
+	}
+	return false
+}
+
+func matches(tuple models.RelationTuple, filter models.RelationTupleFilter) bool {
+	return (filter.Namespace == "" || tuple.Namespace == filter.Namespace) &&
+		(filter.ObjectId == "" || tuple.ObjectId == filter.ObjectId) &&
+		(filter.Relation == "" || tuple.Relation == filter.Relation) &&
+		(filter.Subject == nil || tuple.Subject == *filter.Subject)
+}
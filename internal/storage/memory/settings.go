@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/storage"
+)
+
+// AppSettings returns the settings of the app, or the defaults when none are stored.
+// It does not check that the app exists.
+func (r *Repository) AppSettings(ctx context.Context, appId int64) (models.AppSettings, error) {
+	defer r.rlock(ctx)()
+
+	settings, ok := r.settings[appId]
+	if !ok {
+		return models.DefaultAppSettings(appId), nil
+	}
+	return cloneSettings(settings), nil
+}
+
+// SaveAppSettings creates or replaces the settings of the app.
+func (r *Repository) SaveAppSettings(ctx context.Context, settings models.AppSettings) error {
+	const op = "memory.Repository.SaveAppSettings"
+	defer r.lock(ctx)()
+
+	if _, ok := r.apps[settings.AppId]; !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrAppNotFound)
+	}
+
+	settings = cloneSettings(settings)
+	// TTLs are stored in whole seconds and the arrays are never NULL in PostgreSQL.
+	settings.AccessTokenTTL = settings.AccessTokenTTL.Truncate(time.Second)
+	settings.RefreshTokenTTL = settings.RefreshTokenTTL.Truncate(time.Second)
+	if settings.LoginMethods == nil {
+		settings.LoginMethods = []string{}
+	}
+	if settings.AllowedDomains == nil {
+		settings.AllowedDomains = []string{}
+	}
+	r.settings[settings.AppId] = settings
+	return nil
+}
+
+func cloneSettings(settings models.AppSettings) models.AppSettings {
+	settings.LoginMethods = slices.Clone(settings.LoginMethods)
+	settings.AllowedDomains = slices.Clone(settings.AllowedDomains)
+	return settings
+}
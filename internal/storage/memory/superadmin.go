@@ -0,0 +1,32 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/botanikn/go_sso_service/internal/storage"
+)
+
+func (r *Repository) HasSuperAdmin(ctx context.Context) (bool, error) {
+	defer r.rlock(ctx)()
+
+	for _, user := range r.users {
+		if user.SuperAdmin {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *Repository) SetSuperAdmin(ctx context.Context, userId int64, superAdmin bool) error {
+	const op = "memory.Repository.SetSuperAdmin"
+	defer r.lock(ctx)()
+
+	user, ok := r.users[userId]
+	if !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	user.SuperAdmin = superAdmin
+	r.users[userId] = user
+	return nil
+}
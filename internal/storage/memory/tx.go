@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+)
+
+type txKey struct{}
+
+// lock write-locks the repository for a method and returns the unlock. Inside WithTx
+// the transaction already holds the lock, so it does nothing.
+func (r *Repository) lock(ctx context.Context) func() {
+	if r.inTx(ctx) {
+		return func() {}
+	}
+	r.mu.Lock()
+	return r.mu.Unlock
+}
+
+// rlock is lock for methods that only read.
+func (r *Repository) rlock(ctx context.Context) func() {
+	if r.inTx(ctx) {
+		return func() {}
+	}
+	r.mu.RLock()
+	return r.mu.RUnlock
+}
+
+func (r *Repository) inTx(ctx context.Context) bool {
+	tx, _ := ctx.Value(txKey{}).(*Repository)
+	return tx == r
+}
+
+// WithTx runs fn holding the lock, so every method called with the context fn gets sees
+// no concurrent changes. When fn returns an error the changes it made are undone.
+// Called inside another WithTx, only the changes of the inner fn are undone.
+func (r *Repository) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !r.inTx(ctx) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		ctx = context.WithValue(ctx, txKey{}, r)
+	}
+
+	restore := r.snapshot()
+	if err := fn(ctx); err != nil {
+		restore()
+		return err
+	}
+	return nil
+}
+
+// snapshot copies the state and returns a func that puts it back. Rows are stored
+// by value, so copying the maps and slices is enough.
+func (r *Repository) snapshot() func() {
+	users := maps.Clone(r.users)
+	apps := maps.Clone(r.apps)
+	permissions := maps.Clone(r.permissions)
+	policies := maps.Clone(r.policies)
+	settings := maps.Clone(r.settings)
+	invitations := maps.Clone(r.invitations)
+	namespaces := maps.Clone(r.namespaces)
+	tuples := slices.Clone(r.tuples)
+	auditEvents := slices.Clone(r.auditEvents)
+	revision := r.revision
+	lastUserId, lastAppId, lastPermissionId := r.lastUserId, r.lastAppId, r.lastPermissionId
+	lastPolicyId, lastInvitationId, lastAuditEventId := r.lastPolicyId, r.lastInvitationId, r.lastAuditEventId
+
+	return func() {
+		r.users = users
+		r.apps = apps
+		r.permissions = permissions
+		r.policies = policies
+		r.settings = settings
+		r.invitations = invitations
+		r.namespaces = namespaces
+		r.tuples = tuples
+		r.auditEvents = auditEvents
+		r.revision = revision
+		r.lastUserId, r.lastAppId, r.lastPermissionId = lastUserId, lastAppId, lastPermissionId
+		r.lastPolicyId, r.lastInvitationId, r.lastAuditEventId = lastPolicyId, lastInvitationId, lastAuditEventId
+	}
+}
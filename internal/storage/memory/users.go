@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/storage"
+)
+
+// Users returns up to limit users with id greater than afterId that match the filter, ordered by id.
+// Password hashes and attributes are not loaded.
+func (r *Repository) Users(ctx context.Context, filter models.UserFilter, afterId int64, limit int) ([]models.User, error) {
+	defer r.rlock(ctx)()
+
+	var users []models.User
+	for _, userId := range slices.Sorted(maps.Keys(r.users)) {
+		user := r.users[userId]
+		if userId <= afterId ||
+			(filter.EmailPrefix != "" && !strings.HasPrefix(user.Email, filter.EmailPrefix)) ||
+			(filter.Username != "" && user.Username != filter.Username) ||
+			(filter.AppId != 0 && !r.hasAnyPermission(userId, filter.AppId)) ||
+			(filter.Status != "" && user.Status != filter.Status) {
+			continue
+		}
+		users = append(users, listedUser(user))
+	}
+	return limitSlice(users, limit), nil
+}
+
+// UpdateUser changes the user's email and username, empty values keep the current ones.
+func (r *Repository) UpdateUser(ctx context.Context, userId int64, email string, username string) (models.User, error) {
+	const op = "memory.Repository.UpdateUser"
+	defer r.lock(ctx)()
+
+	user, ok := r.users[userId]
+	if !ok {
+		return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	if email != "" {
+		user.Email = email
+	}
+	if username != "" {
+		user.Username = username
+	}
+	if err := r.userConflict(userId, user.Email, user.Username); err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+	r.users[userId] = user
+	return listedUser(user), nil
+}
+
+func (r *Repository) SetUserStatus(ctx context.Context, userId int64, status string) error {
+	const op = "memory.Repository.SetUserStatus"
+	defer r.lock(ctx)()
+
+	user, ok := r.users[userId]
+	if !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	user.Status = status
+	r.users[userId] = user
+	return nil
+}
+
+// RevokeUserTokens marks every token issued to the user so far as revoked and returns the revocation time.
+func (r *Repository) RevokeUserTokens(ctx context.Context, userId int64) (time.Time, error) {
+	const op = "memory.Repository.RevokeUserTokens"
+	defer r.lock(ctx)()
+
+	user, ok := r.users[userId]
+	if !ok {
+		return time.Time{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	user.TokensRevokedAt = time.Now()
+	r.users[userId] = user
+	return user.TokensRevokedAt, nil
+}
+
+// DeleteUser removes the user, their permissions go with them.
+func (r *Repository) DeleteUser(ctx context.Context, userId int64) error {
+	const op = "memory.Repository.DeleteUser"
+	defer r.lock(ctx)()
+
+	if _, ok := r.users[userId]; !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	r.deleteUser(userId)
+	return nil
+}
+
+func (r *Repository) hasAnyPermission(userId int64, appId int64) bool {
+	for _, permission := range r.permissions {
+		if permission.UserId == userId && permission.AppId == appId {
+			return true
+		}
+	}
+	return false
+}
+
+// listedUser keeps the fields user listings load.
+func listedUser(user models.User) models.User {
+	return models.User{
+		ID:       user.ID,
+		Email:    user.Email,
+		Username: user.Username,
+		Status:   user.Status,
+	}
+}
@@ -0,0 +1,184 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/storage"
+	"github.com/jackc/pgx/v5"
+)
+
+// MarkUserDeleted soft-deletes the user and revokes their tokens, it returns the deletion time.
+// Users that are already deleted are reported as not found.
+func (r *Repository) MarkUserDeleted(ctx context.Context, userId int64) (time.Time, error) {
+	const op = "postgresql.Repository.MarkUserDeleted"
+	query := `UPDATE users SET deleted_at = now(), tokens_revoked_at = now()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING deleted_at`
+
+	var deletedAt time.Time
+	if err := r.conn(ctx).QueryRow(ctx, query, userId).Scan(&deletedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+		return time.Time{}, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return deletedAt, nil
+}
+
+// RestoreUser undoes a soft delete that has not been purged yet.
+func (r *Repository) RestoreUser(ctx context.Context, userId int64) error {
+	const op = "postgresql.Repository.RestoreUser"
+	query := "UPDATE users SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL"
+
+	result, err := r.conn(ctx).Exec(ctx, query, userId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	return nil
+}
+
+// purgeableUsers matches the users soft-deleted before $1 that don't own an app on their
+// own. Owners that are deleted themselves don't count, so an app keeps its last live owner
+// or the deleted one until somebody else owns it.
+const purgeableUsers = `u.deleted_at < $1 AND NOT EXISTS (
+	SELECT 1 FROM permissions p
+	WHERE p.user_id = u.id AND p.permission = 'owner'
+	AND p.valid_from IS NULL AND p.valid_until IS NULL
+	AND NOT EXISTS (
+		SELECT 1 FROM permissions o JOIN users ou ON ou.id = o.user_id
+		WHERE o.app_id = p.app_id AND o.user_id <> p.user_id AND o.permission = 'owner'
+		AND o.valid_from IS NULL AND o.valid_until IS NULL AND ou.deleted_at IS NULL
+	)
+)`
+
+// PurgeDeletedUsers removes users soft-deleted before deletedBefore and returns them with
+// the apps they had permissions in. Users that are still the only owner of an app are kept.
+// Permissions go with the users, audit events keep their rows without the user.
+func (r *Repository) PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) ([]models.PurgedUser, error) {
+	const op = "postgresql.Repository.PurgeDeletedUsers"
+	var purged []models.PurgedUser
+	err := r.WithTx(ctx, func(ctx context.Context) error {
+		tx := r.conn(ctx)
+		purged = nil
+		query := `SELECT DISTINCT u.id, p.app_id FROM users u
+			LEFT JOIN permissions p ON p.user_id = u.id
+			WHERE ` + purgeableUsers + `
+			ORDER BY u.id, p.app_id`
+		rows, err := tx.Query(ctx, query, deletedBefore)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, translateError(err))
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var userId int64
+			var appId sql.NullInt64
+			if err := rows.Scan(&userId, &appId); err != nil {
+				return fmt.Errorf("%s: %w", op, translateError(err))
+			}
+			if len(purged) == 0 || purged[len(purged)-1].ID != userId {
+				purged = append(purged, models.PurgedUser{ID: userId})
+			}
+			if appId.Valid {
+				purged[len(purged)-1].AppIds = append(purged[len(purged)-1].AppIds, appId.Int64)
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("%s: %w", op, translateError(err))
+		}
+		if len(purged) == 0 {
+			return nil
+		}
+
+		if _, err := tx.Exec(ctx, "DELETE FROM users u WHERE "+purgeableUsers, deletedBefore); err != nil {
+			return fmt.Errorf("%s: %w", op, translateError(err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return purged, nil
+}
+
+// UserPermissions returns all permissions of the user, permanent and time-bound, ordered by app.
+func (r *Repository) UserPermissions(ctx context.Context, userId int64) ([]models.PermissionGrant, error) {
+	const op = "postgresql.Repository.UserPermissions"
+	query := `SELECT id, app_id, permission, valid_from, valid_until, granted_by, justification
+		FROM permissions
+		WHERE user_id = $1
+		ORDER BY app_id, id`
+
+	rows, err := r.reader(ctx).Query(ctx, query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	defer rows.Close()
+
+	var permissions []models.PermissionGrant
+	for rows.Next() {
+		permission := models.PermissionGrant{UserId: userId}
+		var validFrom, validUntil sql.NullTime
+		var grantedBy sql.NullInt64
+		if err := rows.Scan(
+			&permission.ID,
+			&permission.AppId,
+			&permission.Permission,
+			&validFrom,
+			&validUntil,
+			&grantedBy,
+			&permission.Justification,
+		); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, translateError(err))
+		}
+		permission.ValidFrom = validFrom.Time
+		permission.ValidUntil = validUntil.Time
+		permission.GrantedBy = grantedBy.Int64
+		permissions = append(permissions, permission)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return permissions, nil
+}
+
+// SoleOwnedAppIds returns the apps in which the user is the only permanent owner.
+func (r *Repository) SoleOwnedAppIds(ctx context.Context, userId int64) ([]int64, error) {
+	const op = "postgresql.Repository.SoleOwnedAppIds"
+	query := `SELECT p.app_id FROM permissions p
+		WHERE p.user_id = $1 AND p.permission = 'owner'
+		AND p.valid_from IS NULL AND p.valid_until IS NULL
+		AND NOT EXISTS (
+			SELECT 1 FROM permissions o
+			WHERE o.app_id = p.app_id AND o.user_id <> p.user_id AND o.permission = 'owner'
+			AND o.valid_from IS NULL AND o.valid_until IS NULL
+		)
+		ORDER BY p.app_id`
+
+	rows, err := r.reader(ctx).Query(ctx, query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	defer rows.Close()
+
+	var appIds []int64
+	for rows.Next() {
+		var appId int64
+		if err := rows.Scan(&appId); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, translateError(err))
+		}
+		appIds = append(appIds, appId)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return appIds, nil
+}
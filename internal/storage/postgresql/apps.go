@@ -0,0 +1,160 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/storage"
+	"github.com/jackc/pgx/v5"
+)
+
+// SaveApp takes the id of the app from the sequence first, the secret is encrypted for it.
+func (r *Repository) SaveApp(ctx context.Context, name string, secret string) (int64, error) {
+	const op = "postgresql.Repository.SaveApp"
+
+	var id int64
+	if err := r.conn(ctx).QueryRow(ctx, "SELECT nextval(pg_get_serial_sequence('apps', 'id'))").Scan(&id); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	stored, keyVersion, err := storage.SealSecret(r.keyring, id, secret)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := "INSERT INTO apps (id, name, secret, key_version) VALUES ($1, $2, $3, $4)"
+	if _, err := r.conn(ctx).Exec(ctx, query, id, name, stored, nullInt64(keyVersion)); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return id, nil
+}
+
+func (r *Repository) UpdateApp(ctx context.Context, appId int64, name string) error {
+	const op = "postgresql.Repository.UpdateApp"
+	query := "UPDATE apps SET name = $1 WHERE id = $2"
+
+	result, err := r.conn(ctx).Exec(ctx, query, name, appId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrAppNotFound)
+	}
+	return nil
+}
+
+func (r *Repository) UpdateAppSecret(ctx context.Context, appId int64, secret string) (models.App, error) {
+	const op = "postgresql.Repository.UpdateAppSecret"
+	query := "UPDATE apps SET secret = $1, key_version = $2 WHERE id = $3 RETURNING id, name"
+
+	stored, keyVersion, err := storage.SealSecret(r.keyring, appId, secret)
+	if err != nil {
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+	app := models.App{Secret: secret}
+	if err := r.conn(ctx).QueryRow(ctx, query, stored, nullInt64(keyVersion), appId).Scan(&app.ID, &app.Name); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.App{}, fmt.Errorf("%s: %w", op, storage.ErrAppNotFound)
+		}
+		return models.App{}, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return app, nil
+}
+
+// Apps returns up to limit apps with id greater than afterId, ordered by id.
+// Secrets are not loaded.
+func (r *Repository) Apps(ctx context.Context, afterId int64, limit int) ([]models.App, error) {
+	const op = "postgresql.Repository.Apps"
+	query := "SELECT id, name FROM apps WHERE id > $1 ORDER BY id LIMIT $2"
+
+	rows, err := r.reader(ctx).Query(ctx, query, afterId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	defer rows.Close()
+
+	var apps []models.App
+	for rows.Next() {
+		var app models.App
+		if err := rows.Scan(&app.ID, &app.Name); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, translateError(err))
+		}
+		apps = append(apps, app)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return apps, nil
+}
+
+func (r *Repository) DeleteApp(ctx context.Context, appId int64) error {
+	const op = "postgresql.Repository.DeleteApp"
+	query := "DELETE FROM apps WHERE id = $1"
+
+	result, err := r.conn(ctx).Exec(ctx, query, appId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrAppNotFound)
+	}
+	return nil
+}
+
+// ReencryptAppSecrets moves every app secret to the current key of the keyring, secrets
+// stored in plaintext are encrypted and encrypted ones get their data key re-encrypted.
+// With toPlaintext they are decrypted instead. It returns the number of secrets changed.
+func (r *Repository) ReencryptAppSecrets(ctx context.Context, toPlaintext bool) (int, error) {
+	const op = "postgresql.Repository.ReencryptAppSecrets"
+	var target int64
+	if !toPlaintext && r.keyring != nil {
+		target = int64(r.keyring.Current())
+	}
+
+	var changed int
+	err := r.WithTx(ctx, func(ctx context.Context) error {
+		tx := r.conn(ctx)
+		query := "SELECT id, secret, key_version FROM apps WHERE COALESCE(key_version, 0) <> $1 FOR UPDATE"
+		rows, err := tx.Query(ctx, query, target)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, translateError(err))
+		}
+		type appSecret struct {
+			id         int64
+			secret     string
+			keyVersion sql.NullInt64
+		}
+		var secrets []appSecret
+		for rows.Next() {
+			var s appSecret
+			if err := rows.Scan(&s.id, &s.secret, &s.keyVersion); err != nil {
+				rows.Close()
+				return fmt.Errorf("%s: %w", op, translateError(err))
+			}
+			secrets = append(secrets, s)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("%s: %w", op, translateError(err))
+		}
+
+		query = "UPDATE apps SET secret = $1, key_version = $2 WHERE id = $3"
+		for _, s := range secrets {
+			stored, keyVersion, err := storage.ResealSecret(r.keyring, s.id, s.secret, s.keyVersion.Int64, toPlaintext)
+			if err != nil {
+				return fmt.Errorf("%s: app %d: %w", op, s.id, err)
+			}
+			if _, err := tx.Exec(ctx, query, stored, nullInt64(keyVersion), s.id); err != nil {
+				return fmt.Errorf("%s: %w", op, translateError(err))
+			}
+		}
+		changed = len(secrets)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return changed, nil
+}
@@ -0,0 +1,86 @@
+package postgresql
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/botanikn/go_sso_service/internal/storage"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// PostgreSQL error codes the repository translates into storage errors.
+const (
+	uniqueViolation      = "23505"
+	foreignKeyViolation  = "23503"
+	checkViolation       = "23514"
+	invalidTextValue     = "22P02"
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+	queryCanceled        = "57014"
+	adminShutdown        = "57P01"
+	crashShutdown        = "57P02"
+	cannotConnectNow     = "57P03"
+)
+
+// uniqueConstraints maps unique constraints to the error for a taken value.
+var uniqueConstraints = map[string]error{
+	"users_email_key":          &storage.ConflictError{Field: "email", Err: storage.ErrUserExists},
+	"users_username_key":       &storage.ConflictError{Field: "username", Err: storage.ErrUserExists},
+	"apps_name_key":            &storage.ConflictError{Field: "name", Err: storage.ErrAppExists},
+	"apps_secret_key":          &storage.ConflictError{Field: "secret", Err: storage.ErrAppExists},
+	"policies_app_id_name_key": &storage.ConflictError{Field: "name", Err: storage.ErrPolicyExists},
+}
+
+// foreignKeyConstraints maps foreign keys to the error for a missing referenced row.
+var foreignKeyConstraints = map[string]error{
+	"permissions_user_id_fkey":        storage.ErrUserNotFound,
+	"permissions_app_id_fkey":         storage.ErrAppNotFound,
+	"permissions_granted_by_fkey":     storage.ErrUserNotFound,
+	"invitations_app_id_fkey":         storage.ErrAppNotFound,
+	"invitations_invited_by_fkey":     storage.ErrUserNotFound,
+	"invitations_accepted_by_fkey":    storage.ErrUserNotFound,
+	"app_settings_app_id_fkey":        storage.ErrAppNotFound,
+	"policies_app_id_fkey":            storage.ErrAppNotFound,
+	"relation_namespaces_app_id_fkey": storage.ErrAppNotFound,
+	"relation_tuples_app_id_fkey":     storage.ErrAppNotFound,
+}
+
+// translateError maps a PostgreSQL error to the storage error for its code and constraint,
+// keeping the driver error in the chain. A failure to connect is ErrUnavailable. Other errors,
+// pgx.ErrNoRows included, are returned as is.
+func translateError(err error) error {
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) {
+		return fmt.Errorf("%w: %w", storage.ErrUnavailable, err)
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	var translated error
+	switch pgErr.Code {
+	case uniqueViolation:
+		translated = uniqueConstraints[pgErr.ConstraintName]
+		if translated == nil {
+			translated = storage.ErrConflict
+		}
+	case foreignKeyViolation:
+		translated = foreignKeyConstraints[pgErr.ConstraintName]
+		if translated == nil {
+			translated = storage.ErrReferenceNotFound
+		}
+	case checkViolation, invalidTextValue:
+		translated = storage.ErrInvalidValue
+	case serializationFailure, deadlockDetected:
+		translated = storage.ErrSerialization
+	case queryCanceled:
+		translated = storage.ErrQueryTimeout
+	case adminShutdown, crashShutdown, cannotConnectNow:
+		translated = storage.ErrUnavailable
+	default:
+		return err
+	}
+	return fmt.Errorf("%w: %w", translated, err)
+}
@@ -0,0 +1,70 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+)
+
+func (r *Repository) GrantPermission(ctx context.Context, grant models.PermissionGrant) (int64, error) {
+	const op = "postgresql.Repository.GrantPermission"
+	query := `INSERT INTO permissions (user_id, app_id, permission, valid_from, valid_until, granted_by, justification)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+
+	var id int64
+	if err := r.conn(ctx).QueryRow(ctx, query,
+		grant.UserId,
+		grant.AppId,
+		grant.Permission,
+		nullTime(grant.ValidFrom),
+		nullTime(grant.ValidUntil),
+		nullInt64(grant.GrantedBy),
+		grant.Justification,
+	).Scan(&id); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return id, nil
+}
+
+// DeleteExpiredPermissions removes grants whose validity ended before now and returns them.
+func (r *Repository) DeleteExpiredPermissions(ctx context.Context, now time.Time) ([]models.PermissionGrant, error) {
+	const op = "postgresql.Repository.DeleteExpiredPermissions"
+	query := `DELETE FROM permissions WHERE valid_until IS NOT NULL AND valid_until <= $1
+		RETURNING id, user_id, app_id, permission, valid_from, valid_until, granted_by, justification`
+
+	rows, err := r.conn(ctx).Query(ctx, query, now)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	defer rows.Close()
+
+	var grants []models.PermissionGrant
+	for rows.Next() {
+		var grant models.PermissionGrant
+		var validFrom, validUntil sql.NullTime
+		var grantedBy sql.NullInt64
+		if err := rows.Scan(
+			&grant.ID,
+			&grant.UserId,
+			&grant.AppId,
+			&grant.Permission,
+			&validFrom,
+			&validUntil,
+			&grantedBy,
+			&grant.Justification,
+		); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, translateError(err))
+		}
+		grant.ValidFrom = validFrom.Time
+		grant.ValidUntil = validUntil.Time
+		grant.GrantedBy = grantedBy.Int64
+		grants = append(grants, grant)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return grants, nil
+}
@@ -0,0 +1,46 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+)
+
+// AppMembers returns up to limit members of the app with user id greater than afterUserId,
+// ordered by user id. The permission is the effective one, so a valid time-bound grant
+// wins over the base permission the same way it does in Permission. An empty role matches any.
+func (r *Repository) AppMembers(ctx context.Context, appId int64, role string, afterUserId int64, limit int) ([]models.AppMember, error) {
+	const op = "postgresql.Repository.AppMembers"
+	query := `SELECT user_id, email, username, permission FROM (
+			SELECT DISTINCT ON (p.user_id) p.user_id, u.email, u.username, p.permission::text AS permission
+			FROM permissions p
+			JOIN users u ON u.id = p.user_id
+			WHERE p.app_id = $1 AND p.user_id > $2
+			AND (p.valid_from IS NULL OR p.valid_from <= now())
+			AND (p.valid_until IS NULL OR p.valid_until > now())
+			ORDER BY p.user_id, (p.valid_from IS NULL AND p.valid_until IS NULL), p.id DESC
+		) members
+		WHERE $3 = '' OR permission = $3
+		ORDER BY user_id
+		LIMIT $4`
+
+	rows, err := r.reader(ctx).Query(ctx, query, appId, afterUserId, role, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	defer rows.Close()
+
+	var members []models.AppMember
+	for rows.Next() {
+		var member models.AppMember
+		if err := rows.Scan(&member.UserId, &member.Email, &member.Username, &member.Permission); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, translateError(err))
+		}
+		members = append(members, member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return members, nil
+}
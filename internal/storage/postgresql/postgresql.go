@@ -3,99 +3,289 @@ package postgresql
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/botanikn/go_sso_service/internal/domain/models"
 	"github.com/botanikn/go_sso_service/internal/storage"
+	"github.com/botanikn/go_sso_service/pkg/database"
+	"github.com/botanikn/go_sso_service/pkg/envelope"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// Repository runs every query on a pgx pool. Statements are prepared on first use and
+// cached on each pooled connection, see database.NewPool. Read-only queries go to replicas
+// when there are any, see reader. App secrets are encrypted with keyring, they are stored
+// in plaintext when it is nil.
 type Repository struct {
-	DB *sql.DB
+	DB       *pgxpool.Pool
+	replicas *database.Replicas
+	keyring  *envelope.Keyring
 }
 
-func New(db *sql.DB) *Repository {
+// New returns a Repository on the primary db, replicas may be nil.
+func New(db *pgxpool.Pool, replicas *database.Replicas, keyring *envelope.Keyring) *Repository {
 	return &Repository{
-		DB: db,
+		DB:       db,
+		replicas: replicas,
+		keyring:  keyring,
 	}
 }
 
-// TODO: Use for all these methods db.Prepare and ExecContext for better performance
 func (r *Repository) SaveUser(ctx context.Context, email string, username string, passHash []byte) (int64, error) {
 	const op = "postgresql.Repository.SaveUser"
 	query := "INSERT INTO users (email, username, pass_hash) VALUES ($1, $2, $3) RETURNING id"
 	var id int64
-	if err := r.DB.QueryRowContext(ctx, query, email, username, passHash).Scan(&id); err != nil {
-		return 0, fmt.Errorf("%s: %w", op, err)
+	if err := r.conn(ctx).QueryRow(ctx, query, email, username, passHash).Scan(&id); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, translateError(err))
 	}
 	return id, nil
 }
 
 func (r *Repository) User(ctx context.Context, email string) (models.User, error) {
 	const op = "postgresql.Repository.User"
-	query := "SELECT id, email, pass_hash FROM users WHERE email = $1"
-	row := r.DB.QueryRowContext(ctx, query, email)
+	query := "SELECT id, email, pass_hash, status, deleted_at FROM users WHERE email = $1"
+	row := r.reader(ctx).QueryRow(ctx, query, email)
 
 	var user models.User
-	if err := row.Scan(&user.ID, &user.Email, &user.PassHash); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+	var deletedAt sql.NullTime
+	if err := row.Scan(&user.ID, &user.Email, &user.PassHash, &user.Status, &deletedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
 		}
-		return models.User{}, fmt.Errorf("%s: %w", op, err)
+		return models.User{}, fmt.Errorf("%s: %w", op, translateError(err))
 	}
+	user.DeletedAt = deletedAt.Time
+	return user, nil
+}
+
+func (r *Repository) UserById(ctx context.Context, userId int64) (models.User, error) {
+	const op = "postgresql.Repository.UserById"
+	query := "SELECT id, email, username, pass_hash, status, is_super_admin, tokens_revoked_at, deleted_at, attributes FROM users WHERE id = $1"
+	row := r.reader(ctx).QueryRow(ctx, query, userId)
+
+	var user models.User
+	var tokensRevokedAt, deletedAt sql.NullTime
+	var attributes []byte
+	if err := row.Scan(&user.ID, &user.Email, &user.Username, &user.PassHash, &user.Status, &user.SuperAdmin, &tokensRevokedAt, &deletedAt, &attributes); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+		return models.User{}, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	if err := json.Unmarshal(attributes, &user.Attributes); err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	user.TokensRevokedAt = tokensRevokedAt.Time
+	user.DeletedAt = deletedAt.Time
 	return user, nil
 }
 
 func (r *Repository) Permission(ctx context.Context, userId int64, appId int64) (string, error) {
-	const op = "postgresql.Repository.GetPermission"
-	query := "SELECT permission FROM permissions WHERE user_id = $1 AND app_id = $2"
-	row := r.DB.QueryRowContext(ctx, query, userId, appId)
+	permission, _, err := r.PermissionUntil(ctx, userId, appId)
+	return permission, err
+}
+
+// PermissionUntil is Permission and the time it may change next, when the grant in
+// effect ends or the next grant starts. The time is zero when neither is going to happen.
+func (r *Repository) PermissionUntil(ctx context.Context, userId int64, appId int64) (string, time.Time, error) {
+	const op = "postgresql.Repository.PermissionUntil"
+	// Time-bound grants override the base permission while they are valid.
+	query := `SELECT permission, valid_until,
+			(SELECT min(valid_from) FROM permissions
+				WHERE user_id = $1 AND app_id = $2 AND valid_from > now())
+		FROM permissions
+		WHERE user_id = $1 AND app_id = $2
+		AND (valid_from IS NULL OR valid_from <= now())
+		AND (valid_until IS NULL OR valid_until > now())
+		ORDER BY (valid_from IS NULL AND valid_until IS NULL), id DESC
+		LIMIT 1`
+	row := r.reader(ctx).QueryRow(ctx, query, userId, appId)
 
 	var permission string
-	if err := row.Scan(&permission); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return "", fmt.Errorf("%s: %w", op, storage.ErrNoPermissionFound)
+	var validUntil, nextValidFrom sql.NullTime
+	if err := row.Scan(&permission, &validUntil, &nextValidFrom); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", time.Time{}, fmt.Errorf("%s: %w", op, storage.ErrNoPermissionFound)
 		}
-		return "", fmt.Errorf("%s: %w", op, err)
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, translateError(err))
 	}
-	return permission, nil
+	return permission, storage.Earliest(validUntil.Time, nextValidFrom.Time), nil
 }
 
 func (r *Repository) App(ctx context.Context, appId int64) (models.App, error) {
 	const op = "postgresql.Repository.App"
-	query := "SELECT id, name, secret FROM apps WHERE id = $1"
-	row := r.DB.QueryRowContext(ctx, query, appId)
+	query := "SELECT id, name, secret, key_version FROM apps WHERE id = $1"
+	row := r.reader(ctx).QueryRow(ctx, query, appId)
 
 	var app models.App
-	if err := row.Scan(&app.ID, &app.Name, &app.Secret); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+	var keyVersion sql.NullInt64
+	if err := row.Scan(&app.ID, &app.Name, &app.Secret, &keyVersion); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return models.App{}, fmt.Errorf("%s: %w", op, storage.ErrAppNotFound)
 		}
+		return models.App{}, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	secret, err := storage.OpenSecret(r.keyring, appId, app.Secret, keyVersion.Int64)
+	if err != nil {
 		return models.App{}, fmt.Errorf("%s: %w", op, err)
 	}
+	app.Secret = secret
 	return app, nil
 }
 
 func (r *Repository) CreatePermission(ctx context.Context, userId int64, appId int64, permission string) (bool, error) {
 	const op = "postgresql.Repository.CreatePermission"
 	query := "INSERT INTO permissions (user_id, app_id, permission) VALUES ($1, $2, $3)"
-	_, err := r.DB.ExecContext(ctx, query, userId, appId, permission)
+	_, err := r.conn(ctx).Exec(ctx, query, userId, appId, permission)
 	if err != nil {
-		return false, fmt.Errorf("%s: %w", op, err)
+		return false, fmt.Errorf("%s: %w", op, translateError(err))
 	}
 	return true, nil
 }
 
+// UpdatePermission sets the permanent permission. Time-bound grants are left in place,
+// they end when they expire or are revoked.
 func (r *Repository) UpdatePermission(ctx context.Context, userId int64, appId int64, permission string) error {
 	const op = "postgresql.Repository.UpdatePermission"
-	query := "UPDATE permissions SET permission = $1 WHERE user_id = $2 AND app_id = $3"
-	result, err := r.DB.ExecContext(ctx, query, permission, userId, appId)
+	query := "UPDATE permissions SET permission = $1 WHERE user_id = $2 AND app_id = $3 AND valid_from IS NULL AND valid_until IS NULL"
+	result, err := r.conn(ctx).Exec(ctx, query, permission, userId, appId)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return fmt.Errorf("%s: %w", op, translateError(err))
 	}
-	rowsAffected, err := result.RowsAffected()
-	if err != nil || rowsAffected == 0 {
-		return fmt.Errorf("%s: %w", op, err)
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrNoPermissionFound)
 	}
 	return nil
 }
+
+// BatchUpdatePermissions sets the permanent permission of every change in one transaction,
+// creating the permission when the user has none in the app yet. Each change runs in its
+// own savepoint so a failing change doesn't hide the outcome of the others. The transaction
+// is committed only if no change failed and dryRun is false.
+func (r *Repository) BatchUpdatePermissions(
+	ctx context.Context,
+	changes []models.PermissionChange,
+	dryRun bool,
+) ([]models.PermissionChangeResult, bool, error) {
+	const op = "postgresql.Repository.BatchUpdatePermissions"
+
+	var results []models.PermissionChangeResult
+	err := r.WithTx(ctx, func(ctx context.Context) error {
+		tx := r.conn(ctx)
+		results = make([]models.PermissionChangeResult, 0, len(changes))
+		failed := false
+		for _, change := range changes {
+			if _, err := tx.Exec(ctx, "SAVEPOINT permission_change"); err != nil {
+				return fmt.Errorf("%s: %w", op, translateError(err))
+			}
+
+			result, err := upsertPermission(ctx, tx, change)
+			if errors.Is(err, storage.ErrSerialization) {
+				// Lost to a concurrent transaction, WithTx retries the whole batch.
+				return fmt.Errorf("%s: %w", op, err)
+			}
+			if err != nil {
+				if _, rbErr := tx.Exec(ctx, "ROLLBACK TO SAVEPOINT permission_change"); rbErr != nil {
+					return fmt.Errorf("%s: %w", op, translateError(rbErr))
+				}
+				failed = true
+				result = models.PermissionChangeResult{
+					Change:  change,
+					Outcome: models.PermissionChangeFailed,
+					Err:     err,
+				}
+			} else if _, err := tx.Exec(ctx, "RELEASE SAVEPOINT permission_change"); err != nil {
+				return fmt.Errorf("%s: %w", op, translateError(err))
+			}
+			results = append(results, result)
+		}
+
+		if failed || dryRun {
+			return errRollback
+		}
+		return nil
+	})
+	if errors.Is(err, errRollback) {
+		return results, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return results, true, nil
+}
+
+// upsertPermission inserts the permanent permission unless the user has one, which the
+// unique index on permanent permissions makes safe against concurrent upserts. An existing
+// permission is locked before it is compared and updated.
+func upsertPermission(ctx context.Context, tx dbtx, change models.PermissionChange) (models.PermissionChangeResult, error) {
+	result := models.PermissionChangeResult{Change: change}
+
+	query := `INSERT INTO permissions (user_id, app_id, permission) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, app_id) WHERE valid_from IS NULL AND valid_until IS NULL DO NOTHING`
+	inserted, err := tx.Exec(ctx, query, change.UserId, change.AppId, change.Permission)
+	if err != nil {
+		return result, translateError(err)
+	}
+	if inserted.RowsAffected() == 1 {
+		result.Outcome = models.PermissionChangeCreated
+		return result, nil
+	}
+
+	query = `SELECT permission FROM permissions
+		WHERE user_id = $1 AND app_id = $2 AND valid_from IS NULL AND valid_until IS NULL
+		FOR UPDATE`
+	if err := tx.QueryRow(ctx, query, change.UserId, change.AppId).Scan(&result.PreviousPermission); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// The permission was deleted since the insert ran into it.
+			return result, storage.ErrSerialization
+		}
+		return result, translateError(err)
+	}
+	if result.PreviousPermission == change.Permission {
+		result.Outcome = models.PermissionChangeUnchanged
+		return result, nil
+	}
+
+	query = "UPDATE permissions SET permission = $1 WHERE user_id = $2 AND app_id = $3 AND valid_from IS NULL AND valid_until IS NULL"
+	if _, err := tx.Exec(ctx, query, change.Permission, change.UserId, change.AppId); err != nil {
+		return result, translateError(err)
+	}
+	result.Outcome = models.PermissionChangeUpdated
+	return result, nil
+}
+
+// RoleMemberIds returns users holding role permanently in the app, time-bound grants are not included.
+func (r *Repository) RoleMemberIds(ctx context.Context, appId int64, role string) ([]int64, error) {
+	const op = "postgresql.Repository.RoleMemberIds"
+	query := "SELECT DISTINCT user_id FROM permissions WHERE app_id = $1 AND permission = $2 AND valid_from IS NULL AND valid_until IS NULL"
+	rows, err := r.reader(ctx).Query(ctx, query, appId, role)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	defer rows.Close()
+
+	var userIds []int64
+	for rows.Next() {
+		var userId int64
+		if err := rows.Scan(&userId); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, translateError(err))
+		}
+		userIds = append(userIds, userId)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return userIds, nil
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+func nullInt64(v int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: v, Valid: v != 0}
+}
@@ -0,0 +1,84 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// AppSettings returns the settings of the app, or the defaults when none are stored.
+// It does not check that the app exists.
+func (r *Repository) AppSettings(ctx context.Context, appId int64) (models.AppSettings, error) {
+	const op = "postgresql.Repository.AppSettings"
+	query := `
+		SELECT access_token_ttl_seconds, refresh_token_ttl_seconds, login_methods,
+			registration_policy, membership_policy, allowed_domains, mfa_required
+		FROM app_settings
+		WHERE app_id = $1`
+
+	settings := models.AppSettings{AppId: appId}
+	var accessTTL, refreshTTL int64
+	err := r.reader(ctx).QueryRow(ctx, query, appId).Scan(
+		&accessTTL,
+		&refreshTTL,
+		&settings.LoginMethods,
+		&settings.RegistrationPolicy,
+		&settings.MembershipPolicy,
+		&settings.AllowedDomains,
+		&settings.MFARequired,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.DefaultAppSettings(appId), nil
+		}
+		return models.AppSettings{}, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	settings.AccessTokenTTL = time.Duration(accessTTL) * time.Second
+	settings.RefreshTokenTTL = time.Duration(refreshTTL) * time.Second
+	return settings, nil
+}
+
+// SaveAppSettings creates or replaces the settings of the app.
+func (r *Repository) SaveAppSettings(ctx context.Context, settings models.AppSettings) error {
+	const op = "postgresql.Repository.SaveAppSettings"
+	query := `
+		INSERT INTO app_settings (app_id, access_token_ttl_seconds, refresh_token_ttl_seconds,
+			login_methods, registration_policy, membership_policy, allowed_domains, mfa_required)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (app_id) DO UPDATE SET
+			access_token_ttl_seconds = EXCLUDED.access_token_ttl_seconds,
+			refresh_token_ttl_seconds = EXCLUDED.refresh_token_ttl_seconds,
+			login_methods = EXCLUDED.login_methods,
+			registration_policy = EXCLUDED.registration_policy,
+			membership_policy = EXCLUDED.membership_policy,
+			allowed_domains = EXCLUDED.allowed_domains,
+			mfa_required = EXCLUDED.mfa_required`
+
+	// pgx sends nil slices as NULL, the columns want empty arrays.
+	loginMethods, allowedDomains := settings.LoginMethods, settings.AllowedDomains
+	if loginMethods == nil {
+		loginMethods = []string{}
+	}
+	if allowedDomains == nil {
+		allowedDomains = []string{}
+	}
+
+	_, err := r.conn(ctx).Exec(ctx, query,
+		settings.AppId,
+		int64(settings.AccessTokenTTL/time.Second),
+		int64(settings.RefreshTokenTTL/time.Second),
+		loginMethods,
+		settings.RegistrationPolicy,
+		settings.MembershipPolicy,
+		allowedDomains,
+		settings.MFARequired,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/botanikn/go_sso_service/internal/storage"
+)
+
+func (r *Repository) HasSuperAdmin(ctx context.Context) (bool, error) {
+	const op = "postgresql.Repository.HasSuperAdmin"
+	query := "SELECT EXISTS (SELECT 1 FROM users WHERE is_super_admin)"
+
+	var exists bool
+	if err := r.reader(ctx).QueryRow(ctx, query).Scan(&exists); err != nil {
+		return false, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return exists, nil
+}
+
+func (r *Repository) SetSuperAdmin(ctx context.Context, userId int64, superAdmin bool) error {
+	const op = "postgresql.Repository.SetSuperAdmin"
+	query := "UPDATE users SET is_super_admin = $1 WHERE id = $2"
+
+	result, err := r.conn(ctx).Exec(ctx, query, superAdmin, userId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/botanikn/go_sso_service/internal/storage"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// dbtx is what methods run their queries on, the pool or the transaction of WithTx.
+// Methods that need a transaction of their own run in WithTx, which nests into the
+// transaction of the caller as a savepoint.
+type dbtx interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+type txKey struct{}
+
+// errRollback is returned from a WithTx fn to roll back without failing, such as a dry run.
+var errRollback = errors.New("rollback")
+
+// conn returns the transaction of WithTx carried by ctx, or the primary outside of one.
+// It records a write in the session of ctx, reads that follow it go to the primary too.
+func (r *Repository) conn(ctx context.Context) dbtx {
+	storage.MarkWritten(ctx)
+	return r.primary(ctx)
+}
+
+// primary is conn for reads that must see the latest committed state.
+func (r *Repository) primary(ctx context.Context) dbtx {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return r.DB
+}
+
+// reader returns where a read-only query runs, a healthy replica unless ctx carries
+// a transaction or its session has written already. It falls back to the primary
+// when no replica is healthy.
+func (r *Repository) reader(ctx context.Context) dbtx {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok || storage.Written(ctx) {
+		return r.primary(ctx)
+	}
+	if replica := r.replicas.Pick(); replica != nil {
+		return replica
+	}
+	return r.DB
+}
+
+// WithTx runs fn in a serializable transaction, every method called with the context fn
+// gets runs in it. The transaction is committed when fn returns nil and rolled back otherwise.
+// It is retried from scratch when it loses to a concurrent one, so fn must not have effects
+// outside the database. Called inside another WithTx, fn runs in a savepoint of the outer
+// transaction and is not retried on its own.
+func (r *Repository) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	const op = "postgresql.Repository.WithTx"
+	storage.MarkWritten(ctx)
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return runTx(ctx, op, tx.Begin, fn)
+	}
+
+	begin := func(ctx context.Context) (pgx.Tx, error) {
+		return r.DB.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
+	}
+	return storage.RetryTx(ctx, func() error {
+		return runTx(ctx, op, begin, fn)
+	})
+}
+
+func runTx(ctx context.Context, op string, begin func(ctx context.Context) (pgx.Tx, error), fn func(ctx context.Context) error) error {
+	tx, err := begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return nil
+}
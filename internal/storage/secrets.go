@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+
+	"github.com/botanikn/go_sso_service/pkg/envelope"
+)
+
+// secretAAD binds an encrypted app secret to the column and the row it is stored in,
+// the secret of one app can't be copied over to another.
+func secretAAD(appId int64) []byte {
+	return []byte("apps.secret:" + strconv.FormatInt(appId, 10))
+}
+
+// SealSecret returns the secret of the app the way the SQL backends store it, encrypted with
+// keyring and base64 encoded, along with the key version. Without a keyring the secret is
+// stored as is with version zero.
+func SealSecret(keyring *envelope.Keyring, appId int64, secret string) (string, int64, error) {
+	if keyring == nil {
+		return secret, 0, nil
+	}
+	ciphertext, version, err := keyring.Encrypt([]byte(secret), secretAAD(appId))
+	if err != nil {
+		return "", 0, err
+	}
+	return base64.StdEncoding.EncodeToString(ciphertext), int64(version), nil
+}
+
+// OpenSecret returns the secret of the app stored by SealSecret with keyVersion.
+func OpenSecret(keyring *envelope.Keyring, appId int64, stored string, keyVersion int64) (string, error) {
+	if keyVersion == 0 {
+		return stored, nil
+	}
+	if keyring == nil {
+		return "", fmt.Errorf("secret is encrypted but no keys are configured: %w", envelope.ErrUnknownKey)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(stored)
+	if err != nil {
+		return "", envelope.ErrMalformed
+	}
+	secret, err := keyring.Decrypt(ciphertext, secretAAD(appId), int(keyVersion))
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
+
+// ResealSecret moves a secret stored by SealSecret with keyVersion to the current key of
+// keyring, only its data key is re-encrypted when it is already encrypted. With toPlaintext
+// the secret is decrypted instead and stored as is.
+func ResealSecret(keyring *envelope.Keyring, appId int64, stored string, keyVersion int64, toPlaintext bool) (string, int64, error) {
+	if toPlaintext {
+		secret, err := OpenSecret(keyring, appId, stored, keyVersion)
+		return secret, 0, err
+	}
+	if keyring == nil {
+		return "", 0, fmt.Errorf("no keys are configured: %w", envelope.ErrUnknownKey)
+	}
+	if keyVersion == 0 {
+		return SealSecret(keyring, appId, stored)
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(stored)
+	if err != nil {
+		return "", 0, envelope.ErrMalformed
+	}
+	rewrapped, version, err := keyring.Rewrap(ciphertext, secretAAD(appId), int(keyVersion))
+	if err != nil {
+		return "", 0, err
+	}
+	return base64.StdEncoding.EncodeToString(rewrapped), int64(version), nil
+}
@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type sessionKey struct{}
+
+// WithSession returns a context that remembers whether a write was made with it, so a backend
+// with read replicas can send the reads that follow a write to the primary and the caller sees
+// its own writes. The gRPC server starts a session for every request.
+func WithSession(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sessionKey{}, new(atomic.Bool))
+}
+
+// MarkWritten records a write in the session of ctx, if it has one.
+func MarkWritten(ctx context.Context) {
+	if written, ok := ctx.Value(sessionKey{}).(*atomic.Bool); ok {
+		written.Store(true)
+	}
+}
+
+// Written reports whether a write was made in the session of ctx.
+func Written(ctx context.Context) bool {
+	written, ok := ctx.Value(sessionKey{}).(*atomic.Bool)
+	return ok && written.Load()
+}
+
+// WithPrimary returns a context whose reads go to the primary, as if a write was made with it.
+// Values that are kept after the read, such as cached ones, must not come from a lagging replica.
+func WithPrimary(ctx context.Context) context.Context {
+	written := new(atomic.Bool)
+	written.Store(true)
+	return context.WithValue(ctx, sessionKey{}, written)
+}
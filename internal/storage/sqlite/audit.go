@@ -0,0 +1,102 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+)
+
+func (r *Repository) SaveAuditEvent(ctx context.Context, event models.AuditEvent) error {
+	const op = "sqlite.Repository.SaveAuditEvent"
+
+	details := event.Details
+	if details == nil {
+		details = map[string]any{}
+	}
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, translateError(err))
+	}
+
+	query := "INSERT INTO audit_events (app_id, actor_id, user_id, action, details, created_at) VALUES ($1, $2, $3, $4, $5, $6)"
+	if _, err := r.conn(ctx).ExecContext(ctx, query,
+		nullInt64(event.AppId),
+		nullInt64(event.ActorId),
+		nullInt64(event.UserId),
+		event.Action,
+		string(detailsJSON),
+		toUnix(time.Now()),
+	); err != nil {
+		return fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return nil
+}
+
+// AuditEvents returns up to limit events with id greater than afterId that match the filter, ordered by id.
+func (r *Repository) AuditEvents(ctx context.Context, filter models.AuditFilter, afterId int64, limit int) ([]models.AuditEvent, error) {
+	const op = "sqlite.Repository.AuditEvents"
+
+	conditions := []string{"id > $1"}
+	args := []any{afterId}
+	if filter.AppId != 0 {
+		args = append(args, filter.AppId)
+		conditions = append(conditions, fmt.Sprintf("app_id = $%d", len(args)))
+	}
+	if filter.ActorId != 0 {
+		args = append(args, filter.ActorId)
+		conditions = append(conditions, fmt.Sprintf("actor_id = $%d", len(args)))
+	}
+	if filter.UserId != 0 {
+		args = append(args, filter.UserId)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	if filter.Action != "" {
+		args = append(args, filter.Action)
+		conditions = append(conditions, fmt.Sprintf("action = $%d", len(args)))
+	}
+	if !filter.Since.IsZero() {
+		args = append(args, toUnix(filter.Since))
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if !filter.Until.IsZero() {
+		args = append(args, toUnix(filter.Until))
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+	args = append(args, limit)
+	query := fmt.Sprintf("SELECT id, app_id, actor_id, user_id, action, details, created_at FROM audit_events WHERE %s ORDER BY id LIMIT $%d",
+		strings.Join(conditions, " AND "), len(args))
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	defer rows.Close()
+
+	var events []models.AuditEvent
+	for rows.Next() {
+		var event models.AuditEvent
+		var appId, actorId, userId sql.NullInt64
+		var details string
+		var createdAt sql.NullInt64
+		if err := rows.Scan(&event.ID, &appId, &actorId, &userId, &event.Action, &details, &createdAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, translateError(err))
+		}
+		if err := json.Unmarshal([]byte(details), &event.Details); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, translateError(err))
+		}
+		event.AppId = appId.Int64
+		event.ActorId = actorId.Int64
+		event.UserId = userId.Int64
+		event.CreatedAt = fromUnix(createdAt)
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return events, nil
+}
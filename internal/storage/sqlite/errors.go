@@ -0,0 +1,64 @@
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/botanikn/go_sso_service/internal/storage"
+	sqlite3 "modernc.org/sqlite"
+	sqlitelib "modernc.org/sqlite/lib"
+)
+
+// uniqueColumns maps unique columns, as SQLite names them in the error message,
+// to the error for a taken value.
+var uniqueColumns = map[string]error{
+	"users.email":                    &storage.ConflictError{Field: "email", Err: storage.ErrUserExists},
+	"users.username":                 &storage.ConflictError{Field: "username", Err: storage.ErrUserExists},
+	"apps.name":                      &storage.ConflictError{Field: "name", Err: storage.ErrAppExists},
+	"apps.secret":                    &storage.ConflictError{Field: "secret", Err: storage.ErrAppExists},
+	"policies.app_id, policies.name": &storage.ConflictError{Field: "name", Err: storage.ErrPolicyExists},
+}
+
+// translateError maps an SQLite error to the storage error for its code, keeping the driver
+// error in the chain. SQLite doesn't name the failed foreign key, so methods that need to
+// tell a missing user from a missing app look them up first. Other errors are returned as is.
+func translateError(err error) error {
+	var sqliteErr *sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return err
+	}
+
+	var translated error
+	switch sqliteErr.Code() {
+	case sqlitelib.SQLITE_CONSTRAINT_UNIQUE, sqlitelib.SQLITE_CONSTRAINT_PRIMARYKEY:
+		translated = storage.ErrConflict
+		if _, columns, ok := strings.Cut(sqliteErr.Error(), "UNIQUE constraint failed: "); ok {
+			columns, _, _ = strings.Cut(columns, " (")
+			if conflict, known := uniqueColumns[columns]; known {
+				translated = conflict
+			}
+		}
+	case sqlitelib.SQLITE_CONSTRAINT_FOREIGNKEY:
+		translated = storage.ErrReferenceNotFound
+	case sqlitelib.SQLITE_CONSTRAINT_CHECK:
+		translated = storage.ErrInvalidValue
+	case sqlitelib.SQLITE_BUSY, sqlitelib.SQLITE_BUSY_SNAPSHOT:
+		translated = storage.ErrSerialization
+	default:
+		// The primary code in the low byte covers every extended I/O error.
+		switch sqliteErr.Code() & 0xff {
+		case sqlitelib.SQLITE_IOERR, sqlitelib.SQLITE_CANTOPEN, sqlitelib.SQLITE_FULL:
+			translated = storage.ErrUnavailable
+		default:
+			return err
+		}
+	}
+	return fmt.Errorf("%w: %w", translated, err)
+}
+
+// isConstraint reports whether err is the SQLite constraint violation with the extended code.
+func isConstraint(err error, code int) bool {
+	var sqliteErr *sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == code
+}
@@ -0,0 +1,70 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+)
+
+func (r *Repository) GrantPermission(ctx context.Context, grant models.PermissionGrant) (int64, error) {
+	const op = "sqlite.Repository.GrantPermission"
+	query := `INSERT INTO permissions (user_id, app_id, permission, valid_from, valid_until, granted_by, justification)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+
+	var id int64
+	if err := r.conn(ctx).QueryRowContext(ctx, query,
+		grant.UserId,
+		grant.AppId,
+		grant.Permission,
+		toUnix(grant.ValidFrom),
+		toUnix(grant.ValidUntil),
+		nullInt64(grant.GrantedBy),
+		grant.Justification,
+	).Scan(&id); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return id, nil
+}
+
+// DeleteExpiredPermissions removes grants whose validity ended before now and returns them.
+func (r *Repository) DeleteExpiredPermissions(ctx context.Context, now time.Time) ([]models.PermissionGrant, error) {
+	const op = "sqlite.Repository.DeleteExpiredPermissions"
+	query := `DELETE FROM permissions WHERE valid_until IS NOT NULL AND valid_until <= $1
+		RETURNING id, user_id, app_id, permission, valid_from, valid_until, granted_by, justification`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, toUnix(now))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	defer rows.Close()
+
+	var grants []models.PermissionGrant
+	for rows.Next() {
+		var grant models.PermissionGrant
+		var validFrom, validUntil sql.NullInt64
+		var grantedBy sql.NullInt64
+		if err := rows.Scan(
+			&grant.ID,
+			&grant.UserId,
+			&grant.AppId,
+			&grant.Permission,
+			&validFrom,
+			&validUntil,
+			&grantedBy,
+			&grant.Justification,
+		); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, translateError(err))
+		}
+		grant.ValidFrom = fromUnix(validFrom)
+		grant.ValidUntil = fromUnix(validUntil)
+		grant.GrantedBy = grantedBy.Int64
+		grants = append(grants, grant)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return grants, nil
+}
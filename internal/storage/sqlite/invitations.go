@@ -0,0 +1,197 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/storage"
+)
+
+const invitationColumns = "id, app_id, email, role, invited_by, expires_at, created_at, accepted_at, accepted_by, revoked_at"
+
+func (r *Repository) SaveInvitation(ctx context.Context, invitation models.Invitation, tokenHash []byte) (models.Invitation, error) {
+	const op = "sqlite.Repository.SaveInvitation"
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return models.Invitation{}, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	defer tx.Rollback()
+
+	if err := checkExists(ctx, tx, "SELECT EXISTS (SELECT 1 FROM apps WHERE id = $1)", invitation.AppId, storage.ErrAppNotFound); err != nil {
+		return models.Invitation{}, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+
+	query := `INSERT INTO invitations (app_id, email, role, token_hash, invited_by, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + invitationColumns
+
+	row := tx.QueryRowContext(ctx, query,
+		invitation.AppId,
+		invitation.Email,
+		invitation.Role,
+		tokenHash,
+		nullInt64(invitation.InvitedBy),
+		toUnix(invitation.ExpiresAt),
+		toUnix(time.Now()),
+	)
+	saved, err := scanInvitation(row)
+	if err != nil {
+		return models.Invitation{}, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Invitation{}, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return saved, nil
+}
+
+// InvitationByTokenHash returns the invitation whatever its status.
+func (r *Repository) InvitationByTokenHash(ctx context.Context, tokenHash []byte) (models.Invitation, error) {
+	const op = "sqlite.Repository.InvitationByTokenHash"
+	query := "SELECT " + invitationColumns + " FROM invitations WHERE token_hash = $1"
+
+	invitation, err := scanInvitation(r.conn(ctx).QueryRowContext(ctx, query, tokenHash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Invitation{}, fmt.Errorf("%s: %w", op, storage.ErrInvitationNotFound)
+		}
+		return models.Invitation{}, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return invitation, nil
+}
+
+// Invitations returns up to limit invitations of the app with id greater than afterId, ordered by id.
+// With pendingOnly, accepted, revoked and expired invitations are left out.
+func (r *Repository) Invitations(ctx context.Context, appId int64, pendingOnly bool, afterId int64, limit int) ([]models.Invitation, error) {
+	const op = "sqlite.Repository.Invitations"
+	query := "SELECT " + invitationColumns + " FROM invitations WHERE app_id = $1 AND id > $2"
+	if pendingOnly {
+		query += " AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > $4"
+	}
+	query += " ORDER BY id LIMIT $3"
+
+	args := []any{appId, afterId, limit}
+	if pendingOnly {
+		args = append(args, toUnix(time.Now()))
+	}
+	rows, err := r.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	defer rows.Close()
+
+	var invitations []models.Invitation
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, translateError(err))
+		}
+		invitations = append(invitations, invitation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return invitations, nil
+}
+
+// RevokeInvitation revokes a pending invitation of the app.
+func (r *Repository) RevokeInvitation(ctx context.Context, appId int64, invitationId int64) (models.Invitation, error) {
+	const op = "sqlite.Repository.RevokeInvitation"
+	query := `UPDATE invitations SET revoked_at = $3
+		WHERE id = $1 AND app_id = $2 AND accepted_at IS NULL AND revoked_at IS NULL
+		RETURNING ` + invitationColumns
+
+	invitation, err := scanInvitation(r.conn(ctx).QueryRowContext(ctx, query, invitationId, appId, toUnix(time.Now())))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Invitation{}, fmt.Errorf("%s: %w", op, storage.ErrInvitationNotFound)
+		}
+		return models.Invitation{}, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return invitation, nil
+}
+
+// AcceptInvitation marks the pending invitation as accepted by the user and gives them
+// the invited role in the same transaction. A user who already has the role or a higher
+// one keeps it, and a banned user stays banned.
+func (r *Repository) AcceptInvitation(ctx context.Context, invitationId int64, userId int64) (models.PermissionChangeResult, error) {
+	const op = "sqlite.Repository.AcceptInvitation"
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return models.PermissionChangeResult{}, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE invitations SET accepted_at = $3, accepted_by = $2
+		WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > $3
+		RETURNING app_id, role`
+	change := models.PermissionChange{UserId: userId}
+	if err := tx.QueryRowContext(ctx, query, invitationId, userId, toUnix(time.Now())).Scan(&change.AppId, &change.Permission); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.PermissionChangeResult{}, fmt.Errorf("%s: %w", op, storage.ErrInvitationNotFound)
+		}
+		return models.PermissionChangeResult{}, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+
+	var current string
+	query = `SELECT permission FROM permissions
+		WHERE user_id = $1 AND app_id = $2 AND valid_from IS NULL AND valid_until IS NULL`
+	err = tx.QueryRowContext(ctx, query, userId, change.AppId).Scan(&current)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return models.PermissionChangeResult{}, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+
+	result := models.PermissionChangeResult{
+		Change:             change,
+		Outcome:            models.PermissionChangeUnchanged,
+		PreviousPermission: current,
+	}
+	currentRank, _ := models.RoleRank(current)
+	invitedRank, _ := models.RoleRank(change.Permission)
+	if current == "" || (current != models.RoleBanned && invitedRank > currentRank) {
+		result, err = upsertPermission(ctx, tx, change)
+		if err != nil {
+			return models.PermissionChangeResult{}, fmt.Errorf("%s: %w", op, translateError(err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.PermissionChangeResult{}, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return result, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanInvitation(row rowScanner) (models.Invitation, error) {
+	var invitation models.Invitation
+	var invitedBy, acceptedBy sql.NullInt64
+	var expiresAt, createdAt, acceptedAt, revokedAt sql.NullInt64
+	if err := row.Scan(
+		&invitation.ID,
+		&invitation.AppId,
+		&invitation.Email,
+		&invitation.Role,
+		&invitedBy,
+		&expiresAt,
+		&createdAt,
+		&acceptedAt,
+		&acceptedBy,
+		&revokedAt,
+	); err != nil {
+		return models.Invitation{}, err
+	}
+	invitation.InvitedBy = invitedBy.Int64
+	invitation.ExpiresAt = fromUnix(expiresAt)
+	invitation.CreatedAt = fromUnix(createdAt)
+	invitation.AcceptedAt = fromUnix(acceptedAt)
+	invitation.AcceptedBy = acceptedBy.Int64
+	invitation.RevokedAt = fromUnix(revokedAt)
+	return invitation, nil
+}
@@ -0,0 +1,123 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/storage"
+)
+
+func (r *Repository) Policies(ctx context.Context, appId int64, action string) ([]models.Policy, error) {
+	const op = "sqlite.Repository.Policies"
+	query := "SELECT id, app_id, name, action, effect, expression FROM policies WHERE app_id = $1 AND action = $2 ORDER BY id"
+	rows, err := r.conn(ctx).QueryContext(ctx, query, appId, action)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	defer rows.Close()
+
+	var policies []models.Policy
+	for rows.Next() {
+		var policy models.Policy
+		if err := rows.Scan(&policy.ID, &policy.AppId, &policy.Name, &policy.Action, &policy.Effect, &policy.Expression); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, translateError(err))
+		}
+		policies = append(policies, policy)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return policies, nil
+}
+
+// AppPolicies returns every policy of the app, ordered by action and id.
+func (r *Repository) AppPolicies(ctx context.Context, appId int64) ([]models.Policy, error) {
+	const op = "sqlite.Repository.AppPolicies"
+	query := "SELECT id, app_id, name, action, effect, expression FROM policies WHERE app_id = $1 ORDER BY action, id"
+	rows, err := r.conn(ctx).QueryContext(ctx, query, appId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	defer rows.Close()
+
+	var policies []models.Policy
+	for rows.Next() {
+		var policy models.Policy
+		if err := rows.Scan(&policy.ID, &policy.AppId, &policy.Name, &policy.Action, &policy.Effect, &policy.Expression); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, translateError(err))
+		}
+		policies = append(policies, policy)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return policies, nil
+}
+
+// SavePolicy stores a new policy of the app and returns its id.
+func (r *Repository) SavePolicy(ctx context.Context, policy models.Policy) (int64, error) {
+	const op = "sqlite.Repository.SavePolicy"
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	defer tx.Rollback()
+
+	if err := checkExists(ctx, tx, "SELECT EXISTS (SELECT 1 FROM apps WHERE id = $1)", policy.AppId, storage.ErrAppNotFound); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+
+	query := `INSERT INTO policies (app_id, name, action, effect, expression)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`
+	var id int64
+	if err := tx.QueryRowContext(ctx, query, policy.AppId, policy.Name, policy.Action, policy.Effect, policy.Expression).Scan(&id); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return id, nil
+}
+
+// UpdatePolicy replaces the name, action, effect and expression of the app's policy.
+func (r *Repository) UpdatePolicy(ctx context.Context, policy models.Policy) error {
+	const op = "sqlite.Repository.UpdatePolicy"
+	query := `UPDATE policies SET name = $3, action = $4, effect = $5, expression = $6
+		WHERE id = $1 AND app_id = $2`
+
+	result, err := r.conn(ctx).ExecContext(ctx, query, policy.ID, policy.AppId, policy.Name, policy.Action, policy.Effect, policy.Expression)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrPolicyNotFound)
+	}
+	return nil
+}
+
+// DeletePolicy removes the app's policy and returns it as it was.
+func (r *Repository) DeletePolicy(ctx context.Context, appId int64, policyId int64) (models.Policy, error) {
+	const op = "sqlite.Repository.DeletePolicy"
+	query := `DELETE FROM policies WHERE id = $1 AND app_id = $2
+		RETURNING id, app_id, name, action, effect, expression`
+
+	var policy models.Policy
+	err := r.conn(ctx).QueryRowContext(ctx, query, policyId, appId).
+		Scan(&policy.ID, &policy.AppId, &policy.Name, &policy.Action, &policy.Effect, &policy.Expression)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Policy{}, fmt.Errorf("%s: %w", op, storage.ErrPolicyNotFound)
+		}
+		return models.Policy{}, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return policy, nil
+}
@@ -0,0 +1,175 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/storage"
+	sqlitelib "modernc.org/sqlite/lib"
+)
+
+func (r *Repository) Namespace(ctx context.Context, appId int64, name string) (models.Namespace, error) {
+	const op = "sqlite.Repository.Namespace"
+	query := "SELECT app_id, name, config FROM relation_namespaces WHERE app_id = $1 AND name = $2"
+	row := r.conn(ctx).QueryRowContext(ctx, query, appId, name)
+
+	var namespace models.Namespace
+	var config []byte
+	if err := row.Scan(&namespace.AppId, &namespace.Name, &config); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Namespace{}, fmt.Errorf("%s: %w", op, storage.ErrNamespaceNotFound)
+		}
+		return models.Namespace{}, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	if err := json.Unmarshal(config, &namespace.Config); err != nil {
+		return models.Namespace{}, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return namespace, nil
+}
+
+// SaveNamespace creates or replaces a namespace of the app.
+func (r *Repository) SaveNamespace(ctx context.Context, namespace models.Namespace) error {
+	const op = "sqlite.Repository.SaveNamespace"
+	query := `
+		INSERT INTO relation_namespaces (app_id, name, config) VALUES ($1, $2, $3)
+		ON CONFLICT (app_id, name) DO UPDATE SET config = EXCLUDED.config`
+
+	config, err := json.Marshal(namespace.Config)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	if _, err := r.conn(ctx).ExecContext(ctx, query, namespace.AppId, namespace.Name, string(config)); err != nil {
+		if isConstraint(err, sqlitelib.SQLITE_CONSTRAINT_FOREIGNKEY) {
+			return fmt.Errorf("%s: %w", op, storage.ErrAppNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return nil
+}
+
+// RelationRevision returns the latest committed revision.
+func (r *Repository) RelationRevision(ctx context.Context) (int64, error) {
+	const op = "sqlite.Repository.RelationRevision"
+	query := "SELECT revision FROM relation_revision"
+
+	var revision int64
+	if err := r.conn(ctx).QueryRowContext(ctx, query).Scan(&revision); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return revision, nil
+}
+
+// RelationTuples returns the tuples matching filter as they were at the given revision.
+func (r *Repository) RelationTuples(ctx context.Context, appId int64, filter models.RelationTupleFilter, revision int64) ([]models.RelationTuple, error) {
+	const op = "sqlite.Repository.RelationTuples"
+
+	conditions := []string{
+		"app_id = $1",
+		"created_revision <= $2",
+		"(deleted_revision IS NULL OR deleted_revision > $2)",
+	}
+	args := []any{appId, revision}
+	addCondition := func(column string, value string) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+	if filter.Namespace != "" {
+		addCondition("namespace", filter.Namespace)
+	}
+	if filter.ObjectId != "" {
+		addCondition("object_id", filter.ObjectId)
+	}
+	if filter.Relation != "" {
+		addCondition("relation", filter.Relation)
+	}
+	if filter.Subject != nil {
+		addCondition("subject_namespace", filter.Subject.Namespace)
+		addCondition("subject_id", filter.Subject.ObjectId)
+		addCondition("subject_relation", filter.Subject.Relation)
+	}
+
+	query := "SELECT namespace, object_id, relation, subject_namespace, subject_id, subject_relation FROM relation_tuples WHERE " +
+		strings.Join(conditions, " AND ") + " ORDER BY id"
+	rows, err := r.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	defer rows.Close()
+
+	var tuples []models.RelationTuple
+	for rows.Next() {
+		var tuple models.RelationTuple
+		if err := rows.Scan(
+			&tuple.Namespace,
+			&tuple.ObjectId,
+			&tuple.Relation,
+			&tuple.Subject.Namespace,
+			&tuple.Subject.ObjectId,
+			&tuple.Subject.Relation,
+		); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, translateError(err))
+		}
+		tuples = append(tuples, tuple)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return tuples, nil
+}
+
+// WriteRelationTuples applies inserts and deletes in one transaction under a new revision
+// and returns that revision.
+func (r *Repository) WriteRelationTuples(ctx context.Context, appId int64, inserts []models.RelationTuple, deletes []models.RelationTuple) (int64, error) {
+	const op = "sqlite.Repository.WriteRelationTuples"
+
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	defer tx.Rollback()
+
+	var revision int64
+	if err := tx.QueryRowContext(ctx, "UPDATE relation_revision SET revision = revision + 1 RETURNING revision").Scan(&revision); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+
+	deleteQuery := `UPDATE relation_tuples SET deleted_revision = $1
+		WHERE app_id = $2 AND namespace = $3 AND object_id = $4 AND relation = $5
+		AND subject_namespace = $6 AND subject_id = $7 AND subject_relation = $8
+		AND deleted_revision IS NULL`
+	for _, tuple := range deletes {
+		if _, err := tx.ExecContext(ctx, deleteQuery,
+			revision, appId,
+			tuple.Namespace, tuple.ObjectId, tuple.Relation,
+			tuple.Subject.Namespace, tuple.Subject.ObjectId, tuple.Subject.Relation,
+		); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, translateError(err))
+		}
+	}
+
+	insertQuery := `INSERT INTO relation_tuples
+		(app_id, namespace, object_id, relation, subject_namespace, subject_id, subject_relation, created_revision)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (app_id, namespace, object_id, relation, subject_namespace, subject_id, subject_relation)
+		WHERE deleted_revision IS NULL DO NOTHING`
+	for _, tuple := range inserts {
+		if _, err := tx.ExecContext(ctx, insertQuery,
+			appId,
+			tuple.Namespace, tuple.ObjectId, tuple.Relation,
+			tuple.Subject.Namespace, tuple.Subject.ObjectId, tuple.Subject.Relation,
+			revision,
+		); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, translateError(err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return revision, nil
+}
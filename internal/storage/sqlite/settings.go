@@ -0,0 +1,105 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/storage"
+	sqlitelib "modernc.org/sqlite/lib"
+)
+
+// AppSettings returns the settings of the app, or the defaults when none are stored.
+// It does not check that the app exists.
+func (r *Repository) AppSettings(ctx context.Context, appId int64) (models.AppSettings, error) {
+	const op = "sqlite.Repository.AppSettings"
+	query := `
+		SELECT access_token_ttl_seconds, refresh_token_ttl_seconds, login_methods,
+			registration_policy, membership_policy, allowed_domains, mfa_required
+		FROM app_settings
+		WHERE app_id = $1`
+
+	settings := models.AppSettings{AppId: appId}
+	var accessTTL, refreshTTL int64
+	var loginMethods, allowedDomains string
+	err := r.conn(ctx).QueryRowContext(ctx, query, appId).Scan(
+		&accessTTL,
+		&refreshTTL,
+		&loginMethods,
+		&settings.RegistrationPolicy,
+		&settings.MembershipPolicy,
+		&allowedDomains,
+		&settings.MFARequired,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.DefaultAppSettings(appId), nil
+		}
+		return models.AppSettings{}, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	if err := json.Unmarshal([]byte(loginMethods), &settings.LoginMethods); err != nil {
+		return models.AppSettings{}, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	if err := json.Unmarshal([]byte(allowedDomains), &settings.AllowedDomains); err != nil {
+		return models.AppSettings{}, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	settings.AccessTokenTTL = time.Duration(accessTTL) * time.Second
+	settings.RefreshTokenTTL = time.Duration(refreshTTL) * time.Second
+	return settings, nil
+}
+
+// SaveAppSettings creates or replaces the settings of the app.
+func (r *Repository) SaveAppSettings(ctx context.Context, settings models.AppSettings) error {
+	const op = "sqlite.Repository.SaveAppSettings"
+	query := `
+		INSERT INTO app_settings (app_id, access_token_ttl_seconds, refresh_token_ttl_seconds,
+			login_methods, registration_policy, membership_policy, allowed_domains, mfa_required)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (app_id) DO UPDATE SET
+			access_token_ttl_seconds = excluded.access_token_ttl_seconds,
+			refresh_token_ttl_seconds = excluded.refresh_token_ttl_seconds,
+			login_methods = excluded.login_methods,
+			registration_policy = excluded.registration_policy,
+			membership_policy = excluded.membership_policy,
+			allowed_domains = excluded.allowed_domains,
+			mfa_required = excluded.mfa_required`
+
+	// nil slices would be stored as null, the columns want empty arrays.
+	loginMethods, allowedDomains := settings.LoginMethods, settings.AllowedDomains
+	if loginMethods == nil {
+		loginMethods = []string{}
+	}
+	if allowedDomains == nil {
+		allowedDomains = []string{}
+	}
+	loginMethodsJSON, err := json.Marshal(loginMethods)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	allowedDomainsJSON, err := json.Marshal(allowedDomains)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, translateError(err))
+	}
+
+	_, err = r.conn(ctx).ExecContext(ctx, query,
+		settings.AppId,
+		int64(settings.AccessTokenTTL/time.Second),
+		int64(settings.RefreshTokenTTL/time.Second),
+		string(loginMethodsJSON),
+		settings.RegistrationPolicy,
+		settings.MembershipPolicy,
+		string(allowedDomainsJSON),
+		settings.MFARequired,
+	)
+	if err != nil {
+		if isConstraint(err, sqlitelib.SQLITE_CONSTRAINT_FOREIGNKEY) {
+			return fmt.Errorf("%s: %w", op, storage.ErrAppNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return nil
+}
@@ -0,0 +1,313 @@
+// Package sqlite is a storage backend for single-node deployments that don't run PostgreSQL.
+// Its schema lives in migrations/sqlite. Timestamps are stored as unix microseconds,
+// arrays and JSON documents as JSON text.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/storage"
+	"github.com/botanikn/go_sso_service/pkg/envelope"
+)
+
+// Repository encrypts app secrets with keyring, they are stored in plaintext when it is nil.
+type Repository struct {
+	DB      *sql.DB
+	keyring *envelope.Keyring
+}
+
+func New(db *sql.DB, keyring *envelope.Keyring) *Repository {
+	return &Repository{
+		DB:      db,
+		keyring: keyring,
+	}
+}
+
+func (r *Repository) SaveUser(ctx context.Context, email string, username string, passHash []byte) (int64, error) {
+	const op = "sqlite.Repository.SaveUser"
+	query := "INSERT INTO users (email, username, pass_hash) VALUES ($1, $2, $3) RETURNING id"
+	var id int64
+	if err := r.conn(ctx).QueryRowContext(ctx, query, email, username, passHash).Scan(&id); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return id, nil
+}
+
+func (r *Repository) User(ctx context.Context, email string) (models.User, error) {
+	const op = "sqlite.Repository.User"
+	query := "SELECT id, email, pass_hash, status, deleted_at FROM users WHERE email = $1"
+	row := r.conn(ctx).QueryRowContext(ctx, query, email)
+
+	var user models.User
+	var deletedAt sql.NullInt64
+	if err := row.Scan(&user.ID, &user.Email, &user.PassHash, &user.Status, &deletedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+		return models.User{}, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	user.DeletedAt = fromUnix(deletedAt)
+	return user, nil
+}
+
+func (r *Repository) UserById(ctx context.Context, userId int64) (models.User, error) {
+	const op = "sqlite.Repository.UserById"
+	query := "SELECT id, email, username, pass_hash, status, is_super_admin, tokens_revoked_at, deleted_at, attributes FROM users WHERE id = $1"
+	row := r.conn(ctx).QueryRowContext(ctx, query, userId)
+
+	var user models.User
+	var tokensRevokedAt, deletedAt sql.NullInt64
+	var attributes string
+	if err := row.Scan(&user.ID, &user.Email, &user.Username, &user.PassHash, &user.Status, &user.SuperAdmin, &tokensRevokedAt, &deletedAt, &attributes); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+		return models.User{}, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	if err := json.Unmarshal([]byte(attributes), &user.Attributes); err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	user.TokensRevokedAt = fromUnix(tokensRevokedAt)
+	user.DeletedAt = fromUnix(deletedAt)
+	return user, nil
+}
+
+func (r *Repository) Permission(ctx context.Context, userId int64, appId int64) (string, error) {
+	permission, _, err := r.PermissionUntil(ctx, userId, appId)
+	return permission, err
+}
+
+// PermissionUntil is Permission and the time it may change next, when the grant in
+// effect ends or the next grant starts. The time is zero when neither is going to happen.
+func (r *Repository) PermissionUntil(ctx context.Context, userId int64, appId int64) (string, time.Time, error) {
+	const op = "sqlite.Repository.PermissionUntil"
+	// Time-bound grants override the base permission while they are valid.
+	query := `SELECT permission, valid_until,
+			(SELECT min(valid_from) FROM permissions
+				WHERE user_id = $1 AND app_id = $2 AND valid_from > $3)
+		FROM permissions
+		WHERE user_id = $1 AND app_id = $2
+		AND (valid_from IS NULL OR valid_from <= $3)
+		AND (valid_until IS NULL OR valid_until > $3)
+		ORDER BY (valid_from IS NULL AND valid_until IS NULL), id DESC
+		LIMIT 1`
+	row := r.conn(ctx).QueryRowContext(ctx, query, userId, appId, toUnix(time.Now()))
+
+	var permission string
+	var validUntil, nextValidFrom sql.NullInt64
+	if err := row.Scan(&permission, &validUntil, &nextValidFrom); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", time.Time{}, fmt.Errorf("%s: %w", op, storage.ErrNoPermissionFound)
+		}
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return permission, storage.Earliest(fromUnix(validUntil), fromUnix(nextValidFrom)), nil
+}
+
+func (r *Repository) App(ctx context.Context, appId int64) (models.App, error) {
+	const op = "sqlite.Repository.App"
+	query := "SELECT id, name, secret, key_version FROM apps WHERE id = $1"
+	row := r.conn(ctx).QueryRowContext(ctx, query, appId)
+
+	var app models.App
+	var keyVersion sql.NullInt64
+	if err := row.Scan(&app.ID, &app.Name, &app.Secret, &keyVersion); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.App{}, fmt.Errorf("%s: %w", op, storage.ErrAppNotFound)
+		}
+		return models.App{}, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	secret, err := storage.OpenSecret(r.keyring, appId, app.Secret, keyVersion.Int64)
+	if err != nil {
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+	app.Secret = secret
+	return app, nil
+}
+
+func (r *Repository) CreatePermission(ctx context.Context, userId int64, appId int64, permission string) (bool, error) {
+	const op = "sqlite.Repository.CreatePermission"
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	defer tx.Rollback()
+
+	if err := insertPermission(ctx, tx, userId, appId, permission); err != nil {
+		return false, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return true, nil
+}
+
+// UpdatePermission sets the permanent permission. Time-bound grants are left in place,
+// they end when they expire or are revoked.
+func (r *Repository) UpdatePermission(ctx context.Context, userId int64, appId int64, permission string) error {
+	const op = "sqlite.Repository.UpdatePermission"
+	query := "UPDATE permissions SET permission = $1 WHERE user_id = $2 AND app_id = $3 AND valid_from IS NULL AND valid_until IS NULL"
+	result, err := r.conn(ctx).ExecContext(ctx, query, permission, userId, appId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrNoPermissionFound)
+	}
+	return nil
+}
+
+// BatchUpdatePermissions sets the permanent permission of every change in one transaction,
+// creating the permission when the user has none in the app yet. Each change runs in its
+// own savepoint so a failing change doesn't hide the outcome of the others. The transaction
+// is committed only if no change failed and dryRun is false.
+func (r *Repository) BatchUpdatePermissions(
+	ctx context.Context,
+	changes []models.PermissionChange,
+	dryRun bool,
+) ([]models.PermissionChangeResult, bool, error) {
+	const op = "sqlite.Repository.BatchUpdatePermissions"
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	defer tx.Rollback()
+
+	results := make([]models.PermissionChangeResult, 0, len(changes))
+	failed := false
+	for _, change := range changes {
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT permission_change"); err != nil {
+			return nil, false, fmt.Errorf("%s: %w", op, translateError(err))
+		}
+
+		result, err := upsertPermission(ctx, tx, change)
+		if err != nil {
+			if _, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT permission_change"); rbErr != nil {
+				return nil, false, fmt.Errorf("%s: %w", op, translateError(rbErr))
+			}
+			failed = true
+			result = models.PermissionChangeResult{
+				Change:  change,
+				Outcome: models.PermissionChangeFailed,
+				Err:     err,
+			}
+		}
+		// Released after a rollback too, SQLite keeps a rolled back savepoint open.
+		if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT permission_change"); err != nil {
+			return nil, false, fmt.Errorf("%s: %w", op, translateError(err))
+		}
+		results = append(results, result)
+	}
+
+	if failed || dryRun {
+		return results, false, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return results, true, nil
+}
+
+// upsertPermission relies on the transaction for locking, SQLite transactions are serialized.
+func upsertPermission(ctx context.Context, tx querier, change models.PermissionChange) (models.PermissionChangeResult, error) {
+	result := models.PermissionChangeResult{Change: change}
+
+	query := `SELECT permission FROM permissions
+		WHERE user_id = $1 AND app_id = $2 AND valid_from IS NULL AND valid_until IS NULL`
+	err := tx.QueryRowContext(ctx, query, change.UserId, change.AppId).Scan(&result.PreviousPermission)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		if err := insertPermission(ctx, tx, change.UserId, change.AppId, change.Permission); err != nil {
+			return result, translateError(err)
+		}
+		result.Outcome = models.PermissionChangeCreated
+	case err != nil:
+		return result, translateError(err)
+	case result.PreviousPermission == change.Permission:
+		result.Outcome = models.PermissionChangeUnchanged
+	default:
+		query = "UPDATE permissions SET permission = $1 WHERE user_id = $2 AND app_id = $3 AND valid_from IS NULL AND valid_until IS NULL"
+		if _, err := tx.ExecContext(ctx, query, change.Permission, change.UserId, change.AppId); err != nil {
+			return result, translateError(err)
+		}
+		result.Outcome = models.PermissionChangeUpdated
+	}
+	return result, nil
+}
+
+// insertPermission adds a permanent permission. SQLite doesn't say which foreign key
+// failed, so the user and the app are looked up first.
+func insertPermission(ctx context.Context, tx querier, userId int64, appId int64, permission string) error {
+	if err := checkExists(ctx, tx, "SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)", userId, storage.ErrUserNotFound); err != nil {
+		return err
+	}
+	if err := checkExists(ctx, tx, "SELECT EXISTS (SELECT 1 FROM apps WHERE id = $1)", appId, storage.ErrAppNotFound); err != nil {
+		return err
+	}
+
+	query := "INSERT INTO permissions (user_id, app_id, permission) VALUES ($1, $2, $3)"
+	_, err := tx.ExecContext(ctx, query, userId, appId, permission)
+	return err
+}
+
+// RoleMemberIds returns users holding role permanently in the app, time-bound grants are not included.
+func (r *Repository) RoleMemberIds(ctx context.Context, appId int64, role string) ([]int64, error) {
+	const op = "sqlite.Repository.RoleMemberIds"
+	query := "SELECT DISTINCT user_id FROM permissions WHERE app_id = $1 AND permission = $2 AND valid_from IS NULL AND valid_until IS NULL"
+	rows, err := r.conn(ctx).QueryContext(ctx, query, appId, role)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	defer rows.Close()
+
+	var userIds []int64
+	for rows.Next() {
+		var userId int64
+		if err := rows.Scan(&userId); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, translateError(err))
+		}
+		userIds = append(userIds, userId)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return userIds, nil
+}
+
+func checkExists(ctx context.Context, tx querier, query string, id int64, notFound error) error {
+	var exists bool
+	if err := tx.QueryRowContext(ctx, query, id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return notFound
+	}
+	return nil
+}
+
+// toUnix converts t to the stored unix microseconds, the zero time is stored as NULL.
+func toUnix(t time.Time) sql.NullInt64 {
+	return sql.NullInt64{Int64: t.UnixMicro(), Valid: !t.IsZero()}
+}
+
+func fromUnix(v sql.NullInt64) time.Time {
+	if !v.Valid {
+		return time.Time{}
+	}
+	return time.UnixMicro(v.Int64).UTC()
+}
+
+func nullInt64(v int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: v, Valid: v != 0}
+}
@@ -0,0 +1,56 @@
+package sqlite_test
+
+import (
+	"bytes"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/botanikn/go_sso_service/internal/storage/sqlite"
+	"github.com/botanikn/go_sso_service/internal/storage/storagetest"
+	"github.com/botanikn/go_sso_service/pkg/database"
+	"github.com/botanikn/go_sso_service/pkg/envelope"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+)
+
+func TestStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Repository {
+		return sqlite.New(newDB(t), nil)
+	})
+}
+
+// TestStorageEncrypted runs the suite with app secrets encrypted at rest.
+func TestStorageEncrypted(t *testing.T) {
+	keyring, err := envelope.NewKeyring(1, map[int][]byte{1: bytes.Repeat([]byte{1}, 32)})
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	storagetest.Run(t, func(t *testing.T) storagetest.Repository {
+		return sqlite.New(newDB(t), keyring)
+	})
+}
+
+// newDB returns a database in a temporary file, migrated to the latest version.
+func newDB(t *testing.T) *sql.DB {
+	path := filepath.Join(t.TempDir(), "sso.db")
+
+	m, err := migrate.New("file://../../../migrations/sqlite", "sqlite://"+path)
+	if err != nil {
+		t.Fatalf("migrate.New: %v", err)
+	}
+	if err := m.Up(); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	if srcErr, dbErr := m.Close(); srcErr != nil || dbErr != nil {
+		t.Fatalf("migrate close: %v, %v", srcErr, dbErr)
+	}
+
+	db, err := database.NewDB("", 0, "", "", path, database.DriverSQLite)
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
@@ -0,0 +1,108 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/botanikn/go_sso_service/internal/storage"
+)
+
+// querier is what methods run their queries on, the database or the transaction of WithTx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// txn is a transaction of a method, a real one or a savepoint inside the transaction of WithTx.
+type txn interface {
+	querier
+	Commit() error
+	Rollback() error
+}
+
+type txKey struct{}
+
+// conn returns the transaction of WithTx carried by ctx, or the database outside of one.
+func (r *Repository) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(querier); ok {
+		return tx
+	}
+	return r.DB
+}
+
+// begin starts the transaction of a method. Inside WithTx it is a savepoint instead:
+// the database has a single connection, which the outer transaction holds.
+func (r *Repository) begin(ctx context.Context) (txn, error) {
+	tx, ok := ctx.Value(txKey{}).(querier)
+	if !ok {
+		return r.DB.BeginTx(ctx, nil)
+	}
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT tx"); err != nil {
+		return nil, err
+	}
+	return &savepoint{querier: tx, ctx: ctx}, nil
+}
+
+// WithTx runs fn in a transaction, every method called with the context fn gets runs in it.
+// The transaction is committed when fn returns nil and rolled back otherwise. It is retried
+// from scratch when the database is busy, so fn must not have effects outside the database.
+// Called inside another WithTx, fn runs in a savepoint of the outer transaction and is not
+// retried on its own.
+func (r *Repository) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	const op = "sqlite.Repository.WithTx"
+	if _, ok := ctx.Value(txKey{}).(querier); ok {
+		return runTx(ctx, op, r.begin, fn)
+	}
+
+	return storage.RetryTx(ctx, func() error {
+		return runTx(ctx, op, r.begin, fn)
+	})
+}
+
+func runTx(ctx context.Context, op string, begin func(ctx context.Context) (txn, error), fn func(ctx context.Context) error) error {
+	tx, err := begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	defer tx.Rollback()
+
+	if err := fn(context.WithValue(ctx, txKey{}, querier(tx))); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return nil
+}
+
+// savepoint nests a transaction into an outer one. SQLite resolves a savepoint name to the
+// innermost savepoint with it, so nested savepoints can share the name.
+type savepoint struct {
+	querier
+	ctx  context.Context
+	done bool
+}
+
+func (s *savepoint) Commit() error {
+	if s.done {
+		return sql.ErrTxDone
+	}
+	s.done = true
+	_, err := s.ExecContext(s.ctx, "RELEASE SAVEPOINT tx")
+	return err
+}
+
+// Rollback undoes the savepoint and releases it, SQLite keeps a rolled back savepoint open.
+func (s *savepoint) Rollback() error {
+	if s.done {
+		return sql.ErrTxDone
+	}
+	s.done = true
+	if _, err := s.ExecContext(s.ctx, "ROLLBACK TO SAVEPOINT tx"); err != nil {
+		return err
+	}
+	_, err := s.ExecContext(s.ctx, "RELEASE SAVEPOINT tx")
+	return err
+}
@@ -0,0 +1,129 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/storage"
+)
+
+// Users returns up to limit users with id greater than afterId that match the filter, ordered by id.
+// Password hashes and attributes are not loaded.
+func (r *Repository) Users(ctx context.Context, filter models.UserFilter, afterId int64, limit int) ([]models.User, error) {
+	const op = "sqlite.Repository.Users"
+
+	conditions := []string{"id > $1"}
+	args := []any{afterId}
+	if filter.EmailPrefix != "" {
+		// LIKE ignores case in SQLite, the prefix is compared as is instead.
+		args = append(args, filter.EmailPrefix)
+		conditions = append(conditions, fmt.Sprintf("substr(email, 1, length($%d)) = $%d", len(args), len(args)))
+	}
+	if filter.Username != "" {
+		args = append(args, filter.Username)
+		conditions = append(conditions, fmt.Sprintf("username = $%d", len(args)))
+	}
+	if filter.AppId != 0 {
+		args = append(args, filter.AppId)
+		conditions = append(conditions, fmt.Sprintf("EXISTS (SELECT 1 FROM permissions WHERE permissions.user_id = users.id AND permissions.app_id = $%d)", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	args = append(args, limit)
+	query := fmt.Sprintf("SELECT id, email, username, status FROM users WHERE %s ORDER BY id LIMIT $%d",
+		strings.Join(conditions, " AND "), len(args))
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.Email, &user.Username, &user.Status); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, translateError(err))
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return users, nil
+}
+
+// UpdateUser changes the user's email and username, empty values keep the current ones.
+func (r *Repository) UpdateUser(ctx context.Context, userId int64, email string, username string) (models.User, error) {
+	const op = "sqlite.Repository.UpdateUser"
+	query := `UPDATE users SET email = COALESCE(NULLIF($1, ''), email), username = COALESCE(NULLIF($2, ''), username)
+		WHERE id = $3 RETURNING id, email, username, status`
+
+	var user models.User
+	if err := r.conn(ctx).QueryRowContext(ctx, query, email, username, userId).Scan(&user.ID, &user.Email, &user.Username, &user.Status); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+		return models.User{}, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return user, nil
+}
+
+func (r *Repository) SetUserStatus(ctx context.Context, userId int64, status string) error {
+	const op = "sqlite.Repository.SetUserStatus"
+	query := "UPDATE users SET status = $1 WHERE id = $2"
+
+	result, err := r.conn(ctx).ExecContext(ctx, query, status, userId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	return nil
+}
+
+// RevokeUserTokens marks every token issued to the user so far as revoked and returns the revocation time.
+func (r *Repository) RevokeUserTokens(ctx context.Context, userId int64) (time.Time, error) {
+	const op = "sqlite.Repository.RevokeUserTokens"
+	query := "UPDATE users SET tokens_revoked_at = $2 WHERE id = $1 RETURNING tokens_revoked_at"
+
+	var revokedAt sql.NullInt64
+	if err := r.conn(ctx).QueryRowContext(ctx, query, userId, toUnix(time.Now())).Scan(&revokedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+		return time.Time{}, fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	return fromUnix(revokedAt), nil
+}
+
+// DeleteUser removes the user, their permissions go with them.
+func (r *Repository) DeleteUser(ctx context.Context, userId int64) error {
+	const op = "sqlite.Repository.DeleteUser"
+	query := "DELETE FROM users WHERE id = $1"
+
+	result, err := r.conn(ctx).ExecContext(ctx, query, userId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, translateError(err))
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	return nil
+}
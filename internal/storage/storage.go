@@ -1,11 +1,48 @@
 package storage
 
 // COMMENT  почему файл не называется errors.go
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrUserExists        = errors.New("user already exists")
 	ErrUserNotFound      = errors.New("user not found")
 	ErrAppNotFound       = errors.New("app not found")
+	ErrAppExists         = errors.New("app already exists")
 	ErrNoPermissionFound = errors.New("no permission found")
+	ErrNamespaceNotFound = errors.New("namespace not found")
+	ErrPolicyNotFound    = errors.New("policy not found")
+	ErrPolicyExists      = errors.New("policy already exists")
+	// ErrInvitationNotFound is also returned for invitations that are no longer pending.
+	ErrInvitationNotFound = errors.New("invitation not found")
+
+	// ErrConflict is a unique violation the backend has no more specific error for.
+	ErrConflict = errors.New("conflicts with an existing record")
+	// ErrReferenceNotFound is a foreign key violation the backend has no more specific error for.
+	ErrReferenceNotFound = errors.New("referenced record not found")
+	// ErrInvalidValue is a value the schema rejects, such as an unknown enum value or a negative TTL.
+	ErrInvalidValue = errors.New("invalid value")
+	// ErrSerialization means the transaction lost to a concurrent one and may be retried.
+	ErrSerialization = errors.New("concurrent update, retry the transaction")
+	// ErrQueryTimeout means the database canceled a statement that ran longer than the query timeout.
+	ErrQueryTimeout = errors.New("query timed out")
+	// ErrUnavailable means the database can't be reached or refuses connections for now.
+	ErrUnavailable = errors.New("database is unavailable")
 )
+
+// ConflictError is returned for a unique violation on a known field, so callers can tell
+// a taken email from a taken username. It matches Err with errors.Is.
+type ConflictError struct {
+	Field string
+	Err   error
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s: %s is taken", e.Err, e.Field)
+}
+
+func (e *ConflictError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,770 @@
+// Package storagetest is a conformance suite for storage backends. Every backend
+// has to pass it, so services behave the same whichever backend they run on.
+//
+// The suite only adds data with fresh names, it can run against a database that
+// already holds data, such as a PostgreSQL instance shared by other tests.
+package storagetest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/botanikn/go_sso_service/internal/domain/models"
+	"github.com/botanikn/go_sso_service/internal/storage"
+)
+
+// Repository is the part of a backend the suite exercises.
+type Repository interface {
+	SaveUser(ctx context.Context, email string, username string, passHash []byte) (int64, error)
+	User(ctx context.Context, email string) (models.User, error)
+	UserById(ctx context.Context, userId int64) (models.User, error)
+	UpdateUser(ctx context.Context, userId int64, email string, username string) (models.User, error)
+	DeleteUser(ctx context.Context, userId int64) error
+
+	SaveApp(ctx context.Context, name string, secret string) (int64, error)
+	App(ctx context.Context, appId int64) (models.App, error)
+	UpdateApp(ctx context.Context, appId int64, name string) error
+	DeleteApp(ctx context.Context, appId int64) error
+
+	Permission(ctx context.Context, userId int64, appId int64) (string, error)
+	PermissionUntil(ctx context.Context, userId int64, appId int64) (string, time.Time, error)
+	CreatePermission(ctx context.Context, userId int64, appId int64, permission string) (bool, error)
+	UpdatePermission(ctx context.Context, userId int64, appId int64, permission string) error
+	BatchUpdatePermissions(ctx context.Context, changes []models.PermissionChange, dryRun bool) ([]models.PermissionChangeResult, bool, error)
+	GrantPermission(ctx context.Context, grant models.PermissionGrant) (int64, error)
+	DeleteExpiredPermissions(ctx context.Context, now time.Time) ([]models.PermissionGrant, error)
+
+	Policies(ctx context.Context, appId int64, action string) ([]models.Policy, error)
+	AppPolicies(ctx context.Context, appId int64) ([]models.Policy, error)
+	SavePolicy(ctx context.Context, policy models.Policy) (int64, error)
+	UpdatePolicy(ctx context.Context, policy models.Policy) error
+	DeletePolicy(ctx context.Context, appId int64, policyId int64) (models.Policy, error)
+
+	AppSettings(ctx context.Context, appId int64) (models.AppSettings, error)
+	SaveAppSettings(ctx context.Context, settings models.AppSettings) error
+
+	SaveInvitation(ctx context.Context, invitation models.Invitation, tokenHash []byte) (models.Invitation, error)
+	InvitationByTokenHash(ctx context.Context, tokenHash []byte) (models.Invitation, error)
+	RevokeInvitation(ctx context.Context, appId int64, invitationId int64) (models.Invitation, error)
+	AcceptInvitation(ctx context.Context, invitationId int64, userId int64) (models.PermissionChangeResult, error)
+
+	SaveNamespace(ctx context.Context, namespace models.Namespace) error
+	Namespace(ctx context.Context, appId int64, name string) (models.Namespace, error)
+	RelationTuples(ctx context.Context, appId int64, filter models.RelationTupleFilter, revision int64) ([]models.RelationTuple, error)
+	WriteRelationTuples(ctx context.Context, appId int64, inserts []models.RelationTuple, deletes []models.RelationTuple) (int64, error)
+
+	SaveAuditEvent(ctx context.Context, event models.AuditEvent) error
+	AuditEvents(ctx context.Context, filter models.AuditFilter, afterId int64, limit int) ([]models.AuditEvent, error)
+
+	MarkUserDeleted(ctx context.Context, userId int64) (time.Time, error)
+	RestoreUser(ctx context.Context, userId int64) error
+	PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) ([]models.PurgedUser, error)
+
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Run runs the suite against the backends returned by newRepository.
+func Run(t *testing.T, newRepository func(t *testing.T) Repository) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, r Repository)
+	}{
+		{"Users", testUsers},
+		{"Apps", testApps},
+		{"Permissions", testPermissions},
+		{"TimeBoundGrants", testTimeBoundGrants},
+		{"BatchUpdatePermissions", testBatchUpdatePermissions},
+		{"Policies", testPolicies},
+		{"AppSettings", testAppSettings},
+		{"Invitations", testInvitations},
+		{"Namespaces", testNamespaces},
+		{"RelationTuples", testRelationTuples},
+		{"AuditEvents", testAuditEvents},
+		{"SoftDelete", testSoftDelete},
+		{"PurgeDeletedUsers", testPurgeDeletedUsers},
+		{"Transactions", testTransactions},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newRepository(t))
+		})
+	}
+}
+
+func testUsers(t *testing.T, r Repository) {
+	ctx := context.Background()
+	email, username := uniqueEmail(), unique("user")
+
+	userId := mustSaveUser(t, r, email, username)
+	_, err := r.SaveUser(ctx, email, unique("user"), []byte("hash"))
+	expectConflict(t, "SaveUser with a taken email", err, storage.ErrUserExists, "email")
+	_, err = r.SaveUser(ctx, uniqueEmail(), username, []byte("hash"))
+	expectConflict(t, "SaveUser with a taken username", err, storage.ErrUserExists, "username")
+
+	user, err := r.User(ctx, email)
+	if err != nil {
+		t.Fatalf("User: %v", err)
+	}
+	if user.ID != strconv.FormatInt(userId, 10) || string(user.PassHash) != "hash" || user.Status != models.UserStatusActive {
+		t.Errorf("User: got %+v", user)
+	}
+	if _, err := r.User(ctx, uniqueEmail()); !errors.Is(err, storage.ErrUserNotFound) {
+		t.Errorf("User of an unknown email: got %v, want %v", err, storage.ErrUserNotFound)
+	}
+
+	other := mustSaveUser(t, r, uniqueEmail(), unique("user"))
+	_, err = r.UpdateUser(ctx, other, email, "")
+	expectConflict(t, "UpdateUser to a taken email", err, storage.ErrUserExists, "email")
+	newUsername := unique("user")
+	updated, err := r.UpdateUser(ctx, userId, "", newUsername)
+	if err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+	if updated.Email != email || updated.Username != newUsername {
+		t.Errorf("UpdateUser: got %+v, want email %q and username %q", updated, email, newUsername)
+	}
+
+	if err := r.DeleteUser(ctx, userId); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if _, err := r.UserById(ctx, userId); !errors.Is(err, storage.ErrUserNotFound) {
+		t.Errorf("UserById after DeleteUser: got %v, want %v", err, storage.ErrUserNotFound)
+	}
+	if err := r.DeleteUser(ctx, userId); !errors.Is(err, storage.ErrUserNotFound) {
+		t.Errorf("DeleteUser twice: got %v, want %v", err, storage.ErrUserNotFound)
+	}
+}
+
+func testApps(t *testing.T, r Repository) {
+	ctx := context.Background()
+	name, secret := unique("app"), unique("secret")
+
+	appId, err := r.SaveApp(ctx, name, secret)
+	if err != nil {
+		t.Fatalf("SaveApp: %v", err)
+	}
+	app, err := r.App(ctx, appId)
+	if err != nil {
+		t.Fatalf("App: %v", err)
+	}
+	if app.Name != name || app.Secret != secret {
+		t.Errorf("App: got %q with secret %q, want %q with secret %q", app.Name, app.Secret, name, secret)
+	}
+
+	_, err = r.SaveApp(ctx, name, unique("secret"))
+	expectConflict(t, "SaveApp with a taken name", err, storage.ErrAppExists, "name")
+
+	other := mustSaveApp(t, r, unique("app"))
+	expectConflict(t, "UpdateApp to a taken name", r.UpdateApp(ctx, other, name), storage.ErrAppExists, "name")
+
+	if err := r.DeleteApp(ctx, appId); err != nil {
+		t.Fatalf("DeleteApp: %v", err)
+	}
+	if _, err := r.App(ctx, appId); !errors.Is(err, storage.ErrAppNotFound) {
+		t.Errorf("App after DeleteApp: got %v, want %v", err, storage.ErrAppNotFound)
+	}
+	if err := r.UpdateApp(ctx, appId, unique("app")); !errors.Is(err, storage.ErrAppNotFound) {
+		t.Errorf("UpdateApp of a deleted app: got %v, want %v", err, storage.ErrAppNotFound)
+	}
+}
+
+func testPermissions(t *testing.T, r Repository) {
+	ctx := context.Background()
+	userId := mustSaveUser(t, r, uniqueEmail(), unique("user"))
+	appId := mustSaveApp(t, r, unique("app"))
+
+	if _, err := r.Permission(ctx, userId, appId); !errors.Is(err, storage.ErrNoPermissionFound) {
+		t.Errorf("Permission before CreatePermission: got %v, want %v", err, storage.ErrNoPermissionFound)
+	}
+	if err := r.UpdatePermission(ctx, userId, appId, models.RoleAdmin); !errors.Is(err, storage.ErrNoPermissionFound) {
+		t.Errorf("UpdatePermission before CreatePermission: got %v, want %v", err, storage.ErrNoPermissionFound)
+	}
+
+	if _, err := r.CreatePermission(ctx, userId, appId, models.RoleUser); err != nil {
+		t.Fatalf("CreatePermission: %v", err)
+	}
+	if _, err := r.CreatePermission(ctx, userId, appId, models.RoleUser); !errors.Is(err, storage.ErrConflict) {
+		t.Errorf("CreatePermission twice: got %v, want %v", err, storage.ErrConflict)
+	}
+	if err := r.UpdatePermission(ctx, userId, appId, models.RoleAdmin); err != nil {
+		t.Fatalf("UpdatePermission: %v", err)
+	}
+	expectPermission(t, r, userId, appId, models.RoleAdmin)
+
+	if err := r.DeleteApp(ctx, appId); err != nil {
+		t.Fatalf("DeleteApp: %v", err)
+	}
+	if _, err := r.Permission(ctx, userId, appId); !errors.Is(err, storage.ErrNoPermissionFound) {
+		t.Errorf("Permission after DeleteApp: got %v, want %v", err, storage.ErrNoPermissionFound)
+	}
+}
+
+func testTimeBoundGrants(t *testing.T, r Repository) {
+	ctx := context.Background()
+	userId := mustSaveUser(t, r, uniqueEmail(), unique("user"))
+	appId := mustSaveApp(t, r, unique("app"))
+	if _, err := r.CreatePermission(ctx, userId, appId, models.RoleUser); err != nil {
+		t.Fatalf("CreatePermission: %v", err)
+	}
+	expectPermissionUntil(t, r, userId, appId, time.Time{})
+
+	now := time.Now()
+	grantId, err := r.GrantPermission(ctx, models.PermissionGrant{
+		UserId:     userId,
+		AppId:      appId,
+		Permission: models.RoleAdmin,
+		ValidFrom:  now.Add(-time.Minute),
+		ValidUntil: now.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("GrantPermission: %v", err)
+	}
+	expectPermission(t, r, userId, appId, models.RoleAdmin)
+	expectPermissionUntil(t, r, userId, appId, now.Add(time.Hour))
+
+	// A grant starting later changes the permission before the current one ends.
+	if _, err := r.GrantPermission(ctx, models.PermissionGrant{
+		UserId:     userId,
+		AppId:      appId,
+		Permission: models.RoleUser,
+		ValidFrom:  now.Add(30 * time.Minute),
+		ValidUntil: now.Add(2 * time.Hour),
+	}); err != nil {
+		t.Fatalf("GrantPermission: %v", err)
+	}
+	expectPermissionUntil(t, r, userId, appId, now.Add(30*time.Minute))
+
+	// Changing the permanent permission keeps the grant, it ends when it expires.
+	if err := r.UpdatePermission(ctx, userId, appId, models.RoleUser); err != nil {
+		t.Fatalf("UpdatePermission: %v", err)
+	}
+	expectPermission(t, r, userId, appId, models.RoleAdmin)
+
+	expired, err := r.DeleteExpiredPermissions(ctx, now.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("DeleteExpiredPermissions: %v", err)
+	}
+	found := false
+	for _, grant := range expired {
+		found = found || grant.ID == grantId
+	}
+	if !found {
+		t.Errorf("DeleteExpiredPermissions: grant %d was not removed", grantId)
+	}
+	expectPermission(t, r, userId, appId, models.RoleUser)
+}
+
+func testBatchUpdatePermissions(t *testing.T, r Repository) {
+	ctx := context.Background()
+	userId := mustSaveUser(t, r, uniqueEmail(), unique("user"))
+	appId := mustSaveApp(t, r, unique("app"))
+	if _, err := r.CreatePermission(ctx, userId, appId, models.RoleUser); err != nil {
+		t.Fatalf("CreatePermission: %v", err)
+	}
+
+	change := models.PermissionChange{UserId: userId, AppId: appId, Permission: models.RoleAdmin}
+	results, committed, err := r.BatchUpdatePermissions(ctx, []models.PermissionChange{change}, true)
+	if err != nil {
+		t.Fatalf("BatchUpdatePermissions dry run: %v", err)
+	}
+	if committed || len(results) != 1 || results[0].Outcome != models.PermissionChangeUpdated {
+		t.Errorf("BatchUpdatePermissions dry run: got %+v, committed %t", results, committed)
+	}
+	expectPermission(t, r, userId, appId, models.RoleUser)
+
+	// A change for an unknown user fails the batch and rolls back the other changes.
+	unknownUser := models.PermissionChange{UserId: userId + 1_000_000, AppId: appId, Permission: models.RoleUser}
+	results, committed, err = r.BatchUpdatePermissions(ctx, []models.PermissionChange{change, unknownUser}, false)
+	if err != nil {
+		t.Fatalf("BatchUpdatePermissions with a failing change: %v", err)
+	}
+	if committed || len(results) != 2 || !errors.Is(results[1].Err, storage.ErrUserNotFound) {
+		t.Errorf("BatchUpdatePermissions with a failing change: got %+v, committed %t", results, committed)
+	}
+	expectPermission(t, r, userId, appId, models.RoleUser)
+
+	if _, committed, err = r.BatchUpdatePermissions(ctx, []models.PermissionChange{change}, false); err != nil || !committed {
+		t.Fatalf("BatchUpdatePermissions: committed %t, error %v", committed, err)
+	}
+	expectPermission(t, r, userId, appId, models.RoleAdmin)
+}
+
+func testPolicies(t *testing.T, r Repository) {
+	ctx := context.Background()
+	appId := mustSaveApp(t, r, unique("app"))
+
+	allow := models.Policy{AppId: appId, Name: "allow-admins", Action: "apps.update", Effect: models.PolicyEffectAllow, Expression: `"admin" in principal.roles`}
+	deny := models.Policy{AppId: appId, Name: "deny-banned", Action: "apps.create", Effect: models.PolicyEffectDeny, Expression: `"banned" in principal.roles`}
+	for _, policy := range []*models.Policy{&allow, &deny} {
+		id, err := r.SavePolicy(ctx, *policy)
+		if err != nil {
+			t.Fatalf("SavePolicy: %v", err)
+		}
+		policy.ID = id
+	}
+
+	_, err := r.SavePolicy(ctx, models.Policy{AppId: appId, Name: allow.Name, Action: "apps.list", Effect: models.PolicyEffectAllow, Expression: "true"})
+	expectConflict(t, "SavePolicy with a taken name", err, storage.ErrPolicyExists, "name")
+	if _, err := r.SavePolicy(ctx, models.Policy{AppId: appId, Name: unique("policy"), Action: "apps.list", Effect: "maybe", Expression: "true"}); !errors.Is(err, storage.ErrInvalidValue) {
+		t.Errorf("SavePolicy with an unknown effect: got %v, want %v", err, storage.ErrInvalidValue)
+	}
+	if _, err := r.SavePolicy(ctx, models.Policy{AppId: -1, Name: unique("policy"), Action: "apps.list", Effect: models.PolicyEffectAllow, Expression: "true"}); !errors.Is(err, storage.ErrAppNotFound) {
+		t.Errorf("SavePolicy in a missing app: got %v, want %v", err, storage.ErrAppNotFound)
+	}
+
+	policies, err := r.AppPolicies(ctx, appId)
+	if err != nil {
+		t.Fatalf("AppPolicies: %v", err)
+	}
+	if want := []models.Policy{deny, allow}; !slices.Equal(policies, want) {
+		t.Errorf("AppPolicies: got %+v, want %+v", policies, want)
+	}
+
+	allow.Action = "apps.delete"
+	allow.Expression = "principal.super_admin"
+	if err := r.UpdatePolicy(ctx, allow); err != nil {
+		t.Fatalf("UpdatePolicy: %v", err)
+	}
+	policies, err = r.Policies(ctx, appId, "apps.delete")
+	if err != nil {
+		t.Fatalf("Policies: %v", err)
+	}
+	if want := []models.Policy{allow}; !slices.Equal(policies, want) {
+		t.Errorf("Policies after UpdatePolicy: got %+v, want %+v", policies, want)
+	}
+	renamed := deny
+	renamed.Name = allow.Name
+	expectConflict(t, "UpdatePolicy to a taken name", r.UpdatePolicy(ctx, renamed), storage.ErrPolicyExists, "name")
+
+	otherApp := mustSaveApp(t, r, unique("app"))
+	if _, err := r.DeletePolicy(ctx, otherApp, deny.ID); !errors.Is(err, storage.ErrPolicyNotFound) {
+		t.Errorf("DeletePolicy in another app: got %v, want %v", err, storage.ErrPolicyNotFound)
+	}
+	deleted, err := r.DeletePolicy(ctx, appId, deny.ID)
+	if err != nil {
+		t.Fatalf("DeletePolicy: %v", err)
+	}
+	if deleted != deny {
+		t.Errorf("DeletePolicy: got %+v, want %+v", deleted, deny)
+	}
+	if err := r.UpdatePolicy(ctx, deny); !errors.Is(err, storage.ErrPolicyNotFound) {
+		t.Errorf("UpdatePolicy of a deleted policy: got %v, want %v", err, storage.ErrPolicyNotFound)
+	}
+}
+
+func testAppSettings(t *testing.T, r Repository) {
+	ctx := context.Background()
+	appId := mustSaveApp(t, r, unique("app"))
+
+	settings, err := r.AppSettings(ctx, appId)
+	if err != nil {
+		t.Fatalf("AppSettings: %v", err)
+	}
+	if settings.RegistrationPolicy != models.RegistrationOpen || !settings.AllowsLoginMethod(models.LoginMethodPassword) {
+		t.Errorf("AppSettings without stored settings: got %+v, want the defaults", settings)
+	}
+
+	settings.AccessTokenTTL = 5 * time.Minute
+	settings.MembershipPolicy = models.MembershipApproval
+	if err := r.SaveAppSettings(ctx, settings); err != nil {
+		t.Fatalf("SaveAppSettings: %v", err)
+	}
+	saved, err := r.AppSettings(ctx, appId)
+	if err != nil {
+		t.Fatalf("AppSettings: %v", err)
+	}
+	if saved.AccessTokenTTL != 5*time.Minute || saved.MembershipPolicy != models.MembershipApproval {
+		t.Errorf("AppSettings after SaveAppSettings: got %+v", saved)
+	}
+
+	settings.AppId = appId + 1_000_000
+	if err := r.SaveAppSettings(ctx, settings); !errors.Is(err, storage.ErrAppNotFound) {
+		t.Errorf("SaveAppSettings of an unknown app: got %v, want %v", err, storage.ErrAppNotFound)
+	}
+}
+
+func testInvitations(t *testing.T, r Repository) {
+	ctx := context.Background()
+	userId := mustSaveUser(t, r, uniqueEmail(), unique("user"))
+	appId := mustSaveApp(t, r, unique("app"))
+	tokenHash := []byte(unique("token"))
+
+	invitation, err := r.SaveInvitation(ctx, models.Invitation{
+		AppId:     appId,
+		Email:     uniqueEmail(),
+		Role:      models.RoleAdmin,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}, tokenHash)
+	if err != nil {
+		t.Fatalf("SaveInvitation: %v", err)
+	}
+	found, err := r.InvitationByTokenHash(ctx, tokenHash)
+	if err != nil || found.ID != invitation.ID {
+		t.Fatalf("InvitationByTokenHash: got %+v, error %v", found, err)
+	}
+
+	result, err := r.AcceptInvitation(ctx, invitation.ID, userId)
+	if err != nil {
+		t.Fatalf("AcceptInvitation: %v", err)
+	}
+	if result.Outcome != models.PermissionChangeCreated {
+		t.Errorf("AcceptInvitation: got outcome %q, want %q", result.Outcome, models.PermissionChangeCreated)
+	}
+	expectPermission(t, r, userId, appId, models.RoleAdmin)
+
+	if _, err := r.AcceptInvitation(ctx, invitation.ID, userId); !errors.Is(err, storage.ErrInvitationNotFound) {
+		t.Errorf("AcceptInvitation twice: got %v, want %v", err, storage.ErrInvitationNotFound)
+	}
+	if _, err := r.RevokeInvitation(ctx, appId, invitation.ID); !errors.Is(err, storage.ErrInvitationNotFound) {
+		t.Errorf("RevokeInvitation of an accepted invitation: got %v, want %v", err, storage.ErrInvitationNotFound)
+	}
+}
+
+func testNamespaces(t *testing.T, r Repository) {
+	ctx := context.Background()
+	appId := mustSaveApp(t, r, unique("app"))
+	namespace := models.Namespace{
+		AppId: appId,
+		Name:  "doc",
+		Config: models.NamespaceConfig{Relations: map[string]models.Rewrite{
+			"owner": {},
+		}},
+	}
+
+	if _, err := r.Namespace(ctx, appId, namespace.Name); !errors.Is(err, storage.ErrNamespaceNotFound) {
+		t.Errorf("Namespace before SaveNamespace: got %v, want %v", err, storage.ErrNamespaceNotFound)
+	}
+	if err := r.SaveNamespace(ctx, namespace); err != nil {
+		t.Fatalf("SaveNamespace: %v", err)
+	}
+
+	namespace.Config.Relations["viewer"] = models.Rewrite{ComputedUserset: &models.ComputedUserset{Relation: "owner"}}
+	if err := r.SaveNamespace(ctx, namespace); err != nil {
+		t.Fatalf("SaveNamespace over an existing namespace: %v", err)
+	}
+	got, err := r.Namespace(ctx, appId, namespace.Name)
+	if err != nil {
+		t.Fatalf("Namespace: %v", err)
+	}
+	viewer, ok := got.Config.Relations["viewer"]
+	if len(got.Config.Relations) != 2 || !ok || viewer.ComputedUserset == nil || viewer.ComputedUserset.Relation != "owner" {
+		t.Errorf("Namespace: got config %+v, want the replaced config", got.Config)
+	}
+
+	namespace.AppId = appId + 1_000_000
+	if err := r.SaveNamespace(ctx, namespace); !errors.Is(err, storage.ErrAppNotFound) {
+		t.Errorf("SaveNamespace for a missing app: got %v, want %v", err, storage.ErrAppNotFound)
+	}
+}
+
+func testRelationTuples(t *testing.T, r Repository) {
+	ctx := context.Background()
+	appId := mustSaveApp(t, r, unique("app"))
+	tuple := models.RelationTuple{
+		Namespace: "doc",
+		ObjectId:  unique("doc"),
+		Relation:  "viewer",
+		Subject:   models.Subject{Namespace: "user", ObjectId: "1"},
+	}
+	filter := models.RelationTupleFilter{Namespace: tuple.Namespace, ObjectId: tuple.ObjectId}
+
+	written, err := r.WriteRelationTuples(ctx, appId, []models.RelationTuple{tuple, tuple}, nil)
+	if err != nil {
+		t.Fatalf("WriteRelationTuples: %v", err)
+	}
+	deleted, err := r.WriteRelationTuples(ctx, appId, nil, []models.RelationTuple{tuple})
+	if err != nil {
+		t.Fatalf("WriteRelationTuples: %v", err)
+	}
+	if deleted <= written {
+		t.Errorf("WriteRelationTuples: revision %d does not follow %d", deleted, written)
+	}
+
+	for revision, want := range map[int64]int{written - 1: 0, written: 1, deleted: 0} {
+		tuples, err := r.RelationTuples(ctx, appId, filter, revision)
+		if err != nil {
+			t.Fatalf("RelationTuples: %v", err)
+		}
+		if len(tuples) != want {
+			t.Errorf("RelationTuples at revision %d: got %d tuples, want %d", revision, len(tuples), want)
+		}
+	}
+}
+
+func testAuditEvents(t *testing.T, r Repository) {
+	ctx := context.Background()
+	appId := mustSaveApp(t, r, unique("app"))
+
+	for i := range 3 {
+		if err := r.SaveAuditEvent(ctx, models.AuditEvent{
+			AppId:   appId,
+			Action:  "test.event",
+			Details: map[string]any{"n": i},
+		}); err != nil {
+			t.Fatalf("SaveAuditEvent: %v", err)
+		}
+	}
+
+	filter := models.AuditFilter{AppId: appId}
+	first, err := r.AuditEvents(ctx, filter, 0, 2)
+	if err != nil {
+		t.Fatalf("AuditEvents: %v", err)
+	}
+	if len(first) != 2 {
+		t.Fatalf("AuditEvents: got %d events, want 2", len(first))
+	}
+	// Details come back as decoded JSON, so numbers are float64 on every backend.
+	if n, ok := first[0].Details["n"].(float64); !ok || n != 0 {
+		t.Errorf("AuditEvents: got details %v, want n = 0", first[0].Details)
+	}
+	rest, err := r.AuditEvents(ctx, filter, first[1].ID, 2)
+	if err != nil {
+		t.Fatalf("AuditEvents: %v", err)
+	}
+	if len(rest) != 1 || rest[0].ID <= first[1].ID {
+		t.Errorf("AuditEvents after id %d: got %+v", first[1].ID, rest)
+	}
+}
+
+func testSoftDelete(t *testing.T, r Repository) {
+	ctx := context.Background()
+	userId := mustSaveUser(t, r, uniqueEmail(), unique("user"))
+
+	deletedAt, err := r.MarkUserDeleted(ctx, userId)
+	if err != nil {
+		t.Fatalf("MarkUserDeleted: %v", err)
+	}
+	user, err := r.UserById(ctx, userId)
+	if err != nil {
+		t.Fatalf("UserById: %v", err)
+	}
+	if user.DeletedAt.IsZero() || user.TokensRevokedAt.IsZero() || !user.DeletedAt.Equal(deletedAt) {
+		t.Errorf("UserById after MarkUserDeleted: got %+v, want deleted at %s with revoked tokens", user, deletedAt)
+	}
+	if _, err := r.MarkUserDeleted(ctx, userId); !errors.Is(err, storage.ErrUserNotFound) {
+		t.Errorf("MarkUserDeleted twice: got %v, want %v", err, storage.ErrUserNotFound)
+	}
+
+	if err := r.RestoreUser(ctx, userId); err != nil {
+		t.Fatalf("RestoreUser: %v", err)
+	}
+	if err := r.RestoreUser(ctx, userId); !errors.Is(err, storage.ErrUserNotFound) {
+		t.Errorf("RestoreUser twice: got %v, want %v", err, storage.ErrUserNotFound)
+	}
+}
+
+func testPurgeDeletedUsers(t *testing.T, r Repository) {
+	ctx := context.Background()
+	sharedApp := mustSaveApp(t, r, unique("app"))
+	soleApp := mustSaveApp(t, r, unique("app"))
+	deletedOwnersApp := mustSaveApp(t, r, unique("app"))
+
+	purgedId := mustSaveUser(t, r, uniqueEmail(), unique("user"))
+	liveOwnerId := mustSaveUser(t, r, uniqueEmail(), unique("user"))
+	soleOwnerId := mustSaveUser(t, r, uniqueEmail(), unique("user"))
+	coOwnerIds := []int64{
+		mustSaveUser(t, r, uniqueEmail(), unique("user")),
+		mustSaveUser(t, r, uniqueEmail(), unique("user")),
+	}
+	owners := []struct {
+		userId int64
+		appId  int64
+	}{
+		{purgedId, sharedApp},
+		{liveOwnerId, sharedApp},
+		{soleOwnerId, soleApp},
+		{coOwnerIds[0], deletedOwnersApp},
+		{coOwnerIds[1], deletedOwnersApp},
+	}
+	for _, owner := range owners {
+		if _, err := r.CreatePermission(ctx, owner.userId, owner.appId, models.RoleOwner); err != nil {
+			t.Fatalf("CreatePermission: %v", err)
+		}
+	}
+	if _, err := r.CreatePermission(ctx, purgedId, soleApp, models.RoleUser); err != nil {
+		t.Fatalf("CreatePermission: %v", err)
+	}
+	for _, userId := range []int64{purgedId, soleOwnerId, coOwnerIds[0], coOwnerIds[1]} {
+		if _, err := r.MarkUserDeleted(ctx, userId); err != nil {
+			t.Fatalf("MarkUserDeleted: %v", err)
+		}
+	}
+
+	purged, err := r.PurgeDeletedUsers(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("PurgeDeletedUsers: %v", err)
+	}
+	purgedApps := make(map[int64][]int64)
+	for _, user := range purged {
+		purgedApps[user.ID] = user.AppIds
+	}
+	if got, want := purgedApps[purgedId], []int64{sharedApp, soleApp}; !slices.Equal(got, want) {
+		t.Errorf("PurgeDeletedUsers: got apps %v for the purged user, want %v", got, want)
+	}
+	if _, err := r.UserById(ctx, purgedId); !errors.Is(err, storage.ErrUserNotFound) {
+		t.Errorf("UserById after purge: got %v, want %v", err, storage.ErrUserNotFound)
+	}
+
+	// The last owners are kept even when the other owners are waiting to be purged too.
+	for _, userId := range []int64{soleOwnerId, coOwnerIds[0], coOwnerIds[1]} {
+		if _, ok := purgedApps[userId]; ok {
+			t.Errorf("PurgeDeletedUsers: purged user %d, the last owner of an app", userId)
+		}
+		if _, err := r.UserById(ctx, userId); err != nil {
+			t.Errorf("UserById of the kept owner %d: %v", userId, err)
+		}
+	}
+}
+
+func testTransactions(t *testing.T, r Repository) {
+	ctx := context.Background()
+	appId := mustSaveApp(t, r, unique("app"))
+	errAbort := errors.New("abort")
+
+	email := uniqueEmail()
+	err := r.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := r.SaveUser(ctx, email, unique("user"), []byte("hash")); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("WithTx: got %v, want %v", err, errAbort)
+	}
+	if _, err := r.User(ctx, email); !errors.Is(err, storage.ErrUserNotFound) {
+		t.Errorf("User saved in a rolled back transaction: got %v, want %v", err, storage.ErrUserNotFound)
+	}
+
+	// Methods with a transaction of their own nest into the outer one, and so does WithTx.
+	var userId int64
+	nestedEmail := uniqueEmail()
+	err = r.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		if userId, err = r.SaveUser(ctx, email, unique("user"), []byte("hash")); err != nil {
+			return err
+		}
+		if _, err := r.CreatePermission(ctx, userId, appId, models.RoleUser); err != nil {
+			return err
+		}
+		err = r.WithTx(ctx, func(ctx context.Context) error {
+			if _, err := r.SaveUser(ctx, nestedEmail, unique("user"), []byte("hash")); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			t.Errorf("nested WithTx: got %v, want %v", err, errAbort)
+		}
+		return r.UpdatePermission(ctx, userId, appId, models.RoleAdmin)
+	})
+	if err != nil {
+		t.Fatalf("WithTx: %v", err)
+	}
+	expectPermission(t, r, userId, appId, models.RoleAdmin)
+	if _, err := r.User(ctx, nestedEmail); !errors.Is(err, storage.ErrUserNotFound) {
+		t.Errorf("User saved in a rolled back nested transaction: got %v, want %v", err, storage.ErrUserNotFound)
+	}
+
+	// Concurrent first logins read the permission and create it when there is none,
+	// exactly one of them may create it.
+	otherId := mustSaveUser(t, r, uniqueEmail(), unique("user"))
+	var created atomic.Int32
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// A retried transaction runs fn again, only the committed attempt counts.
+			var creates bool
+			err := r.WithTx(ctx, func(ctx context.Context) error {
+				_, err := r.Permission(ctx, otherId, appId)
+				if creates = errors.Is(err, storage.ErrNoPermissionFound); !creates {
+					return err
+				}
+				_, err = r.CreatePermission(ctx, otherId, appId, models.RoleUser)
+				return err
+			})
+			if err != nil {
+				t.Errorf("concurrent WithTx: %v", err)
+			} else if creates {
+				created.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := created.Load(); n != 1 {
+		t.Errorf("concurrent WithTx: permission created %d times, want 1", n)
+	}
+}
+
+func mustSaveUser(t *testing.T, r Repository, email string, username string) int64 {
+	t.Helper()
+	userId, err := r.SaveUser(context.Background(), email, username, []byte("hash"))
+	if err != nil {
+		t.Fatalf("SaveUser: %v", err)
+	}
+	return userId
+}
+
+func mustSaveApp(t *testing.T, r Repository, name string) int64 {
+	t.Helper()
+	appId, err := r.SaveApp(context.Background(), name, unique("secret"))
+	if err != nil {
+		t.Fatalf("SaveApp: %v", err)
+	}
+	return appId
+}
+
+func expectPermission(t *testing.T, r Repository, userId int64, appId int64, want string) {
+	t.Helper()
+	got, err := r.Permission(context.Background(), userId, appId)
+	if err != nil {
+		t.Fatalf("Permission: %v", err)
+	}
+	if got != want {
+		t.Errorf("Permission: got %q, want %q", got, want)
+	}
+}
+
+// expectPermissionUntil checks when the permission of the user may change next, a zero want
+// means never. Backends store times with different precision, so they only have to be close.
+func expectPermissionUntil(t *testing.T, r Repository, userId int64, appId int64, want time.Time) {
+	t.Helper()
+	_, got, err := r.PermissionUntil(context.Background(), userId, appId)
+	if err != nil {
+		t.Fatalf("PermissionUntil: %v", err)
+	}
+	if got.IsZero() != want.IsZero() || got.Sub(want).Abs() > time.Millisecond {
+		t.Errorf("PermissionUntil: got %v, want %v", got, want)
+	}
+}
+
+// expectConflict checks that err is the unique violation on field.
+func expectConflict(t *testing.T, call string, err error, want error, field string) {
+	t.Helper()
+	var conflict *storage.ConflictError
+	if !errors.Is(err, want) || !errors.As(err, &conflict) || conflict.Field != field {
+		t.Errorf("%s: got %v, want %v on %s", call, err, want, field)
+	}
+}
+
+// unique returns prefix with a random suffix, short enough for the 50 characters of a username.
+func unique(prefix string) string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return prefix + "-" + hex.EncodeToString(b)
+}
+
+func uniqueEmail() string {
+	return unique("user") + "@example.com"
+}
@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	// txAttempts is how many times RetryTx runs a transaction that fails with ErrSerialization.
+	txAttempts = 3
+	// txBackoff is the base delay before a retry, doubled on every attempt and jittered.
+	txBackoff = 10 * time.Millisecond
+)
+
+// RetryTx runs attempt until it succeeds, fails with an error other than ErrSerialization
+// or runs out of attempts. Backends call it around a whole transaction, attempt has to begin
+// and commit its own one so a retry starts from scratch.
+func RetryTx(ctx context.Context, attempt func() error) error {
+	backoff := txBackoff
+	for i := 1; ; i++ {
+		err := attempt()
+		if err == nil || i == txAttempts || !errors.Is(err, ErrSerialization) {
+			return err
+		}
+
+		delay := backoff/2 + rand.N(backoff)
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(delay):
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,2 @@
+-- Tokens issued at or before tokens_revoked_at are rejected, which ends every session of the user.
+ALTER TABLE users ADD COLUMN tokens_revoked_at TIMESTAMPTZ;
\ No newline at end of file
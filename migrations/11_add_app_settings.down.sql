@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS app_settings;
+DROP TYPE IF EXISTS registration_policy;
\ No newline at end of file
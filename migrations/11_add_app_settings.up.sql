@@ -0,0 +1,13 @@
+CREATE TYPE registration_policy AS ENUM ('open', 'invite_only', 'domain_restricted');
+
+-- Apps without a row use the defaults: service-wide token TTL, password login,
+-- open registration and no MFA. A zero TTL also falls back to the service-wide one.
+CREATE TABLE app_settings (
+    app_id INTEGER PRIMARY KEY REFERENCES apps(id) ON DELETE CASCADE,
+    access_token_ttl_seconds INTEGER NOT NULL DEFAULT 0 CHECK (access_token_ttl_seconds >= 0),
+    refresh_token_ttl_seconds INTEGER NOT NULL DEFAULT 0 CHECK (refresh_token_ttl_seconds >= 0),
+    login_methods TEXT[] NOT NULL DEFAULT '{password}',
+    registration_policy registration_policy NOT NULL DEFAULT 'open',
+    allowed_domains TEXT[] NOT NULL DEFAULT '{}',
+    mfa_required BOOLEAN NOT NULL DEFAULT false
+);
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- PostgreSQL can't drop enum values, 'pending' stays in permission_type.
+ALTER TABLE app_settings DROP COLUMN IF EXISTS membership_policy;
+DROP TYPE IF EXISTS membership_policy;
\ No newline at end of file
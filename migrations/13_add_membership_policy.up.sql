@@ -0,0 +1,6 @@
+-- Users waiting for an admin to approve their membership of an app.
+ALTER TYPE permission_type ADD VALUE IF NOT EXISTS 'pending';
+
+CREATE TYPE membership_policy AS ENUM ('open', 'domain_restricted', 'approval', 'closed');
+
+ALTER TABLE app_settings ADD COLUMN membership_policy membership_policy NOT NULL DEFAULT 'open';
\ No newline at end of file
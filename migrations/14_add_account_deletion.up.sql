@@ -0,0 +1,4 @@
+-- Soft-deleted users are purged with their permissions once the grace period is over.
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at) WHERE deleted_at IS NOT NULL;
\ No newline at end of file
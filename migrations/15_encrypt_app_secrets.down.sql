@@ -0,0 +1,9 @@
+-- Without key_version encrypted secrets would be taken for plaintext ones.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM apps WHERE key_version IS NOT NULL) THEN
+        RAISE EXCEPTION 'app secrets are encrypted, decrypt them with migrator reencrypt -plaintext first';
+    END IF;
+END $$;
+
+ALTER TABLE apps DROP COLUMN IF EXISTS key_version;
\ No newline at end of file
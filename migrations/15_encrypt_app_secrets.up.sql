@@ -0,0 +1,7 @@
+-- Secrets with a key version are encrypted with that version of the key-encryption key and
+-- base64 encoded, the others are in plaintext until `migrator reencrypt` has run.
+ALTER TABLE apps ADD COLUMN key_version INTEGER CHECK (key_version > 0);
+
+-- The secret of the seeded app is in the repository, nothing may keep using it.
+UPDATE apps SET secret = replace(gen_random_uuid()::text || gen_random_uuid()::text, '-', '')
+WHERE secret = 'ksrjhurawdawdgw';
\ No newline at end of file
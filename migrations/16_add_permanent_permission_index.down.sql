@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_permissions_permanent;
\ No newline at end of file
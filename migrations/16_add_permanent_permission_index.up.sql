@@ -0,0 +1,10 @@
+-- A user has one permanent permission per app, only time-bound grants may stack. Duplicates
+-- left by concurrent upserts are dropped first, keeping the newest row as lookups did.
+DELETE FROM permissions p
+USING permissions newer
+WHERE p.user_id = newer.user_id AND p.app_id = newer.app_id AND p.id < newer.id
+    AND p.valid_from IS NULL AND p.valid_until IS NULL
+    AND newer.valid_from IS NULL AND newer.valid_until IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_permissions_permanent ON permissions (user_id, app_id)
+    WHERE valid_from IS NULL AND valid_until IS NULL;
\ No newline at end of file
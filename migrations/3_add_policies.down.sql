@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS policies;
+DROP TYPE IF EXISTS policy_effect;
+ALTER TABLE users DROP COLUMN IF EXISTS attributes;
\ No newline at end of file
@@ -0,0 +1,15 @@
+ALTER TABLE users ADD COLUMN attributes JSONB NOT NULL DEFAULT '{}';
+
+CREATE TYPE policy_effect AS ENUM ('allow', 'deny');
+
+CREATE TABLE policies (
+    id SERIAL PRIMARY KEY,
+    app_id INTEGER NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    action TEXT NOT NULL,
+    effect policy_effect NOT NULL DEFAULT 'allow',
+    expression TEXT NOT NULL,
+    UNIQUE (app_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_policies_app_action ON policies (app_id, action);
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS relation_tuples;
+DROP TABLE IF EXISTS relation_revision;
+DROP TABLE IF EXISTS relation_namespaces;
\ No newline at end of file
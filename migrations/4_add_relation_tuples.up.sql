@@ -0,0 +1,38 @@
+CREATE TABLE relation_namespaces (
+    id SERIAL PRIMARY KEY,
+    app_id INTEGER NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    config JSONB NOT NULL DEFAULT '{}',
+    UNIQUE (app_id, name)
+);
+
+-- Single row holding the latest committed revision. Writers bump it under a row
+-- lock, so revisions are handed out in commit order.
+CREATE TABLE relation_revision (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    revision BIGINT NOT NULL
+);
+
+INSERT INTO relation_revision (revision) VALUES (0);
+
+-- Tuples are never updated in place: a delete stamps deleted_revision, so reads
+-- can be answered at any revision that a consistency token points to.
+CREATE TABLE relation_tuples (
+    id BIGSERIAL PRIMARY KEY,
+    app_id INTEGER NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    namespace TEXT NOT NULL,
+    object_id TEXT NOT NULL,
+    relation TEXT NOT NULL,
+    subject_namespace TEXT NOT NULL,
+    subject_id TEXT NOT NULL,
+    subject_relation TEXT NOT NULL DEFAULT '',
+    created_revision BIGINT NOT NULL,
+    deleted_revision BIGINT
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_relation_tuples_live ON relation_tuples
+    (app_id, namespace, object_id, relation, subject_namespace, subject_id, subject_relation)
+    WHERE deleted_revision IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_relation_tuples_subject ON relation_tuples
+    (app_id, subject_namespace, subject_id, subject_relation);
\ No newline at end of file
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS audit_events;
+DROP INDEX IF EXISTS idx_permissions_valid_until;
+DROP INDEX IF EXISTS idx_permissions_user_app;
+DELETE FROM permissions WHERE valid_from IS NOT NULL OR valid_until IS NOT NULL;
+ALTER TABLE permissions
+    DROP COLUMN IF EXISTS justification,
+    DROP COLUMN IF EXISTS granted_by,
+    DROP COLUMN IF EXISTS valid_until,
+    DROP COLUMN IF EXISTS valid_from;
\ No newline at end of file
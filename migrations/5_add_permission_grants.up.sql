@@ -0,0 +1,22 @@
+-- Rows with a validity window are grants layered over the user's base permission,
+-- they take precedence while valid and are removed by the reaper once expired.
+ALTER TABLE permissions
+    ADD COLUMN valid_from TIMESTAMPTZ,
+    ADD COLUMN valid_until TIMESTAMPTZ,
+    ADD COLUMN granted_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN justification TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_permissions_user_app ON permissions (user_id, app_id);
+CREATE INDEX IF NOT EXISTS idx_permissions_valid_until ON permissions (valid_until) WHERE valid_until IS NOT NULL;
+
+CREATE TABLE audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    app_id INTEGER REFERENCES apps(id) ON DELETE SET NULL,
+    actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    action TEXT NOT NULL,
+    details JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events (created_at);
\ No newline at end of file
@@ -0,0 +1,11 @@
+UPDATE permissions SET permission = 'user' WHERE permission = 'impersonator';
+
+ALTER TYPE permission_type RENAME TO permission_type_old;
+CREATE TYPE permission_type AS ENUM ('banned', 'user', 'admin');
+
+ALTER TABLE permissions
+    ALTER COLUMN permission DROP DEFAULT,
+    ALTER COLUMN permission TYPE permission_type USING permission::text::permission_type,
+    ALTER COLUMN permission SET DEFAULT 'user';
+
+DROP TYPE permission_type_old;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TYPE permission_type ADD VALUE IF NOT EXISTS 'impersonator';
\ No newline at end of file
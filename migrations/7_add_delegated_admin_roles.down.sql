@@ -0,0 +1,12 @@
+UPDATE permissions SET permission = 'admin' WHERE permission = 'owner';
+UPDATE permissions SET permission = 'user' WHERE permission = 'user_manager';
+
+ALTER TYPE permission_type RENAME TO permission_type_old;
+CREATE TYPE permission_type AS ENUM ('banned', 'user', 'admin', 'impersonator');
+
+ALTER TABLE permissions
+    ALTER COLUMN permission DROP DEFAULT,
+    ALTER COLUMN permission TYPE permission_type USING permission::text::permission_type,
+    ALTER COLUMN permission SET DEFAULT 'user';
+
+DROP TYPE permission_type_old;
\ No newline at end of file
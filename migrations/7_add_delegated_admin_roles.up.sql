@@ -0,0 +1,2 @@
+ALTER TYPE permission_type ADD VALUE IF NOT EXISTS 'user_manager';
+ALTER TYPE permission_type ADD VALUE IF NOT EXISTS 'owner';
\ No newline at end of file
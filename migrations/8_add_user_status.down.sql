@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_users_email_prefix;
+ALTER TABLE users DROP COLUMN IF EXISTS status;
+DROP TYPE IF EXISTS user_status;
\ No newline at end of file
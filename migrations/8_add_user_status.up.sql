@@ -0,0 +1,6 @@
+CREATE TYPE user_status AS ENUM ('active', 'disabled');
+
+ALTER TABLE users ADD COLUMN status user_status NOT NULL DEFAULT 'active';
+
+-- text_pattern_ops lets LIKE 'prefix%' use the index regardless of collation.
+CREATE INDEX IF NOT EXISTS idx_users_email_prefix ON users (email text_pattern_ops);
\ No newline at end of file
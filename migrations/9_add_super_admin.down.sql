@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_users_super_admin;
+ALTER TABLE users DROP COLUMN IF EXISTS is_super_admin;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- Super-admins are not bound to an app, they administer the whole SSO.
+ALTER TABLE users ADD COLUMN is_super_admin BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS idx_users_super_admin ON users (id) WHERE is_super_admin;
\ No newline at end of file
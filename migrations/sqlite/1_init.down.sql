@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS invitations;
+DROP TABLE IF EXISTS app_settings;
+DROP TABLE IF EXISTS audit_events;
+DROP TABLE IF EXISTS relation_tuples;
+DROP TABLE IF EXISTS relation_revision;
+DROP TABLE IF EXISTS relation_namespaces;
+DROP TABLE IF EXISTS policies;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS apps;
+DROP TABLE IF EXISTS users;
\ No newline at end of file
@@ -0,0 +1,128 @@
+-- SQLite schema matching the PostgreSQL migrations up to 14_add_account_deletion.
+-- Enums are CHECK constraints, arrays and JSONB are JSON text, and timestamps are
+-- unix microseconds so that they compare and sort as numbers.
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    email TEXT UNIQUE NOT NULL,
+    username TEXT UNIQUE NOT NULL CHECK (length(username) <= 50),
+    pass_hash BLOB NOT NULL,
+    attributes TEXT NOT NULL DEFAULT '{}',
+    status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'disabled')),
+    is_super_admin INTEGER NOT NULL DEFAULT 0,
+    tokens_revoked_at INTEGER,
+    deleted_at INTEGER
+);
+
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at) WHERE deleted_at IS NOT NULL;
+
+CREATE TABLE apps (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    secret TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE permissions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    app_id INTEGER REFERENCES apps(id) ON DELETE CASCADE,
+    permission TEXT NOT NULL DEFAULT 'user'
+        CHECK (permission IN ('banned', 'pending', 'user', 'impersonator', 'user_manager', 'admin', 'owner')),
+    valid_from INTEGER,
+    valid_until INTEGER,
+    granted_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    justification TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_permissions_user_app ON permissions (user_id, app_id);
+CREATE INDEX IF NOT EXISTS idx_permissions_valid_until ON permissions (valid_until) WHERE valid_until IS NOT NULL;
+
+CREATE TABLE policies (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    app_id INTEGER NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    action TEXT NOT NULL,
+    effect TEXT NOT NULL DEFAULT 'allow' CHECK (effect IN ('allow', 'deny')),
+    expression TEXT NOT NULL,
+    UNIQUE (app_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_policies_app_action ON policies (app_id, action);
+
+CREATE TABLE relation_namespaces (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    app_id INTEGER NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    config TEXT NOT NULL DEFAULT '{}',
+    UNIQUE (app_id, name)
+);
+
+CREATE TABLE relation_revision (
+    id INTEGER PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    revision INTEGER NOT NULL
+);
+
+INSERT INTO relation_revision (revision) VALUES (0);
+
+CREATE TABLE relation_tuples (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    app_id INTEGER NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    namespace TEXT NOT NULL,
+    object_id TEXT NOT NULL,
+    relation TEXT NOT NULL,
+    subject_namespace TEXT NOT NULL,
+    subject_id TEXT NOT NULL,
+    subject_relation TEXT NOT NULL DEFAULT '',
+    created_revision INTEGER NOT NULL,
+    deleted_revision INTEGER
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_relation_tuples_live ON relation_tuples
+    (app_id, namespace, object_id, relation, subject_namespace, subject_id, subject_relation)
+    WHERE deleted_revision IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_relation_tuples_subject ON relation_tuples
+    (app_id, subject_namespace, subject_id, subject_relation);
+
+CREATE TABLE audit_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    app_id INTEGER REFERENCES apps(id) ON DELETE SET NULL,
+    actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    action TEXT NOT NULL,
+    details TEXT NOT NULL DEFAULT '{}',
+    created_at INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events (created_at);
+
+CREATE TABLE app_settings (
+    app_id INTEGER PRIMARY KEY REFERENCES apps(id) ON DELETE CASCADE,
+    access_token_ttl_seconds INTEGER NOT NULL DEFAULT 0 CHECK (access_token_ttl_seconds >= 0),
+    refresh_token_ttl_seconds INTEGER NOT NULL DEFAULT 0 CHECK (refresh_token_ttl_seconds >= 0),
+    login_methods TEXT NOT NULL DEFAULT '["password"]',
+    registration_policy TEXT NOT NULL DEFAULT 'open'
+        CHECK (registration_policy IN ('open', 'invite_only', 'domain_restricted')),
+    membership_policy TEXT NOT NULL DEFAULT 'open'
+        CHECK (membership_policy IN ('open', 'domain_restricted', 'approval', 'closed')),
+    allowed_domains TEXT NOT NULL DEFAULT '[]',
+    mfa_required INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE invitations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    app_id INTEGER NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    role TEXT NOT NULL
+        CHECK (role IN ('banned', 'pending', 'user', 'impersonator', 'user_manager', 'admin', 'owner')),
+    token_hash BLOB NOT NULL UNIQUE,
+    invited_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    expires_at INTEGER NOT NULL,
+    created_at INTEGER NOT NULL,
+    accepted_at INTEGER,
+    accepted_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    revoked_at INTEGER
+);
+
+CREATE INDEX IF NOT EXISTS idx_invitations_app ON invitations (app_id, id);
+
+INSERT INTO apps (name, secret) VALUES ('Boba.com', 'ksrjhurawdawdgw');
\ No newline at end of file
@@ -0,0 +1,9 @@
+-- Without key_version encrypted secrets would be taken for plaintext ones. SQLite has
+-- no RAISE outside triggers, the CHECK fails when any secret is still encrypted.
+CREATE TEMP TABLE encrypted_app_secrets (
+    key_version INTEGER CHECK (key_version IS NULL)
+);
+INSERT INTO encrypted_app_secrets SELECT key_version FROM apps WHERE key_version IS NOT NULL;
+DROP TABLE encrypted_app_secrets;
+
+ALTER TABLE apps DROP COLUMN key_version;
\ No newline at end of file
@@ -0,0 +1,12 @@
+-- A user has one permanent permission per app, only time-bound grants may stack.
+DELETE FROM permissions
+WHERE valid_from IS NULL AND valid_until IS NULL
+    AND EXISTS (
+        SELECT 1 FROM permissions newer
+        WHERE newer.user_id = permissions.user_id AND newer.app_id = permissions.app_id
+            AND newer.id > permissions.id
+            AND newer.valid_from IS NULL AND newer.valid_until IS NULL
+    );
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_permissions_permanent ON permissions (user_id, app_id)
+    WHERE valid_from IS NULL AND valid_until IS NULL;
\ No newline at end of file
@@ -5,9 +5,19 @@ import (
 	"fmt"
 
 	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
 )
 
+// DriverSQLite is the database/sql driver name of SQLite.
+const DriverSQLite = "sqlite"
+
+// NewDB connects to the database. For SQLite dbname is the path of the database file,
+// the other connection parameters are not used.
 func NewDB(host string, port int, user string, password string, dbname string, driver string) (*sql.DB, error) {
+	if driver == DriverSQLite {
+		return newSQLite(dbname)
+	}
+
 	connStr := fmt.Sprintf(
 		"host=%s port=%v user=%s password=%s dbname=%s sslmode=disable",
 		host, port, user, password, dbname,
@@ -24,3 +34,22 @@ func NewDB(host string, port int, user string, password string, dbname string, d
 
 	return db, nil
 }
+
+// newSQLite opens the database file with foreign keys enforced, which SQLite leaves off by default.
+// SQLite allows a single writer, so the pool keeps one connection and transactions never wait
+// on each other inside SQLite.
+func newSQLite(path string) (*sql.DB, error) {
+	dsn := "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+
+	db, err := sql.Open(DriverSQLite, dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	if err = db.Ping(); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PoolCollector exports the statistics of a pgx pool as Prometheus metrics.
+type PoolCollector struct {
+	pool *pgxpool.Pool
+
+	maxConns            *prometheus.Desc
+	totalConns          *prometheus.Desc
+	idleConns           *prometheus.Desc
+	acquiredConns       *prometheus.Desc
+	constructingConns   *prometheus.Desc
+	acquires            *prometheus.Desc
+	acquireDuration     *prometheus.Desc
+	emptyAcquires       *prometheus.Desc
+	emptyAcquireWait    *prometheus.Desc
+	canceledAcquires    *prometheus.Desc
+	newConns            *prometheus.Desc
+	maxLifetimeDestroys *prometheus.Desc
+	maxIdleTimeDestroys *prometheus.Desc
+}
+
+func NewPoolCollector(pool *pgxpool.Pool) *PoolCollector {
+	desc := func(name string, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName("sso", "db_pool", name), help, nil, nil)
+	}
+	return &PoolCollector{
+		pool:                pool,
+		maxConns:            desc("max_conns", "Maximum size of the pool."),
+		totalConns:          desc("total_conns", "Connections in the pool, idle, acquired and being constructed."),
+		idleConns:           desc("idle_conns", "Idle connections in the pool."),
+		acquiredConns:       desc("acquired_conns", "Connections currently acquired from the pool."),
+		constructingConns:   desc("constructing_conns", "Connections being constructed."),
+		acquires:            desc("acquires_total", "Successful acquires from the pool."),
+		acquireDuration:     desc("acquire_duration_seconds_total", "Time spent on successful acquires."),
+		emptyAcquires:       desc("empty_acquires_total", "Successful acquires that had to wait for a connection."),
+		emptyAcquireWait:    desc("empty_acquire_wait_seconds_total", "Time spent waiting for a connection by acquires that found the pool empty."),
+		canceledAcquires:    desc("canceled_acquires_total", "Acquires canceled by their context."),
+		newConns:            desc("new_conns_total", "Connections opened by the pool."),
+		maxLifetimeDestroys: desc("max_lifetime_destroys_total", "Connections closed for exceeding the maximum lifetime."),
+		maxIdleTimeDestroys: desc("max_idle_time_destroys_total", "Connections closed for exceeding the maximum idle time."),
+	}
+}
+
+func (c *PoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *PoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	gauge := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value)
+	}
+	counter := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value)
+	}
+
+	gauge(c.maxConns, float64(stat.MaxConns()))
+	gauge(c.totalConns, float64(stat.TotalConns()))
+	gauge(c.idleConns, float64(stat.IdleConns()))
+	gauge(c.acquiredConns, float64(stat.AcquiredConns()))
+	gauge(c.constructingConns, float64(stat.ConstructingConns()))
+	counter(c.acquires, float64(stat.AcquireCount()))
+	counter(c.acquireDuration, stat.AcquireDuration().Seconds())
+	counter(c.emptyAcquires, float64(stat.EmptyAcquireCount()))
+	counter(c.emptyAcquireWait, stat.EmptyAcquireWaitTime().Seconds())
+	counter(c.canceledAcquires, float64(stat.CanceledAcquireCount()))
+	counter(c.newConns, float64(stat.NewConnsCount()))
+	counter(c.maxLifetimeDestroys, float64(stat.MaxLifetimeDestroyCount()))
+	counter(c.maxIdleTimeDestroys, float64(stat.MaxIdleDestroyCount()))
+}
+
+// ReplicasCollector exports whether each read replica passed its last health check.
+type ReplicasCollector struct {
+	replicas *Replicas
+	up       *prometheus.Desc
+}
+
+func NewReplicasCollector(replicas *Replicas) *ReplicasCollector {
+	return &ReplicasCollector{
+		replicas: replicas,
+		up: prometheus.NewDesc(
+			prometheus.BuildFQName("sso", "db_replica", "up"),
+			"Whether the read replica passed its last health check and receives reads.",
+			[]string{"replica"}, nil,
+		),
+	}
+}
+
+func (c *ReplicasCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *ReplicasCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, replica := range c.replicas.replicas {
+		up := 0.0
+		if replica.healthy.Load() {
+			up = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, up, replica.addr)
+	}
+}
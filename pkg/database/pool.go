@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// statementCacheCapacity is how many prepared statements each connection keeps,
+// well above the number of distinct queries the repository runs.
+const statementCacheCapacity = 512
+
+// PoolOptions sizes the PostgreSQL connection pool, zero values keep the pgx defaults.
+type PoolOptions struct {
+	MaxConns          int32
+	MinConns          int32
+	MaxConnLifetime   time.Duration
+	MaxConnIdleTime   time.Duration
+	HealthCheckPeriod time.Duration
+	// QueryTimeout is set as statement_timeout on every connection, so PostgreSQL cancels
+	// statements that run longer. Zero means no limit.
+	QueryTimeout time.Duration
+}
+
+// NewPool connects a pgx pool to PostgreSQL. Statements are prepared on first use and
+// cached on each connection, so later runs of the same query skip parsing and planning.
+func NewPool(ctx context.Context, host string, port int, user string, password string, dbname string, opts PoolOptions) (*pgxpool.Pool, error) {
+	cfg, err := poolConfig(host, port, user, password, dbname, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	return pool, nil
+}
+
+func poolConfig(host string, port int, user string, password string, dbname string, opts PoolOptions) (*pgxpool.Config, error) {
+	connStr := fmt.Sprintf(
+		"host=%s port=%v user=%s password=%s dbname=%s sslmode=disable",
+		host, port, user, password, dbname,
+	)
+
+	cfg, err := pgxpool.ParseConfig(connStr)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeCacheStatement
+	cfg.ConnConfig.StatementCacheCapacity = statementCacheCapacity
+	if opts.QueryTimeout > 0 {
+		cfg.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(opts.QueryTimeout.Milliseconds(), 10)
+	}
+	if opts.MaxConns > 0 {
+		cfg.MaxConns = opts.MaxConns
+	}
+	if opts.MinConns > 0 {
+		cfg.MinConns = opts.MinConns
+	}
+	if opts.MaxConnLifetime > 0 {
+		cfg.MaxConnLifetime = opts.MaxConnLifetime
+	}
+	if opts.MaxConnIdleTime > 0 {
+		cfg.MaxConnIdleTime = opts.MaxConnIdleTime
+	}
+	if opts.HealthCheckPeriod > 0 {
+		cfg.HealthCheckPeriod = opts.HealthCheckPeriod
+	}
+	return cfg, nil
+}
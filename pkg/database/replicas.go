@@ -0,0 +1,146 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ReplicaAddr is where a read replica listens.
+type ReplicaAddr struct {
+	Host string
+	Port int
+}
+
+func (a ReplicaAddr) String() string {
+	return net.JoinHostPort(a.Host, strconv.Itoa(a.Port))
+}
+
+// Replicas spreads reads over the PostgreSQL read replicas that passed their last health
+// check. A nil *Replicas has no replicas.
+type Replicas struct {
+	log      *slog.Logger
+	replicas []*replica
+	next     atomic.Uint64
+}
+
+type replica struct {
+	addr    string
+	pool    *pgxpool.Pool
+	healthy atomic.Bool
+}
+
+// NewReplicas returns Replicas with a pool for every address, connected with the credentials
+// of the primary. Unlike NewPool it doesn't fail when a replica is unreachable, the replica
+// is left out until a health check passes. It returns nil when addrs is empty.
+func NewReplicas(ctx context.Context, log *slog.Logger, addrs []ReplicaAddr, user string, password string, dbname string, opts PoolOptions) (*Replicas, error) {
+	if len(addrs) == 0 {
+		return nil, nil
+	}
+
+	r := &Replicas{log: log}
+	for _, addr := range addrs {
+		cfg, err := poolConfig(addr.Host, addr.Port, user, password, dbname, opts)
+		if err != nil {
+			r.Close()
+			return nil, fmt.Errorf("replica %s: %w", addr, err)
+		}
+		pool, err := pgxpool.NewWithConfig(ctx, cfg)
+		if err != nil {
+			r.Close()
+			return nil, fmt.Errorf("replica %s: %w", addr, err)
+		}
+		replica := &replica{addr: addr.String(), pool: pool}
+		// Taken as healthy until the first check below, so that an unreachable replica is reported.
+		replica.healthy.Store(true)
+		r.replicas = append(r.replicas, replica)
+	}
+
+	r.check(ctx, opts.QueryTimeout)
+	return r, nil
+}
+
+// Pick returns the pool of a healthy replica, taking them in turn, or nil when none is healthy.
+func (r *Replicas) Pick() *pgxpool.Pool {
+	if r == nil {
+		return nil
+	}
+	start := r.next.Add(1)
+	for i := range r.replicas {
+		replica := r.replicas[(start+uint64(i))%uint64(len(r.replicas))]
+		if replica.healthy.Load() {
+			return replica.pool
+		}
+	}
+	return nil
+}
+
+// Run checks the replicas every interval until ctx is canceled. A check that doesn't
+// finish within the interval fails.
+func (r *Replicas) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.check(ctx, interval)
+		}
+	}
+}
+
+func (r *Replicas) Close() {
+	if r == nil {
+		return
+	}
+	for _, replica := range r.replicas {
+		replica.pool.Close()
+	}
+}
+
+// check marks every replica healthy or not. A replica that is out of recovery was promoted,
+// it no longer follows the primary and must not serve reads.
+func (r *Replicas) check(ctx context.Context, timeout time.Duration) {
+	const op = "database.Replicas.check"
+
+	for _, replica := range r.replicas {
+		err := replica.ping(ctx, timeout)
+		healthy := err == nil
+		if replica.healthy.Swap(healthy) == healthy {
+			continue
+		}
+
+		log := r.log.With(slog.String("op", op), slog.String("replica", replica.addr))
+		if healthy {
+			log.Info("replica is healthy, reads are routed to it")
+		} else {
+			log.Warn("replica is unhealthy, reads go to the other replicas or the primary", slog.String("error", err.Error()))
+		}
+	}
+}
+
+func (r *replica) ping(ctx context.Context, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var inRecovery bool
+	if err := r.pool.QueryRow(ctx, "SELECT pg_is_in_recovery()").Scan(&inRecovery); err != nil {
+		return err
+	}
+	if !inRecovery {
+		return errors.New("server is not in recovery")
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+// Package envelope implements envelope encryption of small values such as app secrets.
+// Every value is encrypted with its own random data key, which is stored next to it
+// encrypted with a key-encryption key (KEK). Rotating the KEK only re-encrypts data keys.
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// KeySize is the size of key-encryption keys and data keys, AES-256.
+	KeySize = 32
+
+	// formatV1 is AES-256-GCM for both keys: format byte, sealed data key, sealed value.
+	formatV1 byte = 1
+
+	nonceSize     = 12
+	tagSize       = 16
+	sealedKeySize = nonceSize + KeySize + tagSize
+)
+
+var (
+	ErrUnknownKey       = errors.New("unknown key version")
+	ErrInvalidKey       = errors.New("key must be 32 bytes")
+	ErrMalformed        = errors.New("malformed ciphertext")
+	ErrDecryptionFailed = errors.New("decryption failed")
+)
+
+// Keyring holds the key-encryption keys by version. Values are encrypted with the
+// current version, older versions are kept to decrypt values not yet re-encrypted.
+type Keyring struct {
+	current int
+	keks    map[int]cipher.AEAD
+}
+
+// NewKeyring returns a Keyring encrypting with the key of version current.
+// Versions are positive, version zero stands for a value that isn't encrypted.
+func NewKeyring(current int, keys map[int][]byte) (*Keyring, error) {
+	k := &Keyring{
+		current: current,
+		keks:    make(map[int]cipher.AEAD, len(keys)),
+	}
+	for version, key := range keys {
+		if version <= 0 {
+			return nil, fmt.Errorf("key version %d: must be positive", version)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("key version %d: %w", version, err)
+		}
+		k.keks[version] = aead
+	}
+	if _, ok := k.keks[current]; !ok {
+		return nil, fmt.Errorf("current key version %d: %w", current, ErrUnknownKey)
+	}
+	return k, nil
+}
+
+// Load returns a Keyring of base64 encoded keys by version, taken from keys and from the
+// YAML file at keysFile, a mapping of the same form. It returns nil when there are no keys.
+func Load(current int, keys map[int]string, keysFile string) (*Keyring, error) {
+	encoded := make(map[int]string, len(keys))
+	for version, key := range keys {
+		encoded[version] = key
+	}
+	if keysFile != "" {
+		data, err := os.ReadFile(keysFile)
+		if err != nil {
+			return nil, err
+		}
+		var fileKeys map[int]string
+		if err := yaml.Unmarshal(data, &fileKeys); err != nil {
+			return nil, fmt.Errorf("%s: %w", keysFile, err)
+		}
+		for version, key := range fileKeys {
+			if _, ok := encoded[version]; ok {
+				return nil, fmt.Errorf("%s: key version %d is also in the config", keysFile, version)
+			}
+			encoded[version] = key
+		}
+	}
+	if len(encoded) == 0 {
+		return nil, nil
+	}
+
+	decoded := make(map[int][]byte, len(encoded))
+	for version, key := range encoded {
+		raw, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, fmt.Errorf("key version %d: %w", version, err)
+		}
+		decoded[version] = raw
+	}
+	return NewKeyring(current, decoded)
+}
+
+// Current returns the version values are encrypted with.
+func (k *Keyring) Current() int {
+	return k.current
+}
+
+// Encrypt encrypts plaintext under a new data key and returns the ciphertext with the
+// version of the key-encryption key. aad is authenticated but not encrypted, the same
+// aad has to be passed to Decrypt, so a value can't be moved to a different context.
+func (k *Keyring) Encrypt(plaintext []byte, aad []byte) ([]byte, int, error) {
+	dataKey := make([]byte, KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, 0, err
+	}
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	out := make([]byte, 0, 1+sealedKeySize+nonceSize+len(plaintext)+tagSize)
+	out = append(out, formatV1)
+	out, err = seal(k.keks[k.current], out, dataKey, aad)
+	if err != nil {
+		return nil, 0, err
+	}
+	out, err = seal(data, out, plaintext, aad)
+	if err != nil {
+		return nil, 0, err
+	}
+	return out, k.current, nil
+}
+
+// Decrypt decrypts a ciphertext returned by Encrypt with the key-encryption key of version.
+func (k *Keyring) Decrypt(ciphertext []byte, aad []byte, version int) ([]byte, error) {
+	kek, ok := k.keks[version]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownKey, version)
+	}
+	if len(ciphertext) < 1+sealedKeySize+nonceSize+tagSize || ciphertext[0] != formatV1 {
+		return nil, ErrMalformed
+	}
+
+	dataKey, err := open(kek, ciphertext[1:1+sealedKeySize], aad)
+	if err != nil {
+		return nil, err
+	}
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return open(data, ciphertext[1+sealedKeySize:], aad)
+}
+
+// Rewrap re-encrypts the data key of a ciphertext encrypted with version under the current
+// key-encryption key, the value itself is left as it is. It returns the new ciphertext and version.
+func (k *Keyring) Rewrap(ciphertext []byte, aad []byte, version int) ([]byte, int, error) {
+	kek, ok := k.keks[version]
+	if !ok {
+		return nil, 0, fmt.Errorf("%w: %d", ErrUnknownKey, version)
+	}
+	if len(ciphertext) < 1+sealedKeySize+nonceSize+tagSize || ciphertext[0] != formatV1 {
+		return nil, 0, ErrMalformed
+	}
+
+	dataKey, err := open(kek, ciphertext[1:1+sealedKeySize], aad)
+	if err != nil {
+		return nil, 0, err
+	}
+	out := make([]byte, 0, len(ciphertext))
+	out = append(out, formatV1)
+	out, err = seal(k.keks[k.current], out, dataKey, aad)
+	if err != nil {
+		return nil, 0, err
+	}
+	return append(out, ciphertext[1+sealedKeySize:]...), k.current, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal appends a random nonce and the sealed plaintext to dst.
+func seal(aead cipher.AEAD, dst []byte, plaintext []byte, aad []byte) ([]byte, error) {
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	dst = append(dst, nonce...)
+	return aead.Seal(dst, nonce, plaintext, aad), nil
+}
+
+func open(aead cipher.AEAD, sealed []byte, aad []byte) ([]byte, error) {
+	plaintext, err := aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], aad)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return plaintext, nil
+}
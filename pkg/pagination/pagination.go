@@ -0,0 +1,31 @@
+// Package pagination implements opaque page tokens for keyset pagination by id.
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+)
+
+var ErrInvalidToken = errors.New("invalid page token")
+
+// EncodeToken returns a token pointing right after lastId.
+func EncodeToken(lastId int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(lastId, 10)))
+}
+
+// DecodeToken returns the id the page starts after, an empty token starts from the beginning.
+func DecodeToken(token string) (int64, error) {
+	if token == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, ErrInvalidToken
+	}
+	lastId, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || lastId < 0 {
+		return 0, ErrInvalidToken
+	}
+	return lastId, nil
+}
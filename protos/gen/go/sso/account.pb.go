@@ -0,0 +1,391 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v3.21.12
+// source: sso/account.proto
+
+package ssov1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type DeleteMyAccountRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	AppId int64                  `protobuf:"varint,1,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	// password confirms the deletion.
+	Password      string `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteMyAccountRequest) Reset() {
+	*x = DeleteMyAccountRequest{}
+	mi := &file_sso_account_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteMyAccountRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteMyAccountRequest) ProtoMessage() {}
+
+func (x *DeleteMyAccountRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_account_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteMyAccountRequest.ProtoReflect.Descriptor instead.
+func (*DeleteMyAccountRequest) Descriptor() ([]byte, []int) {
+	return file_sso_account_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *DeleteMyAccountRequest) GetAppId() int64 {
+	if x != nil {
+		return x.AppId
+	}
+	return 0
+}
+
+func (x *DeleteMyAccountRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+type DeleteMyAccountResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PurgeAfter    *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=purge_after,json=purgeAfter,proto3" json:"purge_after,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteMyAccountResponse) Reset() {
+	*x = DeleteMyAccountResponse{}
+	mi := &file_sso_account_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteMyAccountResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteMyAccountResponse) ProtoMessage() {}
+
+func (x *DeleteMyAccountResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_account_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteMyAccountResponse.ProtoReflect.Descriptor instead.
+func (*DeleteMyAccountResponse) Descriptor() ([]byte, []int) {
+	return file_sso_account_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DeleteMyAccountResponse) GetPurgeAfter() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PurgeAfter
+	}
+	return nil
+}
+
+type RestoreMyAccountRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Email         string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreMyAccountRequest) Reset() {
+	*x = RestoreMyAccountRequest{}
+	mi := &file_sso_account_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreMyAccountRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreMyAccountRequest) ProtoMessage() {}
+
+func (x *RestoreMyAccountRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_account_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreMyAccountRequest.ProtoReflect.Descriptor instead.
+func (*RestoreMyAccountRequest) Descriptor() ([]byte, []int) {
+	return file_sso_account_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RestoreMyAccountRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *RestoreMyAccountRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+type RestoreMyAccountResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreMyAccountResponse) Reset() {
+	*x = RestoreMyAccountResponse{}
+	mi := &file_sso_account_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreMyAccountResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreMyAccountResponse) ProtoMessage() {}
+
+func (x *RestoreMyAccountResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_account_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreMyAccountResponse.ProtoReflect.Descriptor instead.
+func (*RestoreMyAccountResponse) Descriptor() ([]byte, []int) {
+	return file_sso_account_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *RestoreMyAccountResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+type ExportMyDataRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AppId         int64                  `protobuf:"varint,1,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportMyDataRequest) Reset() {
+	*x = ExportMyDataRequest{}
+	mi := &file_sso_account_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportMyDataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportMyDataRequest) ProtoMessage() {}
+
+func (x *ExportMyDataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_account_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportMyDataRequest.ProtoReflect.Descriptor instead.
+func (*ExportMyDataRequest) Descriptor() ([]byte, []int) {
+	return file_sso_account_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ExportMyDataRequest) GetAppId() int64 {
+	if x != nil {
+		return x.AppId
+	}
+	return 0
+}
+
+type ExportMyDataResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// archive is a JSON document with the profile, app memberships, sessions and audit events.
+	Archive       []byte `protobuf:"bytes,1,opt,name=archive,proto3" json:"archive,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportMyDataResponse) Reset() {
+	*x = ExportMyDataResponse{}
+	mi := &file_sso_account_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportMyDataResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportMyDataResponse) ProtoMessage() {}
+
+func (x *ExportMyDataResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_account_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportMyDataResponse.ProtoReflect.Descriptor instead.
+func (*ExportMyDataResponse) Descriptor() ([]byte, []int) {
+	return file_sso_account_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ExportMyDataResponse) GetArchive() []byte {
+	if x != nil {
+		return x.Archive
+	}
+	return nil
+}
+
+var File_sso_account_proto protoreflect.FileDescriptor
+
+const file_sso_account_proto_rawDesc = "" +
+	"\n" +
+	"\x11sso/account.proto\x12\x04auth\x1a\x1fgoogle/protobuf/timestamp.proto\"K\n" +
+	"\x16DeleteMyAccountRequest\x12\x15\n" +
+	"\x06app_id\x18\x01 \x01(\x03R\x05appId\x12\x1a\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\"V\n" +
+	"\x17DeleteMyAccountResponse\x12;\n" +
+	"\vpurge_after\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"purgeAfter\"K\n" +
+	"\x17RestoreMyAccountRequest\x12\x14\n" +
+	"\x05email\x18\x01 \x01(\tR\x05email\x12\x1a\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\"4\n" +
+	"\x18RestoreMyAccountResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\",\n" +
+	"\x13ExportMyDataRequest\x12\x15\n" +
+	"\x06app_id\x18\x01 \x01(\x03R\x05appId\"0\n" +
+	"\x14ExportMyDataResponse\x12\x18\n" +
+	"\aarchive\x18\x01 \x01(\fR\aarchive2\xf3\x01\n" +
+	"\aAccount\x12N\n" +
+	"\x0fDeleteMyAccount\x12\x1c.auth.DeleteMyAccountRequest\x1a\x1d.auth.DeleteMyAccountResponse\x12Q\n" +
+	"\x10RestoreMyAccount\x12\x1d.auth.RestoreMyAccountRequest\x1a\x1e.auth.RestoreMyAccountResponse\x12E\n" +
+	"\fExportMyData\x12\x19.auth.ExportMyDataRequest\x1a\x1a.auth.ExportMyDataResponseB\x13Z\x11auth.sso.v1;ssov1b\x06proto3"
+
+var (
+	file_sso_account_proto_rawDescOnce sync.Once
+	file_sso_account_proto_rawDescData []byte
+)
+
+func file_sso_account_proto_rawDescGZIP() []byte {
+	file_sso_account_proto_rawDescOnce.Do(func() {
+		file_sso_account_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_sso_account_proto_rawDesc), len(file_sso_account_proto_rawDesc)))
+	})
+	return file_sso_account_proto_rawDescData
+}
+
+var file_sso_account_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_sso_account_proto_goTypes = []any{
+	(*DeleteMyAccountRequest)(nil),   // 0: auth.DeleteMyAccountRequest
+	(*DeleteMyAccountResponse)(nil),  // 1: auth.DeleteMyAccountResponse
+	(*RestoreMyAccountRequest)(nil),  // 2: auth.RestoreMyAccountRequest
+	(*RestoreMyAccountResponse)(nil), // 3: auth.RestoreMyAccountResponse
+	(*ExportMyDataRequest)(nil),      // 4: auth.ExportMyDataRequest
+	(*ExportMyDataResponse)(nil),     // 5: auth.ExportMyDataResponse
+	(*timestamppb.Timestamp)(nil),    // 6: google.protobuf.Timestamp
+}
+var file_sso_account_proto_depIdxs = []int32{
+	6, // 0: auth.DeleteMyAccountResponse.purge_after:type_name -> google.protobuf.Timestamp
+	0, // 1: auth.Account.DeleteMyAccount:input_type -> auth.DeleteMyAccountRequest
+	2, // 2: auth.Account.RestoreMyAccount:input_type -> auth.RestoreMyAccountRequest
+	4, // 3: auth.Account.ExportMyData:input_type -> auth.ExportMyDataRequest
+	1, // 4: auth.Account.DeleteMyAccount:output_type -> auth.DeleteMyAccountResponse
+	3, // 5: auth.Account.RestoreMyAccount:output_type -> auth.RestoreMyAccountResponse
+	5, // 6: auth.Account.ExportMyData:output_type -> auth.ExportMyDataResponse
+	4, // [4:7] is the sub-list for method output_type
+	1, // [1:4] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_sso_account_proto_init() }
+func file_sso_account_proto_init() {
+	if File_sso_account_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_sso_account_proto_rawDesc), len(file_sso_account_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_sso_account_proto_goTypes,
+		DependencyIndexes: file_sso_account_proto_depIdxs,
+		MessageInfos:      file_sso_account_proto_msgTypes,
+	}.Build()
+	File_sso_account_proto = out.File
+	file_sso_account_proto_goTypes = nil
+	file_sso_account_proto_depIdxs = nil
+}
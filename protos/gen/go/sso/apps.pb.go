@@ -0,0 +1,1094 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v3.21.12
+// source: sso/apps.proto
+
+package ssov1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type AppInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AppInfo) Reset() {
+	*x = AppInfo{}
+	mi := &file_sso_apps_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppInfo) ProtoMessage() {}
+
+func (x *AppInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_apps_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppInfo.ProtoReflect.Descriptor instead.
+func (*AppInfo) Descriptor() ([]byte, []int) {
+	return file_sso_apps_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AppInfo) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *AppInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type CreateAppRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateAppRequest) Reset() {
+	*x = CreateAppRequest{}
+	mi := &file_sso_apps_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateAppRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateAppRequest) ProtoMessage() {}
+
+func (x *CreateAppRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_apps_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateAppRequest.ProtoReflect.Descriptor instead.
+func (*CreateAppRequest) Descriptor() ([]byte, []int) {
+	return file_sso_apps_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CreateAppRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type CreateAppResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	App   *AppInfo               `protobuf:"bytes,1,opt,name=app,proto3" json:"app,omitempty"`
+	// secret is only returned once, on creation.
+	Secret        string `protobuf:"bytes,2,opt,name=secret,proto3" json:"secret,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateAppResponse) Reset() {
+	*x = CreateAppResponse{}
+	mi := &file_sso_apps_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateAppResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateAppResponse) ProtoMessage() {}
+
+func (x *CreateAppResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_apps_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateAppResponse.ProtoReflect.Descriptor instead.
+func (*CreateAppResponse) Descriptor() ([]byte, []int) {
+	return file_sso_apps_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateAppResponse) GetApp() *AppInfo {
+	if x != nil {
+		return x.App
+	}
+	return nil
+}
+
+func (x *CreateAppResponse) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+type UpdateAppRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AppId         int64                  `protobuf:"varint,1,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateAppRequest) Reset() {
+	*x = UpdateAppRequest{}
+	mi := &file_sso_apps_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateAppRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateAppRequest) ProtoMessage() {}
+
+func (x *UpdateAppRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_apps_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateAppRequest.ProtoReflect.Descriptor instead.
+func (*UpdateAppRequest) Descriptor() ([]byte, []int) {
+	return file_sso_apps_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *UpdateAppRequest) GetAppId() int64 {
+	if x != nil {
+		return x.AppId
+	}
+	return 0
+}
+
+func (x *UpdateAppRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type UpdateAppResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	App           *AppInfo               `protobuf:"bytes,1,opt,name=app,proto3" json:"app,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateAppResponse) Reset() {
+	*x = UpdateAppResponse{}
+	mi := &file_sso_apps_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateAppResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateAppResponse) ProtoMessage() {}
+
+func (x *UpdateAppResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_apps_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateAppResponse.ProtoReflect.Descriptor instead.
+func (*UpdateAppResponse) Descriptor() ([]byte, []int) {
+	return file_sso_apps_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *UpdateAppResponse) GetApp() *AppInfo {
+	if x != nil {
+		return x.App
+	}
+	return nil
+}
+
+type ListAppsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PageSize      int32                  `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	PageToken     string                 `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAppsRequest) Reset() {
+	*x = ListAppsRequest{}
+	mi := &file_sso_apps_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAppsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAppsRequest) ProtoMessage() {}
+
+func (x *ListAppsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_apps_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAppsRequest.ProtoReflect.Descriptor instead.
+func (*ListAppsRequest) Descriptor() ([]byte, []int) {
+	return file_sso_apps_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListAppsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListAppsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListAppsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Apps          []*AppInfo             `protobuf:"bytes,1,rep,name=apps,proto3" json:"apps,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAppsResponse) Reset() {
+	*x = ListAppsResponse{}
+	mi := &file_sso_apps_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAppsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAppsResponse) ProtoMessage() {}
+
+func (x *ListAppsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_apps_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAppsResponse.ProtoReflect.Descriptor instead.
+func (*ListAppsResponse) Descriptor() ([]byte, []int) {
+	return file_sso_apps_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListAppsResponse) GetApps() []*AppInfo {
+	if x != nil {
+		return x.Apps
+	}
+	return nil
+}
+
+func (x *ListAppsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type DeleteAppRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AppId         int64                  `protobuf:"varint,1,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteAppRequest) Reset() {
+	*x = DeleteAppRequest{}
+	mi := &file_sso_apps_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteAppRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteAppRequest) ProtoMessage() {}
+
+func (x *DeleteAppRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_apps_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteAppRequest.ProtoReflect.Descriptor instead.
+func (*DeleteAppRequest) Descriptor() ([]byte, []int) {
+	return file_sso_apps_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DeleteAppRequest) GetAppId() int64 {
+	if x != nil {
+		return x.AppId
+	}
+	return 0
+}
+
+type DeleteAppResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteAppResponse) Reset() {
+	*x = DeleteAppResponse{}
+	mi := &file_sso_apps_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteAppResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteAppResponse) ProtoMessage() {}
+
+func (x *DeleteAppResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_apps_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteAppResponse.ProtoReflect.Descriptor instead.
+func (*DeleteAppResponse) Descriptor() ([]byte, []int) {
+	return file_sso_apps_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DeleteAppResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+type RotateAppSecretRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AppId         int64                  `protobuf:"varint,1,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RotateAppSecretRequest) Reset() {
+	*x = RotateAppSecretRequest{}
+	mi := &file_sso_apps_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RotateAppSecretRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RotateAppSecretRequest) ProtoMessage() {}
+
+func (x *RotateAppSecretRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_apps_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RotateAppSecretRequest.ProtoReflect.Descriptor instead.
+func (*RotateAppSecretRequest) Descriptor() ([]byte, []int) {
+	return file_sso_apps_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *RotateAppSecretRequest) GetAppId() int64 {
+	if x != nil {
+		return x.AppId
+	}
+	return 0
+}
+
+type RotateAppSecretResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	App   *AppInfo               `protobuf:"bytes,1,opt,name=app,proto3" json:"app,omitempty"`
+	// secret is only returned once, on rotation.
+	Secret        string `protobuf:"bytes,2,opt,name=secret,proto3" json:"secret,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RotateAppSecretResponse) Reset() {
+	*x = RotateAppSecretResponse{}
+	mi := &file_sso_apps_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RotateAppSecretResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RotateAppSecretResponse) ProtoMessage() {}
+
+func (x *RotateAppSecretResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_apps_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RotateAppSecretResponse.ProtoReflect.Descriptor instead.
+func (*RotateAppSecretResponse) Descriptor() ([]byte, []int) {
+	return file_sso_apps_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *RotateAppSecretResponse) GetApp() *AppInfo {
+	if x != nil {
+		return x.App
+	}
+	return nil
+}
+
+func (x *RotateAppSecretResponse) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+type AppSettings struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	AppId int64                  `protobuf:"varint,1,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	// Zero token TTLs fall back to the service-wide default.
+	AccessTokenTtl  *durationpb.Duration `protobuf:"bytes,2,opt,name=access_token_ttl,json=accessTokenTtl,proto3" json:"access_token_ttl,omitempty"`
+	RefreshTokenTtl *durationpb.Duration `protobuf:"bytes,3,opt,name=refresh_token_ttl,json=refreshTokenTtl,proto3" json:"refresh_token_ttl,omitempty"`
+	// login_methods are any of "password", "passkey" and "external_idp".
+	LoginMethods []string `protobuf:"bytes,4,rep,name=login_methods,json=loginMethods,proto3" json:"login_methods,omitempty"`
+	// registration_policy is "open", "invite_only" or "domain_restricted".
+	RegistrationPolicy string `protobuf:"bytes,5,opt,name=registration_policy,json=registrationPolicy,proto3" json:"registration_policy,omitempty"`
+	// allowed_domains are the email domains that may register or join under "domain_restricted".
+	AllowedDomains []string `protobuf:"bytes,6,rep,name=allowed_domains,json=allowedDomains,proto3" json:"allowed_domains,omitempty"`
+	MfaRequired    bool     `protobuf:"varint,7,opt,name=mfa_required,json=mfaRequired,proto3" json:"mfa_required,omitempty"`
+	// membership_policy decides what happens when a user first logs in to the app:
+	// "open" makes them a member, "domain_restricted" only if their email domain is allowed,
+	// "approval" leaves them pending until an admin approves and "closed" refuses them.
+	MembershipPolicy string `protobuf:"bytes,8,opt,name=membership_policy,json=membershipPolicy,proto3" json:"membership_policy,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *AppSettings) Reset() {
+	*x = AppSettings{}
+	mi := &file_sso_apps_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppSettings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppSettings) ProtoMessage() {}
+
+func (x *AppSettings) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_apps_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppSettings.ProtoReflect.Descriptor instead.
+func (*AppSettings) Descriptor() ([]byte, []int) {
+	return file_sso_apps_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *AppSettings) GetAppId() int64 {
+	if x != nil {
+		return x.AppId
+	}
+	return 0
+}
+
+func (x *AppSettings) GetAccessTokenTtl() *durationpb.Duration {
+	if x != nil {
+		return x.AccessTokenTtl
+	}
+	return nil
+}
+
+func (x *AppSettings) GetRefreshTokenTtl() *durationpb.Duration {
+	if x != nil {
+		return x.RefreshTokenTtl
+	}
+	return nil
+}
+
+func (x *AppSettings) GetLoginMethods() []string {
+	if x != nil {
+		return x.LoginMethods
+	}
+	return nil
+}
+
+func (x *AppSettings) GetRegistrationPolicy() string {
+	if x != nil {
+		return x.RegistrationPolicy
+	}
+	return ""
+}
+
+func (x *AppSettings) GetAllowedDomains() []string {
+	if x != nil {
+		return x.AllowedDomains
+	}
+	return nil
+}
+
+func (x *AppSettings) GetMfaRequired() bool {
+	if x != nil {
+		return x.MfaRequired
+	}
+	return false
+}
+
+func (x *AppSettings) GetMembershipPolicy() string {
+	if x != nil {
+		return x.MembershipPolicy
+	}
+	return ""
+}
+
+type GetAppSettingsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AppId         int64                  `protobuf:"varint,1,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAppSettingsRequest) Reset() {
+	*x = GetAppSettingsRequest{}
+	mi := &file_sso_apps_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAppSettingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAppSettingsRequest) ProtoMessage() {}
+
+func (x *GetAppSettingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_apps_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAppSettingsRequest.ProtoReflect.Descriptor instead.
+func (*GetAppSettingsRequest) Descriptor() ([]byte, []int) {
+	return file_sso_apps_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetAppSettingsRequest) GetAppId() int64 {
+	if x != nil {
+		return x.AppId
+	}
+	return 0
+}
+
+type GetAppSettingsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Settings      *AppSettings           `protobuf:"bytes,1,opt,name=settings,proto3" json:"settings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAppSettingsResponse) Reset() {
+	*x = GetAppSettingsResponse{}
+	mi := &file_sso_apps_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAppSettingsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAppSettingsResponse) ProtoMessage() {}
+
+func (x *GetAppSettingsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_apps_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAppSettingsResponse.ProtoReflect.Descriptor instead.
+func (*GetAppSettingsResponse) Descriptor() ([]byte, []int) {
+	return file_sso_apps_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetAppSettingsResponse) GetSettings() *AppSettings {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+type UpdateAppSettingsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Settings      *AppSettings           `protobuf:"bytes,1,opt,name=settings,proto3" json:"settings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateAppSettingsRequest) Reset() {
+	*x = UpdateAppSettingsRequest{}
+	mi := &file_sso_apps_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateAppSettingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateAppSettingsRequest) ProtoMessage() {}
+
+func (x *UpdateAppSettingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_apps_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateAppSettingsRequest.ProtoReflect.Descriptor instead.
+func (*UpdateAppSettingsRequest) Descriptor() ([]byte, []int) {
+	return file_sso_apps_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *UpdateAppSettingsRequest) GetSettings() *AppSettings {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+type UpdateAppSettingsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Settings      *AppSettings           `protobuf:"bytes,1,opt,name=settings,proto3" json:"settings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateAppSettingsResponse) Reset() {
+	*x = UpdateAppSettingsResponse{}
+	mi := &file_sso_apps_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateAppSettingsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateAppSettingsResponse) ProtoMessage() {}
+
+func (x *UpdateAppSettingsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_apps_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateAppSettingsResponse.ProtoReflect.Descriptor instead.
+func (*UpdateAppSettingsResponse) Descriptor() ([]byte, []int) {
+	return file_sso_apps_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *UpdateAppSettingsResponse) GetSettings() *AppSettings {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+type AssignAppOwnerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AppId         int64                  `protobuf:"varint,1,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	UserId        int64                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AssignAppOwnerRequest) Reset() {
+	*x = AssignAppOwnerRequest{}
+	mi := &file_sso_apps_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AssignAppOwnerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AssignAppOwnerRequest) ProtoMessage() {}
+
+func (x *AssignAppOwnerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_apps_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AssignAppOwnerRequest.ProtoReflect.Descriptor instead.
+func (*AssignAppOwnerRequest) Descriptor() ([]byte, []int) {
+	return file_sso_apps_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *AssignAppOwnerRequest) GetAppId() int64 {
+	if x != nil {
+		return x.AppId
+	}
+	return 0
+}
+
+func (x *AssignAppOwnerRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+type AssignAppOwnerResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// previous_permission is the user's permanent permission in the app before, empty if they had none.
+	PreviousPermission string `protobuf:"bytes,1,opt,name=previous_permission,json=previousPermission,proto3" json:"previous_permission,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *AssignAppOwnerResponse) Reset() {
+	*x = AssignAppOwnerResponse{}
+	mi := &file_sso_apps_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AssignAppOwnerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AssignAppOwnerResponse) ProtoMessage() {}
+
+func (x *AssignAppOwnerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_apps_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AssignAppOwnerResponse.ProtoReflect.Descriptor instead.
+func (*AssignAppOwnerResponse) Descriptor() ([]byte, []int) {
+	return file_sso_apps_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *AssignAppOwnerResponse) GetPreviousPermission() string {
+	if x != nil {
+		return x.PreviousPermission
+	}
+	return ""
+}
+
+var File_sso_apps_proto protoreflect.FileDescriptor
+
+const file_sso_apps_proto_rawDesc = "" +
+	"\n" +
+	"\x0esso/apps.proto\x12\x04auth\x1a\x1egoogle/protobuf/duration.proto\"-\n" +
+	"\aAppInfo\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\"&\n" +
+	"\x10CreateAppRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"L\n" +
+	"\x11CreateAppResponse\x12\x1f\n" +
+	"\x03app\x18\x01 \x01(\v2\r.auth.AppInfoR\x03app\x12\x16\n" +
+	"\x06secret\x18\x02 \x01(\tR\x06secret\"=\n" +
+	"\x10UpdateAppRequest\x12\x15\n" +
+	"\x06app_id\x18\x01 \x01(\x03R\x05appId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\"4\n" +
+	"\x11UpdateAppResponse\x12\x1f\n" +
+	"\x03app\x18\x01 \x01(\v2\r.auth.AppInfoR\x03app\"M\n" +
+	"\x0fListAppsRequest\x12\x1b\n" +
+	"\tpage_size\x18\x01 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x02 \x01(\tR\tpageToken\"]\n" +
+	"\x10ListAppsResponse\x12!\n" +
+	"\x04apps\x18\x01 \x03(\v2\r.auth.AppInfoR\x04apps\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\")\n" +
+	"\x10DeleteAppRequest\x12\x15\n" +
+	"\x06app_id\x18\x01 \x01(\x03R\x05appId\"-\n" +
+	"\x11DeleteAppResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\"/\n" +
+	"\x16RotateAppSecretRequest\x12\x15\n" +
+	"\x06app_id\x18\x01 \x01(\x03R\x05appId\"R\n" +
+	"\x17RotateAppSecretResponse\x12\x1f\n" +
+	"\x03app\x18\x01 \x01(\v2\r.auth.AppInfoR\x03app\x12\x16\n" +
+	"\x06secret\x18\x02 \x01(\tR\x06secret\"\xff\x02\n" +
+	"\vAppSettings\x12\x15\n" +
+	"\x06app_id\x18\x01 \x01(\x03R\x05appId\x12C\n" +
+	"\x10access_token_ttl\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x0eaccessTokenTtl\x12E\n" +
+	"\x11refresh_token_ttl\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\x0frefreshTokenTtl\x12#\n" +
+	"\rlogin_methods\x18\x04 \x03(\tR\floginMethods\x12/\n" +
+	"\x13registration_policy\x18\x05 \x01(\tR\x12registrationPolicy\x12'\n" +
+	"\x0fallowed_domains\x18\x06 \x03(\tR\x0eallowedDomains\x12!\n" +
+	"\fmfa_required\x18\a \x01(\bR\vmfaRequired\x12+\n" +
+	"\x11membership_policy\x18\b \x01(\tR\x10membershipPolicy\".\n" +
+	"\x15GetAppSettingsRequest\x12\x15\n" +
+	"\x06app_id\x18\x01 \x01(\x03R\x05appId\"G\n" +
+	"\x16GetAppSettingsResponse\x12-\n" +
+	"\bsettings\x18\x01 \x01(\v2\x11.auth.AppSettingsR\bsettings\"I\n" +
+	"\x18UpdateAppSettingsRequest\x12-\n" +
+	"\bsettings\x18\x01 \x01(\v2\x11.auth.AppSettingsR\bsettings\"J\n" +
+	"\x19UpdateAppSettingsResponse\x12-\n" +
+	"\bsettings\x18\x01 \x01(\v2\x11.auth.AppSettingsR\bsettings\"G\n" +
+	"\x15AssignAppOwnerRequest\x12\x15\n" +
+	"\x06app_id\x18\x01 \x01(\x03R\x05appId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x03R\x06userId\"I\n" +
+	"\x16AssignAppOwnerResponse\x12/\n" +
+	"\x13previous_permission\x18\x01 \x01(\tR\x12previousPermission2\xbf\x04\n" +
+	"\bAppAdmin\x12<\n" +
+	"\tCreateApp\x12\x16.auth.CreateAppRequest\x1a\x17.auth.CreateAppResponse\x12<\n" +
+	"\tUpdateApp\x12\x16.auth.UpdateAppRequest\x1a\x17.auth.UpdateAppResponse\x129\n" +
+	"\bListApps\x12\x15.auth.ListAppsRequest\x1a\x16.auth.ListAppsResponse\x12<\n" +
+	"\tDeleteApp\x12\x16.auth.DeleteAppRequest\x1a\x17.auth.DeleteAppResponse\x12N\n" +
+	"\x0fRotateAppSecret\x12\x1c.auth.RotateAppSecretRequest\x1a\x1d.auth.RotateAppSecretResponse\x12K\n" +
+	"\x0eGetAppSettings\x12\x1b.auth.GetAppSettingsRequest\x1a\x1c.auth.GetAppSettingsResponse\x12T\n" +
+	"\x11UpdateAppSettings\x12\x1e.auth.UpdateAppSettingsRequest\x1a\x1f.auth.UpdateAppSettingsResponse\x12K\n" +
+	"\x0eAssignAppOwner\x12\x1b.auth.AssignAppOwnerRequest\x1a\x1c.auth.AssignAppOwnerResponseB\x13Z\x11auth.sso.v1;ssov1b\x06proto3"
+
+var (
+	file_sso_apps_proto_rawDescOnce sync.Once
+	file_sso_apps_proto_rawDescData []byte
+)
+
+func file_sso_apps_proto_rawDescGZIP() []byte {
+	file_sso_apps_proto_rawDescOnce.Do(func() {
+		file_sso_apps_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_sso_apps_proto_rawDesc), len(file_sso_apps_proto_rawDesc)))
+	})
+	return file_sso_apps_proto_rawDescData
+}
+
+var file_sso_apps_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
+var file_sso_apps_proto_goTypes = []any{
+	(*AppInfo)(nil),                   // 0: auth.AppInfo
+	(*CreateAppRequest)(nil),          // 1: auth.CreateAppRequest
+	(*CreateAppResponse)(nil),         // 2: auth.CreateAppResponse
+	(*UpdateAppRequest)(nil),          // 3: auth.UpdateAppRequest
+	(*UpdateAppResponse)(nil),         // 4: auth.UpdateAppResponse
+	(*ListAppsRequest)(nil),           // 5: auth.ListAppsRequest
+	(*ListAppsResponse)(nil),          // 6: auth.ListAppsResponse
+	(*DeleteAppRequest)(nil),          // 7: auth.DeleteAppRequest
+	(*DeleteAppResponse)(nil),         // 8: auth.DeleteAppResponse
+	(*RotateAppSecretRequest)(nil),    // 9: auth.RotateAppSecretRequest
+	(*RotateAppSecretResponse)(nil),   // 10: auth.RotateAppSecretResponse
+	(*AppSettings)(nil),               // 11: auth.AppSettings
+	(*GetAppSettingsRequest)(nil),     // 12: auth.GetAppSettingsRequest
+	(*GetAppSettingsResponse)(nil),    // 13: auth.GetAppSettingsResponse
+	(*UpdateAppSettingsRequest)(nil),  // 14: auth.UpdateAppSettingsRequest
+	(*UpdateAppSettingsResponse)(nil), // 15: auth.UpdateAppSettingsResponse
+	(*AssignAppOwnerRequest)(nil),     // 16: auth.AssignAppOwnerRequest
+	(*AssignAppOwnerResponse)(nil),    // 17: auth.AssignAppOwnerResponse
+	(*durationpb.Duration)(nil),       // 18: google.protobuf.Duration
+}
+var file_sso_apps_proto_depIdxs = []int32{
+	0,  // 0: auth.CreateAppResponse.app:type_name -> auth.AppInfo
+	0,  // 1: auth.UpdateAppResponse.app:type_name -> auth.AppInfo
+	0,  // 2: auth.ListAppsResponse.apps:type_name -> auth.AppInfo
+	0,  // 3: auth.RotateAppSecretResponse.app:type_name -> auth.AppInfo
+	18, // 4: auth.AppSettings.access_token_ttl:type_name -> google.protobuf.Duration
+	18, // 5: auth.AppSettings.refresh_token_ttl:type_name -> google.protobuf.Duration
+	11, // 6: auth.GetAppSettingsResponse.settings:type_name -> auth.AppSettings
+	11, // 7: auth.UpdateAppSettingsRequest.settings:type_name -> auth.AppSettings
+	11, // 8: auth.UpdateAppSettingsResponse.settings:type_name -> auth.AppSettings
+	1,  // 9: auth.AppAdmin.CreateApp:input_type -> auth.CreateAppRequest
+	3,  // 10: auth.AppAdmin.UpdateApp:input_type -> auth.UpdateAppRequest
+	5,  // 11: auth.AppAdmin.ListApps:input_type -> auth.ListAppsRequest
+	7,  // 12: auth.AppAdmin.DeleteApp:input_type -> auth.DeleteAppRequest
+	9,  // 13: auth.AppAdmin.RotateAppSecret:input_type -> auth.RotateAppSecretRequest
+	12, // 14: auth.AppAdmin.GetAppSettings:input_type -> auth.GetAppSettingsRequest
+	14, // 15: auth.AppAdmin.UpdateAppSettings:input_type -> auth.UpdateAppSettingsRequest
+	16, // 16: auth.AppAdmin.AssignAppOwner:input_type -> auth.AssignAppOwnerRequest
+	2,  // 17: auth.AppAdmin.CreateApp:output_type -> auth.CreateAppResponse
+	4,  // 18: auth.AppAdmin.UpdateApp:output_type -> auth.UpdateAppResponse
+	6,  // 19: auth.AppAdmin.ListApps:output_type -> auth.ListAppsResponse
+	8,  // 20: auth.AppAdmin.DeleteApp:output_type -> auth.DeleteAppResponse
+	10, // 21: auth.AppAdmin.RotateAppSecret:output_type -> auth.RotateAppSecretResponse
+	13, // 22: auth.AppAdmin.GetAppSettings:output_type -> auth.GetAppSettingsResponse
+	15, // 23: auth.AppAdmin.UpdateAppSettings:output_type -> auth.UpdateAppSettingsResponse
+	17, // 24: auth.AppAdmin.AssignAppOwner:output_type -> auth.AssignAppOwnerResponse
+	17, // [17:25] is the sub-list for method output_type
+	9,  // [9:17] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_sso_apps_proto_init() }
+func file_sso_apps_proto_init() {
+	if File_sso_apps_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_sso_apps_proto_rawDesc), len(file_sso_apps_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   18,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_sso_apps_proto_goTypes,
+		DependencyIndexes: file_sso_apps_proto_depIdxs,
+		MessageInfos:      file_sso_apps_proto_msgTypes,
+	}.Build()
+	File_sso_apps_proto = out.File
+	file_sso_apps_proto_goTypes = nil
+	file_sso_apps_proto_depIdxs = nil
+}
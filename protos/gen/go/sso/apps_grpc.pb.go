@@ -0,0 +1,395 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v3.21.12
+// source: sso/apps.proto
+
+package ssov1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	AppAdmin_CreateApp_FullMethodName         = "/auth.AppAdmin/CreateApp"
+	AppAdmin_UpdateApp_FullMethodName         = "/auth.AppAdmin/UpdateApp"
+	AppAdmin_ListApps_FullMethodName          = "/auth.AppAdmin/ListApps"
+	AppAdmin_DeleteApp_FullMethodName         = "/auth.AppAdmin/DeleteApp"
+	AppAdmin_RotateAppSecret_FullMethodName   = "/auth.AppAdmin/RotateAppSecret"
+	AppAdmin_GetAppSettings_FullMethodName    = "/auth.AppAdmin/GetAppSettings"
+	AppAdmin_UpdateAppSettings_FullMethodName = "/auth.AppAdmin/UpdateAppSettings"
+	AppAdmin_AssignAppOwner_FullMethodName    = "/auth.AppAdmin/AssignAppOwner"
+)
+
+// AppAdminClient is the client API for AppAdmin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AppAdminClient interface {
+	CreateApp(ctx context.Context, in *CreateAppRequest, opts ...grpc.CallOption) (*CreateAppResponse, error)
+	UpdateApp(ctx context.Context, in *UpdateAppRequest, opts ...grpc.CallOption) (*UpdateAppResponse, error)
+	ListApps(ctx context.Context, in *ListAppsRequest, opts ...grpc.CallOption) (*ListAppsResponse, error)
+	DeleteApp(ctx context.Context, in *DeleteAppRequest, opts ...grpc.CallOption) (*DeleteAppResponse, error)
+	// RotateAppSecret replaces the app's secret, tokens signed with the old one stop validating.
+	RotateAppSecret(ctx context.Context, in *RotateAppSecretRequest, opts ...grpc.CallOption) (*RotateAppSecretResponse, error)
+	GetAppSettings(ctx context.Context, in *GetAppSettingsRequest, opts ...grpc.CallOption) (*GetAppSettingsResponse, error)
+	// UpdateAppSettings replaces all settings of the app.
+	UpdateAppSettings(ctx context.Context, in *UpdateAppSettingsRequest, opts ...grpc.CallOption) (*UpdateAppSettingsResponse, error)
+	// AssignAppOwner makes the user an owner of the app, whatever the app's membership policy.
+	// Only super-admins may call it, it is how apps get their first owner.
+	AssignAppOwner(ctx context.Context, in *AssignAppOwnerRequest, opts ...grpc.CallOption) (*AssignAppOwnerResponse, error)
+}
+
+type appAdminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAppAdminClient(cc grpc.ClientConnInterface) AppAdminClient {
+	return &appAdminClient{cc}
+}
+
+func (c *appAdminClient) CreateApp(ctx context.Context, in *CreateAppRequest, opts ...grpc.CallOption) (*CreateAppResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateAppResponse)
+	err := c.cc.Invoke(ctx, AppAdmin_CreateApp_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appAdminClient) UpdateApp(ctx context.Context, in *UpdateAppRequest, opts ...grpc.CallOption) (*UpdateAppResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateAppResponse)
+	err := c.cc.Invoke(ctx, AppAdmin_UpdateApp_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appAdminClient) ListApps(ctx context.Context, in *ListAppsRequest, opts ...grpc.CallOption) (*ListAppsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAppsResponse)
+	err := c.cc.Invoke(ctx, AppAdmin_ListApps_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appAdminClient) DeleteApp(ctx context.Context, in *DeleteAppRequest, opts ...grpc.CallOption) (*DeleteAppResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteAppResponse)
+	err := c.cc.Invoke(ctx, AppAdmin_DeleteApp_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appAdminClient) RotateAppSecret(ctx context.Context, in *RotateAppSecretRequest, opts ...grpc.CallOption) (*RotateAppSecretResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RotateAppSecretResponse)
+	err := c.cc.Invoke(ctx, AppAdmin_RotateAppSecret_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appAdminClient) GetAppSettings(ctx context.Context, in *GetAppSettingsRequest, opts ...grpc.CallOption) (*GetAppSettingsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetAppSettingsResponse)
+	err := c.cc.Invoke(ctx, AppAdmin_GetAppSettings_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appAdminClient) UpdateAppSettings(ctx context.Context, in *UpdateAppSettingsRequest, opts ...grpc.CallOption) (*UpdateAppSettingsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateAppSettingsResponse)
+	err := c.cc.Invoke(ctx, AppAdmin_UpdateAppSettings_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appAdminClient) AssignAppOwner(ctx context.Context, in *AssignAppOwnerRequest, opts ...grpc.CallOption) (*AssignAppOwnerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AssignAppOwnerResponse)
+	err := c.cc.Invoke(ctx, AppAdmin_AssignAppOwner_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AppAdminServer is the server API for AppAdmin service.
+// All implementations must embed UnimplementedAppAdminServer
+// for forward compatibility.
+type AppAdminServer interface {
+	CreateApp(context.Context, *CreateAppRequest) (*CreateAppResponse, error)
+	UpdateApp(context.Context, *UpdateAppRequest) (*UpdateAppResponse, error)
+	ListApps(context.Context, *ListAppsRequest) (*ListAppsResponse, error)
+	DeleteApp(context.Context, *DeleteAppRequest) (*DeleteAppResponse, error)
+	// RotateAppSecret replaces the app's secret, tokens signed with the old one stop validating.
+	RotateAppSecret(context.Context, *RotateAppSecretRequest) (*RotateAppSecretResponse, error)
+	GetAppSettings(context.Context, *GetAppSettingsRequest) (*GetAppSettingsResponse, error)
+	// UpdateAppSettings replaces all settings of the app.
+	UpdateAppSettings(context.Context, *UpdateAppSettingsRequest) (*UpdateAppSettingsResponse, error)
+	// AssignAppOwner makes the user an owner of the app, whatever the app's membership policy.
+	// Only super-admins may call it, it is how apps get their first owner.
+	AssignAppOwner(context.Context, *AssignAppOwnerRequest) (*AssignAppOwnerResponse, error)
+	mustEmbedUnimplementedAppAdminServer()
+}
+
+// UnimplementedAppAdminServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAppAdminServer struct{}
+
+func (UnimplementedAppAdminServer) CreateApp(context.Context, *CreateAppRequest) (*CreateAppResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateApp not implemented")
+}
+func (UnimplementedAppAdminServer) UpdateApp(context.Context, *UpdateAppRequest) (*UpdateAppResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateApp not implemented")
+}
+func (UnimplementedAppAdminServer) ListApps(context.Context, *ListAppsRequest) (*ListAppsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListApps not implemented")
+}
+func (UnimplementedAppAdminServer) DeleteApp(context.Context, *DeleteAppRequest) (*DeleteAppResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteApp not implemented")
+}
+func (UnimplementedAppAdminServer) RotateAppSecret(context.Context, *RotateAppSecretRequest) (*RotateAppSecretResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RotateAppSecret not implemented")
+}
+func (UnimplementedAppAdminServer) GetAppSettings(context.Context, *GetAppSettingsRequest) (*GetAppSettingsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAppSettings not implemented")
+}
+func (UnimplementedAppAdminServer) UpdateAppSettings(context.Context, *UpdateAppSettingsRequest) (*UpdateAppSettingsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateAppSettings not implemented")
+}
+func (UnimplementedAppAdminServer) AssignAppOwner(context.Context, *AssignAppOwnerRequest) (*AssignAppOwnerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AssignAppOwner not implemented")
+}
+func (UnimplementedAppAdminServer) mustEmbedUnimplementedAppAdminServer() {}
+func (UnimplementedAppAdminServer) testEmbeddedByValue()                  {}
+
+// UnsafeAppAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AppAdminServer will
+// result in compilation errors.
+type UnsafeAppAdminServer interface {
+	mustEmbedUnimplementedAppAdminServer()
+}
+
+func RegisterAppAdminServer(s grpc.ServiceRegistrar, srv AppAdminServer) {
+	// If the following call pancis, it indicates UnimplementedAppAdminServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AppAdmin_ServiceDesc, srv)
+}
+
+func _AppAdmin_CreateApp_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateAppRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppAdminServer).CreateApp(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AppAdmin_CreateApp_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppAdminServer).CreateApp(ctx, req.(*CreateAppRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AppAdmin_UpdateApp_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateAppRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppAdminServer).UpdateApp(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AppAdmin_UpdateApp_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppAdminServer).UpdateApp(ctx, req.(*UpdateAppRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AppAdmin_ListApps_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAppsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppAdminServer).ListApps(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AppAdmin_ListApps_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppAdminServer).ListApps(ctx, req.(*ListAppsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AppAdmin_DeleteApp_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteAppRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppAdminServer).DeleteApp(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AppAdmin_DeleteApp_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppAdminServer).DeleteApp(ctx, req.(*DeleteAppRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AppAdmin_RotateAppSecret_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RotateAppSecretRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppAdminServer).RotateAppSecret(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AppAdmin_RotateAppSecret_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppAdminServer).RotateAppSecret(ctx, req.(*RotateAppSecretRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AppAdmin_GetAppSettings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAppSettingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppAdminServer).GetAppSettings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AppAdmin_GetAppSettings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppAdminServer).GetAppSettings(ctx, req.(*GetAppSettingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AppAdmin_UpdateAppSettings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateAppSettingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppAdminServer).UpdateAppSettings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AppAdmin_UpdateAppSettings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppAdminServer).UpdateAppSettings(ctx, req.(*UpdateAppSettingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AppAdmin_AssignAppOwner_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AssignAppOwnerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppAdminServer).AssignAppOwner(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AppAdmin_AssignAppOwner_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppAdminServer).AssignAppOwner(ctx, req.(*AssignAppOwnerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AppAdmin_ServiceDesc is the grpc.ServiceDesc for AppAdmin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AppAdmin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "auth.AppAdmin",
+	HandlerType: (*AppAdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateApp",
+			Handler:    _AppAdmin_CreateApp_Handler,
+		},
+		{
+			MethodName: "UpdateApp",
+			Handler:    _AppAdmin_UpdateApp_Handler,
+		},
+		{
+			MethodName: "ListApps",
+			Handler:    _AppAdmin_ListApps_Handler,
+		},
+		{
+			MethodName: "DeleteApp",
+			Handler:    _AppAdmin_DeleteApp_Handler,
+		},
+		{
+			MethodName: "RotateAppSecret",
+			Handler:    _AppAdmin_RotateAppSecret_Handler,
+		},
+		{
+			MethodName: "GetAppSettings",
+			Handler:    _AppAdmin_GetAppSettings_Handler,
+		},
+		{
+			MethodName: "UpdateAppSettings",
+			Handler:    _AppAdmin_UpdateAppSettings_Handler,
+		},
+		{
+			MethodName: "AssignAppOwner",
+			Handler:    _AppAdmin_AssignAppOwner_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "sso/apps.proto",
+}
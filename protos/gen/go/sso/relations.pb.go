@@ -0,0 +1,1099 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v3.21.12
+// source: sso/relations.proto
+
+package ssov1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type RelationTupleUpdate_Operation int32
+
+const (
+	RelationTupleUpdate_OPERATION_UNSPECIFIED RelationTupleUpdate_Operation = 0
+	RelationTupleUpdate_OPERATION_INSERT      RelationTupleUpdate_Operation = 1
+	RelationTupleUpdate_OPERATION_DELETE      RelationTupleUpdate_Operation = 2
+)
+
+// Enum value maps for RelationTupleUpdate_Operation.
+var (
+	RelationTupleUpdate_Operation_name = map[int32]string{
+		0: "OPERATION_UNSPECIFIED",
+		1: "OPERATION_INSERT",
+		2: "OPERATION_DELETE",
+	}
+	RelationTupleUpdate_Operation_value = map[string]int32{
+		"OPERATION_UNSPECIFIED": 0,
+		"OPERATION_INSERT":      1,
+		"OPERATION_DELETE":      2,
+	}
+)
+
+func (x RelationTupleUpdate_Operation) Enum() *RelationTupleUpdate_Operation {
+	p := new(RelationTupleUpdate_Operation)
+	*p = x
+	return p
+}
+
+func (x RelationTupleUpdate_Operation) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RelationTupleUpdate_Operation) Descriptor() protoreflect.EnumDescriptor {
+	return file_sso_relations_proto_enumTypes[0].Descriptor()
+}
+
+func (RelationTupleUpdate_Operation) Type() protoreflect.EnumType {
+	return &file_sso_relations_proto_enumTypes[0]
+}
+
+func (x RelationTupleUpdate_Operation) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RelationTupleUpdate_Operation.Descriptor instead.
+func (RelationTupleUpdate_Operation) EnumDescriptor() ([]byte, []int) {
+	return file_sso_relations_proto_rawDescGZIP(), []int{7, 0}
+}
+
+type Subject struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Namespace     string                 `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	ObjectId      string                 `protobuf:"bytes,2,opt,name=object_id,json=objectId,proto3" json:"object_id,omitempty"`
+	Relation      string                 `protobuf:"bytes,3,opt,name=relation,proto3" json:"relation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Subject) Reset() {
+	*x = Subject{}
+	mi := &file_sso_relations_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Subject) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Subject) ProtoMessage() {}
+
+func (x *Subject) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_relations_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Subject.ProtoReflect.Descriptor instead.
+func (*Subject) Descriptor() ([]byte, []int) {
+	return file_sso_relations_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Subject) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *Subject) GetObjectId() string {
+	if x != nil {
+		return x.ObjectId
+	}
+	return ""
+}
+
+func (x *Subject) GetRelation() string {
+	if x != nil {
+		return x.Relation
+	}
+	return ""
+}
+
+type RelationTuple struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Namespace     string                 `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	ObjectId      string                 `protobuf:"bytes,2,opt,name=object_id,json=objectId,proto3" json:"object_id,omitempty"`
+	Relation      string                 `protobuf:"bytes,3,opt,name=relation,proto3" json:"relation,omitempty"`
+	Subject       *Subject               `protobuf:"bytes,4,opt,name=subject,proto3" json:"subject,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RelationTuple) Reset() {
+	*x = RelationTuple{}
+	mi := &file_sso_relations_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RelationTuple) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RelationTuple) ProtoMessage() {}
+
+func (x *RelationTuple) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_relations_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RelationTuple.ProtoReflect.Descriptor instead.
+func (*RelationTuple) Descriptor() ([]byte, []int) {
+	return file_sso_relations_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RelationTuple) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *RelationTuple) GetObjectId() string {
+	if x != nil {
+		return x.ObjectId
+	}
+	return ""
+}
+
+func (x *RelationTuple) GetRelation() string {
+	if x != nil {
+		return x.Relation
+	}
+	return ""
+}
+
+func (x *RelationTuple) GetSubject() *Subject {
+	if x != nil {
+		return x.Subject
+	}
+	return nil
+}
+
+type CheckRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	AppId            int64                  `protobuf:"varint,1,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	Namespace        string                 `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	ObjectId         string                 `protobuf:"bytes,3,opt,name=object_id,json=objectId,proto3" json:"object_id,omitempty"`
+	Relation         string                 `protobuf:"bytes,4,opt,name=relation,proto3" json:"relation,omitempty"`
+	Subject          *Subject               `protobuf:"bytes,5,opt,name=subject,proto3" json:"subject,omitempty"`
+	ConsistencyToken string                 `protobuf:"bytes,6,opt,name=consistency_token,json=consistencyToken,proto3" json:"consistency_token,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *CheckRequest) Reset() {
+	*x = CheckRequest{}
+	mi := &file_sso_relations_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckRequest) ProtoMessage() {}
+
+func (x *CheckRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_relations_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckRequest.ProtoReflect.Descriptor instead.
+func (*CheckRequest) Descriptor() ([]byte, []int) {
+	return file_sso_relations_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CheckRequest) GetAppId() int64 {
+	if x != nil {
+		return x.AppId
+	}
+	return 0
+}
+
+func (x *CheckRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *CheckRequest) GetObjectId() string {
+	if x != nil {
+		return x.ObjectId
+	}
+	return ""
+}
+
+func (x *CheckRequest) GetRelation() string {
+	if x != nil {
+		return x.Relation
+	}
+	return ""
+}
+
+func (x *CheckRequest) GetSubject() *Subject {
+	if x != nil {
+		return x.Subject
+	}
+	return nil
+}
+
+func (x *CheckRequest) GetConsistencyToken() string {
+	if x != nil {
+		return x.ConsistencyToken
+	}
+	return ""
+}
+
+type CheckResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Allowed          bool                   `protobuf:"varint,1,opt,name=allowed,proto3" json:"allowed,omitempty"`
+	ConsistencyToken string                 `protobuf:"bytes,2,opt,name=consistency_token,json=consistencyToken,proto3" json:"consistency_token,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *CheckResponse) Reset() {
+	*x = CheckResponse{}
+	mi := &file_sso_relations_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckResponse) ProtoMessage() {}
+
+func (x *CheckResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_relations_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckResponse.ProtoReflect.Descriptor instead.
+func (*CheckResponse) Descriptor() ([]byte, []int) {
+	return file_sso_relations_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CheckResponse) GetAllowed() bool {
+	if x != nil {
+		return x.Allowed
+	}
+	return false
+}
+
+func (x *CheckResponse) GetConsistencyToken() string {
+	if x != nil {
+		return x.ConsistencyToken
+	}
+	return ""
+}
+
+type ExpandRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	AppId            int64                  `protobuf:"varint,1,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	Namespace        string                 `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	ObjectId         string                 `protobuf:"bytes,3,opt,name=object_id,json=objectId,proto3" json:"object_id,omitempty"`
+	Relation         string                 `protobuf:"bytes,4,opt,name=relation,proto3" json:"relation,omitempty"`
+	ConsistencyToken string                 `protobuf:"bytes,5,opt,name=consistency_token,json=consistencyToken,proto3" json:"consistency_token,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ExpandRequest) Reset() {
+	*x = ExpandRequest{}
+	mi := &file_sso_relations_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExpandRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExpandRequest) ProtoMessage() {}
+
+func (x *ExpandRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_relations_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExpandRequest.ProtoReflect.Descriptor instead.
+func (*ExpandRequest) Descriptor() ([]byte, []int) {
+	return file_sso_relations_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ExpandRequest) GetAppId() int64 {
+	if x != nil {
+		return x.AppId
+	}
+	return 0
+}
+
+func (x *ExpandRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *ExpandRequest) GetObjectId() string {
+	if x != nil {
+		return x.ObjectId
+	}
+	return ""
+}
+
+func (x *ExpandRequest) GetRelation() string {
+	if x != nil {
+		return x.Relation
+	}
+	return ""
+}
+
+func (x *ExpandRequest) GetConsistencyToken() string {
+	if x != nil {
+		return x.ConsistencyToken
+	}
+	return ""
+}
+
+type SubjectTree struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Operation     string                 `protobuf:"bytes,1,opt,name=operation,proto3" json:"operation,omitempty"`
+	Namespace     string                 `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	ObjectId      string                 `protobuf:"bytes,3,opt,name=object_id,json=objectId,proto3" json:"object_id,omitempty"`
+	Relation      string                 `protobuf:"bytes,4,opt,name=relation,proto3" json:"relation,omitempty"`
+	Subjects      []*Subject             `protobuf:"bytes,5,rep,name=subjects,proto3" json:"subjects,omitempty"`
+	Children      []*SubjectTree         `protobuf:"bytes,6,rep,name=children,proto3" json:"children,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubjectTree) Reset() {
+	*x = SubjectTree{}
+	mi := &file_sso_relations_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubjectTree) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubjectTree) ProtoMessage() {}
+
+func (x *SubjectTree) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_relations_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubjectTree.ProtoReflect.Descriptor instead.
+func (*SubjectTree) Descriptor() ([]byte, []int) {
+	return file_sso_relations_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SubjectTree) GetOperation() string {
+	if x != nil {
+		return x.Operation
+	}
+	return ""
+}
+
+func (x *SubjectTree) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *SubjectTree) GetObjectId() string {
+	if x != nil {
+		return x.ObjectId
+	}
+	return ""
+}
+
+func (x *SubjectTree) GetRelation() string {
+	if x != nil {
+		return x.Relation
+	}
+	return ""
+}
+
+func (x *SubjectTree) GetSubjects() []*Subject {
+	if x != nil {
+		return x.Subjects
+	}
+	return nil
+}
+
+func (x *SubjectTree) GetChildren() []*SubjectTree {
+	if x != nil {
+		return x.Children
+	}
+	return nil
+}
+
+type ExpandResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Tree             *SubjectTree           `protobuf:"bytes,1,opt,name=tree,proto3" json:"tree,omitempty"`
+	ConsistencyToken string                 `protobuf:"bytes,2,opt,name=consistency_token,json=consistencyToken,proto3" json:"consistency_token,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ExpandResponse) Reset() {
+	*x = ExpandResponse{}
+	mi := &file_sso_relations_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExpandResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExpandResponse) ProtoMessage() {}
+
+func (x *ExpandResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_relations_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExpandResponse.ProtoReflect.Descriptor instead.
+func (*ExpandResponse) Descriptor() ([]byte, []int) {
+	return file_sso_relations_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ExpandResponse) GetTree() *SubjectTree {
+	if x != nil {
+		return x.Tree
+	}
+	return nil
+}
+
+func (x *ExpandResponse) GetConsistencyToken() string {
+	if x != nil {
+		return x.ConsistencyToken
+	}
+	return ""
+}
+
+type RelationTupleUpdate struct {
+	state         protoimpl.MessageState        `protogen:"open.v1"`
+	Operation     RelationTupleUpdate_Operation `protobuf:"varint,1,opt,name=operation,proto3,enum=auth.RelationTupleUpdate_Operation" json:"operation,omitempty"`
+	Tuple         *RelationTuple                `protobuf:"bytes,2,opt,name=tuple,proto3" json:"tuple,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RelationTupleUpdate) Reset() {
+	*x = RelationTupleUpdate{}
+	mi := &file_sso_relations_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RelationTupleUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RelationTupleUpdate) ProtoMessage() {}
+
+func (x *RelationTupleUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_relations_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RelationTupleUpdate.ProtoReflect.Descriptor instead.
+func (*RelationTupleUpdate) Descriptor() ([]byte, []int) {
+	return file_sso_relations_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RelationTupleUpdate) GetOperation() RelationTupleUpdate_Operation {
+	if x != nil {
+		return x.Operation
+	}
+	return RelationTupleUpdate_OPERATION_UNSPECIFIED
+}
+
+func (x *RelationTupleUpdate) GetTuple() *RelationTuple {
+	if x != nil {
+		return x.Tuple
+	}
+	return nil
+}
+
+type WriteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AppId         int64                  `protobuf:"varint,1,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	Updates       []*RelationTupleUpdate `protobuf:"bytes,2,rep,name=updates,proto3" json:"updates,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WriteRequest) Reset() {
+	*x = WriteRequest{}
+	mi := &file_sso_relations_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteRequest) ProtoMessage() {}
+
+func (x *WriteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_relations_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteRequest.ProtoReflect.Descriptor instead.
+func (*WriteRequest) Descriptor() ([]byte, []int) {
+	return file_sso_relations_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *WriteRequest) GetAppId() int64 {
+	if x != nil {
+		return x.AppId
+	}
+	return 0
+}
+
+func (x *WriteRequest) GetUpdates() []*RelationTupleUpdate {
+	if x != nil {
+		return x.Updates
+	}
+	return nil
+}
+
+type WriteResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	ConsistencyToken string                 `protobuf:"bytes,1,opt,name=consistency_token,json=consistencyToken,proto3" json:"consistency_token,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *WriteResponse) Reset() {
+	*x = WriteResponse{}
+	mi := &file_sso_relations_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteResponse) ProtoMessage() {}
+
+func (x *WriteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_relations_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteResponse.ProtoReflect.Descriptor instead.
+func (*WriteResponse) Descriptor() ([]byte, []int) {
+	return file_sso_relations_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *WriteResponse) GetConsistencyToken() string {
+	if x != nil {
+		return x.ConsistencyToken
+	}
+	return ""
+}
+
+type ReadRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	AppId            int64                  `protobuf:"varint,1,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	Namespace        string                 `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	ObjectId         string                 `protobuf:"bytes,3,opt,name=object_id,json=objectId,proto3" json:"object_id,omitempty"`
+	Relation         string                 `protobuf:"bytes,4,opt,name=relation,proto3" json:"relation,omitempty"`
+	Subject          *Subject               `protobuf:"bytes,5,opt,name=subject,proto3" json:"subject,omitempty"`
+	ConsistencyToken string                 `protobuf:"bytes,6,opt,name=consistency_token,json=consistencyToken,proto3" json:"consistency_token,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ReadRequest) Reset() {
+	*x = ReadRequest{}
+	mi := &file_sso_relations_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadRequest) ProtoMessage() {}
+
+func (x *ReadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_relations_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadRequest.ProtoReflect.Descriptor instead.
+func (*ReadRequest) Descriptor() ([]byte, []int) {
+	return file_sso_relations_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ReadRequest) GetAppId() int64 {
+	if x != nil {
+		return x.AppId
+	}
+	return 0
+}
+
+func (x *ReadRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *ReadRequest) GetObjectId() string {
+	if x != nil {
+		return x.ObjectId
+	}
+	return ""
+}
+
+func (x *ReadRequest) GetRelation() string {
+	if x != nil {
+		return x.Relation
+	}
+	return ""
+}
+
+func (x *ReadRequest) GetSubject() *Subject {
+	if x != nil {
+		return x.Subject
+	}
+	return nil
+}
+
+func (x *ReadRequest) GetConsistencyToken() string {
+	if x != nil {
+		return x.ConsistencyToken
+	}
+	return ""
+}
+
+type ReadResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Tuples           []*RelationTuple       `protobuf:"bytes,1,rep,name=tuples,proto3" json:"tuples,omitempty"`
+	ConsistencyToken string                 `protobuf:"bytes,2,opt,name=consistency_token,json=consistencyToken,proto3" json:"consistency_token,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ReadResponse) Reset() {
+	*x = ReadResponse{}
+	mi := &file_sso_relations_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadResponse) ProtoMessage() {}
+
+func (x *ReadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_relations_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadResponse.ProtoReflect.Descriptor instead.
+func (*ReadResponse) Descriptor() ([]byte, []int) {
+	return file_sso_relations_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ReadResponse) GetTuples() []*RelationTuple {
+	if x != nil {
+		return x.Tuples
+	}
+	return nil
+}
+
+func (x *ReadResponse) GetConsistencyToken() string {
+	if x != nil {
+		return x.ConsistencyToken
+	}
+	return ""
+}
+
+// WriteNamespaceRequest creates or replaces a namespace. The config has the same shape as
+// the config stored for the namespace: {"relations": {"<relation>": <rewrite>, ...}}.
+type WriteNamespaceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AppId         int64                  `protobuf:"varint,1,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Config        *structpb.Struct       `protobuf:"bytes,3,opt,name=config,proto3" json:"config,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WriteNamespaceRequest) Reset() {
+	*x = WriteNamespaceRequest{}
+	mi := &file_sso_relations_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteNamespaceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteNamespaceRequest) ProtoMessage() {}
+
+func (x *WriteNamespaceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_relations_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteNamespaceRequest.ProtoReflect.Descriptor instead.
+func (*WriteNamespaceRequest) Descriptor() ([]byte, []int) {
+	return file_sso_relations_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *WriteNamespaceRequest) GetAppId() int64 {
+	if x != nil {
+		return x.AppId
+	}
+	return 0
+}
+
+func (x *WriteNamespaceRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *WriteNamespaceRequest) GetConfig() *structpb.Struct {
+	if x != nil {
+		return x.Config
+	}
+	return nil
+}
+
+type WriteNamespaceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WriteNamespaceResponse) Reset() {
+	*x = WriteNamespaceResponse{}
+	mi := &file_sso_relations_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteNamespaceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteNamespaceResponse) ProtoMessage() {}
+
+func (x *WriteNamespaceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_relations_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteNamespaceResponse.ProtoReflect.Descriptor instead.
+func (*WriteNamespaceResponse) Descriptor() ([]byte, []int) {
+	return file_sso_relations_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *WriteNamespaceResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+var File_sso_relations_proto protoreflect.FileDescriptor
+
+const file_sso_relations_proto_rawDesc = "" +
+	"\n" +
+	"\x13sso/relations.proto\x12\x04auth\x1a\x1cgoogle/protobuf/struct.proto\"`\n" +
+	"\aSubject\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x1b\n" +
+	"\tobject_id\x18\x02 \x01(\tR\bobjectId\x12\x1a\n" +
+	"\brelation\x18\x03 \x01(\tR\brelation\"\x8f\x01\n" +
+	"\rRelationTuple\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x1b\n" +
+	"\tobject_id\x18\x02 \x01(\tR\bobjectId\x12\x1a\n" +
+	"\brelation\x18\x03 \x01(\tR\brelation\x12'\n" +
+	"\asubject\x18\x04 \x01(\v2\r.auth.SubjectR\asubject\"\xd2\x01\n" +
+	"\fCheckRequest\x12\x15\n" +
+	"\x06app_id\x18\x01 \x01(\x03R\x05appId\x12\x1c\n" +
+	"\tnamespace\x18\x02 \x01(\tR\tnamespace\x12\x1b\n" +
+	"\tobject_id\x18\x03 \x01(\tR\bobjectId\x12\x1a\n" +
+	"\brelation\x18\x04 \x01(\tR\brelation\x12'\n" +
+	"\asubject\x18\x05 \x01(\v2\r.auth.SubjectR\asubject\x12+\n" +
+	"\x11consistency_token\x18\x06 \x01(\tR\x10consistencyToken\"V\n" +
+	"\rCheckResponse\x12\x18\n" +
+	"\aallowed\x18\x01 \x01(\bR\aallowed\x12+\n" +
+	"\x11consistency_token\x18\x02 \x01(\tR\x10consistencyToken\"\xaa\x01\n" +
+	"\rExpandRequest\x12\x15\n" +
+	"\x06app_id\x18\x01 \x01(\x03R\x05appId\x12\x1c\n" +
+	"\tnamespace\x18\x02 \x01(\tR\tnamespace\x12\x1b\n" +
+	"\tobject_id\x18\x03 \x01(\tR\bobjectId\x12\x1a\n" +
+	"\brelation\x18\x04 \x01(\tR\brelation\x12+\n" +
+	"\x11consistency_token\x18\x05 \x01(\tR\x10consistencyToken\"\xdc\x01\n" +
+	"\vSubjectTree\x12\x1c\n" +
+	"\toperation\x18\x01 \x01(\tR\toperation\x12\x1c\n" +
+	"\tnamespace\x18\x02 \x01(\tR\tnamespace\x12\x1b\n" +
+	"\tobject_id\x18\x03 \x01(\tR\bobjectId\x12\x1a\n" +
+	"\brelation\x18\x04 \x01(\tR\brelation\x12)\n" +
+	"\bsubjects\x18\x05 \x03(\v2\r.auth.SubjectR\bsubjects\x12-\n" +
+	"\bchildren\x18\x06 \x03(\v2\x11.auth.SubjectTreeR\bchildren\"d\n" +
+	"\x0eExpandResponse\x12%\n" +
+	"\x04tree\x18\x01 \x01(\v2\x11.auth.SubjectTreeR\x04tree\x12+\n" +
+	"\x11consistency_token\x18\x02 \x01(\tR\x10consistencyToken\"\xd7\x01\n" +
+	"\x13RelationTupleUpdate\x12A\n" +
+	"\toperation\x18\x01 \x01(\x0e2#.auth.RelationTupleUpdate.OperationR\toperation\x12)\n" +
+	"\x05tuple\x18\x02 \x01(\v2\x13.auth.RelationTupleR\x05tuple\"R\n" +
+	"\tOperation\x12\x19\n" +
+	"\x15OPERATION_UNSPECIFIED\x10\x00\x12\x14\n" +
+	"\x10OPERATION_INSERT\x10\x01\x12\x14\n" +
+	"\x10OPERATION_DELETE\x10\x02\"Z\n" +
+	"\fWriteRequest\x12\x15\n" +
+	"\x06app_id\x18\x01 \x01(\x03R\x05appId\x123\n" +
+	"\aupdates\x18\x02 \x03(\v2\x19.auth.RelationTupleUpdateR\aupdates\"<\n" +
+	"\rWriteResponse\x12+\n" +
+	"\x11consistency_token\x18\x01 \x01(\tR\x10consistencyToken\"\xd1\x01\n" +
+	"\vReadRequest\x12\x15\n" +
+	"\x06app_id\x18\x01 \x01(\x03R\x05appId\x12\x1c\n" +
+	"\tnamespace\x18\x02 \x01(\tR\tnamespace\x12\x1b\n" +
+	"\tobject_id\x18\x03 \x01(\tR\bobjectId\x12\x1a\n" +
+	"\brelation\x18\x04 \x01(\tR\brelation\x12'\n" +
+	"\asubject\x18\x05 \x01(\v2\r.auth.SubjectR\asubject\x12+\n" +
+	"\x11consistency_token\x18\x06 \x01(\tR\x10consistencyToken\"h\n" +
+	"\fReadResponse\x12+\n" +
+	"\x06tuples\x18\x01 \x03(\v2\x13.auth.RelationTupleR\x06tuples\x12+\n" +
+	"\x11consistency_token\x18\x02 \x01(\tR\x10consistencyToken\"s\n" +
+	"\x15WriteNamespaceRequest\x12\x15\n" +
+	"\x06app_id\x18\x01 \x01(\x03R\x05appId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12/\n" +
+	"\x06config\x18\x03 \x01(\v2\x17.google.protobuf.StructR\x06config\"2\n" +
+	"\x16WriteNamespaceResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess2\xa0\x02\n" +
+	"\tRelations\x120\n" +
+	"\x05Check\x12\x12.auth.CheckRequest\x1a\x13.auth.CheckResponse\x123\n" +
+	"\x06Expand\x12\x13.auth.ExpandRequest\x1a\x14.auth.ExpandResponse\x120\n" +
+	"\x05Write\x12\x12.auth.WriteRequest\x1a\x13.auth.WriteResponse\x12-\n" +
+	"\x04Read\x12\x11.auth.ReadRequest\x1a\x12.auth.ReadResponse\x12K\n" +
+	"\x0eWriteNamespace\x12\x1b.auth.WriteNamespaceRequest\x1a\x1c.auth.WriteNamespaceResponseB\x13Z\x11auth.sso.v1;ssov1b\x06proto3"
+
+var (
+	file_sso_relations_proto_rawDescOnce sync.Once
+	file_sso_relations_proto_rawDescData []byte
+)
+
+func file_sso_relations_proto_rawDescGZIP() []byte {
+	file_sso_relations_proto_rawDescOnce.Do(func() {
+		file_sso_relations_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_sso_relations_proto_rawDesc), len(file_sso_relations_proto_rawDesc)))
+	})
+	return file_sso_relations_proto_rawDescData
+}
+
+var file_sso_relations_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_sso_relations_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_sso_relations_proto_goTypes = []any{
+	(RelationTupleUpdate_Operation)(0), // 0: auth.RelationTupleUpdate.Operation
+	(*Subject)(nil),                    // 1: auth.Subject
+	(*RelationTuple)(nil),              // 2: auth.RelationTuple
+	(*CheckRequest)(nil),               // 3: auth.CheckRequest
+	(*CheckResponse)(nil),              // 4: auth.CheckResponse
+	(*ExpandRequest)(nil),              // 5: auth.ExpandRequest
+	(*SubjectTree)(nil),                // 6: auth.SubjectTree
+	(*ExpandResponse)(nil),             // 7: auth.ExpandResponse
+	(*RelationTupleUpdate)(nil),        // 8: auth.RelationTupleUpdate
+	(*WriteRequest)(nil),               // 9: auth.WriteRequest
+	(*WriteResponse)(nil),              // 10: auth.WriteResponse
+	(*ReadRequest)(nil),                // 11: auth.ReadRequest
+	(*ReadResponse)(nil),               // 12: auth.ReadResponse
+	(*WriteNamespaceRequest)(nil),      // 13: auth.WriteNamespaceRequest
+	(*WriteNamespaceResponse)(nil),     // 14: auth.WriteNamespaceResponse
+	(*structpb.Struct)(nil),            // 15: google.protobuf.Struct
+}
+var file_sso_relations_proto_depIdxs = []int32{
+	1,  // 0: auth.RelationTuple.subject:type_name -> auth.Subject
+	1,  // 1: auth.CheckRequest.subject:type_name -> auth.Subject
+	1,  // 2: auth.SubjectTree.subjects:type_name -> auth.Subject
+	6,  // 3: auth.SubjectTree.children:type_name -> auth.SubjectTree
+	6,  // 4: auth.ExpandResponse.tree:type_name -> auth.SubjectTree
+	0,  // 5: auth.RelationTupleUpdate.operation:type_name -> auth.RelationTupleUpdate.Operation
+	2,  // 6: auth.RelationTupleUpdate.tuple:type_name -> auth.RelationTuple
+	8,  // 7: auth.WriteRequest.updates:type_name -> auth.RelationTupleUpdate
+	1,  // 8: auth.ReadRequest.subject:type_name -> auth.Subject
+	2,  // 9: auth.ReadResponse.tuples:type_name -> auth.RelationTuple
+	15, // 10: auth.WriteNamespaceRequest.config:type_name -> google.protobuf.Struct
+	3,  // 11: auth.Relations.Check:input_type -> auth.CheckRequest
+	5,  // 12: auth.Relations.Expand:input_type -> auth.ExpandRequest
+	9,  // 13: auth.Relations.Write:input_type -> auth.WriteRequest
+	11, // 14: auth.Relations.Read:input_type -> auth.ReadRequest
+	13, // 15: auth.Relations.WriteNamespace:input_type -> auth.WriteNamespaceRequest
+	4,  // 16: auth.Relations.Check:output_type -> auth.CheckResponse
+	7,  // 17: auth.Relations.Expand:output_type -> auth.ExpandResponse
+	10, // 18: auth.Relations.Write:output_type -> auth.WriteResponse
+	12, // 19: auth.Relations.Read:output_type -> auth.ReadResponse
+	14, // 20: auth.Relations.WriteNamespace:output_type -> auth.WriteNamespaceResponse
+	16, // [16:21] is the sub-list for method output_type
+	11, // [11:16] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_sso_relations_proto_init() }
+func file_sso_relations_proto_init() {
+	if File_sso_relations_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_sso_relations_proto_rawDesc), len(file_sso_relations_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   14,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_sso_relations_proto_goTypes,
+		DependencyIndexes: file_sso_relations_proto_depIdxs,
+		EnumInfos:         file_sso_relations_proto_enumTypes,
+		MessageInfos:      file_sso_relations_proto_msgTypes,
+	}.Build()
+	File_sso_relations_proto = out.File
+	file_sso_relations_proto_goTypes = nil
+	file_sso_relations_proto_depIdxs = nil
+}
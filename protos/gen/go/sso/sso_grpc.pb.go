@@ -0,0 +1,549 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v3.21.12
+// source: sso/sso.proto
+
+package ssov1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Auth_Register_FullMethodName               = "/auth.Auth/Register"
+	Auth_Login_FullMethodName                  = "/auth.Auth/Login"
+	Auth_Setup_FullMethodName                  = "/auth.Auth/Setup"
+	Auth_CheckPermissionsByJwt_FullMethodName  = "/auth.Auth/CheckPermissionsByJwt"
+	Auth_UpdatePermissions_FullMethodName      = "/auth.Auth/UpdatePermissions"
+	Auth_BatchUpdatePermissions_FullMethodName = "/auth.Auth/BatchUpdatePermissions"
+	Auth_GetPermissionsByUserId_FullMethodName = "/auth.Auth/GetPermissionsByUserId"
+	Auth_Authorize_FullMethodName              = "/auth.Auth/Authorize"
+	Auth_BreakGlassGrant_FullMethodName        = "/auth.Auth/BreakGlassGrant"
+	Auth_Impersonate_FullMethodName            = "/auth.Auth/Impersonate"
+	Auth_ListAppMembers_FullMethodName         = "/auth.Auth/ListAppMembers"
+	Auth_ExportAppMembers_FullMethodName       = "/auth.Auth/ExportAppMembers"
+)
+
+// AuthClient is the client API for Auth service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AuthClient interface {
+	Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*RegisterResponse, error)
+	Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error)
+	// Setup creates the first super-admin with the one-time token printed at startup.
+	Setup(ctx context.Context, in *SetupRequest, opts ...grpc.CallOption) (*SetupResponse, error)
+	CheckPermissionsByJwt(ctx context.Context, in *PermissionsByJwtRequest, opts ...grpc.CallOption) (*PermissionsByJwtResponse, error)
+	UpdatePermissions(ctx context.Context, in *UpdatePermissionsRequest, opts ...grpc.CallOption) (*UpdatePermissionsResponse, error)
+	// BatchUpdatePermissions applies all changes in one transaction or none of them.
+	BatchUpdatePermissions(ctx context.Context, in *BatchUpdatePermissionsRequest, opts ...grpc.CallOption) (*BatchUpdatePermissionsResponse, error)
+	GetPermissionsByUserId(ctx context.Context, in *PermissionsByUserIdRequest, opts ...grpc.CallOption) (*PermissionsByUserIdResponse, error)
+	Authorize(ctx context.Context, in *AuthorizeRequest, opts ...grpc.CallOption) (*AuthorizeResponse, error)
+	BreakGlassGrant(ctx context.Context, in *BreakGlassGrantRequest, opts ...grpc.CallOption) (*BreakGlassGrantResponse, error)
+	Impersonate(ctx context.Context, in *ImpersonateRequest, opts ...grpc.CallOption) (*ImpersonateResponse, error)
+	ListAppMembers(ctx context.Context, in *ListAppMembersRequest, opts ...grpc.CallOption) (*ListAppMembersResponse, error)
+	// ExportAppMembers streams every member of the app, for apps too large to page through.
+	ExportAppMembers(ctx context.Context, in *ExportAppMembersRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AppMember], error)
+}
+
+type authClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAuthClient(cc grpc.ClientConnInterface) AuthClient {
+	return &authClient{cc}
+}
+
+func (c *authClient) Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*RegisterResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RegisterResponse)
+	err := c.cc.Invoke(ctx, Auth_Register_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LoginResponse)
+	err := c.cc.Invoke(ctx, Auth_Login_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) Setup(ctx context.Context, in *SetupRequest, opts ...grpc.CallOption) (*SetupResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetupResponse)
+	err := c.cc.Invoke(ctx, Auth_Setup_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) CheckPermissionsByJwt(ctx context.Context, in *PermissionsByJwtRequest, opts ...grpc.CallOption) (*PermissionsByJwtResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PermissionsByJwtResponse)
+	err := c.cc.Invoke(ctx, Auth_CheckPermissionsByJwt_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) UpdatePermissions(ctx context.Context, in *UpdatePermissionsRequest, opts ...grpc.CallOption) (*UpdatePermissionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdatePermissionsResponse)
+	err := c.cc.Invoke(ctx, Auth_UpdatePermissions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) BatchUpdatePermissions(ctx context.Context, in *BatchUpdatePermissionsRequest, opts ...grpc.CallOption) (*BatchUpdatePermissionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchUpdatePermissionsResponse)
+	err := c.cc.Invoke(ctx, Auth_BatchUpdatePermissions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) GetPermissionsByUserId(ctx context.Context, in *PermissionsByUserIdRequest, opts ...grpc.CallOption) (*PermissionsByUserIdResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PermissionsByUserIdResponse)
+	err := c.cc.Invoke(ctx, Auth_GetPermissionsByUserId_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) Authorize(ctx context.Context, in *AuthorizeRequest, opts ...grpc.CallOption) (*AuthorizeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AuthorizeResponse)
+	err := c.cc.Invoke(ctx, Auth_Authorize_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) BreakGlassGrant(ctx context.Context, in *BreakGlassGrantRequest, opts ...grpc.CallOption) (*BreakGlassGrantResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BreakGlassGrantResponse)
+	err := c.cc.Invoke(ctx, Auth_BreakGlassGrant_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) Impersonate(ctx context.Context, in *ImpersonateRequest, opts ...grpc.CallOption) (*ImpersonateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ImpersonateResponse)
+	err := c.cc.Invoke(ctx, Auth_Impersonate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) ListAppMembers(ctx context.Context, in *ListAppMembersRequest, opts ...grpc.CallOption) (*ListAppMembersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAppMembersResponse)
+	err := c.cc.Invoke(ctx, Auth_ListAppMembers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) ExportAppMembers(ctx context.Context, in *ExportAppMembersRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AppMember], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Auth_ServiceDesc.Streams[0], Auth_ExportAppMembers_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExportAppMembersRequest, AppMember]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Auth_ExportAppMembersClient = grpc.ServerStreamingClient[AppMember]
+
+// AuthServer is the server API for Auth service.
+// All implementations must embed UnimplementedAuthServer
+// for forward compatibility.
+type AuthServer interface {
+	Register(context.Context, *RegisterRequest) (*RegisterResponse, error)
+	Login(context.Context, *LoginRequest) (*LoginResponse, error)
+	// Setup creates the first super-admin with the one-time token printed at startup.
+	Setup(context.Context, *SetupRequest) (*SetupResponse, error)
+	CheckPermissionsByJwt(context.Context, *PermissionsByJwtRequest) (*PermissionsByJwtResponse, error)
+	UpdatePermissions(context.Context, *UpdatePermissionsRequest) (*UpdatePermissionsResponse, error)
+	// BatchUpdatePermissions applies all changes in one transaction or none of them.
+	BatchUpdatePermissions(context.Context, *BatchUpdatePermissionsRequest) (*BatchUpdatePermissionsResponse, error)
+	GetPermissionsByUserId(context.Context, *PermissionsByUserIdRequest) (*PermissionsByUserIdResponse, error)
+	Authorize(context.Context, *AuthorizeRequest) (*AuthorizeResponse, error)
+	BreakGlassGrant(context.Context, *BreakGlassGrantRequest) (*BreakGlassGrantResponse, error)
+	Impersonate(context.Context, *ImpersonateRequest) (*ImpersonateResponse, error)
+	ListAppMembers(context.Context, *ListAppMembersRequest) (*ListAppMembersResponse, error)
+	// ExportAppMembers streams every member of the app, for apps too large to page through.
+	ExportAppMembers(*ExportAppMembersRequest, grpc.ServerStreamingServer[AppMember]) error
+	mustEmbedUnimplementedAuthServer()
+}
+
+// UnimplementedAuthServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAuthServer struct{}
+
+func (UnimplementedAuthServer) Register(context.Context, *RegisterRequest) (*RegisterResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
+}
+func (UnimplementedAuthServer) Login(context.Context, *LoginRequest) (*LoginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
+}
+func (UnimplementedAuthServer) Setup(context.Context, *SetupRequest) (*SetupResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Setup not implemented")
+}
+func (UnimplementedAuthServer) CheckPermissionsByJwt(context.Context, *PermissionsByJwtRequest) (*PermissionsByJwtResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckPermissionsByJwt not implemented")
+}
+func (UnimplementedAuthServer) UpdatePermissions(context.Context, *UpdatePermissionsRequest) (*UpdatePermissionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdatePermissions not implemented")
+}
+func (UnimplementedAuthServer) BatchUpdatePermissions(context.Context, *BatchUpdatePermissionsRequest) (*BatchUpdatePermissionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchUpdatePermissions not implemented")
+}
+func (UnimplementedAuthServer) GetPermissionsByUserId(context.Context, *PermissionsByUserIdRequest) (*PermissionsByUserIdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPermissionsByUserId not implemented")
+}
+func (UnimplementedAuthServer) Authorize(context.Context, *AuthorizeRequest) (*AuthorizeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Authorize not implemented")
+}
+func (UnimplementedAuthServer) BreakGlassGrant(context.Context, *BreakGlassGrantRequest) (*BreakGlassGrantResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BreakGlassGrant not implemented")
+}
+func (UnimplementedAuthServer) Impersonate(context.Context, *ImpersonateRequest) (*ImpersonateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Impersonate not implemented")
+}
+func (UnimplementedAuthServer) ListAppMembers(context.Context, *ListAppMembersRequest) (*ListAppMembersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAppMembers not implemented")
+}
+func (UnimplementedAuthServer) ExportAppMembers(*ExportAppMembersRequest, grpc.ServerStreamingServer[AppMember]) error {
+	return status.Errorf(codes.Unimplemented, "method ExportAppMembers not implemented")
+}
+func (UnimplementedAuthServer) mustEmbedUnimplementedAuthServer() {}
+func (UnimplementedAuthServer) testEmbeddedByValue()              {}
+
+// UnsafeAuthServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AuthServer will
+// result in compilation errors.
+type UnsafeAuthServer interface {
+	mustEmbedUnimplementedAuthServer()
+}
+
+func RegisterAuthServer(s grpc.ServiceRegistrar, srv AuthServer) {
+	// If the following call pancis, it indicates UnimplementedAuthServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Auth_ServiceDesc, srv)
+}
+
+func _Auth_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).Register(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Auth_Register_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).Register(ctx, req.(*RegisterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Auth_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).Login(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Auth_Login_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).Login(ctx, req.(*LoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Auth_Setup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).Setup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Auth_Setup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).Setup(ctx, req.(*SetupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Auth_CheckPermissionsByJwt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PermissionsByJwtRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).CheckPermissionsByJwt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Auth_CheckPermissionsByJwt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).CheckPermissionsByJwt(ctx, req.(*PermissionsByJwtRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Auth_UpdatePermissions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdatePermissionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).UpdatePermissions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Auth_UpdatePermissions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).UpdatePermissions(ctx, req.(*UpdatePermissionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Auth_BatchUpdatePermissions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchUpdatePermissionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).BatchUpdatePermissions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Auth_BatchUpdatePermissions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).BatchUpdatePermissions(ctx, req.(*BatchUpdatePermissionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Auth_GetPermissionsByUserId_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PermissionsByUserIdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).GetPermissionsByUserId(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Auth_GetPermissionsByUserId_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).GetPermissionsByUserId(ctx, req.(*PermissionsByUserIdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Auth_Authorize_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuthorizeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).Authorize(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Auth_Authorize_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).Authorize(ctx, req.(*AuthorizeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Auth_BreakGlassGrant_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BreakGlassGrantRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).BreakGlassGrant(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Auth_BreakGlassGrant_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).BreakGlassGrant(ctx, req.(*BreakGlassGrantRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Auth_Impersonate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImpersonateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).Impersonate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Auth_Impersonate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).Impersonate(ctx, req.(*ImpersonateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Auth_ListAppMembers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAppMembersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).ListAppMembers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Auth_ListAppMembers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).ListAppMembers(ctx, req.(*ListAppMembersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Auth_ExportAppMembers_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportAppMembersRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AuthServer).ExportAppMembers(m, &grpc.GenericServerStream[ExportAppMembersRequest, AppMember]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Auth_ExportAppMembersServer = grpc.ServerStreamingServer[AppMember]
+
+// Auth_ServiceDesc is the grpc.ServiceDesc for Auth service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Auth_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "auth.Auth",
+	HandlerType: (*AuthServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Register",
+			Handler:    _Auth_Register_Handler,
+		},
+		{
+			MethodName: "Login",
+			Handler:    _Auth_Login_Handler,
+		},
+		{
+			MethodName: "Setup",
+			Handler:    _Auth_Setup_Handler,
+		},
+		{
+			MethodName: "CheckPermissionsByJwt",
+			Handler:    _Auth_CheckPermissionsByJwt_Handler,
+		},
+		{
+			MethodName: "UpdatePermissions",
+			Handler:    _Auth_UpdatePermissions_Handler,
+		},
+		{
+			MethodName: "BatchUpdatePermissions",
+			Handler:    _Auth_BatchUpdatePermissions_Handler,
+		},
+		{
+			MethodName: "GetPermissionsByUserId",
+			Handler:    _Auth_GetPermissionsByUserId_Handler,
+		},
+		{
+			MethodName: "Authorize",
+			Handler:    _Auth_Authorize_Handler,
+		},
+		{
+			MethodName: "BreakGlassGrant",
+			Handler:    _Auth_BreakGlassGrant_Handler,
+		},
+		{
+			MethodName: "Impersonate",
+			Handler:    _Auth_Impersonate_Handler,
+		},
+		{
+			MethodName: "ListAppMembers",
+			Handler:    _Auth_ListAppMembers_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExportAppMembers",
+			Handler:       _Auth_ExportAppMembers_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "sso/sso.proto",
+}
@@ -0,0 +1,8 @@
+module github.com/botanikn/protos
+
+go 1.22.2
+
+require (
+	google.golang.org/grpc v1.76.0
+	google.golang.org/protobuf v1.36.6
+)